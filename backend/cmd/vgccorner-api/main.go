@@ -14,6 +14,24 @@ import (
 func main() {
 	logger := observability.NewLogger()
 
+	if len(os.Args) > 1 && os.Args[1] == "train-winprob" {
+		if err := runTrainWinProb(logger, os.Args[2:]); err != nil {
+			logger.Fatalf("training failed: %v", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "seed-dex" {
+		if err := runSeedDex(logger); err != nil {
+			logger.Fatalf("seeding failed: %v", err)
+		}
+		return
+	}
+
+	if err := loadWinProbabilityModel(logger); err != nil {
+		logger.Fatalf("failed to load win probability model: %v", err)
+	}
+
 	// Initialize database connection
 	dbConnString := getDBConnString()
 	logger.Infof("connecting to database at %s", dbConnString)
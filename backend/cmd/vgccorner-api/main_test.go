@@ -2,8 +2,13 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/analysis"
+	"github.com/dtsong/vgccorner/backend/internal/db"
+	"github.com/dtsong/vgccorner/backend/internal/observability"
 )
 
 func TestGetAddr(t *testing.T) {
@@ -230,3 +235,45 @@ func TestGetEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestTrainingSamples(t *testing.T) {
+	battles := []*db.BattleFeatures{
+		{BattleID: "id1", Winner: "player1", Turns: []analysis.PositionFeatures{{"board": 0}, {"board": 0.5}}},
+		{BattleID: "id2", Winner: "player2", Turns: []analysis.PositionFeatures{{"board": -0.5}}},
+	}
+
+	samples := trainingSamples(battles)
+	if len(samples) != 3 {
+		t.Fatalf("expected a sample per turn, got %d", len(samples))
+	}
+	if !samples[0].Player1Won || !samples[1].Player1Won || samples[2].Player1Won {
+		t.Errorf("expected samples labelled by each battle's winner, got %+v", samples)
+	}
+	if samples[1].Features["board"] != 0.5 {
+		t.Errorf("expected turn features to carry over, got %+v", samples[1].Features)
+	}
+}
+
+func TestLoadWinProbabilityModel(t *testing.T) {
+	original := analysis.DefaultWinProbabilityModel
+	defer func() { analysis.DefaultWinProbabilityModel = original }()
+
+	model := &analysis.WinProbabilityModel{Format: original.Format, Version: "20261016-120000", Features: []string{"board"}, Weights: []float64{1}}
+	path := filepath.Join(t.TempDir(), "winprob-20261016-120000.json")
+	if err := model.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("WIN_PROBABILITY_MODEL", path)
+	if err := loadWinProbabilityModel(observability.NewLogger()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if analysis.DefaultWinProbabilityModel.Version != "20261016-120000" {
+		t.Errorf("expected the saved model to become the default, got %q", analysis.DefaultWinProbabilityModel.Version)
+	}
+
+	t.Setenv("WIN_PROBABILITY_MODEL", filepath.Join(t.TempDir(), "missing.json"))
+	if err := loadWinProbabilityModel(observability.NewLogger()); err == nil {
+		t.Error("expected an error for a missing model")
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dtsong/vgccorner/backend/internal/db"
+	"github.com/dtsong/vgccorner/backend/internal/dex"
+	"github.com/dtsong/vgccorner/backend/internal/observability"
+)
+
+// runSeedDex fills the species, moves and items reference tables from the
+// embedded dex:
+//
+//	vgccorner-api seed-dex
+//
+// It's safe to run again after the dataset changes; existing rows are updated.
+func runSeedDex(logger *observability.Logger) error {
+	database, err := db.NewDatabase(getDBConnString())
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	if err := database.SeedReferenceData(context.Background()); err != nil {
+		return err
+	}
+
+	logger.Infof("seeded %d species, %d moves and %d items",
+		len(dex.AllSpecies()), len(dex.AllMoves()), len(dex.AllItems()))
+	return nil
+}
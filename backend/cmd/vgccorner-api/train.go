@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dtsong/vgccorner/backend/internal/analysis"
+	"github.com/dtsong/vgccorner/backend/internal/db"
+	"github.com/dtsong/vgccorner/backend/internal/observability"
+)
+
+// runTrainWinProb fits a win-probability model to the position features of
+// every stored battle with a winner and saves it as a versioned artifact:
+//
+//	vgccorner-api train-winprob -format gen9vgc2025regh -out models
+//
+// Point WIN_PROBABILITY_MODEL at the artifact to have the API use it.
+func runTrainWinProb(logger *observability.Logger, args []string) error {
+	flags := flag.NewFlagSet("train-winprob", flag.ContinueOnError)
+	format := flags.String("format", "", "only train on battles in this format")
+	outDir := flags.String("out", "models", "directory to write the model to")
+	version := flags.String("version", time.Now().UTC().Format("20060102-150405"), "version to give the model")
+	epochs := flags.Int("epochs", 0, "gradient descent passes (default 2000)")
+	l2 := flags.Float64("l2", 0, "L2 regularization strength (default 0.001)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	database, err := db.NewDatabase(getDBConnString())
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	battles, err := database.ListBattleFeatures(context.Background(), *format)
+	if err != nil {
+		return fmt.Errorf("failed to load battle features: %w", err)
+	}
+
+	model, err := analysis.TrainWinProbabilityModel(trainingSamples(battles), analysis.TrainOptions{
+		Epochs: *epochs,
+		L2:     *l2,
+	})
+	if err != nil {
+		return err
+	}
+	model.Version = *version
+	model.TrainedAt = time.Now().UTC()
+	model.Battles = len(battles)
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", *outDir, err)
+	}
+	path := filepath.Join(*outDir, "winprob-"+model.Version+".json")
+	if err := model.Save(path); err != nil {
+		return err
+	}
+
+	logger.Infof("trained win probability model %s on %d turns from %d battles: log loss %.3f, accuracy %.1f%%",
+		model.Version, model.Samples, model.Battles, model.LogLoss, model.Accuracy*100)
+	logger.Infof("saved to %s", path)
+	return nil
+}
+
+// trainingSamples labels every turn of each battle with whether player 1 won.
+func trainingSamples(battles []*db.BattleFeatures) []analysis.TrainingSample {
+	var samples []analysis.TrainingSample
+	for _, battle := range battles {
+		for _, features := range battle.Turns {
+			samples = append(samples, analysis.TrainingSample{
+				Features:   features,
+				Player1Won: battle.Winner == "player1",
+			})
+		}
+	}
+	return samples
+}
+
+// loadWinProbabilityModel makes the model at WIN_PROBABILITY_MODEL, if set,
+// the one every analysis uses.
+func loadWinProbabilityModel(logger *observability.Logger) error {
+	path := os.Getenv("WIN_PROBABILITY_MODEL")
+	if path == "" {
+		logger.Infof("WIN_PROBABILITY_MODEL not set; using the %s win probability model", analysis.DefaultWinProbabilityModel.Version)
+		return nil
+	}
+
+	model, err := analysis.LoadWinProbabilityModel(path)
+	if err != nil {
+		return err
+	}
+	analysis.DefaultWinProbabilityModel = model
+	logger.Infof("using win probability model %s from %s", model.Version, path)
+	return nil
+}
@@ -0,0 +1,159 @@
+package analysis
+
+import "github.com/dtsong/vgccorner/backend/internal/protocol"
+
+// abilityTrigger is an ability found taking effect in a protocol event.
+type abilityTrigger struct {
+	holder  protocol.PokemonRef
+	ability string
+	target  protocol.PokemonRef
+	result  string
+	copied  string // Ability Trace and similar copied from target
+}
+
+// findAbilityTrigger looks for an ability at work in event, either announced
+// with |-ability| and |-activate| or named by a [from] ability: tag. Most
+// tags name the holder with [of], but the Pokémon an event is about holds
+// the ability when there's no [of] (Levitate) or when it healed (Volt
+// Absorb's [of] is the attacker).
+func findAbilityTrigger(event protocol.Event) (abilityTrigger, bool) {
+	isAbility := func(effect protocol.Effect) bool { return effect.Kind == "ability" }
+	// byTag handles events whose [of] is the holder and whose Pokémon is on the receiving end
+	byTag := func(pokemon, of protocol.PokemonRef, from protocol.Effect, result string) (abilityTrigger, bool) {
+		if !isAbility(from) {
+			return abilityTrigger{}, false
+		}
+		if of.IsZero() || of == pokemon {
+			return abilityTrigger{holder: pokemon, ability: from.Name, result: result}, true
+		}
+		return abilityTrigger{holder: of, ability: from.Name, target: pokemon, result: result}, true
+	}
+
+	switch e := event.(type) {
+	case protocol.AbilityEvent:
+		if isAbility(e.From) {
+			// Trace reveals both its holder's ability and the one it copied
+			return abilityTrigger{holder: e.Pokemon, ability: e.From.Name, target: e.Of, copied: e.Ability}, true
+		}
+		return abilityTrigger{holder: e.Pokemon, ability: e.Ability}, true
+
+	case protocol.ActivateEvent:
+		if isAbility(e.Effect) {
+			return abilityTrigger{holder: e.Pokemon, ability: e.Effect.Name, target: e.Of}, true
+		}
+
+	case protocol.WeatherEvent:
+		if isAbility(e.From) && !e.Upkeep {
+			return abilityTrigger{holder: e.Of, ability: e.From.Name, result: e.Weather}, true
+		}
+
+	case protocol.FieldEvent:
+		if isAbility(e.From) && !e.Ended {
+			return abilityTrigger{holder: e.Of, ability: e.From.Name, result: e.Effect.Name}, true
+		}
+
+	case protocol.HealEvent:
+		if isAbility(e.From) {
+			return abilityTrigger{holder: e.Pokemon, ability: e.From.Name}, true
+		}
+
+	case protocol.EffectivenessEvent:
+		return byTag(e.Pokemon, protocol.PokemonRef{}, e.From, e.Effectiveness)
+
+	case protocol.BoostEvent:
+		return byTag(e.Pokemon, protocol.PokemonRef{}, e.From, "")
+
+	case protocol.CureStatusEvent:
+		return byTag(e.Pokemon, protocol.PokemonRef{}, e.From, "")
+
+	case protocol.DamageEvent:
+		return byTag(e.Pokemon, e.Of, e.From, "")
+
+	case protocol.StatusEvent:
+		return byTag(e.Pokemon, e.Of, e.From, e.Status)
+
+	case protocol.ItemEvent:
+		// Frisk
+		return byTag(e.Pokemon, e.Of, e.From, "")
+
+	case protocol.VolatileEvent:
+		return byTag(e.Pokemon, e.Of, e.From, e.Effect.Name)
+
+	case protocol.CantEvent:
+		if isAbility(e.Reason) {
+			// Armor Tail, Dazzling and Queenly Majesty name their holder first
+			return abilityTrigger{holder: e.Pokemon, ability: e.Reason.Name, target: e.Of, result: e.Move}, true
+		}
+
+	case protocol.UnknownEvent:
+		line := e.Line
+		pokemon := protocol.ParsePokemonRef(line.Arg(0))
+		of := protocol.ParsePokemonRef(line.Kwargs["of"])
+		// e.g. |-fail|p2a: Metagross|unboost|[from] ability: Clear Body|[of] p2a: Metagross
+		return byTag(pokemon, of, protocol.ParseEffect(line.Kwargs["from"]), "")
+	}
+
+	return abilityTrigger{}, false
+}
+
+// RecordAbility notes an ability activation and reveals the ability on its
+// holder. Abilities announced with |-ability|, such as Intimidate, collect the
+// stat changes that follow until the announcement ends.
+func (st *StateTracker) RecordAbility(trigger abilityTrigger, command string) {
+	if trigger.holder.IsZero() || trigger.ability == "" {
+		return
+	}
+	st.revealAbility(trigger.holder, trigger.ability)
+	if trigger.copied != "" {
+		st.revealAbility(trigger.target, trigger.copied)
+	}
+
+	st.abilities = append(st.abilities, AbilityActivation{
+		TurnNumber: st.turnNumber,
+		Pokemon:    trigger.holder.Name,
+		Player:     sideToPlayer(trigger.holder.Side),
+		Ability:    trigger.ability,
+		Event:      command,
+		Target:     trigger.target.Name,
+		Result:     trigger.result,
+	})
+
+	if command == "-ability" {
+		st.announcedAbility = len(st.abilities) - 1
+	}
+}
+
+// AddAnnouncedBoost credits a stat change to the ability just announced, such
+// as Intimidate's attack drop. It reports false if no announcement is open or
+// the change came from something else.
+func (st *StateTracker) AddAnnouncedBoost(e protocol.BoostEvent) bool {
+	if st.announcedAbility < 0 || !e.From.IsZero() {
+		return false
+	}
+	activation := &st.abilities[st.announcedAbility]
+	activation.StatChanges = append(activation.StatChanges, StatChange{
+		Pokemon: e.Pokemon.Name,
+		Stat:    e.Stat,
+		Stages:  e.Amount,
+	})
+	return true
+}
+
+// EndAbilityAnnouncement stops crediting stat changes to the last announced ability.
+func (st *StateTracker) EndAbilityAnnouncement() {
+	st.announcedAbility = -1
+}
+
+// GetAbilityActivations returns every ability activation so far, in order.
+func (st *StateTracker) GetAbilityActivations() []AbilityActivation {
+	return st.abilities
+}
+
+// revealAbility fills in the ability of the Pokémon in ref's slot. The first
+// reveal wins, so abilities gained mid-battle through Skill Swap or Trace
+// don't replace the one it brought.
+func (st *StateTracker) revealAbility(ref protocol.PokemonRef, ability string) {
+	if poke, ok := st.activePokemon[refSlot(ref)]; ok && poke.Ability == "" {
+		poke.Ability = ability
+	}
+}
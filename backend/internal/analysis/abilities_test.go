@@ -0,0 +1,133 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+const abilityBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Incineroar, L50|
+|poke|p1|Torkoal, L50|
+|poke|p2|Gholdengo, L50|
+|poke|p2|Garchomp, L50|
+|teamsize|p1|2
+|teamsize|p2|2
+|start
+|switch|p1a: Incineroar|Incineroar, L50|100/100
+|switch|p1b: Torkoal|Torkoal, L50|100/100
+|switch|p2a: Gholdengo|Gholdengo, L50|100/100
+|switch|p2b: Garchomp|Garchomp, L50|100/100
+|-ability|p1a: Incineroar|Intimidate|boost
+|-unboost|p2a: Gholdengo|atk|1
+|-unboost|p2b: Garchomp|atk|1
+|-weather|SunnyDay|[from] ability: Drought|[of] p1b: Torkoal
+|turn|1
+|move|p1a: Incineroar|Parting Shot|p2a: Gholdengo
+|-immune|p2a: Gholdengo|[from] ability: Good as Gold
+|move|p1b: Torkoal|Body Press|p2b: Garchomp
+|-damage|p2b: Garchomp|80/100
+|-damage|p1b: Torkoal|88/100|[from] ability: Rough Skin|[of] p2b: Garchomp
+|move|p2b: Garchomp|Breaking Swipe|p1a: Incineroar
+|-damage|p1a: Incineroar|70/100
+|-unboost|p1a: Incineroar|atk|1
+|upkeep
+|turn|2
+|win|Alice`
+
+func TestParseShowdownLogAbilityActivations(t *testing.T) {
+	summary, err := ParseShowdownLog(abilityBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []AbilityActivation{
+		{
+			Pokemon: "Incineroar", Player: "player1", Ability: "Intimidate", Event: "-ability",
+			StatChanges: []StatChange{
+				{Pokemon: "Gholdengo", Stat: "atk", Stages: -1},
+				{Pokemon: "Garchomp", Stat: "atk", Stages: -1},
+			},
+		},
+		{Pokemon: "Torkoal", Player: "player1", Ability: "Drought", Event: "-weather", Result: "SunnyDay"},
+		{TurnNumber: 1, Pokemon: "Gholdengo", Player: "player2", Ability: "Good as Gold", Event: "-immune", Result: "immune"},
+		{TurnNumber: 1, Pokemon: "Garchomp", Player: "player2", Ability: "Rough Skin", Event: "-damage", Target: "Torkoal"},
+	}
+
+	if !reflect.DeepEqual(summary.AbilityActivations, expected) {
+		t.Errorf("expected %+v, got %+v", expected, summary.AbilityActivations)
+	}
+}
+
+func TestParseShowdownLogRevealedAbilities(t *testing.T) {
+	summary, _ := ParseShowdownLog(abilityBattleLog)
+
+	abilities := make(map[string]string)
+	for _, poke := range append(summary.Player1.Team, summary.Player2.Team...) {
+		abilities[poke.Name] = poke.Ability
+	}
+
+	expected := map[string]string{
+		"Incineroar": "Intimidate",
+		"Torkoal":    "Drought",
+		"Gholdengo":  "Good as Gold",
+		"Garchomp":   "Rough Skin",
+	}
+	if !reflect.DeepEqual(abilities, expected) {
+		t.Errorf("expected %v, got %v", expected, abilities)
+	}
+
+	// Without a team sheet, Drought still marks Torkoal as a sun setter
+	classification := summary.Player1.Classification
+	if !classification.HasWeatherSetter || classification.WeatherType != "sun" {
+		t.Errorf("expected a sun setter from the revealed ability, got %+v", classification)
+	}
+}
+
+func TestFindAbilityTrigger(t *testing.T) {
+	ref := protocol.ParsePokemonRef
+	tests := []struct {
+		raw      string
+		expected abilityTrigger
+		ok       bool
+	}{
+		{
+			raw:      "|-heal|p2a: Amoonguss|60/100|[from] ability: Volt Absorb|[of] p1a: Raichu",
+			expected: abilityTrigger{holder: ref("p2a: Amoonguss"), ability: "Volt Absorb"},
+			ok:       true,
+		},
+		{
+			raw:      "|-status|p1a: Garchomp|par|[from] ability: Static|[of] p2a: Pikachu",
+			expected: abilityTrigger{holder: ref("p2a: Pikachu"), ability: "Static", target: ref("p1a: Garchomp"), result: "par"},
+			ok:       true,
+		},
+		{
+			raw:      "|-ability|p2a: Porygon2|Intimidate|[from] ability: Trace|[of] p1a: Incineroar",
+			expected: abilityTrigger{holder: ref("p2a: Porygon2"), ability: "Trace", target: ref("p1a: Incineroar"), copied: "Intimidate"},
+			ok:       true,
+		},
+		{
+			raw:      "|cant|p1b: Farigiraf|ability: Armor Tail|Fake Out|[of] p2a: Incineroar",
+			expected: abilityTrigger{holder: ref("p1b: Farigiraf"), ability: "Armor Tail", target: ref("p2a: Incineroar"), result: "Fake Out"},
+			ok:       true,
+		},
+		{
+			raw:      "|-fail|p2a: Metagross|unboost|[from] ability: Clear Body|[of] p2a: Metagross",
+			expected: abilityTrigger{holder: ref("p2a: Metagross"), ability: "Clear Body"},
+			ok:       true,
+		},
+		{raw: "|-damage|p2a: Gholdengo|53/100|[from] item: Life Orb"},
+		{raw: "|-activate|p2a: Maushold|move: Protect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, ok := findAbilityTrigger(protocol.Parse(tt.raw))
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("expected %+v (%v), got %+v (%v)", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
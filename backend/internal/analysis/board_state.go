@@ -0,0 +1,84 @@
+package analysis
+
+// GetBattleState snapshots the board: both sides' active Pokémon with their
+// Tera and stat stages, the bench, and everything on the field. The snapshot
+// is a copy, so later turns don't change it.
+func (st *StateTracker) GetBattleState() BattleState {
+	return BattleState{
+		Player1Active: st.activeSnapshot("p1"),
+		Player2Active: st.activeSnapshot("p2"),
+		Player1Bench:  st.benchSnapshot("p1"),
+		Player2Bench:  st.benchSnapshot("p2"),
+		Player1Team:   st.aliveNames("p1"),
+		Player2Team:   st.aliveNames("p2"),
+		Field:         st.GetFieldState(),
+		Volatiles:     st.GetVolatiles(),
+	}
+}
+
+// activeSnapshot copies the Pokémon in each of a side's active slots.
+func (st *StateTracker) activeSnapshot(playerID string) []*Pokémon {
+	active := st.GetActive(playerID)
+	snapshot := make([]*Pokémon, len(active))
+	for i, poke := range active {
+		if poke == nil {
+			continue
+		}
+		copied := *poke
+		copied.Moves = append([]Move(nil), poke.Moves...)
+		if tera := st.teraUsed[playerID]; tera != nil && tera.Pokemon == poke.Name {
+			copied.Terastallized = true
+		}
+		if boosts := st.GetStatStages(playerID + activeSlotLetters[i]); len(boosts) > 0 {
+			copied.Boosts = boosts
+		}
+		snapshot[i] = &copied
+	}
+	return snapshot
+}
+
+// benchSnapshot lists the side's team members that aren't on the field.
+// Pokémon that haven't been sent out are assumed to be at full HP.
+func (st *StateTracker) benchSnapshot(playerID string) []BenchPokemon {
+	bench := []BenchPokemon{}
+	for i, poke := range st.teams[playerID] {
+		if st.isActive(playerID, i) {
+			continue
+		}
+		entry := BenchPokemon{
+			ID:        poke.ID,
+			Name:      poke.Name,
+			CurrentHP: poke.CurrentHP,
+			MaxHP:     poke.MaxHP,
+			Status:    poke.Status,
+			Revealed:  contains(st.brought[playerID], poke.Name),
+		}
+		if !entry.Revealed {
+			entry.CurrentHP = poke.MaxHP
+		}
+		bench = append(bench, entry)
+	}
+	return bench
+}
+
+// aliveNames lists the side's team members that haven't fainted.
+func (st *StateTracker) aliveNames(playerID string) []string {
+	names := []string{}
+	for _, poke := range st.teams[playerID] {
+		if poke.CurrentHP > 0 || !contains(st.brought[playerID], poke.Name) {
+			names = append(names, poke.Name)
+		}
+	}
+	return names
+}
+
+// isActive reports whether the team member at index is in one of the side's slots.
+func (st *StateTracker) isActive(playerID string, index int) bool {
+	for _, letter := range activeSlotLetters {
+		slot := playerID + letter
+		if _, ok := st.activePokemon[slot]; ok && st.activePokemonIndex[slot] == index {
+			return true
+		}
+	}
+	return false
+}
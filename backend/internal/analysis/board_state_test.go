@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+const boardBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Annihilape, L50|
+|poke|p1|Amoonguss, L50|
+|poke|p1|Incineroar, L50|
+|poke|p2|Dondozo, L50|
+|poke|p2|Tatsugiri, L50|
+|poke|p2|Gholdengo, L50|
+|teamsize|p1|3
+|teamsize|p2|3
+|start
+|switch|p1a: Annihilape|Annihilape, L50|100/100
+|switch|p1b: Amoonguss|Amoonguss, L50|100/100
+|switch|p2a: Dondozo|Dondozo, L50|100/100
+|switch|p2b: Tatsugiri|Tatsugiri, L50|100/100
+|turn|1
+|-terastallize|p1a: Annihilape|Ghost
+|move|p1a: Annihilape|Bulk Up|p1a: Annihilape
+|-boost|p1a: Annihilape|atk|1
+|-boost|p1a: Annihilape|def|1
+|move|p1b: Amoonguss|Spore|p2b: Tatsugiri
+|-status|p2b: Tatsugiri|slp
+|move|p2a: Dondozo|Wave Crash|p1b: Amoonguss
+|-damage|p1b: Amoonguss|0 fnt
+|faint|p1b: Amoonguss
+|-damage|p2a: Dondozo|85/100|[from] Recoil
+|upkeep
+|switch|p1b: Incineroar|Incineroar, L50|100/100
+|turn|2
+|switch|p2b: Gholdengo|Gholdengo, L50|100/100
+|move|p1b: Incineroar|Fake Out|p2a: Dondozo
+|-damage|p2a: Dondozo|75/100
+|upkeep
+|turn|3
+|win|Alice`
+
+func TestParseShowdownLogBoardState(t *testing.T) {
+	summary, err := ParseShowdownLog(boardBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	turn1 := summary.Turns[0].StateAfter
+	annihilape := turn1.Player1Active[0]
+	if annihilape == nil || !annihilape.Terastallized || !reflect.DeepEqual(annihilape.Boosts, map[string]int{"atk": 1, "def": 1}) {
+		t.Errorf("expected a terastallized Annihilape at +1/+1, got %+v", annihilape)
+	}
+	if incineroar := turn1.Player1Active[1]; incineroar == nil || incineroar.Name != "Incineroar" {
+		t.Errorf("expected Incineroar to have replaced Amoonguss, got %+v", incineroar)
+	}
+	if tatsugiri := turn1.Player2Active[1]; tatsugiri == nil || tatsugiri.Status != "slp" {
+		t.Errorf("expected Tatsugiri asleep, got %+v", tatsugiri)
+	}
+
+	expectedBench := []BenchPokemon{
+		{ID: "amoonguss", Name: "Amoonguss", CurrentHP: 0, MaxHP: 100, Revealed: true},
+	}
+	if !reflect.DeepEqual(turn1.Player1Bench, expectedBench) {
+		t.Errorf("expected player1 bench %+v, got %+v", expectedBench, turn1.Player1Bench)
+	}
+	expectedBench = []BenchPokemon{
+		{ID: "gholdengo", Name: "Gholdengo", CurrentHP: 100, MaxHP: 100},
+	}
+	if !reflect.DeepEqual(turn1.Player2Bench, expectedBench) {
+		t.Errorf("expected player2 bench %+v, got %+v", expectedBench, turn1.Player2Bench)
+	}
+	if !reflect.DeepEqual(turn1.Player1Team, []string{"Annihilape", "Incineroar"}) {
+		t.Errorf("expected Amoonguss to be missing from the alive list, got %v", turn1.Player1Team)
+	}
+
+	// Tatsugiri keeps its status on the bench, and turn 1's snapshot isn't
+	// touched by what happened on turn 2
+	turn2 := summary.Turns[1].StateAfter
+	expectedBench = []BenchPokemon{
+		{ID: "tatsugiri", Name: "Tatsugiri", CurrentHP: 100, MaxHP: 100, Status: "slp", Revealed: true},
+	}
+	if !reflect.DeepEqual(turn2.Player2Bench, expectedBench) {
+		t.Errorf("expected player2 bench %+v, got %+v", expectedBench, turn2.Player2Bench)
+	}
+	if turn1.Player2Active[0].CurrentHP != 85 || turn2.Player2Active[0].CurrentHP != 75 {
+		t.Errorf("expected Dondozo at 85 then 75, got %d then %d", turn1.Player2Active[0].CurrentHP, turn2.Player2Active[0].CurrentHP)
+	}
+}
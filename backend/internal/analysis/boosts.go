@@ -0,0 +1,122 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+const (
+	maxStatStage = 6
+
+	// maxBoostScore caps how far stat stages can move a side's position score
+	maxBoostScore = 10.0
+)
+
+// boostWeights is how many position points one stage of each stat is worth.
+// Attack, Special Attack and Speed decide who gets the next KO, so they count
+// for more than bulk.
+var boostWeights = map[string]float64{
+	"atk":      2,
+	"spa":      2,
+	"spe":      2,
+	"def":      1,
+	"spd":      1,
+	"accuracy": 1,
+	"evasion":  1,
+}
+
+// RecordStatChange applies a stat stage change from |-boost| or |-unboost| to
+// the Pokémon in its slot. Stages stay within ±6.
+func (st *StateTracker) RecordStatChange(e protocol.BoostEvent) {
+	slot := refSlot(e.Pokemon)
+	if slot == "" || e.Stat == "" {
+		return
+	}
+	st.setStatStage(slot, e.Stat, st.statBoosts[slot][e.Stat]+e.Amount)
+}
+
+// SetStatStage handles |-setboost|, which sets a stage outright (Belly Drum,
+// Anger Point).
+func (st *StateTracker) SetStatStage(e protocol.SetBoostEvent) {
+	slot := refSlot(e.Pokemon)
+	if slot == "" || e.Stat == "" {
+		return
+	}
+	st.setStatStage(slot, e.Stat, e.Amount)
+}
+
+// ClearStatStages handles |-clearboost| and friends: Clear Smog resets one
+// Pokémon, Haze resets everyone, and White Herb or Spectral Thief only clear
+// drops or boosts.
+func (st *StateTracker) ClearStatStages(e protocol.ClearBoostEvent) {
+	if e.Kind == protocol.ClearAllBoost {
+		st.statBoosts = make(map[string]map[string]int)
+		return
+	}
+
+	slot := refSlot(e.Pokemon)
+	for stat, stage := range st.statBoosts[slot] {
+		switch {
+		case e.Kind == protocol.ClearBoost,
+			e.Kind == protocol.ClearPositiveBoost && stage > 0,
+			e.Kind == protocol.ClearNegativeBoost && stage < 0:
+			delete(st.statBoosts[slot], stat)
+		}
+	}
+}
+
+// InvertStatStages handles |-invertboost| from Topsy-Turvy.
+func (st *StateTracker) InvertStatStages(e protocol.InvertBoostEvent) {
+	stages := st.statBoosts[refSlot(e.Pokemon)]
+	for stat, stage := range stages {
+		stages[stat] = -stage
+	}
+}
+
+// CopyStatStages handles |-copyboost|, where the source takes on every one of
+// the target's stages, e.g. with Psych Up.
+func (st *StateTracker) CopyStatStages(e protocol.CopyBoostEvent) {
+	source := refSlot(e.Source)
+	if source == "" {
+		return
+	}
+	delete(st.statBoosts, source)
+	for stat, stage := range st.statBoosts[refSlot(e.Target)] {
+		st.setStatStage(source, stat, stage)
+	}
+}
+
+// GetStatStages returns the non-zero stat stages of the Pokémon in slot.
+func (st *StateTracker) GetStatStages(slot string) map[string]int {
+	stages := make(map[string]int, len(st.statBoosts[slot]))
+	for stat, stage := range st.statBoosts[slot] {
+		stages[stat] = stage
+	}
+	return stages
+}
+
+// setStatStage stores a clamped stage, dropping stats that are back to 0.
+func (st *StateTracker) setStatStage(slot, stat string, stage int) {
+	stage = max(-maxStatStage, min(maxStatStage, stage))
+	if stage == 0 {
+		delete(st.statBoosts[slot], stat)
+		return
+	}
+	if _, ok := st.statBoosts[slot]; !ok {
+		st.statBoosts[slot] = make(map[string]int)
+	}
+	st.statBoosts[slot][stat] = stage
+}
+
+// boostScore is what the stat stages on a side's active Pokémon are worth to
+// its position, within ±maxBoostScore.
+func (st *StateTracker) boostScore(playerID string) float64 {
+	score := 0.0
+	for _, letter := range activeSlotLetters {
+		for stat, stage := range st.statBoosts[playerID+letter] {
+			score += boostWeights[stat] * float64(stage)
+		}
+	}
+	return math.Max(-maxBoostScore, math.Min(maxBoostScore, score))
+}
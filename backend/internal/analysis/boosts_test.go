@@ -0,0 +1,120 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+func TestRecordStatChangeAccumulates(t *testing.T) {
+	tracker := NewStateTracker()
+
+	for _, line := range []string{
+		"|-boost|p1a: Annihilape|atk|2",
+		"|-boost|p1a: Annihilape|atk|2",
+		"|-boost|p1a: Annihilape|atk|6",
+		"|-unboost|p1a: Annihilape|spe|1",
+		"|-unboost|p1a: Annihilape|spe|1",
+		"|-boost|p1a: Annihilape|spe|2",
+	} {
+		tracker.RecordStatChange(protocol.Parse(line).(protocol.BoostEvent))
+	}
+
+	// Attack caps at +6 and Speed is back to neutral
+	expected := map[string]int{"atk": 6}
+	if stages := tracker.GetStatStages("p1a"); !reflect.DeepEqual(stages, expected) {
+		t.Errorf("expected %v, got %v", expected, stages)
+	}
+}
+
+func TestStatStageEvents(t *testing.T) {
+	setup := func() *StateTracker {
+		tracker := NewStateTracker()
+		tracker.RecordStatChange(protocol.Parse("|-boost|p1a: Dondozo|atk|2").(protocol.BoostEvent))
+		tracker.RecordStatChange(protocol.Parse("|-unboost|p1a: Dondozo|spe|1").(protocol.BoostEvent))
+		tracker.RecordStatChange(protocol.Parse("|-boost|p2a: Indeedee|spa|1").(protocol.BoostEvent))
+		return tracker
+	}
+	apply := func(tracker *StateTracker, line string) {
+		switch e := protocol.Parse(line).(type) {
+		case protocol.SetBoostEvent:
+			tracker.SetStatStage(e)
+		case protocol.ClearBoostEvent:
+			tracker.ClearStatStages(e)
+		case protocol.InvertBoostEvent:
+			tracker.InvertStatStages(e)
+		case protocol.CopyBoostEvent:
+			tracker.CopyStatStages(e)
+		default:
+			t.Fatalf("unexpected event %T for %q", e, line)
+		}
+	}
+
+	tests := []struct {
+		line string
+		p1a  map[string]int
+		p2a  map[string]int
+	}{
+		{"|-setboost|p1a: Dondozo|atk|6|[from] move: Belly Drum", map[string]int{"atk": 6, "spe": -1}, map[string]int{"spa": 1}},
+		{"|-clearboost|p1a: Dondozo", map[string]int{}, map[string]int{"spa": 1}},
+		{"|-clearallboost", map[string]int{}, map[string]int{}},
+		{"|-clearnegativeboost|p1a: Dondozo", map[string]int{"atk": 2}, map[string]int{"spa": 1}},
+		{"|-clearpositiveboost|p1a: Dondozo|p2a: Indeedee|move: Spectral Thief", map[string]int{"spe": -1}, map[string]int{"spa": 1}},
+		{"|-invertboost|p1a: Dondozo", map[string]int{"atk": -2, "spe": 1}, map[string]int{"spa": 1}},
+		{"|-copyboost|p2a: Indeedee|p1a: Dondozo|[from] move: Psych Up", map[string]int{"atk": 2, "spe": -1}, map[string]int{"atk": 2, "spe": -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			tracker := setup()
+			apply(tracker, tt.line)
+			if got := tracker.GetStatStages("p1a"); !reflect.DeepEqual(got, tt.p1a) {
+				t.Errorf("p1a: expected %v, got %v", tt.p1a, got)
+			}
+			if got := tracker.GetStatStages("p2a"); !reflect.DeepEqual(got, tt.p2a) {
+				t.Errorf("p2a: expected %v, got %v", tt.p2a, got)
+			}
+		})
+	}
+}
+
+func TestStatStagesResetOnSwitch(t *testing.T) {
+	tracker := NewStateTracker()
+	tracker.AddPokemonToTeam("p1", Pokémon{Name: "Annihilape"})
+	tracker.AddPokemonToTeam("p1", Pokémon{Name: "Amoonguss"})
+	tracker.SwitchPokemon("p1a", "Annihilape", 100)
+	tracker.RecordStatChange(protocol.Parse("|-boost|p1a: Annihilape|atk|1").(protocol.BoostEvent))
+
+	tracker.SwitchPokemon("p1a", "Amoonguss", 100)
+	if stages := tracker.GetStatStages("p1a"); len(stages) != 0 {
+		t.Errorf("expected Amoonguss to come in unboosted, got %v", stages)
+	}
+
+	// Coming back doesn't bring the old boosts with it
+	tracker.SwitchPokemon("p1a", "Annihilape", 100)
+	if stages := tracker.GetStatStages("p1a"); len(stages) != 0 {
+		t.Errorf("expected Annihilape's boosts to be gone, got %v", stages)
+	}
+}
+
+func TestPositionScoreCountsBoosts(t *testing.T) {
+	tracker := NewStateTracker()
+	tracker.SetPositionEvaluator(SimpleEvaluator{})
+	for _, side := range []string{"p1", "p2"} {
+		tracker.SetTeamSize(side, 4)
+		tracker.AddPokemonToTeam(side, Pokémon{Name: "Dondozo"})
+		tracker.SwitchPokemon(side+"a", "Dondozo", 60)
+	}
+
+	even := tracker.CalculatePositionScore()
+	if even.Player1Score != even.Player2Score {
+		t.Fatalf("expected an even position, got %+v", even)
+	}
+
+	tracker.RecordStatChange(protocol.Parse("|-boost|p1a: Dondozo|atk|6").(protocol.BoostEvent))
+	boosted := tracker.CalculatePositionScore()
+	if boosted.MomentumPlayer != "player1" || boosted.Player1Score != even.Player1Score+maxBoostScore {
+		t.Errorf("expected +6 attack to swing momentum to player1 by the capped amount, got %+v", boosted)
+	}
+}
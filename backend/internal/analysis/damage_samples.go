@@ -0,0 +1,348 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/dtsong/vgccorner/backend/internal/calc"
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+// sampleStatuses maps protocol status IDs to the calculator's names.
+var sampleStatuses = map[string]string{
+	"brn": "burn",
+	"par": "paralysis",
+	"psn": "poison",
+	"tox": "poison",
+	"slp": "sleep",
+	"frz": "freeze",
+}
+
+// survivalEffects leave a Pokémon at 1 HP from a hit that would have knocked
+// it out. Each shows up just before the hit's |-damage|.
+var survivalEffects = map[string]bool{"Focus Sash": true, "Sturdy": true, "Endure": true}
+
+// ruinAbilities lower a stat of every other active Pokémon.
+var ruinAbilities = map[string]bool{
+	"Sword of Ruin":   true,
+	"Beads of Ruin":   true,
+	"Tablets of Ruin": true,
+	"Vessel of Ruin":  true,
+}
+
+// sampleWeathers maps protocol weather IDs to the calculator's names.
+var sampleWeathers = map[string]string{
+	"SunnyDay":      "Sun",
+	"DesolateLand":  "Sun",
+	"RainDance":     "Rain",
+	"PrimordialSea": "Rain",
+	"Sandstorm":     "Sand",
+	"Snowscape":     "Snow",
+	"Snow":          "Snow",
+}
+
+// sampleBoosts are the stat stages the calculator takes.
+var sampleBoosts = map[string]bool{"atk": true, "def": true, "spa": true, "spd": true, "spe": true}
+
+// damageSampler collects a DamageSample for every direct hit from a move.
+// Damage has to be read before the tracker applies it, so the parser passes
+// |-damage| to RecordDamage first and everything else to Observe after.
+type damageSampler struct {
+	tracker  *StateTracker
+	move     *sampleMove       // Move resolving now, if any
+	crits    map[string]bool   // Slot -> the current move crit it
+	survived map[string]bool   // Slot -> Focus Sash, Sturdy or Endure is about to leave it at 1 HP
+	consumed map[string]string // Slot -> item it used up in response to the current move, such as a resist berry
+	helped   map[string]bool   // Slot -> got Helping Hand this turn
+	lostItem map[string]bool   // "p1: Species" -> its last known item was used up or taken
+	samples  []pendingSample
+}
+
+// sampleMove is the move whose hits are being sampled.
+type sampleMove struct {
+	slot   string
+	name   string
+	spread bool // Had more than one target, so its damage was reduced even if Protect left it hitting one
+}
+
+// pendingSample is a sample that may still learn abilities and items the
+// rest of the battle reveals.
+type pendingSample struct {
+	DamageSample
+	attackerItemUnknown bool
+	defenderItemUnknown bool
+}
+
+func newDamageSampler(tracker *StateTracker) *damageSampler {
+	return &damageSampler{
+		tracker:  tracker,
+		crits:    make(map[string]bool),
+		survived: make(map[string]bool),
+		consumed: make(map[string]string),
+		helped:   make(map[string]bool),
+		lostItem: make(map[string]bool),
+	}
+}
+
+// Observe follows the moves, crits, items and effects that bear on the
+// damage of the hits to come.
+func (o *damageSampler) Observe(event protocol.Event) {
+	switch e := event.(type) {
+	case protocol.MoveEvent:
+		o.move = &sampleMove{slot: normalizeSlot(refSlot(e.Source)), name: e.Move, spread: len(e.Spread) > 0}
+		clear(o.crits)
+		clear(o.survived)
+		clear(o.consumed)
+
+	case protocol.CritEvent:
+		o.crits[normalizeSlot(refSlot(e.Pokemon))] = true
+
+	case protocol.SingleTurnEvent:
+		if e.Effect.Name == "Helping Hand" {
+			o.helped[normalizeSlot(refSlot(e.Pokemon))] = true
+		}
+
+	case protocol.ItemEvent:
+		slot := normalizeSlot(refSlot(e.Pokemon))
+		poke, ok := o.tracker.activePokemon[slot]
+		if !ok {
+			return
+		}
+		key := slotSide(slot) + ": " + poke.Name
+		if !e.Ended {
+			delete(o.lostItem, key)
+			return
+		}
+		o.lostItem[key] = true
+		o.consumed[slot] = e.Item
+		if survivalEffects[e.Item] {
+			o.survived[slot] = true
+		}
+
+	case protocol.AbilityEvent:
+		if survivalEffects[e.Ability] {
+			o.survived[normalizeSlot(refSlot(e.Pokemon))] = true
+		}
+
+	case protocol.ActivateEvent:
+		if survivalEffects[e.Effect.Name] {
+			o.survived[normalizeSlot(refSlot(e.Pokemon))] = true
+		}
+
+	case protocol.SwapEvent:
+		slot := normalizeSlot(refSlot(e.Pokemon))
+		other, ok := swapTarget(slot, e.Position)
+		if !ok {
+			return
+		}
+		// Helping Hand and the current move's effects stay with their Pokémon
+		swapSlots(o.helped, slot, other)
+		swapSlots(o.survived, slot, other)
+		swapSlots(o.consumed, slot, other)
+		if o.move != nil {
+			switch o.move.slot {
+			case slot:
+				o.move.slot = other
+			case other:
+				o.move.slot = slot
+			}
+		}
+
+	case protocol.SwitchEvent, protocol.UpkeepEvent, protocol.TurnEvent:
+		// Nothing without a [from] tag after these belongs to the last move
+		o.move = nil
+	}
+}
+
+// FinishTurn forgets the turn's Helping Hands.
+func (o *damageSampler) FinishTurn() {
+	clear(o.helped)
+}
+
+// RecordDamage samples a |-damage| line from the move resolving now. It must
+// run before the tracker applies the damage, while the defender's HP is
+// still what it was before the hit.
+func (o *damageSampler) RecordDamage(e protocol.DamageEvent) {
+	if o.move == nil || !e.From.IsZero() {
+		return
+	}
+	slot := normalizeSlot(refSlot(e.Pokemon))
+	attacker, ok := o.tracker.activePokemon[o.move.slot]
+	defender, ok2 := o.tracker.activePokemon[slot]
+	if !ok || !ok2 || slot == o.move.slot {
+		// Damage to the user, like Substitute's, isn't a hit
+		return
+	}
+
+	before := HPReading{Current: defender.CurrentHP, Max: defender.MaxHP}
+	after := HPReading{Current: e.HP.Current, Max: e.HP.Max}
+	if e.HP.Fainted || after.Max == 0 {
+		after.Max = before.Max
+	}
+	if after.Current >= before.Current {
+		return
+	}
+
+	sample := pendingSample{DamageSample: DamageSample{
+		TurnNumber: o.tracker.turnNumber,
+		Attacker:   o.samplePokemon(o.move.slot, attacker),
+		Defender:   o.samplePokemon(slot, defender),
+		Move:       calc.Move{Name: o.move.name, Crit: o.crits[slot]},
+		Field:      o.field(slot),
+		Before:     before,
+		After:      after,
+		LowerBound: after.Current == 0 || o.survived[slot],
+	}}
+	if item, ok := o.consumed[slot]; ok {
+		// Berries and Focus Sash go just before the hit they react to
+		sample.Defender.Set.Item = item
+		delete(o.consumed, slot)
+	}
+	sample.attackerItemUnknown = sample.Attacker.Set.Item == "" && !o.lostItem[slotSide(o.move.slot)+": "+attacker.Name]
+	sample.defenderItemUnknown = sample.Defender.Set.Item == "" && !o.lostItem[slotSide(slot)+": "+defender.Name]
+	delete(o.crits, slot)
+	delete(o.survived, slot)
+	o.samples = append(o.samples, sample)
+}
+
+// samplePokemon captures what's known about the Pokémon in slot right now.
+func (o *damageSampler) samplePokemon(slot string, poke *Pokémon) SamplePokemon {
+	side := slotSide(slot)
+	set := calc.Pokemon{
+		Species: poke.Name,
+		Level:   poke.Level,
+		Ability: poke.Ability,
+		Item:    poke.Item,
+		Status:  sampleStatuses[poke.Status],
+	}
+	if o.lostItem[side+": "+poke.Name] {
+		set.Item = ""
+	}
+	if poke.MaxHP > 0 && poke.CurrentHP < poke.MaxHP {
+		set.HPPercent = max(1, float64(poke.CurrentHP)*100/float64(poke.MaxHP))
+	}
+	for stat, stage := range o.tracker.statBoosts[slot] {
+		if _, ok := spreadStatLabels[stat]; ok && stat != "hp" && stage != 0 {
+			if set.Boosts == nil {
+				set.Boosts = make(map[string]int)
+			}
+			set.Boosts[stat] = stage
+		}
+	}
+	if tera := o.tracker.teraUsed[side]; tera != nil && tera.Pokemon == poke.Name {
+		set.TeraType = tera.TeraType
+		set.Terastallized = true
+	}
+	o.fillParadox(slot, &set)
+	return SamplePokemon{Pokemon: poke.Name, Player: sideToPlayer(side), Set: set}
+}
+
+// fillParadox fills in Protosynthesis or Quark Drive, and the Booster Energy
+// behind it when the field isn't, for a Pokémon whose ability is active.
+func (o *damageSampler) fillParadox(slot string, set *calc.Pokemon) {
+	field := o.tracker.GetFieldState()
+	for _, paradox := range []struct {
+		volatile, ability string
+		fieldActive       bool
+	}{
+		{"protosynthesis", "Protosynthesis", field.Weather != nil && field.Weather.Name == "SunnyDay"},
+		{"quarkdrive", "Quark Drive", field.Terrain != nil && field.Terrain.Name == "Electric Terrain"},
+	} {
+		for _, stat := range []string{"atk", "def", "spa", "spd", "spe"} {
+			if _, ok := o.tracker.volatiles.active[slot+"|"+paradox.volatile+stat]; !ok {
+				continue
+			}
+			if set.Ability == "" {
+				set.Ability = paradox.ability
+			}
+			if set.Item == "" && !paradox.fieldActive {
+				set.Item = "Booster Energy"
+			}
+			return
+		}
+	}
+}
+
+// field captures the conditions on a hit from the current move to the
+// Pokémon in defSlot.
+func (o *damageSampler) field(defSlot string) calc.Field {
+	state := o.tracker.GetFieldState()
+	field := calc.Field{
+		SingleTarget: !o.move.spread,
+		HelpingHand:  o.helped[o.move.slot],
+	}
+	if state.Weather != nil {
+		field.Weather = sampleWeathers[state.Weather.Name]
+	}
+	if state.Terrain != nil {
+		field.Terrain = strings.TrimSuffix(state.Terrain.Name, " Terrain")
+	}
+
+	conditions := state.Player1Side
+	if slotSide(defSlot) == "p2" {
+		conditions = state.Player2Side
+	}
+	for _, condition := range conditions {
+		switch condition.Name {
+		case "Reflect":
+			field.Reflect = true
+		case "Light Screen":
+			field.LightScreen = true
+		case "Aurora Veil":
+			field.AuroraVeil = true
+		}
+	}
+
+	for _, side := range []string{"p1", "p2"} {
+		for _, letter := range activeSlotLetters {
+			slot := side + letter
+			poke, ok := o.tracker.activePokemon[slot]
+			if !ok || poke.CurrentHP <= 0 {
+				continue
+			}
+			ally := func(of string) bool { return slot != of && slotSide(slot) == slotSide(of) }
+			switch {
+			case ruinAbilities[poke.Ability]:
+				field.Ruin = append(field.Ruin, poke.Ability)
+			case poke.Ability == "Friend Guard" && ally(defSlot):
+				field.FriendGuard = true
+			case poke.Ability == "Battery" && ally(o.move.slot):
+				field.Battery = true
+			case poke.Ability == "Power Spot" && ally(o.move.slot):
+				field.PowerSpot = true
+			}
+		}
+	}
+	return field
+}
+
+// Samples returns the samples taken, with abilities and items revealed
+// later in the battle filled in. An item only counts if the Pokémon hadn't
+// lost one before the hit.
+func (o *damageSampler) Samples() []DamageSample {
+	samples := make([]DamageSample, 0, len(o.samples))
+	for _, s := range o.samples {
+		o.fillFromTeam(&s.Attacker, s.attackerItemUnknown)
+		o.fillFromTeam(&s.Defender, s.defenderItemUnknown)
+		samples = append(samples, s.DamageSample)
+	}
+	return samples
+}
+
+func (o *damageSampler) fillFromTeam(poke *SamplePokemon, itemUnknown bool) {
+	side := "p1"
+	if poke.Player == "player2" {
+		side = "p2"
+	}
+	for _, member := range o.tracker.GetTeam(side) {
+		if member.Name != poke.Pokemon {
+			continue
+		}
+		if poke.Set.Ability == "" {
+			poke.Set.Ability = member.Ability
+		}
+		if itemUnknown && poke.Set.Item == "" {
+			poke.Set.Item = member.Item
+		}
+		return
+	}
+}
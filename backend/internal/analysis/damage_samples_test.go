@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/calc"
+)
+
+const damageSampleBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Flutter Mane, L50|
+|poke|p1|Rillaboom, L50, M|
+|poke|p2|Incineroar, L50, M|
+|poke|p2|Amoonguss, L50, F|
+|teamsize|p1|2
+|teamsize|p2|2
+|start
+|switch|p1a: Flutter Mane|Flutter Mane, L50|100/100
+|switch|p1b: Rillaboom|Rillaboom, L50, M|100/100
+|switch|p2a: Incineroar|Incineroar, L50, M|100/100
+|switch|p2b: Amoonguss|Amoonguss, L50, F|100/100
+|-fieldstart|move: Grassy Terrain|[from] ability: Grassy Surge|[of] p1b: Rillaboom
+|turn|1
+|move|p2b: Amoonguss|Helping Hand|p2a: Incineroar
+|-singleturn|p2a: Incineroar|Helping Hand|[of] p2b: Amoonguss
+|move|p2a: Incineroar|Flare Blitz|p1b: Rillaboom
+|-crit|p1b: Rillaboom
+|-supereffective|p1b: Rillaboom
+|-damage|p1b: Rillaboom|40/100
+|-damage|p2a: Incineroar|85/100|[from] Recoil
+|move|p1a: Flutter Mane|Dazzling Gleam|p2a: Incineroar|[spread] p2a,p2b
+|-enditem|p2b: Amoonguss|Roseli Berry|[eat]
+|-resisted|p2a: Incineroar
+|-damage|p2a: Incineroar|70/100
+|-damage|p2b: Amoonguss|90/100
+|-heal|p2b: Amoonguss|96/100|[from] Grassy Terrain
+|upkeep
+|turn|2
+|move|p2b: Amoonguss|Reflect|p2b: Amoonguss
+|-sidestart|p2: Bob|move: Reflect
+|move|p1b: Rillaboom|Wood Hammer|p2a: Incineroar
+|-resisted|p2a: Incineroar
+|-damage|p2a: Incineroar|60/100
+|-damage|p1b: Rillaboom|35/100|[from] Recoil
+|move|p1a: Flutter Mane|Moonblast|p2b: Amoonguss
+|-damage|p2b: Amoonguss|0 fnt
+|faint|p2b: Amoonguss
+|upkeep
+|win|Alice`
+
+func TestParseShowdownLogDamageSamples(t *testing.T) {
+	summary, err := ParseShowdownLog(damageSampleBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Recoil and Grassy Terrain's healing aren't hits
+	if len(summary.DamageSamples) != 5 {
+		t.Fatalf("expected 5 samples, got %d: %+v", len(summary.DamageSamples), summary.DamageSamples)
+	}
+	samples := summary.DamageSamples
+
+	flareBlitz := samples[0]
+	if flareBlitz.TurnNumber != 1 || flareBlitz.Attacker.Pokemon != "Incineroar" || flareBlitz.Attacker.Player != "player2" ||
+		flareBlitz.Defender.Pokemon != "Rillaboom" || flareBlitz.Defender.Player != "player1" {
+		t.Errorf("unexpected Flare Blitz sample: %+v", flareBlitz)
+	}
+	if !flareBlitz.Move.Crit || !flareBlitz.Field.HelpingHand || !flareBlitz.Field.SingleTarget {
+		t.Errorf("expected a single-target Helping Hand crit, got %+v %+v", flareBlitz.Move, flareBlitz.Field)
+	}
+	if flareBlitz.Field.Terrain != "Grassy" {
+		t.Errorf("expected Grassy Terrain, got %q", flareBlitz.Field.Terrain)
+	}
+	if flareBlitz.Before != (HPReading{100, 100}) || flareBlitz.After != (HPReading{40, 100}) || flareBlitz.LowerBound {
+		t.Errorf("expected 100%% -> 40%%, got %+v -> %+v", flareBlitz.Before, flareBlitz.After)
+	}
+
+	// Dazzling Gleam hit both, and Amoonguss ate its berry for it
+	gleamIncineroar, gleamAmoonguss := samples[1], samples[2]
+	if gleamIncineroar.Field.SingleTarget || gleamAmoonguss.Field.SingleTarget {
+		t.Error("expected Dazzling Gleam's hits to be spread")
+	}
+	if gleamIncineroar.Move.Crit || gleamIncineroar.Field.HelpingHand {
+		t.Errorf("expected no crit or Helping Hand on Flutter Mane's hit, got %+v %+v", gleamIncineroar.Move, gleamIncineroar.Field)
+	}
+	if gleamIncineroar.Defender.Set.HPPercent != 85 {
+		t.Errorf("expected Incineroar at 85%% after recoil, got %v", gleamIncineroar.Defender.Set.HPPercent)
+	}
+	if gleamAmoonguss.Defender.Set.Item != "Roseli Berry" {
+		t.Errorf("expected the Roseli Berry on the hit it was eaten for, got %q", gleamAmoonguss.Defender.Set.Item)
+	}
+
+	// Reflect went up on Bob's side, and the eaten berry is gone
+	woodHammer, moonblast := samples[3], samples[4]
+	if !woodHammer.Field.Reflect || !moonblast.Field.Reflect {
+		t.Error("expected Reflect on Bob's side in turn 2")
+	}
+	if woodHammer.Before != (HPReading{70, 100}) || woodHammer.After != (HPReading{60, 100}) {
+		t.Errorf("expected 70%% -> 60%%, got %+v -> %+v", woodHammer.Before, woodHammer.After)
+	}
+	want := calc.Pokemon{Species: "Amoonguss", Level: 50, HPPercent: 96}
+	if !reflect.DeepEqual(moonblast.Defender.Set, want) {
+		t.Errorf("expected %+v, got %+v", want, moonblast.Defender.Set)
+	}
+	if !moonblast.LowerBound || moonblast.After != (HPReading{0, 100}) {
+		t.Errorf("expected the knockout to be a lower bound, got %+v", moonblast)
+	}
+}
@@ -0,0 +1,261 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+// Damage source kinds reported in DamageSource.Kind.
+const (
+	SourceMove    = "move"
+	SourceItem    = "item"
+	SourceAbility = "ability"
+	SourceWeather = "weather"
+	SourceStatus  = "status"
+	SourceRecoil  = "recoil"
+)
+
+// residualWeathers are the weathers that chip Pokémon at the end of each turn.
+var residualWeathers = map[string]bool{"Sandstorm": true, "Hail": true}
+
+// residualStatuses are the major statuses that chip Pokémon at the end of each turn.
+var residualStatuses = map[string]bool{"psn": true, "tox": true, "brn": true}
+
+// Label formats the source as "kind: name", e.g. "item: Life Orb".
+func (s DamageSource) Label() string {
+	if s.Name == "" {
+		return s.Kind
+	}
+	return s.Kind + ": " + s.Name
+}
+
+// sourceTracker remembers who set up lingering effects, so damage that shows
+// up turns later with only a [from] tag can be traced to the Pokémon
+// responsible for it.
+type sourceTracker struct {
+	lastMover     protocol.PokemonRef
+	lastMove      string
+	weatherSetter protocol.PokemonRef
+	statusSetters map[string]protocol.PokemonRef // "p1: Name" -> who inflicted its status
+	effectSetters map[string]protocol.PokemonRef // "p1: Name|Salt Cure" or "p1|Stealth Rock" -> who started it
+}
+
+func newSourceTracker() *sourceTracker {
+	return &sourceTracker{
+		statusSetters: make(map[string]protocol.PokemonRef),
+		effectSetters: make(map[string]protocol.PokemonRef),
+	}
+}
+
+// RecordMove notes the Pokémon now acting; HP changes without a [from] tag
+// belong to its move.
+func (s *sourceTracker) RecordMove(e protocol.MoveEvent) {
+	s.lastMover = e.Source
+	s.lastMove = e.Move
+}
+
+// RecordSwitch clears the acting Pokémon, so entry effects such as Toxic
+// Spikes aren't blamed on whoever moved before the switch.
+func (s *sourceTracker) RecordSwitch() {
+	s.lastMover = protocol.PokemonRef{}
+	s.lastMove = ""
+}
+
+// RecordWeather tracks who set the current weather.
+func (s *sourceTracker) RecordWeather(e protocol.WeatherEvent) {
+	if e.Upkeep {
+		// End-of-turn effects start here, so nothing after belongs to the last move
+		s.lastMover = protocol.PokemonRef{}
+		s.lastMove = ""
+		return
+	}
+	if e.Weather == "none" {
+		s.weatherSetter = protocol.PokemonRef{}
+		return
+	}
+	s.weatherSetter = s.responsible(e.Of)
+}
+
+// RecordStatus tracks who inflicted a Pokémon's status.
+func (s *sourceTracker) RecordStatus(e protocol.StatusEvent) {
+	setter := s.responsible(e.Of)
+	if e.From.Kind == "item" && e.Of.IsZero() {
+		// Flame Orb and Toxic Orb
+		setter = e.Pokemon
+	}
+	s.statusSetters[pokemonKey(e.Pokemon)] = setter
+}
+
+// RecordVolatile tracks who started a volatile such as Leech Seed or Salt Cure.
+func (s *sourceTracker) RecordVolatile(e protocol.VolatileEvent) {
+	key := pokemonKey(e.Pokemon) + "|" + e.Effect.Name
+	if e.Ended {
+		delete(s.effectSetters, key)
+		return
+	}
+	s.effectSetters[key] = s.responsible(e.Of)
+}
+
+// RecordSide tracks who set a side condition such as Stealth Rock.
+func (s *sourceTracker) RecordSide(e protocol.SideEvent) {
+	key := e.Side + "|" + e.Effect.Name
+	if e.Ended {
+		delete(s.effectSetters, key)
+		return
+	}
+	s.effectSetters[key] = s.lastMover
+}
+
+// Attribute works out what caused an HP change and which Pokémon was responsible.
+func (s *sourceTracker) Attribute(change HPChange) DamageSource {
+	from := change.From
+	var source DamageSource
+	var responsible protocol.PokemonRef
+
+	switch {
+	case change.Direct():
+		source = DamageSource{Kind: SourceMove, Name: s.lastMove}
+		responsible = s.lastMover
+	case from.Kind == "item":
+		// Rocky Helmet names its holder with [of]; Life Orb hurts the holder
+		source = DamageSource{Kind: SourceItem, Name: from.Name}
+		responsible = firstRef(change.Of, change.Pokemon)
+	case from.Kind == "ability":
+		source = DamageSource{Kind: SourceAbility, Name: from.Name}
+		responsible = firstRef(change.Of, change.Pokemon)
+	case strings.EqualFold(from.Name, "Recoil"):
+		source = DamageSource{Kind: SourceRecoil, Name: s.lastMove}
+		responsible = change.Pokemon
+	case residualWeathers[from.Name]:
+		source = DamageSource{Kind: SourceWeather, Name: from.Name}
+		responsible = s.weatherSetter
+	case residualStatuses[from.Name]:
+		source = DamageSource{Kind: SourceStatus, Name: from.Name}
+		responsible = s.statusSetters[pokemonKey(change.Pokemon)]
+	case strings.EqualFold(from.Name, "confusion"):
+		source = DamageSource{Kind: SourceStatus, Name: "confusion"}
+		responsible = s.effectSetters[pokemonKey(change.Pokemon)+"|confusion"]
+	default:
+		// Lingering move effects: Leech Seed, Salt Cure, Stealth Rock, binding moves
+		source = DamageSource{Kind: SourceMove, Name: from.Name}
+		responsible = firstRef(
+			change.Of,
+			s.effectSetters[pokemonKey(change.Pokemon)+"|"+from.Name],
+			s.effectSetters[change.Pokemon.Side+"|"+from.Name],
+		)
+	}
+
+	if source.Name == "" {
+		source.Name = from.Name
+	}
+	if !responsible.IsZero() {
+		source.Pokemon = responsible.Name
+		source.Player = sideToPlayer(responsible.Side)
+	}
+	return source
+}
+
+// responsible is of when the protocol names a Pokémon, otherwise the Pokémon
+// whose move is resolving.
+func (s *sourceTracker) responsible(of protocol.PokemonRef) protocol.PokemonRef {
+	return firstRef(of, s.lastMover)
+}
+
+// firstRef returns the first non-zero reference.
+func firstRef(refs ...protocol.PokemonRef) protocol.PokemonRef {
+	for _, ref := range refs {
+		if !ref.IsZero() {
+			return ref
+		}
+	}
+	return protocol.PokemonRef{}
+}
+
+// pokemonKey identifies a Pokémon regardless of the slot it's in.
+func pokemonKey(ref protocol.PokemonRef) string {
+	return ref.Side + ": " + ref.Name
+}
+
+// damageLedger totals damage by source for each player and Pokémon.
+// Pokémon are totalled by species, though the log names them by nickname.
+type damageLedger struct {
+	dealtByKind map[string]map[string]int // Player -> kind -> damage their Pokémon did to the opponent's
+	takenByKind map[string]map[string]int // Player -> kind -> damage to the player's Pokémon
+	species     map[string]string         // "player1: Nickname" -> species
+	pokemon     map[string]*PokemonDamage
+	order       []string
+}
+
+func newDamageLedger() *damageLedger {
+	return &damageLedger{
+		dealtByKind: map[string]map[string]int{"player1": {}, "player2": {}},
+		takenByKind: map[string]map[string]int{"player1": {}, "player2": {}},
+		species:     make(map[string]string),
+		pokemon:     make(map[string]*PokemonDamage),
+	}
+}
+
+// RecordSwitch notes the species behind the nickname of a Pokémon switching in.
+func (l *damageLedger) RecordSwitch(e protocol.SwitchEvent) {
+	l.species[sideToPlayer(e.Pokemon.Side)+": "+e.Pokemon.Name] = e.Details.Species
+}
+
+// Add records an attributed HP change. Healing is ignored.
+func (l *damageLedger) Add(change HPChange) {
+	source := change.Source
+	damage := change.Damage()
+	if damage == 0 {
+		return
+	}
+
+	victim := sideToPlayer(change.Pokemon.Side)
+	l.takenByKind[victim][source.Kind] += damage
+
+	label := source.Label()
+	taken := l.entry(victim, change.Pokemon.Name)
+	taken.DamageTaken += damage
+	taken.TakenBySource[label] += damage
+
+	// Recoil, Life Orb, friendly fire and field damage nobody on the other
+	// side set up aren't damage dealt
+	if source.Pokemon != "" && source.Player != victim {
+		l.dealtByKind[source.Player][source.Kind] += damage
+		dealt := l.entry(source.Player, source.Pokemon)
+		dealt.DamageDealt += damage
+		dealt.DealtBySource[label] += damage
+	}
+}
+
+func (l *damageLedger) entry(player, nickname string) *PokemonDamage {
+	pokemon := nickname
+	if species, ok := l.species[player+": "+nickname]; ok {
+		pokemon = species
+	}
+	key := player + ": " + pokemon
+	if entry, ok := l.pokemon[key]; ok {
+		return entry
+	}
+	entry := &PokemonDamage{
+		Player:        player,
+		Pokemon:       pokemon,
+		DealtBySource: make(map[string]int),
+		TakenBySource: make(map[string]int),
+	}
+	l.pokemon[key] = entry
+	l.order = append(l.order, key)
+	return entry
+}
+
+// Apply copies the breakdowns into the battle's stats.
+func (l *damageLedger) Apply(stats *BattleStats) {
+	stats.Player1Stats.DamageDealtBySource = l.dealtByKind["player1"]
+	stats.Player1Stats.DamageTakenBySource = l.takenByKind["player1"]
+	stats.Player2Stats.DamageDealtBySource = l.dealtByKind["player2"]
+	stats.Player2Stats.DamageTakenBySource = l.takenByKind["player2"]
+
+	stats.PokemonDamage = make([]PokemonDamage, 0, len(l.order))
+	for _, key := range l.order {
+		stats.PokemonDamage = append(stats.PokemonDamage, *l.pokemon[key])
+	}
+}
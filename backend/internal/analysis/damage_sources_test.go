@@ -0,0 +1,221 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+const damageSourcesLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Tyranitar, L50|
+|poke|p1|Garganacl, L50|
+|poke|p2|Dragapult, L50|
+|poke|p2|Amoonguss, L50|
+|teamsize|p1|2
+|teamsize|p2|2
+|start
+|switch|p1a: Tyranitar|Tyranitar, L50|100/100
+|switch|p1b: Garganacl|Garganacl, L50|100/100
+|switch|p2a: Dragapult|Dragapult, L50|100/100
+|switch|p2b: Amoonguss|Amoonguss, L50|100/100
+|-weather|Sandstorm|[from] ability: Sand Stream|[of] p1a: Tyranitar
+|turn|1
+|move|p2a: Dragapult|Dragon Darts|p1a: Tyranitar
+|-damage|p1a: Tyranitar|80/100
+|-damage|p2a: Dragapult|84/100|[from] item: Rocky Helmet|[of] p1a: Tyranitar
+|-damage|p2a: Dragapult|74/100|[from] item: Life Orb
+|move|p1b: Garganacl|Salt Cure|p2b: Amoonguss
+|-damage|p2b: Amoonguss|90/100
+|-start|p2b: Amoonguss|Salt Cure
+|move|p2b: Amoonguss|Leech Seed|p1a: Tyranitar
+|-start|p1a: Tyranitar|move: Leech Seed
+|move|p1a: Tyranitar|Double-Edge|p2a: Dragapult
+|-damage|p2a: Dragapult|34/100
+|-damage|p1a: Tyranitar|67/100|[from] Recoil
+|-weather|Sandstorm|[upkeep]
+|-damage|p2a: Dragapult|28/100|[from] Sandstorm
+|-damage|p2b: Amoonguss|84/100|[from] Sandstorm
+|-damage|p2b: Amoonguss|72/100|[from] Salt Cure
+|-damage|p1a: Tyranitar|55/100|[from] Leech Seed|[of] p2b: Amoonguss
+|-heal|p2b: Amoonguss|84/100|[silent]
+|upkeep
+|turn|2
+|move|p1b: Garganacl|Toxic|p2a: Dragapult
+|-status|p2a: Dragapult|tox
+|-damage|p2a: Dragapult|22/100|[from] psn
+|upkeep
+|win|Alice`
+
+func TestParseShowdownLogDamageSources(t *testing.T) {
+	summary, err := ParseShowdownLog(damageSourcesLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats := summary.Stats
+
+	p1Dealt := map[string]int{"move": 40 + 10 + 12, "weather": 6 + 6, "status": 6}
+	for kind, expected := range p1Dealt {
+		if got := stats.Player1Stats.DamageDealtBySource[kind]; got != expected {
+			t.Errorf("player1 %s damage: expected %d, got %d", kind, expected, got)
+		}
+	}
+	p2Taken := map[string]int{"move": 62, "item": 26, "weather": 12, "status": 6}
+	for kind, expected := range p2Taken {
+		if got := stats.Player2Stats.DamageTakenBySource[kind]; got != expected {
+			t.Errorf("player2 %s damage taken: expected %d, got %d", kind, expected, got)
+		}
+	}
+
+	// Double-Edge's recoil and Dragapult's Life Orb hurt their own side, so
+	// they're taken but not dealt
+	if got := stats.Player2Stats.DamageDealtBySource["recoil"]; got != 0 {
+		t.Errorf("expected no recoil credited to player2, got %d", got)
+	}
+	if got := stats.Player1Stats.DamageDealtBySource["item"]; got != 16 {
+		t.Errorf("expected only Rocky Helmet's 16 item damage credited to player1, got %d", got)
+	}
+
+	// Breakdowns add up to the totals
+	byPokemon := make(map[string]PokemonDamage)
+	pokemonDealt := make(map[string]int)
+	for _, pd := range stats.PokemonDamage {
+		byPokemon[pd.Pokemon] = pd
+		pokemonDealt[pd.Player] += pd.DamageDealt
+	}
+	for player, ps := range map[string]PlayerStats{"player1": stats.Player1Stats, "player2": stats.Player2Stats} {
+		dealt, taken := 0, 0
+		for _, damage := range ps.DamageDealtBySource {
+			dealt += damage
+		}
+		for _, damage := range ps.DamageTakenBySource {
+			taken += damage
+		}
+		if dealt != ps.DamageDealt || dealt != pokemonDealt[player] || taken != ps.DamageTaken {
+			t.Errorf("%s: expected breakdowns %d/%d to match totals %d/%d", player, dealt, taken, ps.DamageDealt, ps.DamageTaken)
+		}
+	}
+
+	tyranitar := byPokemon["Tyranitar"]
+	expectTyranitar := map[string]int{"move: Double-Edge": 40, "item: Rocky Helmet": 16, "weather: Sandstorm": 12}
+	for source, expected := range expectTyranitar {
+		if got := tyranitar.DealtBySource[source]; got != expected {
+			t.Errorf("Tyranitar %s: expected %d dealt, got %d", source, expected, got)
+		}
+	}
+	if tyranitar.DamageDealt != 68 {
+		t.Errorf("expected Tyranitar to deal 68, got %d", tyranitar.DamageDealt)
+	}
+	if tyranitar.TakenBySource["recoil: Double-Edge"] != 13 || tyranitar.TakenBySource["move: Leech Seed"] != 12 {
+		t.Errorf("unexpected Tyranitar damage taken: %v", tyranitar.TakenBySource)
+	}
+
+	garganacl := byPokemon["Garganacl"]
+	if garganacl.DealtBySource["move: Salt Cure"] != 22 || garganacl.DealtBySource["status: psn"] != 6 {
+		t.Errorf("unexpected Garganacl damage dealt: %v", garganacl.DealtBySource)
+	}
+
+	amoonguss := byPokemon["Amoonguss"]
+	if amoonguss.DealtBySource["move: Leech Seed"] != 12 {
+		t.Errorf("expected Leech Seed damage credited to Amoonguss, got %v", amoonguss.DealtBySource)
+	}
+
+	// Life Orb is self-inflicted, so it's damage taken but not dealt
+	dragapult := byPokemon["Dragapult"]
+	if dragapult.TakenBySource["item: Life Orb"] != 10 {
+		t.Errorf("expected Life Orb damage on Dragapult, got %v", dragapult.TakenBySource)
+	}
+	if dragapult.DamageDealt != 20 {
+		t.Errorf("expected Dragapult to deal only its Dragon Darts damage, got %d", dragapult.DamageDealt)
+	}
+}
+
+func TestParseShowdownLogDamageSourcesNicknames(t *testing.T) {
+	log := `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Tyranitar, L50|
+|poke|p1|Garganacl, L50|
+|poke|p2|Dragapult, L50|
+|teamsize|p1|2
+|teamsize|p2|1
+|start
+|switch|p1a: Pupitar Jr|Tyranitar, L50|100/100
+|switch|p2a: Ghost|Dragapult, L50|100/100
+|-weather|Sandstorm|[from] ability: Sand Stream|[of] p1a: Pupitar Jr
+|turn|1
+|switch|p1a: Salty|Garganacl, L50|100/100
+|move|p2a: Ghost|Dragon Darts|p1a: Salty
+|-damage|p1a: Salty|80/100
+|-weather|Sandstorm|[upkeep]
+|-damage|p2a: Ghost|94/100|[from] Sandstorm
+|upkeep
+|win|Alice`
+	summary, _ := ParseShowdownLog(log)
+
+	// Damage is totalled by species, even once the nicknamed source has switched out
+	byPokemon := make(map[string]PokemonDamage)
+	for _, pd := range summary.Stats.PokemonDamage {
+		byPokemon[pd.Pokemon] = pd
+	}
+	if len(byPokemon) != 3 {
+		t.Fatalf("expected damage for Tyranitar, Garganacl and Dragapult, got %+v", summary.Stats.PokemonDamage)
+	}
+	if got := byPokemon["Tyranitar"].DealtBySource["weather: Sandstorm"]; got != 6 {
+		t.Errorf("expected Sandstorm damage credited to Tyranitar, got %d", got)
+	}
+	if got := byPokemon["Dragapult"].DealtBySource["move: Dragon Darts"]; got != 20 {
+		t.Errorf("expected Dragon Darts damage credited to Dragapult, got %d", got)
+	}
+	if got := byPokemon["Garganacl"].DamageTaken; got != 20 {
+		t.Errorf("expected Garganacl to take 20, got %d", got)
+	}
+}
+
+func TestParseShowdownLogMoveImpactIgnoresResidualDamage(t *testing.T) {
+	summary, _ := ParseShowdownLog(damageSourcesLog)
+	turn := summary.Turns[0]
+
+	darts := turn.Actions[0]
+	if darts.Impact.DamageDealt != 20 {
+		t.Errorf("expected Dragon Darts to deal 20, got %d", darts.Impact.DamageDealt)
+	}
+
+	// Sand, Salt Cure and Leech Seed chip after Double-Edge isn't its damage
+	doubleEdge := turn.Actions[3]
+	if doubleEdge.Impact.DamageDealt != 40 {
+		t.Errorf("expected Double-Edge to deal 40, got %d", doubleEdge.Impact.DamageDealt)
+	}
+}
+
+func TestAttributeDamageSource(t *testing.T) {
+	ref := protocol.ParsePokemonRef
+	tests := []struct {
+		name     string
+		change   HPChange
+		expected DamageSource
+	}{
+		{
+			name:     "ability with of",
+			change:   HPChange{Pokemon: ref("p2a: Dragapult"), From: protocol.ParseEffect("ability: Rough Skin"), Of: ref("p1a: Garchomp")},
+			expected: DamageSource{Kind: SourceAbility, Name: "Rough Skin", Pokemon: "Garchomp", Player: "player1"},
+		},
+		{
+			name:     "unknown confusion source",
+			change:   HPChange{Pokemon: ref("p2a: Dragapult"), From: protocol.ParseEffect("confusion")},
+			expected: DamageSource{Kind: SourceStatus, Name: "confusion"},
+		},
+		{
+			name:     "hazard without a setter",
+			change:   HPChange{Pokemon: ref("p2a: Dragapult"), From: protocol.ParseEffect("Stealth Rock")},
+			expected: DamageSource{Kind: SourceMove, Name: "Stealth Rock"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newSourceTracker().Attribute(tt.change); got != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
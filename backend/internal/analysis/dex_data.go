@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/dtsong/vgccorner/backend/internal/dex"
+)
+
+// withDexData fills in a Pokémon's types and base stats from its species,
+// the details of each of its moves, and the proper names of its item,
+// ability and moves, so a packed "WillOWisp" reads "Will-O-Wisp". Anything
+// missing from the dex is left as it is. A team preview name hiding the
+// forme, such as "Urshifu-*", gets the base species' stats but no types,
+// since formes can differ in type.
+func withDexData(poke Pokémon) Pokémon {
+	if species, ok := dex.LookupSpecies(poke.Name); ok {
+		poke.Stats = Stats{
+			HP:      species.BaseStats.HP,
+			Attack:  species.BaseStats.Atk,
+			Defense: species.BaseStats.Def,
+			SpAtk:   species.BaseStats.SpA,
+			SpDef:   species.BaseStats.SpD,
+			Speed:   species.BaseStats.Spe,
+		}
+		if !strings.HasSuffix(poke.Name, "-*") {
+			poke.Types = species.Types
+		}
+	}
+	if item, ok := dex.LookupItem(poke.Item); ok {
+		poke.Item = item.Name
+	}
+	if ability, ok := dex.LookupAbility(poke.Ability); ok {
+		poke.Ability = ability
+	}
+	for i, move := range poke.Moves {
+		poke.Moves[i] = moveFromDex(move.Name)
+	}
+	return poke
+}
+
+// moveFromDex builds a Move from the dex entry for name, or one with just
+// its name and ID if the move is unknown.
+func moveFromDex(name string) Move {
+	move := Move{ID: toID(name), Name: name}
+	if data, ok := dex.LookupMove(name); ok {
+		move.Name = data.Name
+		move.Type = data.Type
+		move.Category = data.Category
+		move.Power = data.BasePower
+		move.Accuracy = data.Accuracy
+		move.PP = data.PP
+	}
+	return move
+}
@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseShowdownLogDexData(t *testing.T) {
+	log := `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Incineroar, L50, M|
+|poke|p1|Urshifu-*, L50|
+|poke|p2|Missingno, L50|
+|showteam|p1|Incineroar||SitrusBerry|Intimidate|FakeOut,FlareBlitz,MadeUpMove||||||50|
+|start
+|switch|p1a: Incineroar|Incineroar, L50, M|100/100
+|switch|p2a: Missingno|Missingno, L50|100/100
+|turn|1
+|win|Alice
+`
+	summary, err := ParseShowdownLog(log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	incineroar := summary.Player1.Team[0]
+	if !reflect.DeepEqual(incineroar.Types, []string{"Fire", "Dark"}) {
+		t.Errorf("expected Fire/Dark, got %v", incineroar.Types)
+	}
+	expectedStats := Stats{HP: 95, Attack: 115, Defense: 90, SpAtk: 80, SpDef: 90, Speed: 60}
+	if incineroar.Stats != expectedStats {
+		t.Errorf("expected stats %+v, got %+v", expectedStats, incineroar.Stats)
+	}
+
+	expectedMoves := []Move{
+		{ID: "fakeout", Name: "Fake Out", Type: "Normal", Category: "Physical", Power: 40, Accuracy: 100, PP: 10},
+		{ID: "flareblitz", Name: "Flare Blitz", Type: "Fire", Category: "Physical", Power: 120, Accuracy: 100, PP: 15},
+		{ID: "madeupmove", Name: "Made Up Move"},
+	}
+	if !reflect.DeepEqual(incineroar.Moves, expectedMoves) {
+		t.Errorf("expected moves %+v, got %+v", expectedMoves, incineroar.Moves)
+	}
+
+	// A hidden forme gets the shared base stats but not a guessed type
+	urshifu := summary.Player1.Team[1]
+	if urshifu.Stats.Attack != 130 || urshifu.Types != nil {
+		t.Errorf("unexpected Urshifu-* data: types %v, stats %+v", urshifu.Types, urshifu.Stats)
+	}
+
+	if missing := summary.Player2.Team[0]; missing.Types != nil || missing.Stats != (Stats{}) {
+		t.Errorf("expected no data for an unknown species, got %+v", missing)
+	}
+}
+
+func TestParseShowdownLogActionMovesFromDex(t *testing.T) {
+	summary, err := ParseShowdownLog(hpBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	thunderbolt := summary.Turns[0].Actions[0].Move
+	expected := Move{ID: "thunderbolt", Name: "Thunderbolt", Type: "Electric", Category: "Special", Power: 90, Accuracy: 100, PP: 15}
+	if thunderbolt == nil || *thunderbolt != expected {
+		t.Errorf("expected %+v, got %+v", expected, thunderbolt)
+	}
+	flareBlitz := summary.Turns[0].Actions[1].Move
+	if flareBlitz.ID != "flareblitz" || flareBlitz.Type != "Fire" || flareBlitz.Category != "Physical" || flareBlitz.Power != 120 {
+		t.Errorf("unexpected Flare Blitz %+v", flareBlitz)
+	}
+
+	p1, p2 := summary.Stats.Player1Stats, summary.Stats.Player2Stats
+	if !reflect.DeepEqual(p1.MovesByType, map[string]int{"Electric": 3}) {
+		t.Errorf("expected 3 Electric moves for player1, got %v", p1.MovesByType)
+	}
+	if !reflect.DeepEqual(p2.MovesByType, map[string]int{"Fire": 1, "Flying": 1}) {
+		t.Errorf("expected a Fire and a Flying move for player2, got %v", p2.MovesByType)
+	}
+}
@@ -0,0 +1,256 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+// Field condition kinds reported in FieldCondition.Kind.
+const (
+	FieldWeather = "weather"
+	FieldTerrain = "terrain"
+	FieldRoom    = "room"
+	FieldSide    = "side"
+)
+
+// fieldDurations is how many turns each timed condition lasts, counting the
+// turn it was set. Conditions not listed, such as primal weather and entry
+// hazards, last until something removes them.
+var fieldDurations = map[string]int{
+	"SunnyDay":         5,
+	"RainDance":        5,
+	"Sandstorm":        5,
+	"Snow":             5,
+	"Hail":             5,
+	"Electric Terrain": 5,
+	"Grassy Terrain":   5,
+	"Misty Terrain":    5,
+	"Psychic Terrain":  5,
+	"Trick Room":       5,
+	"Magic Room":       5,
+	"Wonder Room":      5,
+	"Gravity":          5,
+	"Tailwind":         4,
+	"Reflect":          5,
+	"Light Screen":     5,
+	"Aurora Veil":      5,
+	"Safeguard":        5,
+	"Mist":             5,
+}
+
+// fieldExtenders are the held items that stretch a condition set by their
+// holder to extendedFieldDuration turns.
+var fieldExtenders = map[string]string{
+	"SunnyDay":         "Heat Rock",
+	"RainDance":        "Damp Rock",
+	"Sandstorm":        "Smooth Rock",
+	"Snow":             "Icy Rock",
+	"Hail":             "Icy Rock",
+	"Electric Terrain": "Terrain Extender",
+	"Grassy Terrain":   "Terrain Extender",
+	"Misty Terrain":    "Terrain Extender",
+	"Psychic Terrain":  "Terrain Extender",
+	"Reflect":          "Light Clay",
+	"Light Screen":     "Light Clay",
+	"Aurora Veil":      "Light Clay",
+}
+
+const extendedFieldDuration = 8
+
+// fieldTimeline records every field condition set during a battle and which
+// ones are still up.
+type fieldTimeline struct {
+	conditions []FieldCondition
+	active     map[string]int // Condition key -> index in conditions
+	order      []string       // Active keys, in the order they were set
+}
+
+func newFieldTimeline() *fieldTimeline {
+	return &fieldTimeline{
+		conditions: []FieldCondition{},
+		active:     make(map[string]int),
+	}
+}
+
+// start adds a condition under key, ending whatever previously held that key
+// (a new weather replaces the old one).
+func (ft *fieldTimeline) start(key string, condition FieldCondition) {
+	ft.end(key, condition.StartTurn)
+	ft.active[key] = len(ft.conditions)
+	ft.order = append(ft.order, key)
+	ft.conditions = append(ft.conditions, condition)
+}
+
+// end closes the condition under key, if one is active.
+func (ft *fieldTimeline) end(key string, turn int) {
+	i, ok := ft.active[key]
+	if !ok {
+		return
+	}
+	ft.conditions[i].EndTurn = turn
+	delete(ft.active, key)
+	for j, k := range ft.order {
+		if k == key {
+			ft.order = append(ft.order[:j], ft.order[j+1:]...)
+			break
+		}
+	}
+}
+
+// snapshot returns the conditions active after the given turn, with the
+// turns each is still expected to last.
+func (ft *fieldTimeline) snapshot(turn int) FieldState {
+	state := FieldState{
+		Rooms:       []FieldCondition{},
+		Player1Side: []FieldCondition{},
+		Player2Side: []FieldCondition{},
+	}
+
+	for _, key := range ft.order {
+		condition := ft.conditions[ft.active[key]]
+		if condition.ExpectedEndTurn > 0 {
+			condition.TurnsLeft = condition.ExpectedEndTurn - turn
+		}
+
+		switch condition.Kind {
+		case FieldWeather:
+			state.Weather = &condition
+		case FieldTerrain:
+			state.Terrain = &condition
+		case FieldRoom:
+			state.Rooms = append(state.Rooms, condition)
+		case FieldSide:
+			if condition.Side == "player1" {
+				state.Player1Side = append(state.Player1Side, condition)
+			} else {
+				state.Player2Side = append(state.Player2Side, condition)
+			}
+		}
+	}
+
+	return state
+}
+
+// RecordWeather tracks |-weather|. Upkeep messages only confirm the weather
+// is still up; "none" ends it.
+func (st *StateTracker) RecordWeather(e protocol.WeatherEvent, setter protocol.PokemonRef) {
+	if e.Upkeep || e.Weather == "" {
+		return
+	}
+	if e.Weather == "none" {
+		st.field.end(FieldWeather, st.turnNumber)
+		return
+	}
+	st.field.start(FieldWeather, st.newFieldCondition(FieldWeather, e.Weather, setter))
+}
+
+// RecordFieldCondition tracks terrains, rooms and Gravity from |-fieldstart|
+// and |-fieldend|.
+func (st *StateTracker) RecordFieldCondition(e protocol.FieldEvent, setter protocol.PokemonRef) {
+	if e.Effect.IsZero() {
+		return
+	}
+
+	kind, key := FieldRoom, FieldRoom+"|"+e.Effect.Name
+	if strings.HasSuffix(e.Effect.Name, "Terrain") {
+		// Only one terrain can be up; a new one replaces the old
+		kind, key = FieldTerrain, FieldTerrain
+	}
+
+	if e.Ended {
+		st.field.end(key, st.turnNumber)
+		return
+	}
+	st.field.start(key, st.newFieldCondition(kind, e.Effect.Name, setter))
+}
+
+// RecordFieldEffect tracks side conditions such as Tailwind, screens and
+// hazards from |-sidestart| and |-sideend|.
+func (st *StateTracker) RecordFieldEffect(e protocol.SideEvent, setter protocol.PokemonRef) {
+	if e.Side == "" || e.Effect.IsZero() {
+		return
+	}
+
+	key := e.Side + "|" + e.Effect.Name
+	if e.Ended {
+		st.field.end(key, st.turnNumber)
+		return
+	}
+	if _, ok := st.field.active[key]; ok {
+		// Stacking another layer of Spikes doesn't restart the condition
+		return
+	}
+
+	condition := st.newFieldCondition(FieldSide, e.Effect.Name, setter)
+	condition.Side = sideToPlayer(e.Side)
+	st.field.start(key, condition)
+}
+
+// MarkUpkeep notes that the current turn's end-of-turn effects have resolved,
+// so anything set from here on starts counting down next turn.
+func (st *StateTracker) MarkUpkeep() {
+	st.afterUpkeep = true
+}
+
+// GetFieldState returns the field conditions in effect right now.
+func (st *StateTracker) GetFieldState() FieldState {
+	return st.field.snapshot(st.turnNumber)
+}
+
+// GetFieldTimeline returns every field condition set so far, in order.
+func (st *StateTracker) GetFieldTimeline() []FieldCondition {
+	return st.field.conditions
+}
+
+// newFieldCondition starts a condition on the current turn and works out when
+// it should run out, including duration-extending items held by the setter.
+func (st *StateTracker) newFieldCondition(kind, name string, setter protocol.PokemonRef) FieldCondition {
+	condition := FieldCondition{
+		Kind:      kind,
+		Name:      name,
+		StartTurn: st.turnNumber,
+	}
+	if !setter.IsZero() {
+		condition.Setter = setter.Name
+		condition.SetterPlayer = sideToPlayer(setter.Side)
+	}
+
+	duration, timed := fieldDurations[name]
+	if !timed {
+		return condition
+	}
+	if poke, ok := st.activePokemon[setter.Slot]; ok {
+		if extender, ok := fieldExtenders[name]; ok && poke.Item == extender {
+			duration = extendedFieldDuration
+		}
+	}
+
+	// Counters tick down at the end of each turn, starting with the turn the
+	// condition was set unless that turn's end has already passed
+	first := st.turnNumber
+	if first == 0 {
+		first = 1
+	} else if st.afterUpkeep {
+		first++
+	}
+	condition.ExpectedEndTurn = first + duration - 1
+
+	return condition
+}
+
+// fieldUptime counts the turns a condition was up, through lastTurn if it never ended.
+func fieldUptime(condition FieldCondition, lastTurn int) int {
+	start := condition.StartTurn
+	if start == 0 {
+		start = 1
+	}
+	end := condition.EndTurn
+	if end == 0 {
+		end = lastTurn
+	}
+	if end < start {
+		return 0
+	}
+	return end - start + 1
+}
@@ -0,0 +1,162 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+const fieldBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Torkoal, L50|
+|poke|p1|Whimsicott, L50|
+|poke|p1|Tyranitar, L50|
+|poke|p2|Farigiraf, L50|
+|poke|p2|Indeedee-F, L50, F|
+|teamsize|p1|3
+|teamsize|p2|2
+|start
+|switch|p1a: Torkoal|Torkoal, L50|100/100
+|switch|p1b: Whimsicott|Whimsicott, L50|100/100
+|switch|p2a: Farigiraf|Farigiraf, L50|100/100
+|switch|p2b: Indeedee|Indeedee-F, L50, F|100/100
+|-weather|SunnyDay|[from] ability: Drought|[of] p1a: Torkoal
+|-fieldstart|move: Psychic Terrain|[from] ability: Psychic Surge|[of] p2b: Indeedee
+|turn|1
+|move|p1b: Whimsicott|Tailwind|p1b: Whimsicott
+|-sidestart|p1: Alice|move: Tailwind
+|move|p2a: Farigiraf|Trick Room|p2a: Farigiraf
+|-fieldstart|move: Trick Room|[of] p2a: Farigiraf
+|-weather|SunnyDay|[upkeep]
+|upkeep
+|turn|2
+|move|p1b: Whimsicott|Light Screen|p1b: Whimsicott
+|-sidestart|p1: Alice|move: Light Screen
+|move|p2a: Farigiraf|Hyper Voice|p1a: Torkoal|[spread] p1a,p1b
+|-damage|p1a: Torkoal|0 fnt
+|-damage|p1b: Whimsicott|70/100
+|faint|p1a: Torkoal
+|-weather|SunnyDay|[upkeep]
+|upkeep
+|switch|p1a: Tyranitar|Tyranitar, L50|100/100
+|-weather|Sandstorm|[from] ability: Sand Stream|[of] p1a: Tyranitar
+|turn|3
+|move|p1b: Whimsicott|Grassy Terrain|p1b: Whimsicott
+|-fieldstart|move: Grassy Terrain
+|move|p2a: Farigiraf|Trick Room|p2a: Farigiraf
+|-fieldend|move: Trick Room
+|-weather|Sandstorm|[upkeep]
+|upkeep
+|turn|4
+|-weather|Sandstorm|[upkeep]
+|-sideend|p1: Alice|move: Tailwind
+|upkeep
+|turn|5
+|win|Alice`
+
+func TestParseShowdownLogFieldTimeline(t *testing.T) {
+	summary, err := ParseShowdownLog(fieldBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []FieldCondition{
+		{Kind: FieldWeather, Name: "SunnyDay", Setter: "Torkoal", SetterPlayer: "player1", StartTurn: 0, ExpectedEndTurn: 5, EndTurn: 2},
+		{Kind: FieldTerrain, Name: "Psychic Terrain", Setter: "Indeedee", SetterPlayer: "player2", StartTurn: 0, ExpectedEndTurn: 5, EndTurn: 3},
+		{Kind: FieldSide, Name: "Tailwind", Side: "player1", Setter: "Whimsicott", SetterPlayer: "player1", StartTurn: 1, ExpectedEndTurn: 4, EndTurn: 4},
+		{Kind: FieldRoom, Name: "Trick Room", Setter: "Farigiraf", SetterPlayer: "player2", StartTurn: 1, ExpectedEndTurn: 5, EndTurn: 3},
+		{Kind: FieldSide, Name: "Light Screen", Side: "player1", Setter: "Whimsicott", SetterPlayer: "player1", StartTurn: 2, ExpectedEndTurn: 6},
+		// Set by a replacement after turn 2's upkeep, so it starts counting on turn 3
+		{Kind: FieldWeather, Name: "Sandstorm", Setter: "Tyranitar", SetterPlayer: "player1", StartTurn: 2, ExpectedEndTurn: 7},
+		{Kind: FieldTerrain, Name: "Grassy Terrain", Setter: "Whimsicott", SetterPlayer: "player1", StartTurn: 3, ExpectedEndTurn: 7},
+	}
+
+	if len(summary.FieldTimeline) != len(expected) {
+		t.Fatalf("expected %d conditions, got %d: %+v", len(expected), len(summary.FieldTimeline), summary.FieldTimeline)
+	}
+	for i, condition := range summary.FieldTimeline {
+		if condition != expected[i] {
+			t.Errorf("condition %d: expected %+v, got %+v", i, expected[i], condition)
+		}
+	}
+}
+
+func TestParseShowdownLogFieldStateAfter(t *testing.T) {
+	summary, _ := ParseShowdownLog(fieldBattleLog)
+	if len(summary.Turns) != 5 {
+		t.Fatalf("expected 5 turns, got %d", len(summary.Turns))
+	}
+
+	turn1 := summary.Turns[0].StateAfter.Field
+	if turn1.Weather == nil || turn1.Weather.Name != "SunnyDay" || turn1.Weather.TurnsLeft != 4 {
+		t.Errorf("turn 1: expected sun with 4 turns left, got %+v", turn1.Weather)
+	}
+	if turn1.Terrain == nil || turn1.Terrain.Name != "Psychic Terrain" {
+		t.Errorf("turn 1: expected Psychic Terrain, got %+v", turn1.Terrain)
+	}
+	if len(turn1.Rooms) != 1 || turn1.Rooms[0].Name != "Trick Room" || turn1.Rooms[0].TurnsLeft != 4 {
+		t.Errorf("turn 1: expected Trick Room with 4 turns left, got %+v", turn1.Rooms)
+	}
+	if len(turn1.Player1Side) != 1 || turn1.Player1Side[0].TurnsLeft != 3 {
+		t.Errorf("turn 1: expected Tailwind with 3 turns left, got %+v", turn1.Player1Side)
+	}
+	if len(turn1.Player2Side) != 0 {
+		t.Errorf("turn 1: expected nothing on player2's side, got %+v", turn1.Player2Side)
+	}
+
+	turn2 := summary.Turns[1].StateAfter.Field
+	if turn2.Weather == nil || turn2.Weather.Name != "Sandstorm" || turn2.Weather.TurnsLeft != 5 {
+		t.Errorf("turn 2: expected sand with 5 turns left, got %+v", turn2.Weather)
+	}
+
+	turn3 := summary.Turns[2].StateAfter.Field
+	if len(turn3.Rooms) != 0 {
+		t.Errorf("turn 3: expected Trick Room to be gone, got %+v", turn3.Rooms)
+	}
+	if turn3.Terrain == nil || turn3.Terrain.Name != "Grassy Terrain" {
+		t.Errorf("turn 3: expected Grassy Terrain, got %+v", turn3.Terrain)
+	}
+
+	turn4 := summary.Turns[3].StateAfter.Field
+	if len(turn4.Player1Side) != 1 || turn4.Player1Side[0].Name != "Light Screen" {
+		t.Errorf("turn 4: expected only Light Screen, got %+v", turn4.Player1Side)
+	}
+}
+
+func TestParseShowdownLogSpeedControlUptime(t *testing.T) {
+	summary, _ := ParseShowdownLog(fieldBattleLog)
+
+	if summary.Stats.TrickRoomTurns != 3 {
+		t.Errorf("expected 3 Trick Room turns, got %d", summary.Stats.TrickRoomTurns)
+	}
+	if summary.Stats.Player1Stats.TailwindTurns != 4 {
+		t.Errorf("expected 4 Tailwind turns for player1, got %d", summary.Stats.Player1Stats.TailwindTurns)
+	}
+	if summary.Stats.Player2Stats.TailwindTurns != 0 {
+		t.Errorf("expected no Tailwind turns for player2, got %d", summary.Stats.Player2Stats.TailwindTurns)
+	}
+}
+
+func TestRecordWeatherExtendedByItem(t *testing.T) {
+	tracker := NewStateTracker()
+	tracker.AddPokemonToTeam("p1", Pokémon{Name: "Torkoal", Item: "Heat Rock"})
+	tracker.SwitchPokemon("p1a", "Torkoal", 100)
+	tracker.SetTurn(2)
+
+	weather := protocol.Parse("|-weather|SunnyDay|[from] ability: Drought|[of] p1a: Torkoal").(protocol.WeatherEvent)
+	tracker.RecordWeather(weather, weather.Of)
+
+	sun := tracker.GetFieldState().Weather
+	if sun == nil || sun.ExpectedEndTurn != 9 {
+		t.Errorf("expected Heat Rock sun to last through turn 9, got %+v", sun)
+	}
+
+	// Primal weather has no fixed duration
+	tracker.RecordWeather(protocol.Parse("|-weather|DesolateLand").(protocol.WeatherEvent), protocol.PokemonRef{})
+	if primal := tracker.GetFieldState().Weather; primal == nil || primal.ExpectedEndTurn != 0 {
+		t.Errorf("expected untimed weather, got %+v", primal)
+	}
+	if tracker.GetFieldTimeline()[0].EndTurn != 2 {
+		t.Errorf("expected sun to end when replaced, got %+v", tracker.GetFieldTimeline()[0])
+	}
+}
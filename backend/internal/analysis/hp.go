@@ -0,0 +1,93 @@
+package analysis
+
+import "github.com/dtsong/vgccorner/backend/internal/protocol"
+
+// HPChange is a -damage or -heal event measured against the HP the Pokémon
+// actually had before it. Before and After are percentages of max HP, so
+// changes from spectator logs (always out of 100) and player logs (real HP)
+// add up in the same unit.
+type HPChange struct {
+	Pokemon    protocol.PokemonRef
+	TurnNumber int
+	Before     int
+	After      int
+	From       protocol.Effect
+	Of         protocol.PokemonRef
+	Source     DamageSource // What caused the change, once attributed
+}
+
+// Command reports "-damage" for HP lost and "-heal" for HP gained, so an
+// HPChange can sit alongside protocol events.
+func (c HPChange) Command() string {
+	if c.Delta() < 0 {
+		return "-damage"
+	}
+	return "-heal"
+}
+
+// Delta is the change in percentage points: negative for damage, positive for healing.
+func (c HPChange) Delta() int {
+	return c.After - c.Before
+}
+
+// Damage returns the percentage points lost, or 0 if the change was healing.
+func (c HPChange) Damage() int {
+	if delta := c.Delta(); delta < 0 {
+		return -delta
+	}
+	return 0
+}
+
+// Healing returns the percentage points restored, or 0 if the change was damage.
+func (c HPChange) Healing() int {
+	if delta := c.Delta(); delta > 0 {
+		return delta
+	}
+	return 0
+}
+
+// Direct reports whether the change came straight from a move, rather than
+// from an item, ability, weather or status ([from] tags). Drain healing
+// counts as direct since the move itself caused it.
+func (c HPChange) Direct() bool {
+	return c.From.IsZero() || c.From.Name == "drain"
+}
+
+// ApplyHPChange updates the HP of the Pokémon in ref's slot and returns the
+// change measured against its previous HP. The change is also appended to
+// that slot's HP history.
+func (st *StateTracker) ApplyHPChange(ref protocol.PokemonRef, hp protocol.HP) HPChange {
+	slot := normalizeSlot(refSlot(ref))
+	change := HPChange{
+		Pokemon:    ref,
+		TurnNumber: st.turnNumber,
+		Before:     100,
+		After:      hpPercent(hp.Current, hp.Max),
+	}
+
+	if poke, ok := st.activePokemon[slot]; ok {
+		change.Before = hpPercent(poke.CurrentHP, poke.MaxHP)
+		if hp.Max == 0 && !hp.Fainted {
+			// HP reported without a max is on the same scale as before
+			change.After = hpPercent(hp.Current, poke.MaxHP)
+		}
+	}
+
+	st.UpdatePokemonHP(slot, hp.Current, hp.Max)
+	st.hpHistory[slot] = append(st.hpHistory[slot], change)
+	return change
+}
+
+// GetHPHistory returns every HP change recorded for a slot, in order.
+func (st *StateTracker) GetHPHistory(slot string) []HPChange {
+	return st.hpHistory[normalizeSlot(slot)]
+}
+
+// hpPercent converts HP to a whole percentage of max HP. A max of 0 means the
+// value is already a percentage.
+func hpPercent(current, max int) int {
+	if max <= 0 {
+		return current
+	}
+	return (current*100 + max/2) / max
+}
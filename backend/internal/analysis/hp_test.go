@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+const hpBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Pikachu, L50|
+|poke|p2|Charizard, L50|
+|teamsize|p1|1
+|teamsize|p2|1
+|start
+|switch|p1a: Pikachu|Pikachu, L50|100/100
+|switch|p2a: Charizard|Charizard, L50|100/100
+|turn|1
+|move|p1a: Pikachu|Thunderbolt|p2a: Charizard
+|-damage|p2a: Charizard|60/100
+|move|p2a: Charizard|Flare Blitz|p1a: Pikachu
+|-damage|p1a: Pikachu|30/100
+|-damage|p2a: Charizard|47/100|[from] Recoil
+|upkeep
+|turn|2
+|move|p2a: Charizard|Roost|p2a: Charizard
+|-heal|p2a: Charizard|97/100
+|move|p1a: Pikachu|Thunderbolt|p2a: Charizard
+|-damage|p2a: Charizard|37/100
+|-heal|p1a: Pikachu|36/100|[from] item: Leftovers
+|upkeep
+|turn|3
+|move|p1a: Pikachu|Thunderbolt|p2a: Charizard
+|-damage|p2a: Charizard|0 fnt
+|faint|p2a: Charizard
+|win|Alice`
+
+func TestParseShowdownLogHPDeltas(t *testing.T) {
+	summary, err := ParseShowdownLog(hpBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(summary.Turns) != 3 {
+		t.Fatalf("expected 3 turns, got %d", len(summary.Turns))
+	}
+
+	tests := []struct {
+		turn                 int
+		p1Dealt, p2Dealt     int
+		p1Taken, p2Taken     int
+		p1Healing, p2Healing int
+	}{
+		// Pikachu deals 40 and Charizard takes 13 more from its own recoil;
+		// Charizard deals 70
+		{turn: 1, p1Dealt: 40, p2Dealt: 70, p1Taken: 70, p2Taken: 53},
+		// Roost restores 50 of the 47 Charizard had left; Leftovers restores 6
+		{turn: 2, p1Dealt: 60, p2Taken: 60, p1Healing: 6, p2Healing: 50},
+		{turn: 3, p1Dealt: 37, p2Taken: 37},
+	}
+
+	for i, tt := range tests {
+		turn := summary.Turns[i]
+		if turn.DamageDealt["player1"] != tt.p1Dealt || turn.DamageDealt["player2"] != tt.p2Dealt {
+			t.Errorf("turn %d: expected damage %d/%d, got %v", tt.turn, tt.p1Dealt, tt.p2Dealt, turn.DamageDealt)
+		}
+		if turn.DamageTaken["player1"] != tt.p1Taken || turn.DamageTaken["player2"] != tt.p2Taken {
+			t.Errorf("turn %d: expected damage taken %d/%d, got %v", tt.turn, tt.p1Taken, tt.p2Taken, turn.DamageTaken)
+		}
+		if turn.HealingDone["player1"] != tt.p1Healing || turn.HealingDone["player2"] != tt.p2Healing {
+			t.Errorf("turn %d: expected healing %d/%d, got %v", tt.turn, tt.p1Healing, tt.p2Healing, turn.HealingDone)
+		}
+	}
+
+	// Charizard's recoil is damage it took, but not damage Pikachu dealt
+	p1, p2 := summary.Stats.Player1Stats, summary.Stats.Player2Stats
+	if p1.DamageDealt != 137 || p2.DamageTaken != 150 {
+		t.Errorf("expected player1 to deal 137 and player2 to take 150, got %d and %d", p1.DamageDealt, p2.DamageTaken)
+	}
+	if p2.DamageDealt != 70 || p1.DamageTaken != 70 {
+		t.Errorf("expected player2 to deal 70 and player1 to take 70, got %d and %d", p2.DamageDealt, p1.DamageTaken)
+	}
+	if p1.HealingReceived != 6 || p2.HealingReceived != 50 {
+		t.Errorf("expected healing received 6/50, got %d/%d", p1.HealingReceived, p2.HealingReceived)
+	}
+	if summary.Stats.AvgDamagePerTurn != 220.0/3 {
+		t.Errorf("expected average damage %.2f, got %.2f", 220.0/3, summary.Stats.AvgDamagePerTurn)
+	}
+}
+
+func TestParseShowdownLogMoveImpactDamage(t *testing.T) {
+	summary, _ := ParseShowdownLog(hpBattleLog)
+
+	flareBlitz := summary.Turns[0].Actions[1]
+	if flareBlitz.Impact == nil || flareBlitz.Impact.DamageDealt != 70 {
+		t.Fatalf("expected Flare Blitz to deal 70, got %+v", flareBlitz.Impact)
+	}
+
+	roost := summary.Turns[1].Actions[0]
+	if roost.Impact == nil || roost.Impact.HealingDone != 50 || roost.Impact.DamageDealt != 0 {
+		t.Errorf("expected Roost to heal 50, got %+v", roost.Impact)
+	}
+
+	// Leftovers at the end of the turn isn't part of Thunderbolt's impact
+	thunderbolt := summary.Turns[1].Actions[1]
+	if thunderbolt.Impact == nil || thunderbolt.Impact.DamageDealt != 60 || thunderbolt.Impact.HealingDone != 0 {
+		t.Errorf("expected Thunderbolt to deal 60 and heal nothing, got %+v", thunderbolt.Impact)
+	}
+
+	finisher := summary.Turns[2].Actions[0]
+	if finisher.Impact == nil || finisher.Impact.DamageDealt != 37 {
+		t.Errorf("expected the finishing Thunderbolt to deal 37, got %+v", finisher.Impact)
+	}
+}
+
+func TestApplyHPChangeHistory(t *testing.T) {
+	tracker := NewStateTracker()
+	tracker.AddPokemonToTeam("p1", Pokémon{Name: "Incineroar", CurrentHP: 100, MaxHP: 100})
+	tracker.SwitchPokemon("p1a", "Incineroar", 202)
+	tracker.UpdatePokemonHP("p1a", 202, 202)
+
+	ref := protocol.ParsePokemonRef("p1a: Incineroar")
+	damage := tracker.ApplyHPChange(ref, protocol.ParseHP("101/202"))
+	if damage.Before != 100 || damage.After != 50 || damage.Delta() != -50 {
+		t.Errorf("expected 100 -> 50, got %+v", damage)
+	}
+
+	heal := tracker.ApplyHPChange(ref, protocol.ParseHP("126/202"))
+	if heal.Before != 50 || heal.After != 62 || heal.Healing() != 12 || heal.Damage() != 0 {
+		t.Errorf("expected 50 -> 62, got %+v", heal)
+	}
+	if heal.Command() != "-heal" || damage.Command() != "-damage" {
+		t.Errorf("unexpected commands %q and %q", damage.Command(), heal.Command())
+	}
+
+	history := tracker.GetHPHistory("p1a")
+	if len(history) != 2 || history[0] != damage || history[1] != heal {
+		t.Errorf("expected both changes in history, got %+v", history)
+	}
+	if len(tracker.GetHPHistory("p1b")) != 0 {
+		t.Error("expected empty history for an unused slot")
+	}
+}
+
+func TestHPPercent(t *testing.T) {
+	tests := []struct {
+		current, max, expected int
+	}{
+		{63, 100, 63},
+		{101, 202, 50},
+		{187, 201, 93},
+		{1, 201, 0},
+		{75, 0, 75},
+		{0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := hpPercent(tt.current, tt.max); got != tt.expected {
+			t.Errorf("hpPercent(%d, %d) = %d, expected %d", tt.current, tt.max, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+// Item change kinds reported in ItemChange.Change.
+const (
+	ItemRevealed = "revealed"
+	ItemConsumed = "consumed"
+	ItemRemoved  = "removed"
+	ItemSwapped  = "swapped"
+	ItemGained   = "gained"
+)
+
+// itemSwapMoves trade held items between the user and its target.
+var itemSwapMoves = map[string]bool{"Trick": true, "Switcheroo": true}
+
+// Command lets item changes travel through the TurnParser alongside the
+// protocol events they came from.
+func (c ItemChange) Command() string {
+	if c.Change == ItemConsumed || c.Change == ItemRemoved {
+		return "-enditem"
+	}
+	return "-item"
+}
+
+// RecordItem applies |-item| or |-enditem| to the Pokémon's known item. by is
+// the Pokémon responsible and move the move resolving at the time, if any.
+//
+// Pokémon.Item keeps the last item the Pokémon was known to hold, so a Focus
+// Sash that was used up still shows on the team.
+func (st *StateTracker) RecordItem(e protocol.ItemEvent, by protocol.PokemonRef, move string) ItemChange {
+	change := ItemChange{
+		TurnNumber: st.turnNumber,
+		Pokemon:    e.Pokemon.Name,
+		Player:     sideToPlayer(e.Pokemon.Side),
+		Item:       e.Item,
+		Change:     itemChangeKind(e),
+		Cause:      e.From.Name,
+		Move:       move,
+		By:         by.Name,
+	}
+
+	if poke, ok := st.activePokemon[refSlot(e.Pokemon)]; ok {
+		if !e.Ended || poke.Item == "" {
+			poke.Item = e.Item
+		}
+	}
+
+	st.itemTimeline = append(st.itemTimeline, change)
+	return change
+}
+
+// RevealItem notes an item that gave itself away through its effect, such as
+// Leftovers healing or Rocky Helmet damage. It reports false if the item was
+// already known.
+func (st *StateTracker) RevealItem(holder protocol.PokemonRef, item string) (ItemChange, bool) {
+	poke, ok := st.activePokemon[refSlot(holder)]
+	if !ok || item == "" || poke.Item == item {
+		return ItemChange{}, false
+	}
+	poke.Item = item
+
+	change := ItemChange{
+		TurnNumber: st.turnNumber,
+		Pokemon:    holder.Name,
+		Player:     sideToPlayer(holder.Side),
+		Item:       item,
+		Change:     ItemRevealed,
+		By:         holder.Name,
+	}
+	st.itemTimeline = append(st.itemTimeline, change)
+	return change, true
+}
+
+// GetItemTimeline returns every item change so far, in order.
+func (st *StateTracker) GetItemTimeline() []ItemChange {
+	return st.itemTimeline
+}
+
+// itemChangeKind works out what an item event did to the Pokémon's item.
+func itemChangeKind(e protocol.ItemEvent) string {
+	switch {
+	case itemSwapMoves[e.From.Name] && !e.Ended:
+		return ItemSwapped
+	case e.Ended && e.From.Kind == "move":
+		// Knock Off, Thief, Bug Bite, Incinerate, or Trick with nothing given back
+		return ItemRemoved
+	case e.Ended && e.From.Name == "stealeat":
+		return ItemRemoved
+	case e.Ended:
+		// Berries, Focus Sash, Booster Energy, Air Balloon, gems
+		return ItemConsumed
+	case e.From.Kind == "ability" && e.From.Name == "Frisk", e.From.IsZero():
+		return ItemRevealed
+	}
+	// Harvest, Recycle, or the item a Thief user stole
+	return ItemGained
+}
+
+// itemMoment describes an item change worth calling out as a key moment.
+// Reveals aren't, and Focus Sash saves get a moment of their own.
+func itemMoment(change ItemChange) (description string, significance int) {
+	switch change.Change {
+	case ItemConsumed:
+		return fmt.Sprintf("%s used up its %s", change.Pokemon, change.Item), 4
+	case ItemRemoved:
+		return fmt.Sprintf("%s lost its %s to %s", change.Pokemon, change.Item, change.Cause), 5
+	case ItemSwapped:
+		return fmt.Sprintf("%s was given %s by %s", change.Pokemon, change.Item, change.Cause), 5
+	case ItemGained:
+		return fmt.Sprintf("%s gained %s", change.Pokemon, change.Item), 3
+	}
+	return "", 0
+}
@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+)
+
+const itemBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Incineroar, L50|
+|poke|p1|Farigiraf, L50|
+|poke|p2|Iron Hands, L50|
+|poke|p2|Amoonguss, L50|
+|teamsize|p1|2
+|teamsize|p2|2
+|start
+|switch|p1a: Incineroar|Incineroar, L50|100/100
+|switch|p1b: Farigiraf|Farigiraf, L50|100/100
+|switch|p2a: Iron Hands|Iron Hands, L50|100/100
+|switch|p2b: Amoonguss|Amoonguss, L50|100/100
+|-activate|p2a: Iron Hands|ability: Quark Drive|[fromitem]
+|-enditem|p2a: Iron Hands|Booster Energy
+|-item|p2b: Amoonguss|Rocky Helmet|[from] ability: Frisk|[of] p1b: Farigiraf
+|turn|1
+|move|p1a: Incineroar|Knock Off|p2b: Amoonguss
+|-damage|p2b: Amoonguss|70/100
+|-enditem|p2b: Amoonguss|Rocky Helmet|[from] move: Knock Off|[of] p1a: Incineroar
+|move|p2a: Iron Hands|Drain Punch|p1a: Incineroar
+|-damage|p1a: Incineroar|45/100
+|-heal|p2a: Iron Hands|100/100|[from] drain|[of] p1a: Incineroar
+|-enditem|p1a: Incineroar|Sitrus Berry|[eat]
+|-heal|p1a: Incineroar|70/100|[from] item: Sitrus Berry
+|move|p1b: Farigiraf|Trick|p2a: Iron Hands
+|-activate|p1b: Farigiraf|move: Trick|[of] p2a: Iron Hands
+|-item|p2a: Iron Hands|Choice Scarf|[from] move: Trick
+|-enditem|p1b: Farigiraf|Choice Scarf|[silent]|[from] move: Trick
+|-heal|p2b: Amoonguss|76/100|[from] item: Leftovers
+|upkeep
+|turn|2
+|win|Alice`
+
+func TestParseShowdownLogItemTimeline(t *testing.T) {
+	summary, err := ParseShowdownLog(itemBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []ItemChange{
+		{TurnNumber: 0, Pokemon: "Iron Hands", Player: "player2", Item: "Booster Energy", Change: ItemConsumed},
+		{TurnNumber: 0, Pokemon: "Amoonguss", Player: "player2", Item: "Rocky Helmet", Change: ItemRevealed, Cause: "Frisk", By: "Farigiraf"},
+		{TurnNumber: 1, Pokemon: "Amoonguss", Player: "player2", Item: "Rocky Helmet", Change: ItemRemoved, Cause: "Knock Off", Move: "Knock Off", By: "Incineroar"},
+		{TurnNumber: 1, Pokemon: "Incineroar", Player: "player1", Item: "Sitrus Berry", Change: ItemConsumed, Move: "Drain Punch", By: "Iron Hands"},
+		{TurnNumber: 1, Pokemon: "Iron Hands", Player: "player2", Item: "Choice Scarf", Change: ItemSwapped, Cause: "Trick", Move: "Trick", By: "Farigiraf"},
+		{TurnNumber: 1, Pokemon: "Farigiraf", Player: "player1", Item: "Choice Scarf", Change: ItemRemoved, Cause: "Trick", Move: "Trick", By: "Farigiraf"},
+		{TurnNumber: 1, Pokemon: "Amoonguss", Player: "player2", Item: "Leftovers", Change: ItemRevealed, By: "Amoonguss"},
+	}
+
+	if len(summary.ItemTimeline) != len(expected) {
+		t.Fatalf("expected %d item changes, got %d: %+v", len(expected), len(summary.ItemTimeline), summary.ItemTimeline)
+	}
+	for i, change := range summary.ItemTimeline {
+		if change != expected[i] {
+			t.Errorf("change %d: expected %+v, got %+v", i, expected[i], change)
+		}
+	}
+}
+
+func TestParseShowdownLogKnownItems(t *testing.T) {
+	summary, _ := ParseShowdownLog(itemBattleLog)
+
+	items := make(map[string]string)
+	for _, poke := range append(summary.Player1.Team, summary.Player2.Team...) {
+		items[poke.Name] = poke.Item
+	}
+
+	// The last item each Pokémon was known to hold, even once it's gone
+	expected := map[string]string{
+		"Incineroar": "Sitrus Berry",
+		"Farigiraf":  "Choice Scarf",
+		"Iron Hands": "Choice Scarf",
+		"Amoonguss":  "Leftovers",
+	}
+	for name, item := range expected {
+		if items[name] != item {
+			t.Errorf("%s: expected %q, got %q", name, item, items[name])
+		}
+	}
+}
+
+func TestParseShowdownLogItemImpacts(t *testing.T) {
+	summary, _ := ParseShowdownLog(itemBattleLog)
+	actions := summary.Turns[0].Actions
+
+	knockOff := actions[0].Impact
+	if len(knockOff.ItemChanges) != 1 || knockOff.ItemChanges[0].Change != ItemRemoved {
+		t.Errorf("expected Knock Off to remove an item, got %+v", knockOff.ItemChanges)
+	}
+	if !strings.Contains(actions[0].Details, "Amoonguss lost its Rocky Helmet") {
+		t.Errorf("expected details to mention the removal, got %q", actions[0].Details)
+	}
+
+	trick := actions[2].Impact
+	if len(trick.ItemChanges) != 2 {
+		t.Errorf("expected Trick to change both items, got %+v", trick.ItemChanges)
+	}
+
+	// Leftovers at the end of the turn isn't part of Trick
+	for _, change := range trick.ItemChanges {
+		if change.Item == "Leftovers" {
+			t.Errorf("unexpected Leftovers reveal in Trick's impact")
+		}
+	}
+}
+
+func TestParseShowdownLogItemKeyMoments(t *testing.T) {
+	summary, _ := ParseShowdownLog(itemBattleLog)
+
+	var descriptions []string
+	for _, moment := range summary.KeyMoments {
+		if moment.Type == "item" {
+			descriptions = append(descriptions, moment.Description)
+		}
+	}
+
+	expected := []string{
+		"Iron Hands used up its Booster Energy",
+		"Amoonguss lost its Rocky Helmet to Knock Off",
+		"Incineroar used up its Sitrus Berry",
+		"Iron Hands was given Choice Scarf by Trick",
+		"Farigiraf lost its Choice Scarf to Trick",
+	}
+	if strings.Join(descriptions, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected item key moments %q, got %q", expected, descriptions)
+	}
+}
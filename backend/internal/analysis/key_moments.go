@@ -0,0 +1,583 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+// Key moment types reported in KeyMoment.Type.
+const (
+	MomentKO           = "KO"
+	MomentCriticalKO   = "critical"      // A critical hit that decided a KO
+	MomentDoubleKO     = "double_ko"     // A side lost two or more Pokémon in one turn
+	MomentMiss         = "miss"          // A miss that let a Pokémon survive or strike back
+	MomentTera         = "tera"          // Terastallization
+	MomentSpeedControl = "speed_control" // Tailwind or Trick Room going up or ending
+	MomentProtect      = "protect"       // Protect or a similar move blocked an attack
+	MomentFakeOut      = "fake_out"      // Fake Out made its target flinch
+	MomentFocusSash    = "focus_sash"    // Focus Sash left its holder at 1 HP
+	MomentLastPokemon  = "last_pokemon"  // A side is down to its last Pokémon
+	MomentItem         = "item"          // Items used up, knocked off or swapped
+	MomentTurningPoint = "turning_point" // A large swing in win probability
+)
+
+// Significance of each kind of key moment, on the 1-10 scale. Moments that
+// settle the game outright rank highest; ones that only shape the next few
+// turns rank lowest.
+const (
+	doubleKOSignificance        = 9
+	criticalKOSignificance      = 9
+	koSignificance              = 8
+	lastPokemonSignificance     = 7
+	teraSignificance            = 7
+	trickRoomSignificance       = 7
+	missRevengeSignificance     = 7
+	missSignificance            = 6
+	tailwindSignificance        = 6
+	focusSashSignificance       = 6
+	fakeOutSignificance         = 5
+	speedControlEndSignificance = 4
+	protectSignificance         = 4
+)
+
+// critKOMinHP is the HP, in percent, a Pokémon needs going into a critical
+// hit for the crit to be credited with the KO. Below it, the regular roll,
+// two thirds as strong, would usually have knocked it out anyway.
+const critKOMinHP = 50
+
+// missKOMaxHP is the HP, in percent, at or below which a missed attack is
+// assumed to have cost a KO.
+const missKOMaxHP = 33
+
+// protectEffects are the moves whose |-activate| means they blocked an attack.
+var protectEffects = map[string]bool{
+	"Protect":         true,
+	"Detect":          true,
+	"King's Shield":   true,
+	"Spiky Shield":    true,
+	"Baneful Bunker":  true,
+	"Silk Trap":       true,
+	"Burning Bulwark": true,
+	"Obstruct":        true,
+	"Max Guard":       true,
+	"Wide Guard":      true,
+	"Quick Guard":     true,
+}
+
+// momentDetector finds key moments as a battle's events arrive. Most are
+// recorded as they happen; Tera waits for the end of the turn to be linked
+// to the Terastallized Pokémon's action, and misses and double KOs need the
+// rest of the turn to be judged.
+type momentDetector struct {
+	summary   *BattleSummary
+	tracker   *StateTracker
+	turns     *TurnParser
+	moments   []KeyMoment         // This turn's moments, in order
+	tera      []pendingTera       // Tera moments still to be linked to an action
+	knockouts map[string]HPChange // pokemonKey -> the HP change that took it to 0
+	crits     map[string]bool     // pokemonKey -> crit by the action resolving now
+	protected map[string]bool     // pokemonKey -> already credited with a block this turn
+	faints    []knockout          // This turn's faints, in order
+	misses    []missedMove        // This turn's misses, in order
+}
+
+// pendingTera is a Tera moment waiting for its action.
+type pendingTera struct {
+	index   int // In moments
+	player  string
+	pokemon string
+}
+
+// knockout is a Pokémon fainting and who, if anyone, knocked it out.
+type knockout struct {
+	pokemon MomentPokemon
+	by      MomentPokemon // Zero unless an opposing move did it
+	order   *int
+}
+
+// missedMove is an attack that missed, with the target's HP at the time.
+type missedMove struct {
+	attacker MomentPokemon
+	target   MomentPokemon
+	move     string
+	targetHP int
+	order    int
+}
+
+func newMomentDetector(summary *BattleSummary, tracker *StateTracker, turns *TurnParser) *momentDetector {
+	return &momentDetector{
+		summary:   summary,
+		tracker:   tracker,
+		turns:     turns,
+		knockouts: make(map[string]HPChange),
+		crits:     make(map[string]bool),
+		protected: make(map[string]bool),
+	}
+}
+
+// Observe checks an event for key moments. It's called once the tracker has
+// applied the event.
+func (d *momentDetector) Observe(event protocol.Event) {
+	switch e := event.(type) {
+	case protocol.MoveEvent:
+		d.crits = make(map[string]bool)
+
+	case protocol.CritEvent:
+		d.crits[pokemonKey(e.Pokemon)] = true
+
+	case HPChange:
+		if e.After == 0 {
+			d.knockouts[pokemonKey(e.Pokemon)] = e
+		}
+
+	case protocol.FaintEvent:
+		d.recordFaint(e.Pokemon)
+
+	case protocol.MissEvent:
+		d.recordMiss(e)
+
+	case protocol.TerastallizeEvent:
+		player := sideToPlayer(e.Pokemon.Side)
+		d.tera = append(d.tera, pendingTera{index: len(d.moments), player: player, pokemon: e.Pokemon.Name})
+		d.add(KeyMoment{
+			Type:         MomentTera,
+			Description:  fmt.Sprintf("%s terastallized %s into the %s type", d.playerName(e.Pokemon.Side), e.Pokemon.Name, e.TeraType),
+			Significance: teraSignificance,
+			Player:       player,
+			Pokemon:      []MomentPokemon{{Pokemon: e.Pokemon.Name, Player: player}},
+		})
+
+	case protocol.SideEvent:
+		if e.Effect.Name == "Tailwind" {
+			d.recordTailwind(e)
+		}
+
+	case protocol.FieldEvent:
+		if e.Effect.Name == "Trick Room" {
+			d.recordTrickRoom(e)
+		}
+
+	case protocol.ActivateEvent:
+		if protectEffects[e.Effect.Name] {
+			d.recordProtect(e)
+		}
+
+	case protocol.CantEvent:
+		if e.Reason.Name == "flinch" {
+			d.recordFlinch(e.Pokemon)
+		}
+
+	case ItemChange:
+		d.recordItemChange(e)
+	}
+}
+
+// FinishTurn settles the turn's pending moments and adds them to the summary.
+// It has to run before the TurnParser closes the turn.
+func (d *momentDetector) FinishTurn() {
+	for _, tera := range d.tera {
+		d.moments[tera.index].ActionOrder = orderOf(d.findAction(tera.player, tera.pokemon, ""))
+	}
+	d.judgeMisses()
+	d.detectDoubleKOs()
+
+	d.summary.KeyMoments = append(d.summary.KeyMoments, d.moments...)
+
+	d.moments = nil
+	d.tera = nil
+	d.faints = nil
+	d.misses = nil
+	d.knockouts = make(map[string]HPChange)
+	d.protected = make(map[string]bool)
+}
+
+// add records a moment on the current turn.
+func (d *momentDetector) add(moment KeyMoment) {
+	moment.TurnNumber = d.tracker.turnNumber
+	d.moments = append(d.moments, moment)
+}
+
+// recordFaint adds a KO moment naming the fainted Pokémon and, when an
+// opposing move did it, the attacker.
+func (d *momentDetector) recordFaint(ref protocol.PokemonRef) {
+	if d.turns.currentTurn == nil {
+		return
+	}
+
+	key := pokemonKey(ref)
+	fainted := MomentPokemon{Pokemon: ref.Name, Player: sideToPlayer(ref.Side)}
+	blow, hit := d.knockouts[key]
+	delete(d.knockouts, key)
+	source := blow.Source
+
+	ko := knockout{pokemon: fainted}
+	moment := KeyMoment{
+		Type:         MomentKO,
+		Significance: koSignificance,
+		Player:       fainted.Player,
+		Pokemon:      []MomentPokemon{fainted},
+	}
+	switch {
+	case hit && source.Kind == SourceMove && source.Pokemon != "" && source.Player != fainted.Player:
+		ko.by = MomentPokemon{Pokemon: source.Pokemon, Player: source.Player}
+		moment.Player = source.Player
+		moment.Pokemon = []MomentPokemon{ko.by, fainted}
+		moment.ActionOrder = orderOf(d.findAction(source.Player, source.Pokemon, source.Name))
+		moment.Description = fmt.Sprintf("%s knocked out %s with %s",
+			d.describe(ko.by), d.describe(fainted), source.Name)
+		if d.crits[key] && blow.Before > critKOMinHP {
+			moment.Type = MomentCriticalKO
+			moment.Significance = criticalKOSignificance
+			moment.Description = fmt.Sprintf("%s knocked out %s with a critical hit from %s",
+				d.describe(ko.by), d.describe(fainted), source.Name)
+		}
+	case hit && source.Name != "":
+		moment.Description = fmt.Sprintf("%s fainted (%s)", d.describe(fainted), source.Label())
+		// Recoil and Life Orb belong to the fainted Pokémon's own move
+		if action := d.turns.currentAction(); (source.Kind == SourceRecoil || source.Kind == SourceItem) &&
+			action != nil && action.Player == fainted.Player && action.Pokemon == fainted.Pokemon {
+			moment.ActionOrder = orderOf(action)
+		}
+	default:
+		moment.Description = fmt.Sprintf("%s fainted", d.describe(fainted))
+	}
+	d.add(moment)
+
+	ko.order = moment.ActionOrder
+	d.faints = append(d.faints, ko)
+	d.checkLastPokemon(ref.Side, moment.ActionOrder)
+}
+
+// checkLastPokemon adds a moment when a side is down to one Pokémon.
+func (d *momentDetector) checkLastPokemon(side string, order *int) {
+	if d.tracker.GetTeamSize(side)-d.tracker.losses[side] != 1 {
+		return
+	}
+
+	player := sideToPlayer(side)
+	moment := KeyMoment{
+		Type:         MomentLastPokemon,
+		Description:  fmt.Sprintf("%s is down to their last Pokémon", d.playerName(side)),
+		Significance: lastPokemonSignificance,
+		Player:       player,
+		ActionOrder:  order,
+	}
+	if name := d.tracker.lastPokemon(side); name != "" {
+		moment.Description += ", " + name
+		moment.Pokemon = []MomentPokemon{{Pokemon: name, Player: player}}
+	}
+	d.add(moment)
+}
+
+// recordMiss notes a missed attack on an opponent, to be judged at the end
+// of the turn.
+func (d *momentDetector) recordMiss(e protocol.MissEvent) {
+	action := d.turns.currentAction()
+	if action == nil || action.Move == nil || e.Target.IsZero() || e.Target.Side == e.Source.Side {
+		return
+	}
+
+	miss := missedMove{
+		attacker: MomentPokemon{Pokemon: e.Source.Name, Player: sideToPlayer(e.Source.Side)},
+		target:   MomentPokemon{Pokemon: e.Target.Name, Player: sideToPlayer(e.Target.Side)},
+		move:     action.Move.Name,
+		targetHP: 100,
+		order:    action.OrderInTurn,
+	}
+	if poke, ok := d.tracker.activePokemon[normalizeSlot(refSlot(e.Target))]; ok {
+		miss.targetHP = hpPercent(poke.CurrentHP, poke.MaxHP)
+	}
+	d.misses = append(d.misses, miss)
+}
+
+// judgeMisses adds a moment for each miss that likely changed a KO: the
+// target was low enough that a hit should have taken it out, or it went on
+// to knock out one of the attacker's side later in the turn.
+func (d *momentDetector) judgeMisses() {
+	for _, miss := range d.misses {
+		var revenge *knockout
+		for i, ko := range d.faints {
+			if ko.by == miss.target && ko.pokemon.Player == miss.attacker.Player &&
+				ko.order != nil && *ko.order > miss.order {
+				revenge = &d.faints[i]
+				break
+			}
+		}
+		if revenge == nil && miss.targetHP > missKOMaxHP {
+			continue
+		}
+
+		order := miss.order
+		moment := KeyMoment{
+			Type: MomentMiss,
+			Description: fmt.Sprintf("%s missed %s at %d%% with %s",
+				d.describe(miss.attacker), d.describe(miss.target), miss.targetHP, miss.move),
+			Significance: missSignificance,
+			Player:       miss.attacker.Player,
+			Pokemon:      []MomentPokemon{miss.attacker, miss.target},
+			ActionOrder:  &order,
+		}
+		if revenge != nil {
+			moment.Significance = missRevengeSignificance
+			moment.Description += fmt.Sprintf(", and %s knocked out %s in return", miss.target.Pokemon, revenge.pokemon.Pokemon)
+			if revenge.pokemon != miss.attacker {
+				moment.Pokemon = append(moment.Pokemon, revenge.pokemon)
+			}
+		}
+		d.add(moment)
+	}
+}
+
+// detectDoubleKOs adds a moment for each side that lost two or more Pokémon
+// this turn, linked to the action behind the last of them.
+func (d *momentDetector) detectDoubleKOs() {
+	for _, player := range []string{"player1", "player2"} {
+		var lost []MomentPokemon
+		var names []string
+		var order *int
+		for _, ko := range d.faints {
+			if ko.pokemon.Player == player {
+				lost = append(lost, ko.pokemon)
+				names = append(names, ko.pokemon.Pokemon)
+				order = ko.order
+			}
+		}
+		if len(lost) < 2 {
+			continue
+		}
+
+		d.add(KeyMoment{
+			Type: MomentDoubleKO,
+			Description: fmt.Sprintf("%s lost %s and %s in the same turn", d.playerName(playerSide(player)),
+				strings.Join(names[:len(names)-1], ", "), names[len(names)-1]),
+			Significance: doubleKOSignificance,
+			Player:       opponentOf(player),
+			Pokemon:      lost,
+			ActionOrder:  order,
+		})
+	}
+}
+
+// recordTailwind adds a moment when Tailwind starts or runs out.
+func (d *momentDetector) recordTailwind(e protocol.SideEvent) {
+	player := sideToPlayer(e.Side)
+	moment := KeyMoment{
+		Type:   MomentSpeedControl,
+		Player: player,
+	}
+	if e.Ended {
+		moment.Description = fmt.Sprintf("%s's Tailwind ran out", d.playerName(e.Side))
+		moment.Significance = speedControlEndSignificance
+		d.add(moment)
+		return
+	}
+
+	moment.Description = fmt.Sprintf("%s set up Tailwind", d.playerName(e.Side))
+	moment.Significance = tailwindSignificance
+	if action := d.turns.currentAction(); action != nil && action.Player == player {
+		setter := MomentPokemon{Pokemon: action.Pokemon, Player: player}
+		moment.Description = fmt.Sprintf("%s set up Tailwind", d.describe(setter))
+		moment.Pokemon = []MomentPokemon{setter}
+		moment.ActionOrder = orderOf(action)
+	}
+	d.add(moment)
+}
+
+// recordTrickRoom adds a moment when Trick Room goes up, is reversed by
+// another Trick Room or runs out.
+func (d *momentDetector) recordTrickRoom(e protocol.FieldEvent) {
+	moment := KeyMoment{Type: MomentSpeedControl}
+	action := d.turns.currentAction()
+	usedTrickRoom := action != nil && action.Move != nil && action.Move.Name == "Trick Room"
+
+	switch {
+	case !e.Ended:
+		moment.Description = "Trick Room was set up"
+		moment.Significance = trickRoomSignificance
+	case usedTrickRoom:
+		moment.Description = "Trick Room was reversed"
+		moment.Significance = trickRoomSignificance
+	default:
+		moment.Description = "Trick Room ran out"
+		moment.Significance = speedControlEndSignificance
+		d.add(moment)
+		return
+	}
+
+	if action != nil {
+		setter := MomentPokemon{Pokemon: action.Pokemon, Player: action.Player}
+		if e.Ended {
+			moment.Description = fmt.Sprintf("%s reversed Trick Room", d.describe(setter))
+		} else {
+			moment.Description = fmt.Sprintf("%s set up Trick Room", d.describe(setter))
+		}
+		moment.Player = setter.Player
+		moment.Pokemon = []MomentPokemon{setter}
+		moment.ActionOrder = orderOf(action)
+	}
+	d.add(moment)
+}
+
+// recordProtect credits a Pokémon whose Protect blocked an opposing attack,
+// once per turn.
+func (d *momentDetector) recordProtect(e protocol.ActivateEvent) {
+	action := d.turns.currentAction()
+	protector := MomentPokemon{Pokemon: e.Pokemon.Name, Player: sideToPlayer(e.Pokemon.Side)}
+	if action == nil || action.Player == protector.Player || d.protected[pokemonKey(e.Pokemon)] {
+		return
+	}
+	d.protected[pokemonKey(e.Pokemon)] = true
+
+	attacker := MomentPokemon{Pokemon: action.Pokemon, Player: action.Player}
+	d.add(KeyMoment{
+		Type:         MomentProtect,
+		Description:  fmt.Sprintf("%s blocked %s with %s", d.describe(protector), d.describe(attacker), e.Effect.Name),
+		Significance: protectSignificance,
+		Player:       protector.Player,
+		Pokemon:      []MomentPokemon{protector, attacker},
+		ActionOrder:  orderOf(action),
+	})
+}
+
+// recordFlinch adds a moment when a Pokémon flinches from an opposing Fake Out.
+func (d *momentDetector) recordFlinch(ref protocol.PokemonRef) {
+	if d.turns.currentTurn == nil {
+		return
+	}
+	flinched := MomentPokemon{Pokemon: ref.Name, Player: sideToPlayer(ref.Side)}
+	slot := normalizeSlot(refSlot(ref))
+
+	actions := d.turns.currentTurn.Actions
+	for i := len(actions) - 1; i >= 0; i-- {
+		action := &actions[i]
+		if action.Move == nil || action.Move.Name != "Fake Out" || action.Player == flinched.Player || !targetsSlot(action, slot) {
+			continue
+		}
+		user := MomentPokemon{Pokemon: action.Pokemon, Player: action.Player}
+		d.add(KeyMoment{
+			Type:         MomentFakeOut,
+			Description:  fmt.Sprintf("%s made %s flinch with Fake Out", d.describe(user), d.describe(flinched)),
+			Significance: fakeOutSignificance,
+			Player:       user.Player,
+			Pokemon:      []MomentPokemon{user, flinched},
+			ActionOrder:  orderOf(action),
+		})
+		return
+	}
+}
+
+// recordItemChange adds a Focus Sash save, or an item moment for other item
+// changes worth calling out.
+func (d *momentDetector) recordItemChange(change ItemChange) {
+	holder := MomentPokemon{Pokemon: change.Pokemon, Player: change.Player}
+	action := d.turns.currentAction()
+	if change.Move == "" {
+		action = nil
+	}
+
+	if change.Change == ItemConsumed && change.Item == "Focus Sash" {
+		moment := KeyMoment{
+			Type:         MomentFocusSash,
+			Description:  fmt.Sprintf("%s hung on with its Focus Sash", d.describe(holder)),
+			Significance: focusSashSignificance,
+			Player:       holder.Player,
+			Pokemon:      []MomentPokemon{holder},
+			ActionOrder:  orderOf(action),
+		}
+		if action != nil && action.Player != holder.Player {
+			attacker := MomentPokemon{Pokemon: action.Pokemon, Player: action.Player}
+			moment.Description = fmt.Sprintf("%s survived %s's %s with its Focus Sash",
+				d.describe(holder), d.describe(attacker), change.Move)
+			moment.Pokemon = append(moment.Pokemon, attacker)
+		}
+		d.add(moment)
+		return
+	}
+
+	if description, significance := itemMoment(change); description != "" {
+		d.add(KeyMoment{
+			Type:         MomentItem,
+			Description:  description,
+			Significance: significance,
+			Player:       holder.Player,
+			Pokemon:      []MomentPokemon{holder},
+			ActionOrder:  orderOf(action),
+		})
+	}
+}
+
+// findAction returns the latest move this turn by the player's Pokémon,
+// using move if it's given, or nil if there isn't one.
+func (d *momentDetector) findAction(player, pokemon, move string) *Action {
+	if d.turns.currentTurn == nil {
+		return nil
+	}
+	actions := d.turns.currentTurn.Actions
+	for i := len(actions) - 1; i >= 0; i-- {
+		action := &actions[i]
+		if action.ActionType != "move" || action.Player != player || action.Pokemon != pokemon {
+			continue
+		}
+		if move == "" || (action.Move != nil && action.Move.Name == move) {
+			return action
+		}
+	}
+	return nil
+}
+
+// describe names a Pokémon with its player, e.g. "Alice's Incineroar".
+func (d *momentDetector) describe(poke MomentPokemon) string {
+	return d.playerName(playerSide(poke.Player)) + "'s " + poke.Pokemon
+}
+
+// playerName returns the side's player name, or "Player 1" or "Player 2" if
+// the log didn't give one.
+func (d *momentDetector) playerName(side string) string {
+	if name := d.tracker.playerNames[side]; name != "" {
+		return name
+	}
+	if side == "p1" {
+		return "Player 1"
+	}
+	return "Player 2"
+}
+
+// lastPokemon returns the side's one Pokémon still standing, or "" if it
+// hasn't been sent out yet.
+func (st *StateTracker) lastPokemon(playerID string) string {
+	for _, poke := range st.teams[playerID] {
+		if poke.CurrentHP > 0 && contains(st.brought[playerID], poke.Name) {
+			return poke.Name
+		}
+	}
+	return ""
+}
+
+// targetsSlot reports whether the action was aimed at the Pokémon in slot.
+func targetsSlot(action *Action, slot string) bool {
+	for _, target := range action.Targets {
+		if target.Slot == slot {
+			return true
+		}
+	}
+	return false
+}
+
+// orderOf returns a pointer to the action's OrderInTurn, or nil for no action.
+func orderOf(action *Action) *int {
+	if action == nil {
+		return nil
+	}
+	order := action.OrderInTurn
+	return &order
+}
+
+// playerSide returns the side, "p1" or "p2", for a player key.
+func playerSide(player string) string {
+	if player == "player1" {
+		return "p1"
+	}
+	return "p2"
+}
@@ -0,0 +1,156 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+const keyMomentBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Incineroar, L50|
+|poke|p1|Tornadus, L50|
+|poke|p1|Flutter Mane, L50|
+|poke|p2|Rillaboom, L50|
+|poke|p2|Farigiraf, L50|
+|poke|p2|Urshifu, L50|
+|teamsize|p1|3
+|teamsize|p2|3
+|start
+|switch|p1a: Incineroar|Incineroar, L50|100/100
+|switch|p1b: Tornadus|Tornadus, L50|100/100
+|switch|p2a: Rillaboom|Rillaboom, L50|100/100
+|switch|p2b: Farigiraf|Farigiraf, L50|100/100
+|turn|1
+|move|p1a: Incineroar|Fake Out|p2b: Farigiraf
+|-damage|p2b: Farigiraf|90/100
+|move|p1b: Tornadus|Tailwind|p1b: Tornadus
+|-sidestart|p1: Alice|move: Tailwind
+|cant|p2b: Farigiraf|flinch
+|move|p2a: Rillaboom|Wood Hammer|p1b: Tornadus
+|-damage|p1b: Tornadus|1/100
+|-enditem|p1b: Tornadus|Focus Sash
+|-damage|p2a: Rillaboom|85/100|[from] Recoil
+|upkeep
+|turn|2
+|-terastallize|p2a: Rillaboom|Fire
+|move|p1b: Tornadus|Protect|p1b: Tornadus
+|-singleturn|p1b: Tornadus|Protect
+|move|p1a: Incineroar|Flare Blitz|p2b: Farigiraf
+|-crit|p2b: Farigiraf
+|-damage|p2b: Farigiraf|0 fnt
+|faint|p2b: Farigiraf
+|-damage|p1a: Incineroar|70/100|[from] Recoil
+|move|p2a: Rillaboom|Wood Hammer|p1b: Tornadus
+|-activate|p1b: Tornadus|move: Protect
+|upkeep
+|switch|p2b: Urshifu|Urshifu, L50|100/100
+|turn|3
+|move|p2b: Urshifu|Surging Strikes|p1b: Tornadus
+|-damage|p1b: Tornadus|0 fnt
+|faint|p1b: Tornadus
+|move|p2a: Rillaboom|Wood Hammer|p1a: Incineroar
+|-miss|p2a: Rillaboom|p1a: Incineroar
+|move|p1a: Incineroar|Flare Blitz|p2a: Rillaboom
+|-damage|p2a: Rillaboom|0 fnt
+|-damage|p1a: Incineroar|0 fnt|[from] Recoil
+|faint|p2a: Rillaboom
+|faint|p1a: Incineroar
+|-sideend|p1: Alice|move: Tailwind
+|upkeep
+|turn|4
+|win|Bob`
+
+func TestParseShowdownLogKeyMomentDetectors(t *testing.T) {
+	summary, err := ParseShowdownLog(keyMomentBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// order is the triggering action's OrderInTurn, or -1 for none
+	type moment struct {
+		turn        int
+		kind        string
+		player      string
+		order       int
+		description string
+	}
+	expected := []moment{
+		{1, MomentSpeedControl, "player1", 1, "Alice's Tornadus set up Tailwind"},
+		{1, MomentFakeOut, "player1", 0, "Alice's Incineroar made Bob's Farigiraf flinch with Fake Out"},
+		{1, MomentFocusSash, "player1", 2, "Alice's Tornadus survived Bob's Rillaboom's Wood Hammer with its Focus Sash"},
+		{2, MomentTera, "player2", 2, "Bob terastallized Rillaboom into the Fire type"},
+		{2, MomentCriticalKO, "player1", 1, "Alice's Incineroar knocked out Bob's Farigiraf with a critical hit from Flare Blitz"},
+		{2, MomentProtect, "player1", 2, "Alice's Tornadus blocked Bob's Rillaboom with Protect"},
+		{3, MomentKO, "player2", 0, "Bob's Urshifu knocked out Alice's Tornadus with Surging Strikes"},
+		{3, MomentKO, "player1", 2, "Alice's Incineroar knocked out Bob's Rillaboom with Flare Blitz"},
+		{3, MomentLastPokemon, "player2", 2, "Bob is down to their last Pokémon, Urshifu"},
+		{3, MomentKO, "player1", 2, "Alice's Incineroar fainted (recoil: Flare Blitz)"},
+		{3, MomentLastPokemon, "player1", 2, "Alice is down to their last Pokémon"},
+		{3, MomentSpeedControl, "player1", -1, "Alice's Tailwind ran out"},
+		{3, MomentMiss, "player2", 1, "Bob's Rillaboom missed Alice's Incineroar at 70% with Wood Hammer, and Incineroar knocked out Rillaboom in return"},
+		{3, MomentDoubleKO, "player2", 2, "Alice lost Tornadus and Incineroar in the same turn"},
+	}
+
+	var got []moment
+	for _, km := range summary.KeyMoments {
+		if km.Type == MomentTurningPoint {
+			continue
+		}
+		order := -1
+		if km.ActionOrder != nil {
+			order = *km.ActionOrder
+		}
+		got = append(got, moment{km.TurnNumber, km.Type, km.Player, order, km.Description})
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d key moments, got %d: %+v", len(expected), len(got), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("key moment %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestKeyMomentPokemon(t *testing.T) {
+	summary, _ := ParseShowdownLog(keyMomentBattleLog)
+
+	expected := map[string][]MomentPokemon{
+		MomentCriticalKO: {{Pokemon: "Incineroar", Player: "player1"}, {Pokemon: "Farigiraf", Player: "player2"}},
+		MomentMiss:       {{Pokemon: "Rillaboom", Player: "player2"}, {Pokemon: "Incineroar", Player: "player1"}},
+		MomentDoubleKO:   {{Pokemon: "Tornadus", Player: "player1"}, {Pokemon: "Incineroar", Player: "player1"}},
+	}
+	for _, moment := range summary.KeyMoments {
+		if want, ok := expected[moment.Type]; ok && !reflect.DeepEqual(moment.Pokemon, want) {
+			t.Errorf("%s: expected Pokémon %+v, got %+v", moment.Type, want, moment.Pokemon)
+		}
+	}
+}
+
+func TestKeyMomentCritOnLowTargetIsPlainKO(t *testing.T) {
+	// A crit on a Pokémon already in range of a regular hit doesn't get the credit
+	log := `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Incineroar, L50|
+|poke|p2|Amoonguss, L50|
+|teamsize|p1|1
+|teamsize|p2|1
+|start
+|switch|p1a: Incineroar|Incineroar, L50|100/100
+|switch|p2a: Amoonguss|Amoonguss, L50|30/100
+|turn|1
+|move|p1a: Incineroar|Flare Blitz|p2a: Amoonguss
+|-crit|p2a: Amoonguss
+|-damage|p2a: Amoonguss|0 fnt
+|faint|p2a: Amoonguss
+|upkeep
+|win|Alice`
+
+	summary, _ := ParseShowdownLog(log)
+	for _, moment := range summary.KeyMoments {
+		if moment.Type == MomentCriticalKO {
+			t.Errorf("expected a plain KO, got %+v", moment)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package analysis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+// ParseShowdownLogReader parses a Pokémon Showdown battle log from r in a
+// single pass. Lines are read one at a time and fed through one StateTracker
+// and TurnParser, so memory use doesn't grow with the size of the raw log.
+func ParseShowdownLogReader(r io.Reader, opts ...ParseOption) (*BattleSummary, error) {
+	parser := newLogParser()
+	for _, opt := range opts {
+		opt(parser)
+	}
+	reader := bufio.NewReader(r)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			parser.ProcessLine(strings.TrimRight(line, "\r\n"))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read battle log: %w", err)
+		}
+	}
+
+	return parser.Finish(), nil
+}
+
+// ParseOption configures how a battle log is analyzed.
+type ParseOption func(*logParser)
+
+// WithPositionEvaluator scores each turn's position with evaluator instead of
+// DefaultPositionEvaluator.
+func WithPositionEvaluator(evaluator PositionEvaluator) ParseOption {
+	return func(p *logParser) {
+		p.tracker.SetPositionEvaluator(evaluator)
+		p.summary.PositionModel = evaluator.Name()
+	}
+}
+
+// WithWinProbabilityModel estimates each turn's win probability with model
+// instead of DefaultWinProbabilityModel.
+func WithWinProbabilityModel(model *WinProbabilityModel) ParseOption {
+	return func(p *logParser) {
+		p.winModel = model
+		p.summary.WinProbabilityModel = model.Version
+	}
+}
+
+// logParser builds a BattleSummary from protocol lines as they arrive.
+// Team preview, |poke| and |showteam| lines all precede the first switch in a
+// Showdown log, so the team is complete before any in-battle state is tracked.
+type logParser struct {
+	summary    *BattleSummary
+	tracker    *StateTracker
+	turnParser *TurnParser
+	sources    *sourceTracker
+	damage     *damageLedger
+	moments    *momentDetector
+	speed      *speedObserver
+	samples    *damageSampler
+	winModel   *WinProbabilityModel
+	clock      turnClock
+	turnNumber int
+}
+
+func newLogParser() *logParser {
+	p := &logParser{
+		summary: &BattleSummary{
+			ID:                  generateUUID(),
+			Timestamp:           time.Now(),
+			Turns:               []Turn{},
+			KeyMoments:          []KeyMoment{},
+			Stats:               BattleStats{},
+			PositionModel:       DefaultPositionEvaluator.Name(),
+			WinProbabilityModel: DefaultWinProbabilityModel.Version,
+		},
+		winModel:   DefaultWinProbabilityModel,
+		tracker:    NewStateTracker(),
+		turnParser: NewTurnParser(),
+		sources:    newSourceTracker(),
+		damage:     newDamageLedger(),
+	}
+	p.moments = newMomentDetector(p.summary, p.tracker, p.turnParser)
+	p.speed = newSpeedObserver(p.tracker, p.turnParser)
+	p.samples = newDamageSampler(p.tracker)
+	return p
+}
+
+// ProcessLine applies a single protocol line to the battle state.
+func (p *logParser) ProcessLine(line string) {
+	if event := protocol.Parse(line); event != nil {
+		p.HandleEvent(event)
+	}
+}
+
+// HandleEvent applies a decoded protocol event to the battle state.
+func (p *logParser) HandleEvent(event protocol.Event) {
+	summary := p.summary
+	tracker := p.tracker
+
+	p.recordAbility(event)
+
+	switch e := event.(type) {
+	case protocol.TierEvent:
+		summary.Format = e.Format
+
+	case protocol.PlayerEvent:
+		// A bare |player|p1| line is sent when a player leaves the room
+		if e.Name == "" {
+			return
+		}
+		tracker.SetPlayerName(e.Side, e.Name)
+
+		var player *Player
+		switch e.Side {
+		case "p1":
+			player = &summary.Player1
+		case "p2":
+			player = &summary.Player2
+		}
+		if player != nil {
+			player.Name = e.Name
+			if e.Avatar != "" {
+				player.Avatar = e.Avatar
+			}
+			if e.Rating > 0 {
+				player.Rating = e.Rating
+			}
+		}
+
+	case protocol.RatedEvent:
+		summary.Rated = true
+
+	case protocol.TeamSizeEvent:
+		tracker.SetTeamSize(e.Side, e.Size)
+
+	case protocol.PokeEvent:
+		tracker.AddPokemonToTeam(e.Side, pokemonFromDetails(e.Details))
+
+	case protocol.UHTMLEvent:
+		// Best-of-3 series marker. The |uhtml|next| link to the following
+		// game adds nothing, since games are grouped by series ID.
+		if e.Name == "bestof" {
+			summary.SeriesID, summary.GameNumber = parseBestOfMarker(e.HTML)
+		}
+
+	case protocol.ShowTeamEvent:
+		// Open team sheet
+		tracker.ApplyTeamSheet(e.Side, ParsePackedTeam(e.Packed))
+
+	case protocol.TimestampEvent:
+		p.clock.Tick(e.Unix, p.turnParser.currentTurn)
+
+	case protocol.TurnEvent:
+		// Save previous turn and start new one
+		p.clock.BeginTurn(p.turnParser.currentTurn)
+		p.finalizeTurn()
+		p.turnNumber = e.Number
+		tracker.SetTurn(p.turnNumber)
+		p.turnParser.StartNewTurn(p.turnNumber)
+
+	case protocol.SwitchEvent:
+		p.turnParser.HandleEvent(e)
+		p.sources.RecordSwitch()
+		p.damage.RecordSwitch(e)
+		hp := 100
+		if e.HasHP {
+			hp = e.HP.Current
+		}
+		if e.Kind == "replace" {
+			tracker.RevealIllusion(refSlot(e.Pokemon), e.Details.Species)
+		}
+		tracker.SwitchPokemon(refSlot(e.Pokemon), e.Details.Species, hp)
+		if e.HasHP {
+			tracker.UpdatePokemonHP(refSlot(e.Pokemon), e.HP.Current, e.HP.Max)
+		}
+
+	case protocol.SwapEvent:
+		tracker.SwapPokemon(refSlot(e.Pokemon), e.Position)
+
+	case protocol.MoveEvent:
+		p.turnParser.HandleEvent(e)
+		p.sources.RecordMove(e)
+
+	case protocol.WeatherEvent:
+		p.turnParser.HandleEvent(e)
+		tracker.RecordWeather(e, p.sources.responsible(e.Of))
+		p.sources.RecordWeather(e)
+
+	case protocol.FieldEvent:
+		p.turnParser.HandleEvent(e)
+		tracker.RecordFieldCondition(e, p.sources.responsible(e.Of))
+
+	case protocol.MissEvent:
+		p.turnParser.HandleEvent(e)
+
+	case protocol.UpkeepEvent:
+		tracker.MarkUpkeep()
+
+	case protocol.VolatileEvent:
+		if volatile, ok := tracker.RecordVolatile(e, p.sources.responsible(e.Of), p.sources.lastMove); ok {
+			p.turnParser.HandleEvent(volatile)
+		}
+		p.sources.RecordVolatile(e)
+
+	case protocol.SingleTurnEvent:
+		p.turnParser.HandleEvent(tracker.RecordSingleTurn(e, p.sources.responsible(e.Of), p.sources.lastMove))
+
+	case protocol.ItemEvent:
+		p.recordItemChange(tracker.RecordItem(e, p.sources.responsible(e.Of), p.sources.lastMove))
+
+	case protocol.DamageEvent:
+		p.revealItem(e.From, firstRef(e.Of, e.Pokemon))
+		p.samples.RecordDamage(e)
+		change := tracker.ApplyHPChange(e.Pokemon, e.HP)
+		change.From, change.Of = e.From, e.Of
+		p.recordHPChange(change)
+
+	case protocol.HealEvent:
+		p.revealItem(e.From, firstRef(e.Of, e.Pokemon))
+		change := tracker.ApplyHPChange(e.Pokemon, e.HP)
+		change.From, change.Of = e.From, e.Of
+		p.recordHPChange(change)
+
+	case protocol.FaintEvent:
+		p.turnParser.HandleEvent(e)
+		tracker.FaintPokemon(refSlot(e.Pokemon))
+
+	case protocol.BoostEvent:
+		// Track stat changes for position scoring
+		p.turnParser.HandleEvent(e)
+		tracker.RecordStatChange(e)
+
+	case protocol.SetBoostEvent:
+		tracker.SetStatStage(e)
+
+	case protocol.ClearBoostEvent:
+		tracker.ClearStatStages(e)
+
+	case protocol.InvertBoostEvent:
+		tracker.InvertStatStages(e)
+
+	case protocol.CopyBoostEvent:
+		tracker.CopyStatStages(e)
+
+	case protocol.StatusEvent:
+		// Track status conditions
+		p.turnParser.HandleEvent(e)
+		p.sources.RecordStatus(e)
+		p.revealItem(e.From, e.Pokemon)
+		tracker.UpdatePokemonStatus(refSlot(e.Pokemon), e.Status)
+
+	case protocol.CureStatusEvent:
+		p.revealItem(e.From, e.Pokemon)
+		tracker.UpdatePokemonStatus(refSlot(e.Pokemon), "")
+
+	case protocol.TerastallizeEvent:
+		tracker.TerastallizePokemon(refSlot(e.Pokemon), e.TeraType)
+
+	case protocol.SideEvent:
+		// Track side conditions like Tailwind and screens
+		tracker.RecordFieldEffect(e, p.sources.lastMover)
+		p.sources.RecordSide(e)
+
+	case protocol.CritEvent:
+		p.turnParser.HandleEvent(e)
+		summary.Stats.CriticalHits++
+
+	case protocol.EffectivenessEvent:
+		p.turnParser.HandleEvent(e)
+		switch e.Effectiveness {
+		case protocol.SuperEffective:
+			summary.Stats.SuperEffective++
+		case protocol.NotVeryEffective:
+			summary.Stats.NotVeryEffective++
+		}
+
+	case protocol.WinEvent:
+		summary.Winner = tracker.PlayerToID(e.Winner)
+	}
+
+	p.moments.Observe(event)
+	p.speed.Observe(event)
+	p.samples.Observe(event)
+}
+
+// recordHPChange attributes an HP change to its source before it's added to
+// the turn and the damage breakdowns.
+func (p *logParser) recordHPChange(change HPChange) {
+	change.Source = p.sources.Attribute(change)
+	p.damage.Add(change)
+	p.turnParser.HandleEvent(change)
+	p.moments.Observe(change)
+}
+
+// recordAbility reveals any ability at work in event. Stat changes right
+// after an announced ability, like the drops after Intimidate, are its
+// effects; anything else ends the announcement.
+func (p *logParser) recordAbility(event protocol.Event) {
+	if trigger, ok := findAbilityTrigger(event); ok {
+		p.tracker.RecordAbility(trigger, event.Command())
+		return
+	}
+	if boost, ok := event.(protocol.BoostEvent); ok && p.tracker.AddAnnouncedBoost(boost) {
+		return
+	}
+	p.tracker.EndAbilityAnnouncement()
+}
+
+// recordItemChange passes an item change to the turn and to the key moment
+// detector.
+func (p *logParser) recordItemChange(change ItemChange) {
+	p.turnParser.HandleEvent(change)
+	p.moments.Observe(change)
+}
+
+// revealItem records the holder's item when an effect comes [from] it, e.g.
+// Leftovers, Life Orb, Rocky Helmet or Flame Orb.
+func (p *logParser) revealItem(from protocol.Effect, holder protocol.PokemonRef) {
+	if from.Kind != "item" {
+		return
+	}
+	if change, ok := p.tracker.RevealItem(holder, from.Name); ok {
+		p.recordItemChange(change)
+	}
+}
+
+// finalizeTurn closes the turn in progress and adds it to the summary.
+func (p *logParser) finalizeTurn() {
+	p.moments.FinishTurn()
+	p.speed.FinishTurn()
+	p.samples.FinishTurn()
+	if turn := p.turnParser.FinalizeTurn(p.tracker); turn != nil {
+		turn.WinProbability = p.winModel.WinProbability(turn.Features)
+		p.summary.Turns = append(p.summary.Turns, *turn)
+	}
+}
+
+// Finish closes the last turn and computes battle-wide results.
+func (p *logParser) Finish() *BattleSummary {
+	summary := p.summary
+	tracker := p.tracker
+
+	// Add the last turn
+	p.clock.Finish(p.turnParser.currentTurn)
+	p.finalizeTurn()
+
+	// Use the log's own timestamps when it has them
+	if p.clock.HasTimestamps() {
+		summary.Timestamp = p.clock.StartTime()
+		summary.EndTime = p.clock.EndTime()
+		summary.Duration = p.clock.DurationSec()
+	}
+
+	// Update player teams and losses from tracker
+	summary.Player1.Team = tracker.GetTeam("p1")
+	summary.Player2.Team = tracker.GetTeam("p2")
+	summary.Player1.Losses = tracker.losses["p1"]
+	summary.Player2.Losses = tracker.losses["p2"]
+	summary.Player1.TotalLeft = tracker.GetTeamSize("p1") - tracker.losses["p1"]
+	summary.Player2.TotalLeft = tracker.GetTeamSize("p2") - tracker.losses["p2"]
+
+	// Record team preview choices and Tera usage
+	summary.Player1.Leads = tracker.GetLeads("p1")
+	summary.Player2.Leads = tracker.GetLeads("p2")
+	summary.Player1.Brought = tracker.GetBrought("p1")
+	summary.Player2.Brought = tracker.GetBrought("p2")
+	summary.Player1.BackLine = tracker.GetBackLine("p1")
+	summary.Player2.BackLine = tracker.GetBackLine("p2")
+	summary.Player1.Benched, summary.Player1.BenchedKnown = tracker.GetBenched("p1")
+	summary.Player2.Benched, summary.Player2.BenchedKnown = tracker.GetBenched("p2")
+	summary.Player1.Unrevealed = tracker.GetUnrevealed("p1")
+	summary.Player2.Unrevealed = tracker.GetUnrevealed("p2")
+	summary.Player1.Terastallized = tracker.GetTerastallization("p1")
+	summary.Player2.Terastallized = tracker.GetTerastallization("p2")
+	summary.FieldTimeline = tracker.GetFieldTimeline()
+	summary.ItemTimeline = tracker.GetItemTimeline()
+	summary.AbilityActivations = tracker.GetAbilityActivations()
+	summary.VolatileTimeline = tracker.GetVolatileTimeline()
+	summary.Speed = p.speed.Analysis()
+	summary.DamageSamples = p.samples.Samples()
+	attachSpreads(summary)
+
+	// Calculate statistics and turning points
+	calculateStats(summary)
+	p.damage.Apply(&summary.Stats)
+	detectTurningPoints(summary)
+
+	// Classify teams
+	summary.Player1.Classification = ClassifyTeam(summary.Player1.Team)
+	summary.Player1.TeamArchetype = summary.Player1.Classification.Archetype
+	summary.Player2.Classification = ClassifyTeam(summary.Player2.Team)
+	summary.Player2.TeamArchetype = summary.Player2.Classification.Archetype
+
+	return summary
+}
@@ -0,0 +1,120 @@
+package analysis
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseShowdownLogReaderMatchesString(t *testing.T) {
+	log := sampleBattleLog()
+
+	fromString, err := ParseShowdownLog(log)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	fromReader, err := ParseShowdownLogReader(iotest.OneByteReader(strings.NewReader(log)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(fromReader.Turns) != len(fromString.Turns) {
+		t.Fatalf("expected %d turns, got %d", len(fromString.Turns), len(fromReader.Turns))
+	}
+	if fromReader.Winner != fromString.Winner {
+		t.Errorf("expected winner %q, got %q", fromString.Winner, fromReader.Winner)
+	}
+	if fromReader.Stats.CriticalHits != fromString.Stats.CriticalHits {
+		t.Errorf("expected %d crits, got %d", fromString.Stats.CriticalHits, fromReader.Stats.CriticalHits)
+	}
+}
+
+func TestParseShowdownLogReaderCRLF(t *testing.T) {
+	log := strings.ReplaceAll(timedBattleLog, "\n", "\r\n")
+
+	summary, err := ParseShowdownLogReader(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if summary.Winner != "player1" {
+		t.Errorf("expected player1 to win, got %q", summary.Winner)
+	}
+	if summary.Player1.Name != "Alice" {
+		t.Errorf("expected player name without carriage return, got %q", summary.Player1.Name)
+	}
+	if len(summary.Turns) != 2 {
+		t.Errorf("expected 2 turns, got %d", len(summary.Turns))
+	}
+}
+
+func TestParseShowdownLogReaderLongLine(t *testing.T) {
+	// |raw| and |html| lines can be far longer than a default scanner buffer
+	log := "|raw|" + strings.Repeat("x", 256*1024) + "\n" + timedBattleLog
+
+	summary, err := ParseShowdownLogReader(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(summary.Turns) != 2 {
+		t.Errorf("expected 2 turns, got %d", len(summary.Turns))
+	}
+}
+
+func TestParseShowdownLogReaderError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	reader := iotest.ErrReader(readErr)
+
+	_, err := ParseShowdownLogReader(reader)
+	if !errors.Is(err, readErr) {
+		t.Errorf("expected read error to be wrapped, got %v", err)
+	}
+}
+
+func TestParseShowdownLogSinglePassTurns(t *testing.T) {
+	summary, err := ParseShowdownLog(timedBattleLog)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Turns carry detailed action data from the turn parser
+	first := summary.Turns[0]
+	if len(first.Actions) != 2 {
+		t.Fatalf("expected 2 actions in turn 1, got %d", len(first.Actions))
+	}
+	if first.Actions[0].Move.Name != "Thunderbolt" || first.Actions[1].OrderInTurn != 1 {
+		t.Errorf("expected ordered move actions, got %+v", first.Actions)
+	}
+	if first.Actions[0].Impact == nil {
+		t.Error("expected move impact to be attached")
+	}
+
+	// The same tracker sees the faint, so the final position reflects the KO
+	last := summary.Turns[len(summary.Turns)-1]
+	if last.PositionScore == nil || last.PositionScore.MomentumPlayer != "player1" {
+		t.Errorf("expected player1 momentum after the KO, got %+v", last.PositionScore)
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"42", 42},
+		{" 7 ", 7},
+		{"-3", -3},
+		{"+2", 2},
+		{"50 fnt", 50},
+		{"L50", 0},
+		{"", 0},
+		{"1763188046", 1763188046},
+	}
+
+	for _, tt := range tests {
+		if got := parseInt(tt.input); got != tt.expected {
+			t.Errorf("parseInt(%q) = %d, expected %d", tt.input, got, tt.expected)
+		}
+	}
+}
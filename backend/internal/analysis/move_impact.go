@@ -1,16 +1,29 @@
 package analysis
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
 
 // EnhanceActionWithImpact adds detailed impact information to an action based on subsequent battle events
-func EnhanceActionWithImpact(action *Action, moveName string, events []string) {
+func EnhanceActionWithImpact(action *Action, moveName string, events []protocol.Event) {
 	if action.Impact == nil {
 		action.Impact = &MoveImpact{
-			Fainted:     []string{},
-			StatChanges: []StatChange{},
+			Fainted:        []string{},
+			StatChanges:    []StatChange{},
+			ItemChanges:    []ItemChange{},
+			Volatiles:      []Volatile{},
+			SpreadModifier: 1,
 		}
 	}
 
+	// Moves that hit more than one target deal 75% damage to each
+	if len(action.Targets) > 1 {
+		action.Impact.Spread = true
+		action.Impact.SpreadModifier = spreadModifier
+	}
+
 	moveID := strings.ToLower(moveName)
 
 	// Track special move types
@@ -31,109 +44,97 @@ func EnhanceActionWithImpact(action *Action, moveName string, events []string) {
 		action.Impact.SpeedControl = "paralysis"
 	}
 
-	// Parse events to extract impact
+	// Apply events to extract impact
 	for _, event := range events {
-		if !strings.HasPrefix(event, "|") {
-			continue
-		}
-		parts := strings.Split(event, "|")
-		if len(parts) < 2 {
-			continue
-		}
-
-		eventType := parts[1]
+		switch e := event.(type) {
+		case HPChange:
+			// Only count HP this move changed, not recoil, items or residual damage
+			if e.Source.Kind != SourceMove || e.Source.Name != moveName || e.Source.Pokemon != action.Pokemon {
+				continue
+			}
+			if sideToPlayer(e.Pokemon.Side) == action.Player {
+				action.Impact.HealingDone += e.Healing()
+			} else {
+				action.Impact.DamageDealt += e.Damage()
+			}
+			if target := actionTarget(action, e.Pokemon); target != nil {
+				target.DamageDealt += e.Damage()
+				target.HealingDone += e.Healing()
+				target.Fainted = e.After == 0
+			}
 
-		switch eventType {
-		case "-damage":
-			// Damage dealt - parse HP change
-			if len(parts) >= 4 {
-				hpBefore, hpAfter := parseHPChange(parts)
-				if hpBefore > hpAfter {
-					action.Impact.DamageDealt += (hpBefore - hpAfter)
-				}
+		case ItemChange:
+			// Items this move knocked off, swapped or used up
+			if e.Move == moveName && e.By == action.Pokemon {
+				action.Impact.ItemChanges = append(action.Impact.ItemChanges, e)
 			}
 
-		case "-heal":
-			// Healing done
-			if len(parts) >= 4 {
-				hpBefore, hpAfter := parseHPChange(parts)
-				if hpAfter > hpBefore {
-					action.Impact.HealingDone += (hpAfter - hpBefore)
-				}
+		case Volatile:
+			// Confusion, Taunt, Protect and the like
+			if e.Move == moveName && e.Source == action.Pokemon {
+				action.Impact.Volatiles = append(action.Impact.Volatiles, e)
 			}
 
-		case "-status":
+		case protocol.StatusEvent:
 			// Status inflicted
-			if len(parts) >= 4 {
-				action.Impact.StatusInflicted = parts[3]
-			}
+			action.Impact.StatusInflicted = e.Status
 
-		case "faint":
+		case protocol.FaintEvent:
 			// Pokémon fainted
-			if len(parts) >= 3 {
-				faintedPoke := extractPokemonName(parts[2])
-				action.Impact.Fainted = append(action.Impact.Fainted, faintedPoke)
-			}
+			action.Impact.Fainted = append(action.Impact.Fainted, e.Pokemon.Name)
 
-		case "-crit":
+		case protocol.CritEvent:
 			// Critical hit
 			action.Impact.Critical = true
 			action.Result = "critical-hit"
+			if target := actionTarget(action, e.Pokemon); target != nil {
+				target.Critical = true
+			}
 
-		case "-supereffective":
-			// Super effective
-			action.Impact.Effectiveness = "super-effective"
-			action.Result = "super-effective"
-
-		case "-resisted":
-			// Not very effective
-			action.Impact.Effectiveness = "not-very-effective"
-			action.Result = "not-very-effective"
-
-		case "-immune":
-			// Immune
-			action.Impact.Effectiveness = "immune"
-			action.Result = "immune"
+		case protocol.EffectivenessEvent:
+			// Super effective, not very effective or immune
+			action.Impact.Effectiveness = e.Effectiveness
+			action.Result = e.Effectiveness
+			if target := actionTarget(action, e.Pokemon); target != nil {
+				target.Effectiveness = e.Effectiveness
+				target.Immune = e.Effectiveness == protocol.Immune
+			}
 
-		case "-miss":
+		case protocol.MissEvent:
 			// Move missed
 			action.Impact.Missed = true
 			action.Result = "miss"
+			if target := actionTarget(action, e.Target); target != nil {
+				target.Missed = true
+			}
 
-		case "-weather":
+		case protocol.WeatherEvent:
 			// Weather set
-			if len(parts) >= 3 {
-				action.Impact.WeatherSet = parts[2]
+			if !e.Upkeep {
+				action.Impact.WeatherSet = e.Weather
 			}
 
-		case "-fieldstart":
+		case protocol.FieldEvent:
 			// Terrain or room set
-			if len(parts) >= 3 {
-				field := strings.ToLower(parts[2])
-				if strings.Contains(field, "terrain") {
-					action.Impact.TerrainSet = parts[2]
-				} else if strings.Contains(field, "trick room") {
-					action.Impact.SpeedControl = "trick-room"
-				} else if strings.Contains(field, "tailwind") {
-					action.Impact.SpeedControl = "tailwind"
-				}
+			if e.Ended {
+				continue
+			}
+			field := strings.ToLower(e.Effect.Name)
+			if strings.Contains(field, "terrain") {
+				action.Impact.TerrainSet = e.Effect.Name
+			} else if strings.Contains(field, "trick room") {
+				action.Impact.SpeedControl = "trick-room"
+			} else if strings.Contains(field, "tailwind") {
+				action.Impact.SpeedControl = "tailwind"
 			}
 
-		case "-boost", "-unboost":
+		case protocol.BoostEvent:
 			// Stat changes
-			if len(parts) >= 5 {
-				pokemon := extractPokemonName(parts[2])
-				stat := parts[3]
-				stages := parseInt(parts[4])
-				if eventType == "-unboost" {
-					stages = -stages
-				}
-				action.Impact.StatChanges = append(action.Impact.StatChanges, StatChange{
-					Pokemon: pokemon,
-					Stat:    stat,
-					Stages:  stages,
-				})
-			}
+			action.Impact.StatChanges = append(action.Impact.StatChanges, StatChange{
+				Pokemon: e.Pokemon.Name,
+				Stat:    e.Stat,
+				Stages:  e.Amount,
+			})
 		}
 	}
 
@@ -152,15 +153,23 @@ func EnhanceActionWithImpact(action *Action, moveName string, events []string) {
 	action.Details = generateActionDetails(action)
 }
 
-// parseHPChange extracts HP before and after from an HP string
-func parseHPChange(parts []string) (int, int) {
-	if len(parts) < 4 {
-		return 0, 0
+// spreadModifier is the damage multiplier for moves that hit more than one
+// target in doubles.
+const spreadModifier = 0.75
+
+// actionTarget returns the action's entry for the Pokémon in ref's slot, or
+// nil if the action wasn't aimed at it.
+func actionTarget(action *Action, ref protocol.PokemonRef) *ActionTarget {
+	for i := range action.Targets {
+		target := &action.Targets[i]
+		if target.Slot == ref.Slot && ref.Slot != "" {
+			if target.Pokemon == "" {
+				target.Pokemon = ref.Name
+			}
+			return target
+		}
 	}
-	// HP format: "100/100" or "50/100"
-	hpStr := parts[3]
-	hp, _ := parseHP(hpStr)
-	return 100, hp // Simplified - in real impl, track actual HP
+	return nil
 }
 
 // generateActionDetails creates a human-readable description of the action's impact
@@ -200,7 +209,7 @@ func generateActionDetails(action *Action) string {
 
 	if len(impact.Fainted) > 0 {
 		for _, poke := range impact.Fainted {
-			details = append(details, poke+" flinched")
+			details = append(details, poke+" fainted")
 		}
 	}
 
@@ -208,6 +217,15 @@ func generateActionDetails(action *Action) string {
 		details = append(details, "But it missed")
 	}
 
+	for _, change := range impact.ItemChanges {
+		switch change.Change {
+		case ItemRemoved:
+			details = append(details, change.Pokemon+" lost its "+change.Item)
+		case ItemSwapped:
+			details = append(details, change.Pokemon+" received "+change.Item)
+		}
+	}
+
 	if len(details) > 0 {
 		return strings.Join(details, ", ")
 	}
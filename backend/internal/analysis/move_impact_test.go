@@ -0,0 +1,97 @@
+package analysis
+
+import "testing"
+
+const spreadBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Typhlosion-Hisui, L50|
+|poke|p1|Landorus, L50|
+|poke|p2|Amoonguss, L50|
+|poke|p2|Dragonite, L50|
+|teamsize|p1|2
+|teamsize|p2|2
+|start
+|switch|p1a: Typhlosion|Typhlosion-Hisui, L50|100/100
+|switch|p1b: Landorus|Landorus, L50|100/100
+|switch|p2a: Amoonguss|Amoonguss, L50|100/100
+|switch|p2b: Dragonite|Dragonite, L50|100/100
+|turn|1
+|move|p1a: Typhlosion|Heat Wave|p2a: Amoonguss|[spread] p2a,p2b
+|-supereffective|p2a: Amoonguss
+|-resisted|p2b: Dragonite
+|-crit|p2b: Dragonite
+|-damage|p2a: Amoonguss|0 fnt
+|-damage|p2b: Dragonite|81/100
+|faint|p2a: Amoonguss
+|move|p1b: Landorus|Rock Slide|p2b: Dragonite
+|-miss|p1b: Landorus|p2b: Dragonite
+|upkeep
+|turn|2
+|move|p1b: Landorus|Earthquake|p2b: Dragonite|[spread] p1a,p2b
+|-immune|p2b: Dragonite
+|-damage|p1a: Typhlosion|62/100
+|upkeep
+|win|Alice`
+
+func TestSpreadMoveTargets(t *testing.T) {
+	summary, err := ParseShowdownLog(spreadBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	heatWave := summary.Turns[0].Actions[0]
+	if len(heatWave.Targets) != 2 {
+		t.Fatalf("expected Heat Wave to have 2 targets, got %+v", heatWave.Targets)
+	}
+
+	amoonguss, dragonite := heatWave.Targets[0], heatWave.Targets[1]
+	expectedAmoonguss := ActionTarget{Pokemon: "Amoonguss", Player: "player2", Slot: "p2a", DamageDealt: 100, Effectiveness: "super-effective", Fainted: true}
+	if amoonguss != expectedAmoonguss {
+		t.Errorf("expected %+v, got %+v", expectedAmoonguss, amoonguss)
+	}
+	expectedDragonite := ActionTarget{Pokemon: "Dragonite", Player: "player2", Slot: "p2b", DamageDealt: 19, Effectiveness: "not-very-effective", Critical: true}
+	if dragonite != expectedDragonite {
+		t.Errorf("expected %+v, got %+v", expectedDragonite, dragonite)
+	}
+
+	if !heatWave.Impact.Spread || heatWave.Impact.SpreadModifier != 0.75 {
+		t.Errorf("expected spread reduction on Heat Wave, got %+v", heatWave.Impact)
+	}
+	if heatWave.Impact.DamageDealt != 119 {
+		t.Errorf("expected Heat Wave to deal 119 in total, got %d", heatWave.Impact.DamageDealt)
+	}
+}
+
+func TestSingleTargetMoveResults(t *testing.T) {
+	summary, _ := ParseShowdownLog(spreadBattleLog)
+
+	rockSlide := summary.Turns[0].Actions[1]
+	if len(rockSlide.Targets) != 1 || !rockSlide.Targets[0].Missed {
+		t.Errorf("expected Rock Slide to miss its one target, got %+v", rockSlide.Targets)
+	}
+	if rockSlide.Impact.Spread || rockSlide.Impact.SpreadModifier != 1 {
+		t.Errorf("expected no spread reduction, got %+v", rockSlide.Impact)
+	}
+}
+
+func TestSpreadMoveImmunityAndAllyDamage(t *testing.T) {
+	summary, _ := ParseShowdownLog(spreadBattleLog)
+
+	earthquake := summary.Turns[1].Actions[0]
+	if len(earthquake.Targets) != 2 {
+		t.Fatalf("expected Earthquake to have 2 targets, got %+v", earthquake.Targets)
+	}
+
+	dragonite, typhlosion := earthquake.Targets[0], earthquake.Targets[1]
+	if !dragonite.Immune || dragonite.DamageDealt != 0 {
+		t.Errorf("expected Dragonite to be immune, got %+v", dragonite)
+	}
+	if typhlosion.Pokemon != "Typhlosion" || typhlosion.Player != "player1" || typhlosion.DamageDealt != 38 {
+		t.Errorf("expected Earthquake to hit the ally Typhlosion for 38, got %+v", typhlosion)
+	}
+
+	// Hitting an ally isn't damage dealt to the opponent
+	if earthquake.Impact.DamageDealt != 0 {
+		t.Errorf("expected no damage to the opponent, got %d", earthquake.Impact.DamageDealt)
+	}
+}
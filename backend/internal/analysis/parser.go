@@ -5,222 +5,13 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
 )
 
 // ParseShowdownLog parses a Pokémon Showdown battle log and returns a comprehensive BattleSummary.
-func ParseShowdownLog(logContent string) (*BattleSummary, error) {
-	lines := strings.Split(logContent, "\n")
-
-	summary := &BattleSummary{
-		ID:         generateUUID(),
-		Timestamp:  time.Now(),
-		Turns:      []Turn{},
-		KeyMoments: []KeyMoment{},
-		Stats:      BattleStats{},
-	}
-
-	// Create a state tracker to maintain battle state throughout
-	tracker := NewStateTracker()
-
-	// First pass: extract metadata and team information
-	for _, line := range lines {
-		if line == "" || !strings.HasPrefix(line, "|") {
-			continue
-		}
-
-		parts := strings.Split(line, "|")
-		if len(parts) < 2 {
-			continue
-		}
-
-		command := parts[1]
-
-		switch command {
-		case "tier":
-			if len(parts) > 2 {
-				summary.Format = strings.Join(parts[2:], "|")
-			}
-
-		case "player":
-			if len(parts) > 3 {
-				playerID := parts[2]
-				playerName := parts[3]
-				tracker.SetPlayerName(playerID, playerName)
-				switch playerID {
-				case "p1":
-					summary.Player1.Name = playerName
-				case "p2":
-					summary.Player2.Name = playerName
-				}
-			}
-
-		case "teamsize":
-			if len(parts) > 3 {
-				playerID := parts[2]
-				teamSize := parseInt(parts[3])
-				tracker.SetTeamSize(playerID, teamSize)
-			}
-
-		case "poke":
-			if len(parts) > 3 {
-				playerID := parts[2]
-				pokeStr := parts[3]
-				poke := parsePokemonFromTeamPreview(pokeStr)
-				tracker.AddPokemonToTeam(playerID, poke)
-			}
-		}
-	}
-
-	// Initialize tracker with teams
-	summary.Player1.Team = tracker.GetTeam("p1")
-	summary.Player2.Team = tracker.GetTeam("p2")
-	summary.Player1.TotalLeft = tracker.GetTeamSize("p1")
-	summary.Player2.TotalLeft = tracker.GetTeamSize("p2")
-
-	// Second pass: process all battle events
-	var currentTurn *Turn
-	var turnNumber int
-
-	for _, line := range lines {
-		if line == "" || !strings.HasPrefix(line, "|") {
-			continue
-		}
-
-		parts := strings.Split(line, "|")
-		if len(parts) < 2 {
-			continue
-		}
-
-		command := parts[1]
-
-		switch command {
-		case "turn":
-			// Save previous turn and start new one
-			if currentTurn != nil {
-				// Calculate position score for the turn
-				currentTurn.PositionScore = tracker.CalculatePositionScore()
-				summary.Turns = append(summary.Turns, *currentTurn)
-			}
-			turnNumber = parseInt(parts[2])
-			currentTurn = &Turn{
-				TurnNumber:  turnNumber,
-				Actions:     []Action{},
-				DamageDealt: make(map[string]int),
-				HealingDone: make(map[string]int),
-			}
-
-		case "switch":
-			if len(parts) >= 4 {
-				action := parseSwitch(parts)
-				if currentTurn != nil {
-					currentTurn.Actions = append(currentTurn.Actions, action)
-				}
-				// Update tracker state
-				playerID := extractRawPlayerID(parts[2])
-				pokeName := extractPokemonName(parts[3])
-				pokehp := extractHPFromSwitch(parts)
-				tracker.SwitchPokemon(playerID, pokeName, pokehp)
-			}
-
-		case "move":
-			if len(parts) >= 4 {
-				action := parseMove(parts)
-				if currentTurn != nil {
-					currentTurn.Actions = append(currentTurn.Actions, action)
-				}
-			}
-
-		case "-damage":
-			if len(parts) >= 4 {
-				playerID := extractRawPlayerID(parts[2])
-				hpStr := parts[3]
-				hp, maxHP := parseHP(hpStr)
-				tracker.UpdatePokemonHP(playerID, hp, maxHP)
-			}
-
-		case "-heal":
-			if len(parts) >= 4 {
-				playerID := extractRawPlayerID(parts[2])
-				hpStr := parts[3]
-				hp, maxHP := parseHP(hpStr)
-				tracker.UpdatePokemonHP(playerID, hp, maxHP)
-			}
-
-		case "faint":
-			if len(parts) > 2 {
-				playerID := extractRawPlayerID(parts[2])
-				tracker.FaintPokemon(playerID)
-				if currentTurn != nil {
-					addKeyMoment(summary, turnNumber, "KO", "Pokémon fainted", 8)
-				}
-			}
-
-		case "-boost", "-unboost":
-			// Track stat changes for position scoring
-			if len(parts) > 3 {
-				tracker.RecordStatChange(parts)
-			}
-
-		case "-status":
-			// Track status conditions
-			if len(parts) > 2 {
-				playerID := extractRawPlayerID(parts[2])
-				status := parts[3]
-				tracker.UpdatePokemonStatus(playerID, status)
-			}
-
-		case "-terastallize":
-			// Track terastallization
-			if len(parts) > 3 {
-				playerID := extractRawPlayerID(parts[2])
-				teraType := parts[3]
-				tracker.TerastallizePokemon(playerID, teraType)
-			}
-
-		case "-sidestart", "-sideend":
-			// Track field effects like Tailwind
-			tracker.RecordFieldEffect(parts)
-
-		case "-crit":
-			summary.Stats.CriticalHits++
-
-		case "-supereffective":
-			summary.Stats.SuperEffective++
-
-		case "-resisted":
-			summary.Stats.NotVeryEffective++
-
-		case "win":
-			if len(parts) > 2 {
-				winner := parts[2]
-				summary.Winner = tracker.PlayerToID(winner)
-			}
-		}
-	}
-
-	// Add the last turn
-	if currentTurn != nil {
-		currentTurn.PositionScore = tracker.CalculatePositionScore()
-		summary.Turns = append(summary.Turns, *currentTurn)
-	}
-
-	// Update player losses from tracker
-	summary.Player1.Losses = tracker.losses["p1"]
-	summary.Player2.Losses = tracker.losses["p2"]
-	summary.Player1.TotalLeft = tracker.GetTeamSize("p1") - tracker.losses["p1"]
-	summary.Player2.TotalLeft = tracker.GetTeamSize("p2") - tracker.losses["p2"]
-
-	// Calculate statistics and turning points
-	calculateStats(summary)
-	detectTurningPoints(summary)
-
-	// Classify teams
-	summary.Player1.Classification = ClassifyTeam(summary.Player1.Team)
-	summary.Player1.TeamArchetype = summary.Player1.Classification.Archetype
-	summary.Player2.Classification = ClassifyTeam(summary.Player2.Team)
-	summary.Player2.TeamArchetype = summary.Player2.Classification.Archetype
-
-	return summary, nil
+func ParseShowdownLog(logContent string, opts ...ParseOption) (*BattleSummary, error) {
+	return ParseShowdownLogReader(strings.NewReader(logContent), opts...)
 }
 
 // StateTracker maintains the game state throughout the battle
@@ -228,11 +19,22 @@ type StateTracker struct {
 	playerNames        map[string]string
 	teamSizes          map[string]int
 	teams              map[string][]Pokémon
-	activePokemon      map[string]*Pokémon // Current active mon for each player
-	activePokemonIndex map[string]int
+	activePokemon      map[string]*Pokémon       // Current active mon for each slot ("p1a", "p1b", ...)
+	activePokemonIndex map[string]int            // Team index of the active mon for each slot
 	losses             map[string]int            // Fainted pokemon count
-	fieldEffects       map[string][]string       // Side effects like Tailwind
-	statBoosts         map[string]map[string]int // Player->stat->boost level
+	field              *fieldTimeline            // Weather, terrain, rooms and side conditions
+	volatiles          *volatileTimeline         // Conditions on individual active Pokémon
+	statBoosts         map[string]map[string]int // Slot->stat->stage for the Pokémon in that slot
+	leads              map[string][]string       // Pokémon sent out before turn 1
+	brought            map[string][]string       // Pokémon that have appeared, in order
+	teraUsed           map[string]*Terastallization
+	hpHistory          map[string][]HPChange // Slot->HP changes, in order; Ally Switch takes them along
+	itemTimeline       []ItemChange
+	abilities          []AbilityActivation
+	announcedAbility   int // Index in abilities of an |-ability| announcement still collecting effects, or -1
+	evaluator          PositionEvaluator
+	turnNumber         int
+	afterUpkeep        bool // End-of-turn effects for turnNumber have resolved
 }
 
 func NewStateTracker() *StateTracker {
@@ -243,11 +45,26 @@ func NewStateTracker() *StateTracker {
 		activePokemon:      make(map[string]*Pokémon),
 		activePokemonIndex: make(map[string]int),
 		losses:             make(map[string]int),
-		fieldEffects:       make(map[string][]string),
+		field:              newFieldTimeline(),
+		volatiles:          newVolatileTimeline(),
 		statBoosts:         make(map[string]map[string]int),
+		leads:              make(map[string][]string),
+		brought:            make(map[string][]string),
+		teraUsed:           make(map[string]*Terastallization),
+		hpHistory:          make(map[string][]HPChange),
+		itemTimeline:       []ItemChange{},
+		abilities:          []AbilityActivation{},
+		announcedAbility:   -1,
+		evaluator:          DefaultPositionEvaluator,
 	}
 }
 
+// SetTurn records the current turn number; switches before turn 1 are leads.
+func (st *StateTracker) SetTurn(turnNumber int) {
+	st.turnNumber = turnNumber
+	st.afterUpkeep = false
+}
+
 func (st *StateTracker) SetPlayerName(playerID, name string) {
 	st.playerNames[playerID] = name
 }
@@ -260,6 +77,12 @@ func (st *StateTracker) AddPokemonToTeam(playerID string, poke Pokémon) {
 	st.teams[playerID] = append(st.teams[playerID], poke)
 }
 
+// ApplyTeamSheet merges items, abilities, moves and Tera types from an open
+// team sheet into the player's team.
+func (st *StateTracker) ApplyTeamSheet(playerID string, sheet []Pokémon) {
+	st.teams[playerID] = MergeTeamSheet(st.teams[playerID], sheet)
+}
+
 func (st *StateTracker) GetTeam(playerID string) []Pokémon {
 	return st.teams[playerID]
 }
@@ -271,71 +94,151 @@ func (st *StateTracker) GetTeamSize(playerID string) int {
 	return len(st.teams[playerID])
 }
 
-func (st *StateTracker) SwitchPokemon(playerID, pokeName string, hp int) {
+// GetActive returns the Pokémon in each of a side's active slots, indexed by
+// position (0 for slot a, 1 for slot b). Empty slots are nil.
+func (st *StateTracker) GetActive(playerID string) []*Pokémon {
+	active := make([]*Pokémon, len(activeSlotLetters))
+	for i, letter := range activeSlotLetters {
+		active[i] = st.activePokemon[playerID+letter]
+	}
+	return active
+}
+
+// SwitchPokemon places pokeName into the given slot. A bare side such as "p1"
+// is treated as that side's first slot.
+func (st *StateTracker) SwitchPokemon(slot, pokeName string, hp int) {
+	slot = normalizeSlot(slot)
+	playerID := slotSide(slot)
 	team := st.teams[playerID]
+	st.volatiles.endSlot(slot, st.turnNumber)
+	delete(st.statBoosts, slot)
+	index := -1
 	for i, poke := range team {
 		if poke.Name == pokeName {
-			st.activePokemon[playerID] = &team[i]
-			st.activePokemonIndex[playerID] = i
-			team[i].CurrentHP = hp
-			if team[i].MaxHP == 0 {
-				team[i].MaxHP = 100 // Default to 100 for now
-			}
+			index = i
 			break
 		}
 	}
-}
-
-func (st *StateTracker) UpdatePokemonHP(playerID string, currentHP, maxHP int) {
-	if poke, ok := st.activePokemon[playerID]; ok {
-		poke.CurrentHP = currentHP
-		if poke.MaxHP == 0 {
-			poke.MaxHP = maxHP
+	if index < 0 {
+		// Team preview hid the forme, e.g. "Urshifu-*", and the switch reveals it
+		for i, poke := range team {
+			if strings.HasSuffix(poke.Name, "-*") && broughtAs([]string{pokeName}, poke.Name) {
+				team[i].ID = normalizeID(pokeName)
+				team[i].Name = pokeName
+				team[i] = withDexData(team[i])
+				index = i
+				break
+			}
+		}
+	}
+	if index >= 0 {
+		st.activePokemon[slot] = &team[index]
+		st.activePokemonIndex[slot] = index
+		team[index].CurrentHP = hp
+		if team[index].MaxHP == 0 {
+			team[index].MaxHP = 100 // Default to 100 for now
 		}
 	}
+
+	if st.turnNumber == 0 && !contains(st.leads[playerID], pokeName) {
+		st.leads[playerID] = append(st.leads[playerID], pokeName)
+	}
+	if !contains(st.brought[playerID], pokeName) {
+		st.brought[playerID] = append(st.brought[playerID], pokeName)
+	}
 }
 
-func (st *StateTracker) FaintPokemon(playerID string) {
-	if poke, ok := st.activePokemon[playerID]; ok {
-		poke.CurrentHP = 0
+// SwapPokemon moves the Pokémon in slot to the active position given, as
+// Ally Switch does, and the Pokémon there to slot. Everything tracked by
+// slot goes with them.
+func (st *StateTracker) SwapPokemon(slot string, position int) {
+	from := normalizeSlot(slot)
+	to, ok := swapTarget(from, position)
+	if !ok {
+		return
 	}
-	st.losses[playerID]++
+	swapSlots(st.activePokemon, from, to)
+	swapSlots(st.activePokemonIndex, from, to)
+	swapSlots(st.statBoosts, from, to)
+	swapSlots(st.hpHistory, from, to)
+	st.volatiles.swapSlots(from, to)
 }
 
-func (st *StateTracker) UpdatePokemonStatus(playerID, status string) {
-	if poke, ok := st.activePokemon[playerID]; ok {
-		poke.Status = status
+// swapTarget returns the slot a |swap| moves the Pokémon in slot to, and
+// false if it stays put.
+func swapTarget(slot string, position int) (string, bool) {
+	if position < 0 || position >= len(activeSlotLetters) {
+		return "", false
 	}
+	to := slotSide(slot) + activeSlotLetters[position]
+	return to, to != slot
 }
 
-func (st *StateTracker) TerastallizePokemon(playerID, teraType string) {
-	if poke, ok := st.activePokemon[playerID]; ok {
-		poke.TeraType = teraType
+// swapSlots exchanges the entries for slots a and b, leaving a slot without
+// an entry if the other had none.
+func swapSlots[V any](m map[string]V, a, b string) {
+	va, okA := m[a]
+	vb, okB := m[b]
+	delete(m, a)
+	delete(m, b)
+	if okA {
+		m[b] = va
+	}
+	if okB {
+		m[a] = vb
 	}
 }
 
-func (st *StateTracker) RecordFieldEffect(parts []string) {
-	if len(parts) < 4 {
-		return
+func (st *StateTracker) GetLeads(playerID string) []string {
+	return st.leads[playerID]
+}
+
+func (st *StateTracker) GetBrought(playerID string) []string {
+	return st.brought[playerID]
+}
+
+// GetTerastallization returns the side's use of Tera, or nil if it hasn't terastallized.
+func (st *StateTracker) GetTerastallization(playerID string) *Terastallization {
+	return st.teraUsed[playerID]
+}
+
+// UpdatePokemonHP sets the HP of the Pokémon in slot. A maxHP of 0 leaves
+// the known max HP unchanged.
+func (st *StateTracker) UpdatePokemonHP(slot string, currentHP, maxHP int) {
+	if poke, ok := st.activePokemon[normalizeSlot(slot)]; ok {
+		poke.CurrentHP = currentHP
+		if maxHP > 0 {
+			poke.MaxHP = maxHP
+		}
 	}
-	playerID := extractRawPlayerID(parts[2])
-	effect := parts[3]
-	if !contains(st.fieldEffects[playerID], effect) {
-		st.fieldEffects[playerID] = append(st.fieldEffects[playerID], effect)
+}
+
+func (st *StateTracker) FaintPokemon(slot string) {
+	slot = normalizeSlot(slot)
+	if poke, ok := st.activePokemon[slot]; ok {
+		poke.CurrentHP = 0
 	}
+	st.volatiles.endSlot(slot, st.turnNumber)
+	delete(st.statBoosts, slot)
+	st.losses[slotSide(slot)]++
 }
 
-func (st *StateTracker) RecordStatChange(parts []string) {
-	if len(parts) < 4 {
-		return
+func (st *StateTracker) UpdatePokemonStatus(slot, status string) {
+	if poke, ok := st.activePokemon[normalizeSlot(slot)]; ok {
+		poke.Status = status
 	}
-	playerID := extractRawPlayerID(parts[2])
-	stat := parts[3]
-	if _, ok := st.statBoosts[playerID]; !ok {
-		st.statBoosts[playerID] = make(map[string]int)
+}
+
+func (st *StateTracker) TerastallizePokemon(slot, teraType string) {
+	slot = normalizeSlot(slot)
+	if poke, ok := st.activePokemon[slot]; ok {
+		poke.TeraType = teraType
+		st.teraUsed[slotSide(slot)] = &Terastallization{
+			Pokemon:    poke.Name,
+			TeraType:   teraType,
+			TurnNumber: st.turnNumber,
+		}
 	}
-	boost := parseInt(parts[4])
-	st.statBoosts[playerID][stat] = boost
 }
 
 func (st *StateTracker) PlayerToID(playerName string) string {
@@ -350,144 +253,75 @@ func (st *StateTracker) PlayerToID(playerName string) string {
 	return "player2"
 }
 
+// CalculatePositionScore scores the current position with the tracker's
+// PositionEvaluator.
 func (st *StateTracker) CalculatePositionScore() *PositionScore {
-	score := &PositionScore{}
-
-	// Calculate Player 1 score
-	p1Poke := st.activePokemon["p1"]
-	p1HP := 0.0
-	if p1Poke != nil && p1Poke.MaxHP > 0 {
-		p1HP = float64(p1Poke.CurrentHP) / float64(p1Poke.MaxHP) * 100
-	}
-	p1Team := 0.0
-	if st.teamSizes["p1"] > 0 {
-		p1Team = float64((st.teamSizes["p1"] - st.losses["p1"]) * 100 / st.teamSizes["p1"])
-	}
-	score.Player1Score = (p1HP * 0.6) + (p1Team * 0.4)
-
-	// Calculate Player 2 score
-	p2Poke := st.activePokemon["p2"]
-	p2HP := 0.0
-	if p2Poke != nil && p2Poke.MaxHP > 0 {
-		p2HP = float64(p2Poke.CurrentHP) / float64(p2Poke.MaxHP) * 100
-	}
-	p2Team := 0.0
-	if st.teamSizes["p2"] > 0 {
-		p2Team = float64((st.teamSizes["p2"] - st.losses["p2"]) * 100 / st.teamSizes["p2"])
-	}
-	score.Player2Score = (p2HP * 0.6) + (p2Team * 0.4)
-
-	// Determine momentum
-	if score.Player1Score > score.Player2Score+5 {
-		score.MomentumPlayer = "player1"
-	} else if score.Player2Score > score.Player1Score+5 {
-		score.MomentumPlayer = "player2"
-	} else {
-		score.MomentumPlayer = "neutral"
-	}
+	return st.evaluator.Evaluate(st)
+}
 
-	return score
+// SetPositionEvaluator changes the model CalculatePositionScore uses.
+func (st *StateTracker) SetPositionEvaluator(evaluator PositionEvaluator) {
+	st.evaluator = evaluator
 }
 
 // Helper parsing functions
 
-func parsePokemonFromTeamPreview(pokeStr string) Pokémon {
-	// Format: "Ursaluna-Bloodmoon, L50, M"
-	parts := strings.Split(pokeStr, ",")
-	name := strings.TrimSpace(parts[0])
-
-	poke := Pokémon{
-		ID:        normalizeID(name),
-		Name:      name,
+func pokemonFromDetails(details protocol.Details) Pokémon {
+	// From "Ursaluna-Bloodmoon, L50, M"
+	return withDexData(Pokémon{
+		ID:        normalizeID(details.Species),
+		Name:      details.Species,
+		Level:     details.Level,
+		Gender:    details.Gender,
+		Shiny:     details.Shiny,
+		TeraType:  details.TeraType,
 		MaxHP:     100, // Default max HP for level 50
 		CurrentHP: 100,
-	}
-
-	if len(parts) > 1 {
-		levelStr := strings.TrimSpace(parts[1])
-		poke.Level = parseInt(strings.TrimPrefix(levelStr, "L"))
-	}
-
-	if len(parts) > 2 {
-		genderStr := strings.TrimSpace(parts[2])
-		poke.Gender = genderStr
-	}
-
-	return poke
-}
-
-func parseMove(parts []string) Action {
-	// |move|p1a: Whimsicott|Tailwind|p1a: Whimsicott
-	playerID := extractPlayerIDFromRef(parts[2])
-	moveName := strings.TrimSpace(parts[3])
-
-	return Action{
-		Player:     playerID,
-		ActionType: "move",
-		Move: &Move{
-			ID:   normalizeID(moveName),
-			Name: moveName,
-		},
-	}
-}
-
-func parseSwitch(parts []string) Action {
-	// |switch|p1b: Typhlosion|Typhlosion-Hisui, L50, M|100\/100
-	playerID := extractPlayerIDFromRef(parts[2])
-	switchToPoke := extractPokemonName(parts[3])
-
-	return Action{
-		Player:     playerID,
-		ActionType: "switch",
-		SwitchTo:   switchToPoke,
-	}
+	})
 }
 
-func extractPlayerIDFromRef(ref string) string {
-	// Convert "p1a: Whimsicott" to "player1" or "p2b: Maushold" to "player2"
-	if strings.HasPrefix(ref, "p1") {
+// sideToPlayer converts "p1" to "player1" and "p2" to "player2".
+func sideToPlayer(side string) string {
+	if side == "p1" {
 		return "player1"
 	}
 	return "player2"
 }
 
-func extractRawPlayerID(ref string) string {
-	// Convert "p1a: Whimsicott" to "p1" or "p2b: Maushold" to "p2"
-	if strings.HasPrefix(ref, "p1") {
-		return "p1"
+// opponentOf returns the other player: "player2" for "player1" and vice versa.
+func opponentOf(player string) string {
+	if player == "player1" {
+		return "player2"
 	}
-	return "p2"
+	return "player1"
 }
 
-func extractPokemonName(ref string) string {
-	// From "Typhlosion-Hisui, L50, M" extract "Typhlosion-Hisui"
-	parts := strings.Split(ref, ",")
-	return strings.TrimSpace(parts[0])
+// refSlot is the tracker slot for a reference: "p1a" for "p1a: Whimsicott",
+// or the bare side for side references.
+func refSlot(ref protocol.PokemonRef) string {
+	if ref.Slot != "" {
+		return ref.Slot
+	}
+	return ref.Side
 }
 
-func extractHPFromSwitch(parts []string) int {
-	// From "100\/100" extract 100
-	if len(parts) > 4 {
-		hpStr := parts[4]
-		hpParts := strings.Split(hpStr, "\\/")
-		return parseInt(hpParts[0])
+// activeSlotLetters are the active positions on each side in doubles.
+var activeSlotLetters = []string{"a", "b"}
+
+func normalizeSlot(slot string) string {
+	// A bare side ("p1") refers to its first slot, as in singles
+	if len(slot) == 2 {
+		return slot + activeSlotLetters[0]
 	}
-	return 100
+	return slot
 }
 
-func parseHP(hpStr string) (int, int) {
-	// "63\/100" -> (63, 100) or "0 fnt" -> (0, maxHP)
-	if strings.Contains(hpStr, "\\/") {
-		parts := strings.Split(hpStr, "\\/")
-		current := parseInt(parts[0])
-		max := parseInt(parts[1])
-		return current, max
+func slotSide(slot string) string {
+	// "p1a" -> "p1"
+	if len(slot) > 2 {
+		return slot[:2]
 	}
-	// Handle "0 fnt" format
-	if strings.Contains(hpStr, "fnt") {
-		return 0, 100
-	}
-	return parseInt(hpStr), 100
+	return slot
 }
 
 func normalizeID(name string) string {
@@ -495,9 +329,7 @@ func normalizeID(name string) string {
 }
 
 func parseInt(s string) int {
-	var result int
-	_, _ = fmt.Sscanf(strings.TrimSpace(s), "%d", &result)
-	return result
+	return protocol.Atoi(s)
 }
 
 func addKeyMoment(summary *BattleSummary, turnNumber int, mType, description string, significance int) {
@@ -509,6 +341,11 @@ func addKeyMoment(summary *BattleSummary, turnNumber int, mType, description str
 	})
 }
 
+// turningPointSwing is how many percentage points a player's chance of
+// winning has to move in one turn to make it a turning point.
+const turningPointSwing = 15
+
+// detectTurningPoints finds turns that swung the win probability.
 func detectTurningPoints(summary *BattleSummary) {
 	if len(summary.Turns) < 2 {
 		return
@@ -520,42 +357,42 @@ func detectTurningPoints(summary *BattleSummary) {
 		prev := summary.Turns[i-1]
 		curr := summary.Turns[i]
 
-		if prev.PositionScore == nil || curr.PositionScore == nil {
+		if prev.WinProbability == nil || curr.WinProbability == nil {
 			continue
 		}
 
-		// Calculate score change
-		p1Delta := curr.PositionScore.Player1Score - prev.PositionScore.Player1Score
-		p2Delta := curr.PositionScore.Player2Score - prev.PositionScore.Player2Score
-		momentumShift := p1Delta - p2Delta
+		// Swing in player 1's chances, in percentage points
+		momentumShift := (curr.WinProbability.Player1 - prev.WinProbability.Player1) * 100
 
-		// Significant momentum shifts are 15+ points
-		if absFloat(momentumShift) >= 15 {
-			significance := int(absFloat(momentumShift) / 10)
+		if absFloat(momentumShift) >= turningPointSwing {
+			significance := int(absFloat(momentumShift) / 5)
 			if significance > 10 {
 				significance = 10
 			}
 
-			direction := "Player 1"
+			direction, before, after := "Player 1", prev.WinProbability.Player1, curr.WinProbability.Player1
 			if momentumShift < 0 {
-				direction = "Player 2"
+				direction, before, after = "Player 2", prev.WinProbability.Player2, curr.WinProbability.Player2
 			}
 
 			tp := TurningPoint{
 				TurnNumber:    curr.TurnNumber,
-				Score1Before:  prev.PositionScore.Player1Score,
-				Score1After:   curr.PositionScore.Player1Score,
-				Score2Before:  prev.PositionScore.Player2Score,
-				Score2After:   curr.PositionScore.Player2Score,
+				WinProbBefore: prev.WinProbability.Player1,
+				WinProbAfter:  curr.WinProbability.Player1,
 				MomentumShift: momentumShift,
 				Significance:  significance,
-				Description:   fmt.Sprintf("%s gained significant momentum this turn", direction),
+				Description: fmt.Sprintf("%s's chance of winning went from %.0f%% to %.0f%%",
+					direction, before*100, after*100),
+			}
+			if prev.PositionScore != nil && curr.PositionScore != nil {
+				tp.Score1Before, tp.Score1After = prev.PositionScore.Player1Score, curr.PositionScore.Player1Score
+				tp.Score2Before, tp.Score2After = prev.PositionScore.Player2Score, curr.PositionScore.Player2Score
 			}
 
 			turningPoints = append(turningPoints, tp)
 
 			// Add to key moments as well
-			addKeyMoment(summary, curr.TurnNumber, "turning_point",
+			addKeyMoment(summary, curr.TurnNumber, MomentTurningPoint,
 				fmt.Sprintf("Turn %d: %s", curr.TurnNumber, tp.Description), significance)
 		}
 	}
@@ -575,6 +412,8 @@ func calculateStats(summary *BattleSummary) {
 
 	totalDamageDealt1 := 0
 	totalDamageDealt2 := 0
+	totalDamageTaken1 := 0
+	totalDamageTaken2 := 0
 	totalHealing1 := 0
 	totalHealing2 := 0
 
@@ -583,10 +422,13 @@ func calculateStats(summary *BattleSummary) {
 			if action.ActionType == "move" && action.Move != nil {
 				summary.Stats.MoveFrequency[action.Move.ID]++
 
+				playerStats := &summary.Stats.Player2Stats
 				if action.Player == "player1" {
-					summary.Stats.Player1Stats.MoveCount++
-				} else {
-					summary.Stats.Player2Stats.MoveCount++
+					playerStats = &summary.Stats.Player1Stats
+				}
+				playerStats.MoveCount++
+				if action.Move.Type != "" {
+					playerStats.MovesByType[action.Move.Type]++
 				}
 			} else if action.ActionType == "switch" {
 				summary.Stats.Switch++
@@ -606,6 +448,13 @@ func calculateStats(summary *BattleSummary) {
 				totalDamageDealt2 += damage
 			}
 		}
+		for player, damage := range turn.DamageTaken {
+			if player == "player1" {
+				totalDamageTaken1 += damage
+			} else {
+				totalDamageTaken2 += damage
+			}
+		}
 		for player, healing := range turn.HealingDone {
 			if player == "player1" {
 				totalHealing1 += healing
@@ -615,13 +464,33 @@ func calculateStats(summary *BattleSummary) {
 		}
 	}
 
+	// Damage taken includes recoil and other self-inflicted damage the
+	// opponent isn't credited with; healing is credited to the side that
+	// received it
 	summary.Stats.Player1Stats.DamageDealt = totalDamageDealt1
 	summary.Stats.Player2Stats.DamageDealt = totalDamageDealt2
+	summary.Stats.Player1Stats.DamageTaken = totalDamageTaken1
+	summary.Stats.Player2Stats.DamageTaken = totalDamageTaken2
 	summary.Stats.Player1Stats.HealingDone = totalHealing1
 	summary.Stats.Player2Stats.HealingDone = totalHealing2
+	summary.Stats.Player1Stats.HealingReceived = totalHealing1
+	summary.Stats.Player2Stats.HealingReceived = totalHealing2
+
+	// Speed control uptime
+	for _, condition := range summary.FieldTimeline {
+		uptime := fieldUptime(condition, summary.Stats.TotalTurns)
+		switch {
+		case condition.Name == "Trick Room":
+			summary.Stats.TrickRoomTurns += uptime
+		case condition.Name == "Tailwind" && condition.Side == "player1":
+			summary.Stats.Player1Stats.TailwindTurns += uptime
+		case condition.Name == "Tailwind" && condition.Side == "player2":
+			summary.Stats.Player2Stats.TailwindTurns += uptime
+		}
+	}
 
 	if summary.Stats.TotalTurns > 0 {
-		summary.Stats.AvgDamagePerTurn = float64(totalDamageDealt1+totalDamageDealt2) / float64(summary.Stats.TotalTurns)
+		summary.Stats.AvgDamagePerTurn = float64(totalDamageTaken1+totalDamageTaken2) / float64(summary.Stats.TotalTurns)
 		summary.Stats.AvgHealPerTurn = float64(totalHealing1+totalHealing2) / float64(summary.Stats.TotalTurns)
 	}
 }
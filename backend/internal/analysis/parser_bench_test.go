@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// stallBattleLog builds a long timer-stall style log with the given number of turns.
+func stallBattleLog(turns int) string {
+	var b strings.Builder
+	b.WriteString(`|t:|1700000000
+|gametype|doubles
+|player|p1|Alice|1|1500
+|player|p2|Bob|2|1500
+|teamsize|p1|4
+|teamsize|p2|4
+|gen|9
+|tier|[Gen 9] VGC 2025 Reg H
+|rated|
+|poke|p1|Amoonguss, L50, F|
+|poke|p1|Incineroar, L50, M|
+|poke|p1|Farigiraf, L50, F|
+|poke|p1|Dondozo, L50, M|
+|poke|p2|Amoonguss, L50, M|
+|poke|p2|Incineroar, L50, F|
+|poke|p2|Farigiraf, L50, M|
+|poke|p2|Dondozo, L50, F|
+|start
+|switch|p1a: Amoonguss|Amoonguss, L50, F|100/100
+|switch|p1b: Incineroar|Incineroar, L50, M|100/100
+|switch|p2a: Amoonguss|Amoonguss, L50, M|100/100
+|switch|p2b: Incineroar|Incineroar, L50, F|100/100
+`)
+	for i := 1; i <= turns; i++ {
+		hp := 100 - i%50
+		fmt.Fprintf(&b, "|t:|%d\n|turn|%d\n|inactive|Alice has 30 seconds left.\n|t:|%d\n", 1700000000+i*60, i, 1700000000+i*60+45)
+		fmt.Fprintf(&b, "|move|p1a: Amoonguss|Protect|p1a: Amoonguss\n|-singleturn|p1a: Amoonguss|Protect\n")
+		fmt.Fprintf(&b, "|move|p2b: Incineroar|Flare Blitz|p1b: Incineroar\n|-resisted|p1b: Incineroar\n|-damage|p1b: Incineroar|%d/100\n", hp)
+		fmt.Fprintf(&b, "|move|p2a: Amoonguss|Pollen Puff|p2b: Incineroar\n|-heal|p2b: Incineroar|%d/100\n", hp+5)
+		fmt.Fprintf(&b, "|move|p1b: Incineroar|Parting Shot|p2a: Amoonguss\n|-unboost|p2a: Amoonguss|atk|1\n|-unboost|p2a: Amoonguss|spa|1\n")
+		fmt.Fprintf(&b, "|\n|upkeep\n")
+	}
+	b.WriteString("|win|Alice\n")
+	return b.String()
+}
+
+func sampleReplayLog(b *testing.B) string {
+	b.Helper()
+	content, err := os.ReadFile(sampleReplayPath)
+	if err != nil {
+		b.Skipf("sample replay not available: %v", err)
+	}
+	replay, err := ExtractReplayHTML(string(content))
+	if err != nil {
+		b.Fatalf("failed to extract replay: %v", err)
+	}
+	return replay.Log
+}
+
+func BenchmarkParseShowdownLogSample(b *testing.B) {
+	log := sampleReplayLog(b)
+	b.SetBytes(int64(len(log)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseShowdownLog(log); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseShowdownLogStall(b *testing.B) {
+	log := stallBattleLog(1000)
+	b.SetBytes(int64(len(log)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseShowdownLog(log); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseShowdownLogReaderStall(b *testing.B) {
+	log := []byte(stallBattleLog(1000))
+	b.SetBytes(int64(len(log)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseShowdownLogReader(bytes.NewReader(log)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
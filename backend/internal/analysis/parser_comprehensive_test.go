@@ -116,11 +116,10 @@ func TestParseShowdownLogNegativeHP(t *testing.T) {
 
 	// HP should not be negative
 	for _, turn := range summary.Turns {
-		if turn.StateAfter.Player1Active != nil && turn.StateAfter.Player1Active.CurrentHP < 0 {
-			t.Error("expected HP to not be negative")
-		}
-		if turn.StateAfter.Player2Active != nil && turn.StateAfter.Player2Active.CurrentHP < 0 {
-			t.Error("expected HP to not be negative")
+		for _, poke := range append(turn.StateAfter.Player1Active, turn.StateAfter.Player2Active...) {
+			if poke != nil && poke.CurrentHP < 0 {
+				t.Error("expected HP to not be negative")
+			}
 		}
 	}
 }
@@ -146,9 +145,9 @@ func TestParseShowdownLogHPOver100Percent(t *testing.T) {
 
 	// HP should cap at max
 	for _, turn := range summary.Turns {
-		if turn.StateAfter.Player1Active != nil {
-			if turn.StateAfter.Player1Active.CurrentHP > turn.StateAfter.Player1Active.MaxHP {
-				t.Errorf("expected HP <= maxHP, got %d/%d", turn.StateAfter.Player1Active.CurrentHP, turn.StateAfter.Player1Active.MaxHP)
+		for _, poke := range turn.StateAfter.Player1Active {
+			if poke != nil && poke.CurrentHP > poke.MaxHP {
+				t.Errorf("expected HP <= maxHP, got %d/%d", poke.CurrentHP, poke.MaxHP)
 			}
 		}
 	}
@@ -404,7 +403,6 @@ func TestTurningPointsSignificance(t *testing.T) {
 // ===== Damage and Healing Tests =====
 
 func TestDamageTracking(t *testing.T) {
-	t.Skip("TODO: Implement damage tracking in Turn.DamageDealt")
 	log := sampleBattleLog()
 	summary, _ := ParseShowdownLog(log)
 
@@ -427,7 +425,6 @@ func TestDamageTracking(t *testing.T) {
 }
 
 func TestHealingTracking(t *testing.T) {
-	t.Skip("TODO: Implement healing tracking in Turn.HealingDone")
 	logWithHealing := `|j|☆Player1
 |j|☆Player2
 |player|p1|Player1|test|1500
@@ -474,7 +471,6 @@ func TestHealingTracking(t *testing.T) {
 // ===== Status Conditions Tests =====
 
 func TestStatusConditionParsing(t *testing.T) {
-	t.Skip("TODO: Implement status condition tracking in Turn.StateAfter")
 	logWithStatus := `|j|☆Player1
 |j|☆Player2
 |player|p1|Player1|test|1500
@@ -507,11 +503,10 @@ func TestStatusConditionParsing(t *testing.T) {
 	// Should track status conditions
 	hasStatus := false
 	for _, turn := range summary.Turns {
-		if turn.StateAfter.Player1Active != nil && turn.StateAfter.Player1Active.Status != "" {
-			hasStatus = true
-		}
-		if turn.StateAfter.Player2Active != nil && turn.StateAfter.Player2Active.Status != "" {
-			hasStatus = true
+		for _, poke := range append(turn.StateAfter.Player1Active, turn.StateAfter.Player2Active...) {
+			if poke != nil && poke.Status != "" {
+				hasStatus = true
+			}
 		}
 	}
 
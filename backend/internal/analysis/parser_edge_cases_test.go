@@ -1,6 +1,11 @@
 package analysis
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
 
 // Tests for uncovered functions
 
@@ -17,8 +22,8 @@ func TestUpdatePokemonStatus(t *testing.T) {
 	// Update status
 	tracker.UpdatePokemonStatus("p1", "par")
 
-	if tracker.activePokemon["p1"].Status != "par" {
-		t.Errorf("expected status 'par', got %q", tracker.activePokemon["p1"].Status)
+	if tracker.activePokemon["p1a"].Status != "par" {
+		t.Errorf("expected status 'par', got %q", tracker.activePokemon["p1a"].Status)
 	}
 
 	// Test with non-existent player
@@ -39,8 +44,8 @@ func TestTerastallizePokemon(t *testing.T) {
 	// Terastallize
 	tracker.TerastallizePokemon("p2", "Dragon")
 
-	if tracker.activePokemon["p2"].TeraType != "Dragon" {
-		t.Errorf("expected tera type 'Dragon', got %q", tracker.activePokemon["p2"].TeraType)
+	if tracker.activePokemon["p2a"].TeraType != "Dragon" {
+		t.Errorf("expected tera type 'Dragon', got %q", tracker.activePokemon["p2a"].TeraType)
 	}
 
 	// Test with non-existent player
@@ -50,54 +55,66 @@ func TestTerastallizePokemon(t *testing.T) {
 
 func TestRecordFieldEffect(t *testing.T) {
 	tracker := NewStateTracker()
+	setter := protocol.ParsePokemonRef("p1a: Whimsicott")
 
-	// Test with valid parts
-	parts := []string{"", "-sidestart", "p1a: Pikachu", "Tailwind"}
-	tracker.RecordFieldEffect(parts)
+	// Test with a valid event
+	event := protocol.Parse("|-sidestart|p1: Alice|move: Tailwind").(protocol.SideEvent)
+	tracker.RecordFieldEffect(event, setter)
 
-	if len(tracker.fieldEffects["p1"]) == 0 {
-		t.Error("expected field effect to be recorded")
+	side := tracker.GetFieldState().Player1Side
+	if len(side) == 0 {
+		t.Fatal("expected field effect to be recorded")
 	}
 
-	if tracker.fieldEffects["p1"][0] != "Tailwind" {
-		t.Errorf("expected 'Tailwind', got %q", tracker.fieldEffects["p1"][0])
+	if side[0].Name != "Tailwind" || side[0].Setter != "Whimsicott" {
+		t.Errorf("expected Tailwind set by Whimsicott, got %+v", side[0])
 	}
 
 	// Test duplicate effect (should not add twice)
-	tracker.RecordFieldEffect(parts)
-	if len(tracker.fieldEffects["p1"]) != 1 {
-		t.Errorf("expected 1 field effect, got %d", len(tracker.fieldEffects["p1"]))
+	tracker.RecordFieldEffect(event, setter)
+	if len(tracker.GetFieldState().Player1Side) != 1 {
+		t.Errorf("expected 1 field effect, got %d", len(tracker.GetFieldState().Player1Side))
 	}
 
-	// Test with insufficient parts
-	shortParts := []string{"", "-sidestart", "p1a: Pikachu"}
-	tracker.RecordFieldEffect(shortParts)
-	// Should not panic
+	// Test with a truncated line
+	short := protocol.Parse("|-sidestart|p1: Alice").(protocol.SideEvent)
+	tracker.RecordFieldEffect(short, setter)
+	if len(tracker.GetFieldState().Player1Side) != 1 {
+		t.Errorf("expected truncated line to be ignored, got %v", tracker.GetFieldState().Player1Side)
+	}
+
+	// Ending the effect removes it from the field but keeps it in the timeline
+	tracker.RecordFieldEffect(protocol.Parse("|-sideend|p1: Alice|move: Tailwind").(protocol.SideEvent), protocol.PokemonRef{})
+	if len(tracker.GetFieldState().Player1Side) != 0 {
+		t.Errorf("expected Tailwind to have ended, got %v", tracker.GetFieldState().Player1Side)
+	}
+	if len(tracker.GetFieldTimeline()) != 1 {
+		t.Errorf("expected Tailwind to stay in the timeline, got %v", tracker.GetFieldTimeline())
+	}
 }
 
 func TestRecordStatChange(t *testing.T) {
 	tracker := NewStateTracker()
 
-	// Test with valid parts
-	parts := []string{"", "-boost", "p1a: Pikachu", "atk", "2"}
-	tracker.RecordStatChange(parts)
+	// Test with a valid event
+	tracker.RecordStatChange(protocol.Parse("|-boost|p1a: Pikachu|atk|2").(protocol.BoostEvent))
 
-	if tracker.statBoosts["p1"]["atk"] != 2 {
-		t.Errorf("expected atk boost of 2, got %d", tracker.statBoosts["p1"]["atk"])
+	if tracker.statBoosts["p1a"]["atk"] != 2 {
+		t.Errorf("expected atk boost of 2, got %d", tracker.statBoosts["p1a"]["atk"])
 	}
 
-	// Test with another stat
-	parts2 := []string{"", "-unboost", "p2a: Charizard", "def", "-1"}
-	tracker.RecordStatChange(parts2)
+	// Test an unboost, which the protocol reports as a positive amount
+	tracker.RecordStatChange(protocol.Parse("|-unboost|p2a: Charizard|def|1").(protocol.BoostEvent))
 
-	if tracker.statBoosts["p2"]["def"] != -1 {
-		t.Errorf("expected def boost of -1, got %d", tracker.statBoosts["p2"]["def"])
+	if tracker.statBoosts["p2a"]["def"] != -1 {
+		t.Errorf("expected def boost of -1, got %d", tracker.statBoosts["p2a"]["def"])
 	}
 
-	// Test with insufficient parts
-	shortParts := []string{"", "-boost", "p1a: Pikachu"}
-	tracker.RecordStatChange(shortParts)
-	// Should not panic
+	// Test with a truncated line (previously read past the end of the parts)
+	tracker.RecordStatChange(protocol.Parse("|-boost|p1a: Pikachu").(protocol.BoostEvent))
+	if len(tracker.statBoosts["p1a"]) != 1 {
+		t.Errorf("expected truncated line to be ignored, got %v", tracker.statBoosts["p1a"])
+	}
 }
 
 func TestContains(t *testing.T) {
@@ -143,91 +160,6 @@ func TestContains(t *testing.T) {
 	}
 }
 
-func TestParseHPEdgeCases(t *testing.T) {
-	tests := []struct {
-		name        string
-		hpStr       string
-		expectedCur int
-		expectedMax int
-	}{
-		{
-			name:        "normal HP",
-			hpStr:       "50/100",
-			expectedCur: 50,
-			expectedMax: 100,
-		},
-		{
-			name:        "fainted",
-			hpStr:       "0 fnt",
-			expectedCur: 0,
-			expectedMax: 100,
-		},
-		{
-			name:        "full HP",
-			hpStr:       "100/100",
-			expectedCur: 100,
-			expectedMax: 100,
-		},
-		{
-			name:        "single number (no slash)",
-			hpStr:       "75",
-			expectedCur: 75,
-			expectedMax: 100,
-		},
-		{
-			name:        "zero HP",
-			hpStr:       "0/100",
-			expectedCur: 0,
-			expectedMax: 100,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			cur, max := parseHP(tt.hpStr)
-			if cur != tt.expectedCur {
-				t.Errorf("expected current HP %d, got %d", tt.expectedCur, cur)
-			}
-			if max != tt.expectedMax {
-				t.Errorf("expected max HP %d, got %d", tt.expectedMax, max)
-			}
-		})
-	}
-}
-
-func TestExtractHPFromSwitchEdgeCases(t *testing.T) {
-	tests := []struct {
-		name     string
-		parts    []string
-		expected int
-	}{
-		{
-			name:     "with HP",
-			parts:    []string{"", "switch", "p1a: Pikachu", "Pikachu, L50", "80/100"},
-			expected: 80,
-		},
-		{
-			name:     "without HP (too few parts)",
-			parts:    []string{"", "switch", "p1a: Pikachu", "Pikachu, L50"},
-			expected: 100,
-		},
-		{
-			name:     "full HP",
-			parts:    []string{"", "switch", "p1a: Pikachu", "Pikachu, L50", "100/100"},
-			expected: 100,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := extractHPFromSwitch(tt.parts)
-			if result != tt.expected {
-				t.Errorf("expected HP %d, got %d", tt.expected, result)
-			}
-		})
-	}
-}
-
 func TestSwitchPokemonEdgeCases(t *testing.T) {
 	tracker := NewStateTracker()
 
@@ -239,14 +171,14 @@ func TestSwitchPokemonEdgeCases(t *testing.T) {
 
 	// Switch to first pokemon
 	tracker.SwitchPokemon("p1", "Pikachu", 80)
-	if tracker.activePokemon["p1"].Name != "Pikachu" {
-		t.Errorf("expected Pikachu to be active, got %s", tracker.activePokemon["p1"].Name)
+	if tracker.activePokemon["p1a"].Name != "Pikachu" {
+		t.Errorf("expected Pikachu to be active, got %s", tracker.activePokemon["p1a"].Name)
 	}
 
 	// Switch to second pokemon
 	tracker.SwitchPokemon("p1", "Charizard", 90)
-	if tracker.activePokemon["p1"].Name != "Charizard" {
-		t.Errorf("expected Charizard to be active, got %s", tracker.activePokemon["p1"].Name)
+	if tracker.activePokemon["p1a"].Name != "Charizard" {
+		t.Errorf("expected Charizard to be active, got %s", tracker.activePokemon["p1a"].Name)
 	}
 
 	// Try to switch to non-existent pokemon (should not panic)
@@ -263,15 +195,15 @@ func TestUpdatePokemonHPEdgeCases(t *testing.T) {
 
 	// Update HP normally
 	tracker.UpdatePokemonHP("p1", 50, 100)
-	if tracker.activePokemon["p1"].CurrentHP != 50 {
-		t.Errorf("expected HP 50, got %d", tracker.activePokemon["p1"].CurrentHP)
+	if tracker.activePokemon["p1a"].CurrentHP != 50 {
+		t.Errorf("expected HP 50, got %d", tracker.activePokemon["p1a"].CurrentHP)
 	}
 
 	// Update HP when MaxHP is 0 (should set it)
-	tracker.activePokemon["p1"].MaxHP = 0
+	tracker.activePokemon["p1a"].MaxHP = 0
 	tracker.UpdatePokemonHP("p1", 80, 120)
-	if tracker.activePokemon["p1"].MaxHP != 120 {
-		t.Errorf("expected MaxHP 120, got %d", tracker.activePokemon["p1"].MaxHP)
+	if tracker.activePokemon["p1a"].MaxHP != 120 {
+		t.Errorf("expected MaxHP 120, got %d", tracker.activePokemon["p1a"].MaxHP)
 	}
 
 	// Update non-existent player (should not panic)
@@ -297,3 +229,165 @@ func TestGenerateUUIDFormat(t *testing.T) {
 		t.Errorf("UUID has incorrect format: %s", uuid1)
 	}
 }
+
+func TestDoublesSlotTracking(t *testing.T) {
+	tracker := NewStateTracker()
+	tracker.AddPokemonToTeam("p1", Pokémon{Name: "Whimsicott", CurrentHP: 100, MaxHP: 100})
+	tracker.AddPokemonToTeam("p1", Pokémon{Name: "Ursaluna-Bloodmoon", CurrentHP: 100, MaxHP: 100})
+
+	tracker.SwitchPokemon("p1a", "Whimsicott", 100)
+	tracker.SwitchPokemon("p1b", "Ursaluna-Bloodmoon", 100)
+
+	tracker.UpdatePokemonHP("p1a", 40, 100)
+	tracker.UpdatePokemonStatus("p1b", "brn")
+	tracker.TerastallizePokemon("p1b", "Normal")
+
+	active := tracker.GetActive("p1")
+	if len(active) != 2 || active[0] == nil || active[1] == nil {
+		t.Fatalf("expected two active Pokémon, got %v", active)
+	}
+
+	if active[0].Name != "Whimsicott" || active[0].CurrentHP != 40 {
+		t.Errorf("expected Whimsicott at 40 HP in slot a, got %s at %d", active[0].Name, active[0].CurrentHP)
+	}
+	if active[0].Status != "" || active[0].TeraType != "" {
+		t.Errorf("expected slot a to be unaffected by slot b events, got status %q tera %q", active[0].Status, active[0].TeraType)
+	}
+
+	if active[1].Name != "Ursaluna-Bloodmoon" || active[1].CurrentHP != 100 {
+		t.Errorf("expected Ursaluna-Bloodmoon at 100 HP in slot b, got %s at %d", active[1].Name, active[1].CurrentHP)
+	}
+	if active[1].Status != "brn" || active[1].TeraType != "Normal" {
+		t.Errorf("expected slot b burned and Tera Normal, got status %q tera %q", active[1].Status, active[1].TeraType)
+	}
+
+	tracker.FaintPokemon("p1a")
+	if active[0].CurrentHP != 0 {
+		t.Errorf("expected fainted slot a to have 0 HP, got %d", active[0].CurrentHP)
+	}
+	if tracker.losses["p1"] != 1 {
+		t.Errorf("expected 1 loss for p1, got %d", tracker.losses["p1"])
+	}
+}
+
+func TestParseShowdownLogDoublesSlots(t *testing.T) {
+	log := `|player|p1|Player1|test|1500
+|player|p2|Player2|test|1500
+|poke|p1|Whimsicott, L50, M|
+|poke|p1|Ursaluna-Bloodmoon, L50, M|
+|poke|p2|Maushold, L50|
+|poke|p2|Gholdengo, L50|
+|teamsize|p1|2
+|teamsize|p2|2
+|start
+|switch|p1a: Whimsicott|Whimsicott, L50, M|100/100
+|switch|p1b: Ursaluna|Ursaluna-Bloodmoon, L50, M|100/100
+|switch|p2a: Maushold|Maushold, L50|100/100
+|switch|p2b: Gholdengo|Gholdengo, L50|100/100
+|turn|1
+|move|p1b: Ursaluna|Hyper Voice|p2a: Maushold|[spread] p2a,p2b
+|-damage|p2a: Maushold|20/100
+|-damage|p2b: Gholdengo|70/100
+|-boost|p2b: Gholdengo|spa|2
+|upkeep
+|turn|2
+|win|Player1`
+
+	summary, _ := ParseShowdownLog(log, WithPositionEvaluator(SimpleEvaluator{}))
+	if summary == nil {
+		t.Fatal("expected summary")
+	}
+
+	score := summary.Turns[0].PositionScore
+	if score == nil {
+		t.Fatal("expected position score on first turn")
+	}
+
+	// Player 2's active average is (20 + 70) / 2 = 45, both mons still
+	// standing, and Gholdengo's +2 Special Attack is worth 2 points a stage
+	expected := 45*0.6 + 100*0.4 + 2*2
+	if score.Player2Score != expected {
+		t.Errorf("expected player2 score %.1f, got %.1f", expected, score.Player2Score)
+	}
+	if score.Player1Score != 100 {
+		t.Errorf("expected player1 score 100, got %.1f", score.Player1Score)
+	}
+}
+
+func TestParseShowdownLogAllySwitch(t *testing.T) {
+	log := `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Indeedee-F, L50, F|
+|poke|p1|Armarouge, L50, M|
+|poke|p2|Incineroar, L50, M|
+|poke|p2|Amoonguss, L50, F|
+|start
+|switch|p1a: Indeedee|Indeedee-F, L50, F|100/100
+|switch|p1b: Armarouge|Armarouge, L50, M|100/100
+|switch|p2a: Incineroar|Incineroar, L50, M|100/100
+|switch|p2b: Amoonguss|Amoonguss, L50, F|100/100
+|turn|1
+|move|p1a: Indeedee|Ally Switch|p1a: Indeedee
+|swap|p1a: Indeedee|1|[from] move: Ally Switch
+|move|p2b: Amoonguss|Rage Powder|p2b: Amoonguss
+|-singleturn|p2b: Amoonguss|move: Rage Powder
+|move|p1a: Armarouge|Calm Mind|p1a: Armarouge
+|-boost|p1a: Armarouge|spa|1
+|-boost|p1a: Armarouge|spd|1
+|move|p2a: Incineroar|Knock Off|p1a: Armarouge
+|-damage|p1a: Armarouge|60/100
+|-start|p1b: Indeedee|confusion
+|upkeep
+|turn|2
+|move|p1b: Indeedee|Ally Switch|p1b: Indeedee
+|swap|p1b: Indeedee|0|[from] move: Ally Switch
+|move|p2a: Incineroar|Flare Blitz|p1b: Armarouge
+|-resisted|p1b: Armarouge
+|-damage|p1b: Armarouge|50/100
+|upkeep
+|turn|3
+|win|Alice`
+
+	parser := newLogParser()
+	for _, line := range strings.Split(log, "\n") {
+		parser.ProcessLine(line)
+	}
+	tracker := parser.tracker
+
+	indeedee, armarouge := tracker.activePokemon["p1a"], tracker.activePokemon["p1b"]
+	if indeedee == nil || indeedee.Name != "Indeedee-F" || armarouge == nil || armarouge.Name != "Armarouge" {
+		t.Fatalf("expected Indeedee back in p1a and Armarouge in p1b, got %v and %v", indeedee, armarouge)
+	}
+	if indeedee.CurrentHP != 100 || armarouge.CurrentHP != 50 {
+		t.Errorf("expected the damage on Armarouge only, got Indeedee %d and Armarouge %d", indeedee.CurrentHP, armarouge.CurrentHP)
+	}
+	if tracker.statBoosts["p1b"]["spa"] != 1 || tracker.statBoosts["p1a"]["spa"] != 0 {
+		t.Errorf("expected Calm Mind's boosts to follow Armarouge, got %v", tracker.statBoosts)
+	}
+	if _, ok := tracker.volatiles.active["p1a|confusion"]; !ok {
+		t.Errorf("expected Indeedee's confusion to follow it to p1a, got %v", tracker.volatiles.active)
+	}
+
+	// Each hit was measured against Armarouge's own HP, and its history went with it
+	var drops []int
+	for _, change := range tracker.GetHPHistory("p1b") {
+		drops = append(drops, change.Before-change.After)
+	}
+	if len(drops) != 2 || drops[0] != 40 || drops[1] != 10 {
+		t.Errorf("expected HP drops of 40 and 10, got %v", drops)
+	}
+	if len(tracker.GetHPHistory("p1a")) != 0 {
+		t.Errorf("expected no HP changes for Indeedee, got %v", tracker.GetHPHistory("p1a"))
+	}
+
+	// Armarouge moving from Indeedee's old slot is a mover of its own
+	found := false
+	for _, comparison := range parser.Finish().Speed.Comparisons {
+		if comparison.TurnNumber == 1 && comparison.First.Move == "Calm Mind" && comparison.Second.Move == "Knock Off" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected Calm Mind to be compared with Knock Off")
+	}
+}
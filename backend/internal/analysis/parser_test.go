@@ -47,6 +47,46 @@ func TestParseShowdownLogPlayerNames(t *testing.T) {
 	}
 }
 
+func TestParseShowdownLogPlayerRatings(t *testing.T) {
+	summary, _ := ParseShowdownLog(sampleBattleLog())
+
+	if !summary.Rated {
+		t.Error("expected battle to be rated")
+	}
+
+	if summary.Player1.Rating != 1487 || summary.Player1.Avatar != "giovanni" {
+		t.Errorf("expected player1 rating 1487 and avatar giovanni, got %d %q", summary.Player1.Rating, summary.Player1.Avatar)
+	}
+
+	if summary.Player2.Rating != 1398 || summary.Player2.Avatar != "steven" {
+		t.Errorf("expected player2 rating 1398 and avatar steven, got %d %q", summary.Player2.Rating, summary.Player2.Avatar)
+	}
+}
+
+func TestParseShowdownLogUnratedPlayers(t *testing.T) {
+	log := `|player|p1|Alice|1|
+|player|p2|Bob||
+|poke|p1|Pikachu, L50|
+|poke|p2|Charizard, L50|
+|turn|1
+|win|Alice
+|player|p1|
+`
+	summary, _ := ParseShowdownLog(log)
+
+	if summary.Rated {
+		t.Error("expected battle without |rated| to be unrated")
+	}
+
+	if summary.Player1.Name != "Alice" || summary.Player1.Avatar != "1" || summary.Player1.Rating != 0 {
+		t.Errorf("expected Alice with avatar 1 and no rating, got %+v", summary.Player1)
+	}
+
+	if summary.Player2.Avatar != "" || summary.Player2.Rating != 0 {
+		t.Errorf("expected no avatar or rating for Bob, got %q %d", summary.Player2.Avatar, summary.Player2.Rating)
+	}
+}
+
 func TestParseShowdownLogFormat(t *testing.T) {
 	log := sampleBattleLog()
 	summary, _ := ParseShowdownLog(log)
@@ -404,7 +444,6 @@ and no proper structure`
 // Edge case tests for comprehensive coverage
 
 func TestParseShowdownLogDamageTracking(t *testing.T) {
-	t.Skip("TODO: Implement damage tracking in PlayerStats.DamageDealt")
 	log := sampleBattleLog()
 	summary, _ := ParseShowdownLog(log)
 
@@ -505,7 +544,6 @@ func TestParseShowdownLogConsecutiveSwitches(t *testing.T) {
 }
 
 func TestParseShowdownLogPartialDamage(t *testing.T) {
-	t.Skip("TODO: Implement damage tracking in PlayerStats.DamageTaken")
 	logPartialDamage := `|j|☆Player1
 |j|☆Player2
 |player|p1|Player1|test|1500
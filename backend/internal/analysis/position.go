@@ -0,0 +1,193 @@
+package analysis
+
+import "math"
+
+// PositionEvaluator scores both sides' positions from the battle state after
+// a turn. Scores are on a 0-100 scale, but each model has its own idea of
+// what a point is worth, so only compare scores from the same model.
+type PositionEvaluator interface {
+	// Name identifies the model in BattleSummary.PositionModel.
+	Name() string
+	Evaluate(st *StateTracker) *PositionScore
+}
+
+// DefaultPositionEvaluator is the model used unless the parser is given
+// another with WithPositionEvaluator.
+var DefaultPositionEvaluator PositionEvaluator = BoardEvaluator{}
+
+// PositionEvaluators lists the built-in models by name.
+var PositionEvaluators = map[string]PositionEvaluator{
+	SimpleEvaluator{}.Name(): SimpleEvaluator{},
+	BoardEvaluator{}.Name():  BoardEvaluator{},
+}
+
+// momentumMargin is how far ahead a side's score must be to have momentum.
+const momentumMargin = 5
+
+// newPositionScore pairs both sides' scores and works out who has momentum.
+func newPositionScore(player1, player2 float64) *PositionScore {
+	score := &PositionScore{
+		Player1Score: player1,
+		Player2Score: player2,
+	}
+
+	// Determine momentum
+	if score.Player1Score > score.Player2Score+momentumMargin {
+		score.MomentumPlayer = "player1"
+	} else if score.Player2Score > score.Player1Score+momentumMargin {
+		score.MomentumPlayer = "player2"
+	} else {
+		score.MomentumPlayer = "neutral"
+	}
+
+	return score
+}
+
+// SimpleEvaluator weighs the average HP of a side's active Pokémon against
+// how much of its team is still standing.
+type SimpleEvaluator struct{}
+
+func (SimpleEvaluator) Name() string { return "simple" }
+
+func (SimpleEvaluator) Evaluate(st *StateTracker) *PositionScore {
+	return newPositionScore(simpleSideScore(st, "p1"), simpleSideScore(st, "p2"))
+}
+
+// simpleSideScore is 60% active HP and 40% team remaining, nudged by the stat
+// stages on the side's active Pokémon.
+func simpleSideScore(st *StateTracker, playerID string) float64 {
+	activeHP := 0.0
+	activeCount := 0
+	for _, poke := range st.GetActive(playerID) {
+		if poke == nil || poke.MaxHP <= 0 {
+			continue
+		}
+		activeHP += float64(poke.CurrentHP) / float64(poke.MaxHP) * 100
+		activeCount++
+	}
+	if activeCount > 0 {
+		activeHP /= float64(activeCount)
+	}
+
+	team := 0.0
+	if st.teamSizes[playerID] > 0 {
+		team = float64((st.teamSizes[playerID] - st.losses[playerID]) * 100 / st.teamSizes[playerID])
+	}
+
+	score := (activeHP * 0.6) + (team * 0.4) + st.boostScore(playerID)
+	return math.Max(0, math.Min(100, score))
+}
+
+// Weights for BoardEvaluator. A full-health side with both slots filled and
+// Tera in hand scores 80 before speed control and boosts.
+const (
+	remainingHPWeight  = 60.0 // Share of the team's total HP left
+	boardWeight        = 15.0 // Share of active slots with a Pokémon standing in them
+	speedControlWeight = 5.0  // Tailwind on its side, or a Trick Room it set
+	unusedTeraWeight   = 5.0  // Tera still available
+	boostWeight        = 0.5  // Times boostScore, so ±5
+	maxStatusPenalty   = 10.0
+)
+
+// statusPenalties is what a non-volatile status on a Pokémon still standing
+// costs its side.
+var statusPenalties = map[string]float64{
+	"slp": 4,
+	"frz": 4,
+	"par": 3,
+	"brn": 3,
+	"tox": 3,
+	"psn": 2,
+}
+
+// BoardEvaluator looks at the whole side: HP left across every Pokémon
+// brought, how many are on the field, speed control, stat stages, status
+// and whether Tera has been spent.
+type BoardEvaluator struct{}
+
+func (BoardEvaluator) Name() string { return "board" }
+
+func (BoardEvaluator) Evaluate(st *StateTracker) *PositionScore {
+	return newPositionScore(boardSideScore(st, "p1"), boardSideScore(st, "p2"))
+}
+
+func boardSideScore(st *StateTracker, playerID string) float64 {
+	score := remainingHPWeight*remainingHP(st, playerID) +
+		boardWeight*boardPresence(st, playerID) +
+		speedControlWeight*speedControl(st, playerID) +
+		boostWeight*st.boostScore(playerID) -
+		statusPenalty(st, playerID)
+	if st.GetTerastallization(playerID) == nil {
+		score += unusedTeraWeight
+	}
+	return math.Max(0, math.Min(100, score))
+}
+
+// remainingHP is the fraction of the side's total HP left, counting Pokémon
+// it brought but hasn't sent out yet at full HP.
+func remainingHP(st *StateTracker, playerID string) float64 {
+	size := st.GetTeamSize(playerID)
+	if size == 0 {
+		return 0
+	}
+
+	left := 0.0
+	seen := 0
+	for _, poke := range st.teams[playerID] {
+		if !contains(st.brought[playerID], poke.Name) || poke.MaxHP <= 0 {
+			continue
+		}
+		left += float64(poke.CurrentHP) / float64(poke.MaxHP)
+		seen++
+	}
+	if unseen := size - seen; unseen > 0 {
+		left += float64(unseen)
+	}
+	return math.Min(1, left/float64(size))
+}
+
+// boardPresence is the fraction of the side's active slots holding a
+// Pokémon that hasn't fainted.
+func boardPresence(st *StateTracker, playerID string) float64 {
+	standing := 0
+	for _, poke := range st.GetActive(playerID) {
+		if poke != nil && poke.CurrentHP > 0 {
+			standing++
+		}
+	}
+	return float64(standing) / float64(len(activeSlotLetters))
+}
+
+// speedControl is 1 if the side has Tailwind up or set the Trick Room in
+// effect, 2 for both.
+func speedControl(st *StateTracker, playerID string) float64 {
+	field := st.GetFieldState()
+	side := field.Player1Side
+	if playerID == "p2" {
+		side = field.Player2Side
+	}
+
+	control := 0.0
+	for _, condition := range side {
+		if condition.Name == "Tailwind" {
+			control++
+		}
+	}
+	for _, room := range field.Rooms {
+		if room.Name == "Trick Room" && room.SetterPlayer == sideToPlayer(playerID) {
+			control++
+		}
+	}
+	return control
+}
+
+// statusPenalty adds up statusPenalties across the side's standing Pokémon.
+func statusPenalty(st *StateTracker, playerID string) float64 {
+	penalty := 0.0
+	for _, poke := range st.teams[playerID] {
+		if poke.CurrentHP > 0 {
+			penalty += statusPenalties[poke.Status]
+		}
+	}
+	return math.Min(maxStatusPenalty, penalty)
+}
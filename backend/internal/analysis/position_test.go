@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+func TestParseShowdownLogPositionModel(t *testing.T) {
+	summary, _ := ParseShowdownLog(boardBattleLog)
+	if summary.PositionModel != "board" {
+		t.Errorf("expected the board model by default, got %q", summary.PositionModel)
+	}
+
+	summary, _ = ParseShowdownLog(boardBattleLog, WithPositionEvaluator(SimpleEvaluator{}))
+	if summary.PositionModel != "simple" {
+		t.Errorf("expected the simple model, got %q", summary.PositionModel)
+	}
+}
+
+func TestBoardEvaluator(t *testing.T) {
+	newTracker := func() *StateTracker {
+		tracker := NewStateTracker()
+		for _, side := range []string{"p1", "p2"} {
+			tracker.SetTeamSize(side, 4)
+			for _, name := range []string{"Incineroar", "Rillaboom", "Urshifu", "Amoonguss"} {
+				tracker.AddPokemonToTeam(side, Pokémon{Name: name, MaxHP: 100})
+			}
+			tracker.SwitchPokemon(side+"a", "Incineroar", 100)
+			tracker.SwitchPokemon(side+"b", "Rillaboom", 100)
+		}
+		return tracker
+	}
+
+	tests := []struct {
+		name     string
+		setup    func(*StateTracker)
+		player1  float64
+		player2  float64
+		momentum string
+	}{
+		{
+			name:     "even",
+			setup:    func(*StateTracker) {},
+			player1:  80,
+			player2:  80,
+			momentum: "neutral",
+		},
+		{
+			// Half of one of four Pokémon's HP is an eighth of the team's
+			name: "damage across the team",
+			setup: func(st *StateTracker) {
+				st.UpdatePokemonHP("p2a", 50, 0)
+			},
+			player1:  80,
+			player2:  72.5,
+			momentum: "player1",
+		},
+		{
+			name: "a faint empties a slot",
+			setup: func(st *StateTracker) {
+				st.UpdatePokemonHP("p2a", 0, 0)
+				st.FaintPokemon("p2a")
+			},
+			player1:  80,
+			player2:  57.5,
+			momentum: "player1",
+		},
+		{
+			name: "Tailwind",
+			setup: func(st *StateTracker) {
+				st.RecordFieldEffect(protocol.Parse("|-sidestart|p1: Alice|move: Tailwind").(protocol.SideEvent), protocol.PokemonRef{})
+			},
+			player1:  85,
+			player2:  80,
+			momentum: "neutral",
+		},
+		{
+			name: "spent Tera and a burn",
+			setup: func(st *StateTracker) {
+				st.TerastallizePokemon("p1a", "Grass")
+				st.UpdatePokemonStatus("p1b", "brn")
+			},
+			player1:  72,
+			player2:  80,
+			momentum: "player2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newTracker()
+			tt.setup(tracker)
+			score := BoardEvaluator{}.Evaluate(tracker)
+			if score.Player1Score != tt.player1 || score.Player2Score != tt.player2 || score.MomentumPlayer != tt.momentum {
+				t.Errorf("expected %.1f/%.1f (%s), got %+v", tt.player1, tt.player2, tt.momentum, score)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// ErrNoReplayLog is returned when a replay HTML file has no embedded battle log.
+var ErrNoReplayLog = errors.New("no battle log found in replay HTML")
+
+var (
+	replayLogPattern = regexp.MustCompile(`(?s)<script[^>]*class="battle-log-data"[^>]*>(.*?)</script>`)
+	replayIDPattern  = regexp.MustCompile(`<input[^>]*name="replayid"[^>]*value="([^"]*)"`)
+)
+
+// ReplayHTML holds the data embedded in a downloaded Showdown replay file.
+type ReplayHTML struct {
+	ReplayID string // e.g., "gen9vgc2025reghbo3-2481642254"
+	Log      string // Unescaped protocol log
+}
+
+// ExtractReplayHTML pulls the protocol log and replay ID out of a Showdown
+// replay HTML file. The log is stored inside a
+// <script class="battle-log-data"> block with "/" escaped as "\/" so that
+// closing tags in |html| lines can't end the script early.
+func ExtractReplayHTML(content string) (*ReplayHTML, error) {
+	match := replayLogPattern.FindStringSubmatch(content)
+	if match == nil {
+		return nil, ErrNoReplayLog
+	}
+
+	log := strings.TrimSpace(unescapeReplayLog(match[1]))
+	if log == "" {
+		return nil, ErrNoReplayLog
+	}
+
+	replay := &ReplayHTML{Log: log}
+	if idMatch := replayIDPattern.FindStringSubmatch(content); idMatch != nil {
+		replay.ReplayID = html.UnescapeString(idMatch[1])
+	}
+
+	return replay, nil
+}
+
+// ParseShowdownReplayHTML analyzes a downloaded Showdown replay HTML file.
+func ParseShowdownReplayHTML(content string, opts ...ParseOption) (*BattleSummary, error) {
+	replay, err := ExtractReplayHTML(content)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := ParseShowdownLog(replay.Log, opts...)
+	if err != nil {
+		return nil, err
+	}
+	summary.ReplayID = replay.ReplayID
+
+	return summary, nil
+}
+
+func unescapeReplayLog(log string) string {
+	return strings.ReplaceAll(log, `\/`, "/")
+}
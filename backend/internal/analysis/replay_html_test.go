@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+const sampleReplayPath = "../../../data/sample-gen9-vgc-2025-regh-bo3.html"
+
+func readSampleReplay(t *testing.T) string {
+	t.Helper()
+	content, err := os.ReadFile(sampleReplayPath)
+	if err != nil {
+		t.Skipf("sample replay not available: %v", err)
+	}
+	return string(content)
+}
+
+func TestExtractReplayHTML(t *testing.T) {
+	replay, err := ExtractReplayHTML(readSampleReplay(t))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if replay.ReplayID != "gen9vgc2025reghbo3-2481642254" {
+		t.Errorf("expected replay ID gen9vgc2025reghbo3-2481642254, got %q", replay.ReplayID)
+	}
+
+	if !strings.HasPrefix(replay.Log, "|j|") {
+		t.Errorf("expected log to start with |j|, got %q", replay.Log[:20])
+	}
+
+	if strings.Contains(replay.Log, `\/`) {
+		t.Error("expected escaped slashes to be unescaped")
+	}
+
+	if !strings.Contains(replay.Log, "|switch|p1a: Whimsicott|Whimsicott, L50, M|100/100") {
+		t.Error("expected unescaped HP in switch lines")
+	}
+}
+
+func TestExtractReplayHTMLMissingLog(t *testing.T) {
+	_, err := ExtractReplayHTML(`<!DOCTYPE html><title>not a replay</title>`)
+	if !errors.Is(err, ErrNoReplayLog) {
+		t.Errorf("expected ErrNoReplayLog, got %v", err)
+	}
+
+	_, err = ExtractReplayHTML(`<script type="text/plain" class="battle-log-data">   </script>`)
+	if !errors.Is(err, ErrNoReplayLog) {
+		t.Errorf("expected ErrNoReplayLog for empty log, got %v", err)
+	}
+}
+
+func TestParseShowdownReplayHTML(t *testing.T) {
+	summary, err := ParseShowdownReplayHTML(readSampleReplay(t))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if summary.ReplayID != "gen9vgc2025reghbo3-2481642254" {
+		t.Errorf("expected replay ID to be recorded, got %q", summary.ReplayID)
+	}
+
+	if summary.Player1.Name != "Player1" || summary.Player2.Name != "Player2" {
+		t.Errorf("expected players Player1/Player2, got %q/%q", summary.Player1.Name, summary.Player2.Name)
+	}
+
+	if summary.Winner != "player2" {
+		t.Errorf("expected player2 to win, got %q", summary.Winner)
+	}
+
+	if len(summary.Turns) != 6 {
+		t.Errorf("expected 6 turns, got %d", len(summary.Turns))
+	}
+}
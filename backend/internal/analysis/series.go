@@ -0,0 +1,165 @@
+package analysis
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// |uhtml|bestof|<h2><strong>Game 1</strong> of <a href="/game-bestof3-gen9vgc2025reghbo3-2481642253">a best-of-3</a></h2>
+	bestOfGamePattern   = regexp.MustCompile(`Game (\d+)`)
+	bestOfSeriesPattern = regexp.MustCompile(`href="/game-(bestof\d+-[a-z0-9-]+)"`)
+)
+
+// parseBestOfMarker extracts the series ID and game number from a |uhtml|bestof| line.
+func parseBestOfMarker(content string) (string, int) {
+	content = unescapeReplayLog(content)
+
+	var seriesID string
+	if match := bestOfSeriesPattern.FindStringSubmatch(content); match != nil {
+		seriesID = match[1]
+	}
+
+	var gameNumber int
+	if match := bestOfGamePattern.FindStringSubmatch(content); match != nil {
+		gameNumber = parseInt(match[1])
+	}
+
+	return seriesID, gameNumber
+}
+
+// AnalyzeSeries builds a series-level summary from the games of one series.
+// Games are ordered by GameNumber; the series winner is the first player to
+// win a majority of the best-of-N.
+func AnalyzeSeries(seriesID string, games []*BattleSummary) *SeriesSummary {
+	series := &SeriesSummary{
+		SeriesID:    seriesID,
+		Games:       []SeriesGame{},
+		Adjustments: []SeriesAdjustment{},
+	}
+	if len(games) == 0 {
+		return series
+	}
+
+	ordered := make([]*BattleSummary, len(games))
+	copy(ordered, games)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].GameNumber < ordered[j].GameNumber
+	})
+
+	series.Player1 = ordered[0].Player1.Name
+	series.Player2 = ordered[0].Player2.Name
+
+	for _, game := range ordered {
+		seriesGame := SeriesGame{
+			GameNumber: game.GameNumber,
+			BattleID:   game.ID,
+			ReplayID:   game.ReplayID,
+			Winner:     gameWinnerName(game),
+			TotalTurns: len(game.Turns),
+			Player1:    seriesPlayerGame(game, series.Player1),
+			Player2:    seriesPlayerGame(game, series.Player2),
+		}
+
+		switch seriesGame.Winner {
+		case series.Player1:
+			series.Player1Wins++
+		case series.Player2:
+			series.Player2Wins++
+		}
+
+		series.Games = append(series.Games, seriesGame)
+	}
+
+	winsNeeded := seriesLength(seriesID)/2 + 1
+	if series.Player1Wins >= winsNeeded {
+		series.Winner = series.Player1
+	} else if series.Player2Wins >= winsNeeded {
+		series.Winner = series.Player2
+	}
+
+	for i := 1; i < len(series.Games); i++ {
+		prev, curr := series.Games[i-1], series.Games[i]
+		series.Adjustments = append(series.Adjustments,
+			compareSeriesGames(prev.GameNumber, curr.GameNumber, prev.Player1, curr.Player1),
+			compareSeriesGames(prev.GameNumber, curr.GameNumber, prev.Player2, curr.Player2),
+		)
+	}
+
+	return series
+}
+
+// seriesLength reads N from a "bestofN-..." series ID, defaulting to 3.
+func seriesLength(seriesID string) int {
+	if strings.HasPrefix(seriesID, "bestof") {
+		if n := parseInt(strings.TrimPrefix(seriesID, "bestof")); n > 0 {
+			return n
+		}
+	}
+	return 3
+}
+
+func gameWinnerName(game *BattleSummary) string {
+	switch game.Winner {
+	case "player1":
+		return game.Player1.Name
+	case "player2":
+		return game.Player2.Name
+	}
+	return ""
+}
+
+// seriesPlayerGame finds the named player's side in a game.
+func seriesPlayerGame(game *BattleSummary, name string) SeriesPlayerGame {
+	player := game.Player1
+	if game.Player2.Name == name {
+		player = game.Player2
+	}
+	return SeriesPlayerGame{
+		Name:          name,
+		Archetype:     player.TeamArchetype,
+		Leads:         player.Leads,
+		Brought:       player.Brought,
+		Terastallized: player.Terastallized,
+	}
+}
+
+func compareSeriesGames(fromGame, toGame int, before, after SeriesPlayerGame) SeriesAdjustment {
+	return SeriesAdjustment{
+		Player:       after.Name,
+		FromGame:     fromGame,
+		ToGame:       toGame,
+		LeadsChanged: !sameMembers(before.Leads, after.Leads),
+		LeadsBefore:  before.Leads,
+		LeadsAfter:   after.Leads,
+		BroughtIn:    difference(after.Brought, before.Brought),
+		LeftBehind:   difference(before.Brought, after.Brought),
+		TeraChanged:  !sameTerastallization(before.Terastallized, after.Terastallized),
+		TeraBefore:   before.Terastallized,
+		TeraAfter:    after.Terastallized,
+	}
+}
+
+func sameTerastallization(a, b *Terastallization) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Pokemon == b.Pokemon && a.TeraType == b.TeraType
+}
+
+// sameMembers reports whether two lists hold the same names, ignoring order.
+func sameMembers(a, b []string) bool {
+	return len(difference(a, b)) == 0 && len(difference(b, a)) == 0
+}
+
+// difference returns the items of a that are not in b.
+func difference(a, b []string) []string {
+	result := []string{}
+	for _, item := range a {
+		if !contains(b, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package analysis
+
+import "testing"
+
+func TestParseBestOfMarker(t *testing.T) {
+	content := `<h2><strong>Game 2<\/strong> of <a href="\/game-bestof3-gen9vgc2025reghbo3-2481642253">a best-of-3<\/a><\/h2>`
+
+	seriesID, gameNumber := parseBestOfMarker(content)
+	if seriesID != "bestof3-gen9vgc2025reghbo3-2481642253" {
+		t.Errorf("expected series ID bestof3-gen9vgc2025reghbo3-2481642253, got %q", seriesID)
+	}
+	if gameNumber != 2 {
+		t.Errorf("expected game 2, got %d", gameNumber)
+	}
+}
+
+func TestParseShowdownReplayHTMLSeries(t *testing.T) {
+	summary, err := ParseShowdownReplayHTML(readSampleReplay(t))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if summary.SeriesID != "bestof3-gen9vgc2025reghbo3-2481642253" {
+		t.Errorf("expected series ID, got %q", summary.SeriesID)
+	}
+	if summary.GameNumber != 1 {
+		t.Errorf("expected game 1, got %d", summary.GameNumber)
+	}
+
+	if !sameMembers(summary.Player1.Leads, []string{"Whimsicott", "Ursaluna-Bloodmoon"}) {
+		t.Errorf("expected player1 leads Whimsicott + Ursaluna-Bloodmoon, got %v", summary.Player1.Leads)
+	}
+	if len(summary.Player1.Brought) != 4 {
+		t.Errorf("expected player1 to have brought 4, got %v", summary.Player1.Brought)
+	}
+
+	tera := summary.Player2.Terastallized
+	if tera == nil || tera.Pokemon != "Gholdengo" || tera.TeraType != "Water" || tera.TurnNumber != 2 {
+		t.Errorf("expected player2 to Tera Water Gholdengo on turn 2, got %+v", tera)
+	}
+}
+
+func TestAnalyzeSeries(t *testing.T) {
+	game1 := &BattleSummary{
+		ID:         "battle-1",
+		GameNumber: 1,
+		Winner:     "player2",
+		Player1: Player{
+			Name:          "Alice",
+			Leads:         []string{"Whimsicott", "Ursaluna-Bloodmoon"},
+			Brought:       []string{"Whimsicott", "Ursaluna-Bloodmoon", "Typhlosion-Hisui", "Dragapult"},
+			Terastallized: &Terastallization{Pokemon: "Dragapult", TeraType: "Dragon", TurnNumber: 5},
+		},
+		Player2: Player{
+			Name:          "Bob",
+			Leads:         []string{"Maushold", "Gholdengo"},
+			Brought:       []string{"Maushold", "Gholdengo", "Whimsicott", "Dragonite"},
+			Terastallized: &Terastallization{Pokemon: "Gholdengo", TeraType: "Water", TurnNumber: 2},
+		},
+	}
+	// Sides swap in game 2
+	game2 := &BattleSummary{
+		ID:         "battle-2",
+		GameNumber: 2,
+		Winner:     "player2",
+		Player1: Player{
+			Name:          "Bob",
+			Leads:         []string{"Gholdengo", "Maushold"},
+			Brought:       []string{"Gholdengo", "Maushold", "Whimsicott", "Dragonite"},
+			Terastallized: &Terastallization{Pokemon: "Gholdengo", TeraType: "Water", TurnNumber: 1},
+		},
+		Player2: Player{
+			Name:    "Alice",
+			Leads:   []string{"Farigiraf", "Incineroar"},
+			Brought: []string{"Farigiraf", "Incineroar", "Ursaluna-Bloodmoon", "Dragapult"},
+		},
+	}
+	game3 := &BattleSummary{
+		ID:         "battle-3",
+		GameNumber: 3,
+		Winner:     "player1",
+		Player1:    game1.Player1,
+		Player2:    game1.Player2,
+	}
+
+	// Pass games out of order to check sorting
+	series := AnalyzeSeries("bestof3-test", []*BattleSummary{game2, game3, game1})
+
+	if len(series.Games) != 3 || series.Games[0].GameNumber != 1 || series.Games[2].GameNumber != 3 {
+		t.Fatalf("expected games ordered 1-3, got %+v", series.Games)
+	}
+	if series.Player1 != "Alice" || series.Player2 != "Bob" {
+		t.Errorf("expected Alice vs Bob, got %q vs %q", series.Player1, series.Player2)
+	}
+	if series.Player1Wins != 2 || series.Player2Wins != 1 {
+		t.Errorf("expected 2-1 to Alice, got %d-%d", series.Player1Wins, series.Player2Wins)
+	}
+	if series.Winner != "Alice" {
+		t.Errorf("expected Alice to win the series, got %q", series.Winner)
+	}
+
+	if series.Games[1].Player1.Name != "Alice" || series.Games[1].Player1.Leads[0] != "Farigiraf" {
+		t.Errorf("expected Alice's game 2 choices to follow her across sides, got %+v", series.Games[1].Player1)
+	}
+
+	if len(series.Adjustments) != 4 {
+		t.Fatalf("expected 4 adjustments, got %d", len(series.Adjustments))
+	}
+
+	alice := series.Adjustments[0]
+	if alice.Player != "Alice" || !alice.LeadsChanged || !alice.TeraChanged {
+		t.Errorf("expected Alice to change leads and Tera, got %+v", alice)
+	}
+	if !sameMembers(alice.BroughtIn, []string{"Farigiraf", "Incineroar"}) {
+		t.Errorf("expected Farigiraf and Incineroar brought in, got %v", alice.BroughtIn)
+	}
+	if !sameMembers(alice.LeftBehind, []string{"Whimsicott", "Typhlosion-Hisui"}) {
+		t.Errorf("expected Whimsicott and Typhlosion-Hisui left behind, got %v", alice.LeftBehind)
+	}
+
+	bob := series.Adjustments[1]
+	if bob.Player != "Bob" || bob.LeadsChanged || bob.TeraChanged {
+		t.Errorf("expected Bob to keep the same leads and Tera, got %+v", bob)
+	}
+}
+
+func TestAnalyzeSeriesUndecided(t *testing.T) {
+	game1 := &BattleSummary{GameNumber: 1, Winner: "player1", Player1: Player{Name: "Alice"}, Player2: Player{Name: "Bob"}}
+
+	series := AnalyzeSeries("bestof3-test", []*BattleSummary{game1})
+	if series.Winner != "" {
+		t.Errorf("expected no series winner after one game, got %q", series.Winner)
+	}
+	if len(series.Adjustments) != 0 {
+		t.Errorf("expected no adjustments for a single game, got %d", len(series.Adjustments))
+	}
+}
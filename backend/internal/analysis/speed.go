@@ -0,0 +1,554 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/dtsong/vgccorner/backend/internal/calc"
+	"github.com/dtsong/vgccorner/backend/internal/dex"
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+// orderChangers are abilities and items that move their holder within, or
+// out of, its priority bracket, so its place in the order says nothing
+// about its Speed.
+var orderChangers = map[string]bool{
+	"Stall":        true,
+	"Lagging Tail": true,
+	"Full Incense": true,
+}
+
+// moveOrderChangers are abilities that only change the priority of some
+// moves: Prankster raises status moves, Gale Wings Flying moves (at full
+// HP) and Triage healing moves, while Mycelium Might sends status moves last.
+var moveOrderChangers = map[string]func(move *dex.Move) bool{
+	"Prankster":      func(move *dex.Move) bool { return move.Category == "Status" },
+	"Gale Wings":     func(move *dex.Move) bool { return move.Type == "Flying" },
+	"Triage":         func(move *dex.Move) bool { return move.HasFlag("heal") },
+	"Mycelium Might": func(move *dex.Move) bool { return move.Category == "Status" },
+}
+
+// orderActivations are the effects whose |-activate| means their Pokémon was
+// sent to the front of its bracket (Quick Claw, Quick Draw, Custap Berry) or
+// moved to its front or back by another's move (After You, Quash).
+var orderActivations = map[string]bool{
+	"Quick Claw":   true,
+	"Quick Draw":   true,
+	"Custap Berry": true,
+	"After You":    true,
+	"Quash":        true,
+}
+
+// speedAbilities scale their holder's Speed while their condition holds:
+// the weather abilities in their weather, Surge Surfer in Electric Terrain,
+// Unburden once its item is gone and Quick Feet with a status.
+var speedAbilities = map[string]float64{
+	"Chlorophyll":  2,
+	"Swift Swim":   2,
+	"Sand Rush":    2,
+	"Slush Rush":   2,
+	"Surge Surfer": 2,
+	"Unburden":     2,
+	"Quick Feet":   1.5,
+}
+
+// weatherSpeedAbilities are the weather each weather ability needs.
+var weatherSpeedAbilities = map[string]string{
+	"Chlorophyll": "Sun",
+	"Swift Swim":  "Rain",
+	"Sand Rush":   "Sand",
+	"Slush Rush":  "Snow",
+}
+
+// Multiplier returns how much the conditions scale the Pokémon's Speed.
+func (c SpeedConditions) Multiplier() float64 {
+	multiplier := 1.0
+	if c.Stage >= 0 {
+		multiplier = float64(2+c.Stage) / 2
+	} else {
+		multiplier = 2 / float64(2-c.Stage)
+	}
+	if c.Tailwind {
+		multiplier *= 2
+	}
+	if c.ChoiceScarf {
+		multiplier *= 1.5
+	}
+	if c.Boosted {
+		multiplier *= 1.5
+	}
+	if m, ok := speedAbilities[c.Ability]; ok {
+		multiplier *= m
+	}
+	if c.Paralyzed {
+		multiplier *= 0.5
+	}
+	if c.IronBall {
+		multiplier *= 0.5
+	}
+	return multiplier
+}
+
+// speedObserver works out which Pokémon outsped which from the order moves
+// resolve in. Since Gen 8 the order is recalculated after every action, so
+// each move is compared with every Pokémon still to move using their speed
+// modifiers at that moment.
+type speedObserver struct {
+	tracker     *StateTracker
+	turns       *TurnParser
+	movers      []speedMover    // This turn's moves so far, in order
+	reordered   map[string]bool // Slot -> moved out of speed order this turn
+	lostItem    map[string]bool // "p1: Species" -> its last known item was used up or taken
+	unburdened  map[string]bool // Slot -> lost its item since it came in
+	comparisons []SpeedComparison
+}
+
+// speedMover is a move that resolved this turn, with the speed conditions of
+// every active Pokémon when it did. A Pokémon that moves can't have switched
+// in this turn, so its slot identifies it for the rest of the turn, once
+// Ally Switch is followed.
+type speedMover struct {
+	slot       string
+	side       SpeedSide
+	priority   int
+	trickRoom  bool
+	outOfOrder bool                       // Its place in the order wasn't down to Speed
+	conditions map[string]SpeedConditions // Slot -> conditions
+	unknown    map[string]bool            // Slot -> an unrevealed ability might have changed its Speed
+}
+
+func newSpeedObserver(tracker *StateTracker, turns *TurnParser) *speedObserver {
+	return &speedObserver{
+		tracker:     tracker,
+		turns:       turns,
+		reordered:   make(map[string]bool),
+		lostItem:    make(map[string]bool),
+		unburdened:  make(map[string]bool),
+		comparisons: []SpeedComparison{},
+	}
+}
+
+// Observe checks an event for moves and for anything that changes the
+// order they resolve in.
+func (o *speedObserver) Observe(event protocol.Event) {
+	switch e := event.(type) {
+	case protocol.MoveEvent:
+		o.recordMove(e)
+
+	case protocol.ActivateEvent:
+		if !orderActivations[e.Effect.Name] {
+			return
+		}
+		o.reordered[normalizeSlot(refSlot(e.Pokemon))] = true
+
+	case protocol.ItemEvent:
+		slot := normalizeSlot(refSlot(e.Pokemon))
+		if e.Item == "Custap Berry" && e.Ended {
+			o.reordered[slot] = true
+		}
+		poke, ok := o.tracker.activePokemon[slot]
+		if !ok {
+			return
+		}
+		key := slotSide(slot) + ": " + poke.Name
+		if !e.Ended {
+			delete(o.lostItem, key)
+			delete(o.unburdened, slot)
+			return
+		}
+		o.lostItem[key] = true
+		o.unburdened[slot] = true
+
+	case protocol.SwitchEvent:
+		delete(o.unburdened, normalizeSlot(refSlot(e.Pokemon)))
+
+	case protocol.SwapEvent:
+		slot := normalizeSlot(refSlot(e.Pokemon))
+		if other, ok := swapTarget(slot, e.Position); ok {
+			o.swap(slot, other)
+		}
+	}
+}
+
+// swap follows an Ally Switch, so this turn's moves stay with the Pokémon
+// that made them.
+func (o *speedObserver) swap(slot, other string) {
+	for i := range o.movers {
+		mover := &o.movers[i]
+		switch mover.slot {
+		case slot:
+			mover.slot = other
+		case other:
+			mover.slot = slot
+		}
+		swapSlots(mover.conditions, slot, other)
+		swapSlots(mover.unknown, slot, other)
+	}
+	swapSlots(o.reordered, slot, other)
+	swapSlots(o.unburdened, slot, other)
+}
+
+// FinishTurn forgets the turn's moves.
+func (o *speedObserver) FinishTurn() {
+	o.movers = nil
+	o.reordered = make(map[string]bool)
+}
+
+// recordMove compares a move with every earlier one this turn in the same
+// priority bracket. Moves called by another (Instruct, Dancer) don't take a
+// place of their own in the order.
+func (o *speedObserver) recordMove(e protocol.MoveEvent) {
+	action := o.turns.currentAction()
+	if action == nil || (e.From.Name != "" && e.From.Name != "lockedmove") {
+		return
+	}
+	slot := normalizeSlot(refSlot(e.Source))
+	for _, mover := range o.movers {
+		if mover.slot == slot {
+			return
+		}
+	}
+
+	// Matchups are stored by species, and the log names Pokémon by nickname
+	pokemon := e.Source.Name
+	if poke, ok := o.tracker.activePokemon[slot]; ok {
+		pokemon = poke.Name
+	}
+
+	mover := speedMover{
+		slot: slot,
+		side: SpeedSide{
+			Pokemon:     pokemon,
+			Player:      sideToPlayer(e.Source.Side),
+			Move:        e.Move,
+			OrderInTurn: action.OrderInTurn,
+		},
+		priority:   o.priority(e.Move),
+		trickRoom:  o.trickRoom(),
+		outOfOrder: o.outOfOrder(slot, e.Move),
+	}
+	mover.conditions, mover.unknown = o.activeConditions()
+
+	if !mover.outOfOrder {
+		for _, earlier := range o.movers {
+			if earlier.priority != mover.priority || earlier.outOfOrder ||
+				earlier.unknown[earlier.slot] || earlier.unknown[slot] {
+				continue
+			}
+			first, second := earlier.side, mover.side
+			first.Conditions = earlier.conditions[earlier.slot]
+			second.Conditions = earlier.conditions[slot]
+			o.comparisons = append(o.comparisons, SpeedComparison{
+				TurnNumber: o.tracker.turnNumber,
+				First:      first,
+				Second:     second,
+				Priority:   mover.priority,
+				TrickRoom:  earlier.trickRoom,
+			})
+		}
+	}
+
+	o.movers = append(o.movers, mover)
+}
+
+// outOfOrder reports whether the Pokémon's place in the order was set by
+// something other than its Speed. A move missing from the dex counts as
+// changed when the Pokémon's ability changes some moves' priority.
+func (o *speedObserver) outOfOrder(slot, move string) bool {
+	if o.reordered[slot] {
+		return true
+	}
+	poke, ok := o.tracker.activePokemon[slot]
+	if !ok {
+		return false
+	}
+	if orderChangers[poke.Ability] || orderChangers[poke.Item] {
+		return true
+	}
+	if changes, ok := moveOrderChangers[poke.Ability]; ok {
+		data, known := dex.LookupMove(move)
+		return !known || changes(data)
+	}
+	return false
+}
+
+// activeConditions returns the speed conditions of the Pokémon in each
+// active slot, and the slots whose Speed can't be known because an ability
+// the Pokémon might have, but hasn't revealed, would be raising it.
+func (o *speedObserver) activeConditions() (map[string]SpeedConditions, map[string]bool) {
+	field := o.tracker.GetFieldState()
+	tailwind := map[string]bool{}
+	for side, conditions := range map[string][]FieldCondition{"p1": field.Player1Side, "p2": field.Player2Side} {
+		for _, condition := range conditions {
+			if condition.Name == "Tailwind" {
+				tailwind[side] = true
+			}
+		}
+	}
+
+	active := make(map[string]SpeedConditions)
+	unknown := make(map[string]bool)
+	for slot, poke := range o.tracker.activePokemon {
+		if poke.CurrentHP <= 0 {
+			continue
+		}
+		side := slotSide(slot)
+		item := o.heldItem(slot, poke)
+		_, protosynthesis := o.tracker.volatiles.active[slot+"|protosynthesisspe"]
+		_, quarkDrive := o.tracker.volatiles.active[slot+"|quarkdrivespe"]
+		conditions := SpeedConditions{
+			Stage:       o.tracker.statBoosts[slot]["spe"],
+			Tailwind:    tailwind[side],
+			Paralyzed:   poke.Status == "par" && poke.Ability != "Quick Feet",
+			ChoiceScarf: item == "Choice Scarf",
+			Boosted:     protosynthesis || quarkDrive,
+			IronBall:    item == "Iron Ball",
+		}
+		if poke.Ability != "" {
+			if o.abilityActive(slot, poke, poke.Ability, field) {
+				conditions.Ability = poke.Ability
+			}
+		} else if species, ok := dex.LookupSpecies(poke.Name); ok {
+			for _, ability := range species.Abilities {
+				if o.abilityActive(slot, poke, ability, field) {
+					unknown[slot] = true
+				}
+			}
+		}
+		active[slot] = conditions
+	}
+	return active, unknown
+}
+
+// abilityActive reports whether ability would be raising the Pokémon's
+// Speed right now.
+func (o *speedObserver) abilityActive(slot string, poke *Pokémon, ability string, field FieldState) bool {
+	switch ability {
+	case "Surge Surfer":
+		return field.Terrain != nil && field.Terrain.Name == "Electric Terrain"
+	case "Unburden":
+		return o.unburdened[slot]
+	case "Quick Feet":
+		return poke.Status != ""
+	}
+	weather, ok := weatherSpeedAbilities[ability]
+	if !ok || field.Weather == nil || sampleWeathers[field.Weather.Name] != weather {
+		return false
+	}
+	// Utility Umbrella shuts its holder off from sun and rain
+	return o.heldItem(slot, poke) != "Utility Umbrella" || weather == "Sand" || weather == "Snow"
+}
+
+// heldItem returns the Pokémon's item, or "" once it has been used up or
+// taken.
+func (o *speedObserver) heldItem(slot string, poke *Pokémon) string {
+	if o.lostItem[slotSide(slot)+": "+poke.Name] {
+		return ""
+	}
+	return poke.Item
+}
+
+// priority returns the move's priority bracket. Grassy Glide only gets its
+// +1 in Grassy Terrain.
+func (o *speedObserver) priority(move string) int {
+	if move == "Grassy Glide" {
+		if terrain := o.tracker.GetFieldState().Terrain; terrain != nil && terrain.Name == "Grassy Terrain" {
+			return 1
+		}
+	}
+	if data, ok := dex.LookupMove(move); ok {
+		return data.Priority
+	}
+	return 0
+}
+
+// trickRoom reports whether Trick Room is up.
+func (o *speedObserver) trickRoom() bool {
+	for _, room := range o.tracker.GetFieldState().Rooms {
+		if room.Name == "Trick Room" {
+			return true
+		}
+	}
+	return false
+}
+
+// Analysis returns the comparisons made so far with the ties and speed
+// ranges they imply.
+func (o *speedObserver) Analysis() SpeedAnalysis {
+	return SpeedAnalysis{
+		Comparisons: o.comparisons,
+		Ties:        findSpeedTies(o.comparisons),
+		Ranges:      estimateSpeedRanges(o.comparisons),
+	}
+}
+
+// findSpeedTies returns the pairs seen moving in both orders under the same
+// conditions, which only happens when their Speed is equal and the tie is
+// broken at random.
+func findSpeedTies(comparisons []SpeedComparison) []SpeedTie {
+	type matchup struct {
+		a, b           MomentPokemon
+		aConds, bConds SpeedConditions
+		trickRoom      bool
+	}
+	type seen struct {
+		aFirst, bFirst bool
+		turns          []int
+	}
+
+	var order []matchup
+	matchups := make(map[matchup]*seen)
+	for _, c := range comparisons {
+		first := MomentPokemon{Pokemon: c.First.Pokemon, Player: c.First.Player}
+		second := MomentPokemon{Pokemon: c.Second.Pokemon, Player: c.Second.Player}
+		m := matchup{a: first, b: second, aConds: c.First.Conditions, bConds: c.Second.Conditions, trickRoom: c.TrickRoom}
+		aFirst := true
+		if momentPokemonLess(second, first) {
+			m = matchup{a: second, b: first, aConds: c.Second.Conditions, bConds: c.First.Conditions, trickRoom: c.TrickRoom}
+			aFirst = false
+		}
+
+		s, ok := matchups[m]
+		if !ok {
+			s = &seen{}
+			matchups[m] = s
+			order = append(order, m)
+		}
+		if aFirst {
+			s.aFirst = true
+		} else {
+			s.bFirst = true
+		}
+		s.turns = append(s.turns, c.TurnNumber)
+	}
+
+	ties := []SpeedTie{}
+	for _, m := range order {
+		if s := matchups[m]; s.aFirst && s.bFirst {
+			ties = append(ties, SpeedTie{Pokemon: []MomentPokemon{m.a, m.b}, TrickRoom: m.trickRoom, Turns: s.turns})
+		}
+	}
+	return ties
+}
+
+// estimateSpeedRanges bounds each Pokémon's Speed stat against the opposing
+// Pokémon it was compared with. Moving first outside Trick Room means
+// speed × multiplier was at least the other's, so the unmodified Speed is at
+// least the other's times the ratio of their multipliers; Trick Room turns
+// that into an upper bound. The tightest bound against each Pokémon is kept.
+func estimateSpeedRanges(comparisons []SpeedComparison) []SpeedRange {
+	var ranges []*SpeedRange
+	index := make(map[MomentPokemon]*SpeedRange)
+	rangeFor := func(side SpeedSide) *SpeedRange {
+		poke := MomentPokemon{Pokemon: side.Pokemon, Player: side.Player}
+		if r, ok := index[poke]; ok {
+			return r
+		}
+		r := &SpeedRange{Pokemon: side.Pokemon, Player: side.Player, AtLeast: []SpeedBound{}, AtMost: []SpeedBound{}}
+		index[poke] = r
+		ranges = append(ranges, r)
+		return r
+	}
+
+	for _, c := range comparisons {
+		if c.First.Player == c.Second.Player {
+			continue
+		}
+		first, second := rangeFor(c.First), rangeFor(c.Second)
+		// first's Speed relative to second's
+		ratio := c.Second.Conditions.Multiplier() / c.First.Conditions.Multiplier()
+		if c.TrickRoom {
+			first.AtMost = tighten(first.AtMost, c.Second, ratio, math.Min)
+			second.AtLeast = tighten(second.AtLeast, c.First, 1/ratio, math.Max)
+		} else {
+			first.AtLeast = tighten(first.AtLeast, c.Second, ratio, math.Max)
+			second.AtMost = tighten(second.AtMost, c.First, 1/ratio, math.Min)
+		}
+	}
+
+	narrowSpeedStats(comparisons, index)
+
+	result := make([]SpeedRange, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, *r)
+	}
+	return result
+}
+
+// narrowSpeedStats turns the relative bounds into Speed stats. Each Pokémon
+// starts with every Speed its species can have at level 50, and each
+// comparison then raises the lower limit of the one that moved first (or
+// second, under Trick Room) and lowers the upper limit of the other, until
+// nothing changes. A bound that would leave no Speed possible, as a missed
+// modifier can, is ignored.
+func narrowSpeedStats(comparisons []SpeedComparison, index map[MomentPokemon]*SpeedRange) {
+	for _, r := range index {
+		r.MinSpeed, r.MaxSpeed, _ = speedStatRange(r.Pokemon)
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, c := range comparisons {
+			if c.First.Player == c.Second.Player {
+				continue
+			}
+			faster := index[MomentPokemon{Pokemon: c.First.Pokemon, Player: c.First.Player}]
+			slower := index[MomentPokemon{Pokemon: c.Second.Pokemon, Player: c.Second.Player}]
+			fasterMult, slowerMult := c.First.Conditions.Multiplier(), c.Second.Conditions.Multiplier()
+			if c.TrickRoom {
+				faster, slower = slower, faster
+				fasterMult, slowerMult = slowerMult, fasterMult
+			}
+			if faster == nil || slower == nil || faster.MinSpeed == 0 || slower.MinSpeed == 0 {
+				continue
+			}
+
+			// faster × fasterMult ≥ slower × slowerMult
+			least := int(math.Ceil(float64(slower.MinSpeed)*slowerMult/fasterMult - 1e-9))
+			if least > faster.MinSpeed && least <= faster.MaxSpeed {
+				faster.MinSpeed = least
+				changed = true
+			}
+			most := int(math.Floor(float64(faster.MaxSpeed)*fasterMult/slowerMult + 1e-9))
+			if most < slower.MaxSpeed && most >= slower.MinSpeed {
+				slower.MaxSpeed = most
+				changed = true
+			}
+		}
+	}
+}
+
+// speedStatRange returns the slowest and fastest level 50 Speed stat a
+// species can have: 0 IVs, no EVs and a hindering nature, up to 252 EVs and a
+// boosting one. ok is false if the species isn't in the dex.
+func speedStatRange(species string) (slowest, fastest int, ok bool) {
+	minStats, err := calc.Stats(calc.Pokemon{Species: species, Level: 50, Nature: "Brave", IVs: map[string]int{"spe": 0}})
+	if err != nil {
+		return 0, 0, false
+	}
+	maxStats, err := calc.Stats(calc.Pokemon{Species: species, Level: 50, Nature: "Timid", EVs: map[string]int{"spe": 252}})
+	if err != nil {
+		return 0, 0, false
+	}
+	return minStats.Spe, maxStats.Spe, true
+}
+
+// tighten adds a bound against other, or keeps whichever of it and the
+// existing bound pick prefers.
+func tighten(bounds []SpeedBound, other SpeedSide, ratio float64, pick func(a, b float64) float64) []SpeedBound {
+	ratio = math.Round(ratio*1000) / 1000
+	for i, bound := range bounds {
+		if bound.Pokemon == other.Pokemon && bound.Player == other.Player {
+			bounds[i].Ratio = pick(bound.Ratio, ratio)
+			return bounds
+		}
+	}
+	return append(bounds, SpeedBound{Pokemon: other.Pokemon, Player: other.Player, Ratio: ratio})
+}
+
+// momentPokemonLess orders Pokémon by player, then name.
+func momentPokemonLess(a, b MomentPokemon) bool {
+	if a.Player != b.Player {
+		return a.Player < b.Player
+	}
+	return a.Pokemon < b.Pokemon
+}
@@ -0,0 +1,331 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+const speedBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Flutter Mane, L50|
+|poke|p1|Incineroar, L50|
+|poke|p2|Chien-Pao, L50|
+|poke|p2|Amoonguss, L50|
+|teamsize|p1|2
+|teamsize|p2|2
+|start
+|switch|p1a: Flutter Mane|Flutter Mane, L50|100/100
+|switch|p1b: Incineroar|Incineroar, L50|100/100
+|switch|p2a: Chien-Pao|Chien-Pao, L50|100/100
+|switch|p2b: Amoonguss|Amoonguss, L50|100/100
+|turn|1
+|move|p1b: Incineroar|Fake Out|p2b: Amoonguss
+|-damage|p2b: Amoonguss|95/100
+|move|p2a: Chien-Pao|Sacred Sword|p1b: Incineroar
+|-damage|p1b: Incineroar|70/100
+|move|p1a: Flutter Mane|Moonblast|p2a: Chien-Pao
+|-damage|p2a: Chien-Pao|40/100
+|cant|p2b: Amoonguss|flinch
+|upkeep
+|turn|2
+|move|p1a: Flutter Mane|Icy Wind|p2a: Chien-Pao|[spread] p2a,p2b
+|-damage|p2a: Chien-Pao|30/100
+|-damage|p2b: Amoonguss|85/100
+|-unboost|p2a: Chien-Pao|spe|1
+|-unboost|p2b: Amoonguss|spe|1
+|move|p1b: Incineroar|Knock Off|p2a: Chien-Pao
+|-damage|p2a: Chien-Pao|10/100
+|move|p2a: Chien-Pao|Sacred Sword|p1b: Incineroar
+|-damage|p1b: Incineroar|40/100
+|move|p2b: Amoonguss|Pollen Puff|p1a: Flutter Mane
+|-damage|p1a: Flutter Mane|60/100
+|upkeep
+|turn|3
+|-activate|p2b: Amoonguss|item: Quick Claw
+|move|p2b: Amoonguss|Trick Room|p2b: Amoonguss
+|-fieldstart|move: Trick Room|[of] p2b: Amoonguss
+|move|p1a: Flutter Mane|Moonblast|p2a: Chien-Pao
+|-damage|p2a: Chien-Pao|0 fnt
+|faint|p2a: Chien-Pao
+|move|p1b: Incineroar|Flare Blitz|p2b: Amoonguss
+|-damage|p2b: Amoonguss|40/100
+|-damage|p1b: Incineroar|30/100|[from] Recoil
+|upkeep
+|turn|4
+|move|p2b: Amoonguss|Pollen Puff|p1b: Incineroar
+|-damage|p1b: Incineroar|0 fnt
+|faint|p1b: Incineroar
+|move|p1a: Flutter Mane|Moonblast|p2b: Amoonguss
+|-damage|p2b: Amoonguss|0 fnt
+|faint|p2b: Amoonguss
+|upkeep
+|win|Alice`
+
+func TestParseShowdownLogSpeedComparisons(t *testing.T) {
+	summary, err := ParseShowdownLog(speedBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// first > second, with second's Speed stage when first moved
+	type comparison struct {
+		turn          int
+		first, second string
+		secondStage   int
+		trickRoom     bool
+	}
+	expected := []comparison{
+		// Fake Out is in a bracket of its own; Amoonguss flinched
+		{1, "Chien-Pao", "Flutter Mane", 0, false},
+		{2, "Flutter Mane", "Incineroar", 0, false},
+		{2, "Flutter Mane", "Chien-Pao", 0, false},
+		// Icy Wind's drop counts from the next move on
+		{2, "Incineroar", "Chien-Pao", -1, false},
+		{2, "Flutter Mane", "Amoonguss", 0, false},
+		{2, "Incineroar", "Amoonguss", -1, false},
+		{2, "Chien-Pao", "Amoonguss", -1, false},
+		// Amoonguss's Quick Claw and Trick Room's -7 leave it out on turn 3
+		{3, "Flutter Mane", "Incineroar", 0, true},
+		{4, "Amoonguss", "Flutter Mane", 0, true},
+	}
+
+	var got []comparison
+	for _, c := range summary.Speed.Comparisons {
+		got = append(got, comparison{c.TurnNumber, c.First.Pokemon, c.Second.Pokemon, c.Second.Conditions.Stage, c.TrickRoom})
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected comparisons\n%+v\ngot\n%+v", expected, got)
+	}
+}
+
+func TestParseShowdownLogSpeedTies(t *testing.T) {
+	summary, _ := ParseShowdownLog(speedBattleLog)
+
+	expected := []SpeedTie{{
+		Pokemon:   []MomentPokemon{{Pokemon: "Flutter Mane", Player: "player1"}, {Pokemon: "Chien-Pao", Player: "player2"}},
+		TrickRoom: false,
+		Turns:     []int{1, 2},
+	}}
+	if !reflect.DeepEqual(summary.Speed.Ties, expected) {
+		t.Errorf("expected ties %+v, got %+v", expected, summary.Speed.Ties)
+	}
+}
+
+func TestParseShowdownLogSpeedRanges(t *testing.T) {
+	summary, _ := ParseShowdownLog(speedBattleLog)
+
+	ranges := make(map[string]SpeedRange)
+	for _, r := range summary.Speed.Ranges {
+		ranges[r.Pokemon] = r
+	}
+
+	// Incineroar outsped Chien-Pao at -1: at least 2/3 of its Speed
+	incineroar := ranges["Incineroar"]
+	if !reflect.DeepEqual(incineroar.AtLeast, []SpeedBound{
+		{Pokemon: "Chien-Pao", Player: "player2", Ratio: 0.667},
+		{Pokemon: "Amoonguss", Player: "player2", Ratio: 0.667},
+	}) || len(incineroar.AtMost) != 0 {
+		t.Errorf("unexpected Incineroar range %+v", incineroar)
+	}
+
+	// Trick Room on turn 4 only gives Amoonguss a looser bound than turn 2
+	amoonguss := ranges["Amoonguss"]
+	if !reflect.DeepEqual(amoonguss.AtMost, []SpeedBound{
+		{Pokemon: "Flutter Mane", Player: "player1", Ratio: 1},
+		{Pokemon: "Incineroar", Player: "player1", Ratio: 1.5},
+	}) {
+		t.Errorf("unexpected Amoonguss range %+v", amoonguss)
+	}
+
+	// As level 50 stats: Incineroar at least 2/3 of the slowest Chien-Pao,
+	// Chien-Pao at most 1.5x the fastest Incineroar, and Flutter Mane, which
+	// tied Chien-Pao, within Chien-Pao's range. Nothing narrows Amoonguss.
+	stats := make(map[string][2]int)
+	for name, r := range ranges {
+		stats[name] = [2]int{r.MinSpeed, r.MaxSpeed}
+	}
+	expectedStats := map[string][2]int{
+		"Incineroar":   {84, 123},
+		"Chien-Pao":    {126, 184},
+		"Flutter Mane": {126, 184},
+		"Amoonguss":    {31, 90},
+	}
+	if !reflect.DeepEqual(stats, expectedStats) {
+		t.Errorf("expected Speed stats %v, got %v", expectedStats, stats)
+	}
+
+	// Only opposing Pokémon bound each other
+	for _, bound := range ranges["Flutter Mane"].AtLeast {
+		if bound.Player == "player1" {
+			t.Errorf("expected no bounds from Flutter Mane's own side, got %+v", bound)
+		}
+	}
+}
+
+func TestParseShowdownLogSpeedPrankster(t *testing.T) {
+	log := `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Whimsicott, L50|
+|poke|p1|Incineroar, L50|
+|poke|p2|Chien-Pao, L50|
+|poke|p2|Amoonguss, L50|
+|showteam|p1|Whimsicott||CovertCloak|Prankster|Tailwind,Moonblast||||||50|]Incineroar||SitrusBerry|Intimidate|FakeOut,KnockOff||||||50|
+|start
+|switch|p1a: Whimsicott|Whimsicott, L50|100/100
+|switch|p1b: Incineroar|Incineroar, L50|100/100
+|switch|p2a: Chien-Pao|Chien-Pao, L50|100/100
+|switch|p2b: Amoonguss|Amoonguss, L50|100/100
+|turn|1
+|move|p1a: Whimsicott|Tailwind|p1a: Whimsicott
+|-sidestart|p1: Alice|move: Tailwind
+|move|p2a: Chien-Pao|Sacred Sword|p1b: Incineroar
+|-damage|p1b: Incineroar|60/100
+|move|p1b: Incineroar|Knock Off|p2a: Chien-Pao
+|-damage|p2a: Chien-Pao|50/100
+|upkeep
+|turn|2
+|move|p1a: Whimsicott|Moonblast|p2a: Chien-Pao
+|-damage|p2a: Chien-Pao|20/100
+|move|p1b: Incineroar|Knock Off|p2a: Chien-Pao
+|-damage|p2a: Chien-Pao|0 fnt
+|faint|p2a: Chien-Pao
+|upkeep
+|win|Alice
+`
+	summary, err := ParseShowdownLog(log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Prankster Tailwind says nothing about Whimsicott's Speed; Moonblast does
+	type comparison struct {
+		turn          int
+		first, second string
+		tailwind      bool
+	}
+	expected := []comparison{
+		{1, "Chien-Pao", "Incineroar", false},
+		{2, "Whimsicott", "Incineroar", true},
+	}
+
+	var got []comparison
+	for _, c := range summary.Speed.Comparisons {
+		got = append(got, comparison{c.TurnNumber, c.First.Pokemon, c.Second.Pokemon, c.First.Conditions.Tailwind})
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected comparisons %+v, got %+v", expected, got)
+	}
+}
+
+func TestParseShowdownLogSpeedNicknames(t *testing.T) {
+	log := `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Flutter Mane, L50|
+|poke|p2|Chien-Pao, L50|
+|start
+|switch|p1a: Boo|Flutter Mane, L50|100/100
+|switch|p2a: Pao|Chien-Pao, L50|100/100
+|turn|1
+|move|p2a: Pao|Sacred Sword|p1a: Boo
+|-immune|p1a: Boo
+|move|p1a: Boo|Moonblast|p2a: Pao
+|-damage|p2a: Pao|50/100
+|upkeep
+|win|Alice
+`
+	summary, _ := ParseShowdownLog(log)
+
+	// Matchups are looked up by species, not the nicknames in the log
+	comparisons := summary.Speed.Comparisons
+	if len(comparisons) != 1 || comparisons[0].First.Pokemon != "Chien-Pao" || comparisons[0].Second.Pokemon != "Flutter Mane" {
+		t.Errorf("expected Chien-Pao to outspeed Flutter Mane by species, got %+v", comparisons)
+	}
+}
+
+func TestParseShowdownLogSpeedAbilities(t *testing.T) {
+	log := `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Torkoal, L50|
+|poke|p1|Lilligant-Hisui, L50|
+|poke|p2|Sneasler, L50|
+|poke|p2|Whimsicott, L50|
+|showteam|p1|Torkoal||Charcoal|Drought|Eruption,HeatWave||||||50|]Lilligant-Hisui||FocusSash|Chlorophyll|CloseCombat,LeafBlade||||||50|
+|start
+|switch|p1a: Torkoal|Torkoal, L50|100/100
+|switch|p1b: Lilligant|Lilligant-Hisui, L50|100/100
+|switch|p2a: Sneasler|Sneasler, L50|100/100
+|switch|p2b: Whimsicott|Whimsicott, L50|100/100
+|-weather|SunnyDay|[from] ability: Drought|[of] p1a: Torkoal
+|turn|1
+|move|p1b: Lilligant|Close Combat|p2a: Sneasler
+|-damage|p2a: Sneasler|40/100
+|-enditem|p2a: Sneasler|Sitrus Berry|[eat]
+|-heal|p2a: Sneasler|65/100|[from] item: Sitrus Berry
+|move|p2b: Whimsicott|Moonblast|p1b: Lilligant
+|-damage|p1b: Lilligant|50/100
+|move|p2a: Sneasler|Close Combat|p1a: Torkoal
+|-damage|p1a: Torkoal|60/100
+|move|p1a: Torkoal|Heat Wave|p2a: Sneasler|[spread] p2a,p2b
+|-damage|p2a: Sneasler|20/100
+|-damage|p2b: Whimsicott|50/100
+|upkeep
+|win|Alice
+`
+	summary, err := ParseShowdownLog(log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Whimsicott might have Chlorophyll in the sun, and Sneasler Unburden
+	// once its berry is gone, so neither can be compared then
+	type comparison struct {
+		first, second string
+		ability       string
+	}
+	expected := []comparison{
+		{"Lilligant-Hisui", "Sneasler", "Chlorophyll"},
+		{"Lilligant-Hisui", "Torkoal", "Chlorophyll"},
+	}
+
+	var got []comparison
+	for _, c := range summary.Speed.Comparisons {
+		got = append(got, comparison{c.First.Pokemon, c.Second.Pokemon, c.First.Conditions.Ability})
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected comparisons %+v, got %+v", expected, got)
+	}
+
+	// Chlorophyll doubled Lilligant's Speed, so Sneasler is only bounded by twice it
+	ranges := make(map[string]SpeedRange)
+	for _, r := range summary.Speed.Ranges {
+		ranges[r.Pokemon] = r
+	}
+	sneasler := ranges["Sneasler"]
+	if !reflect.DeepEqual(sneasler.AtMost, []SpeedBound{{Pokemon: "Lilligant-Hisui", Player: "player1", Ratio: 2}}) {
+		t.Errorf("unexpected Sneasler range %+v", sneasler)
+	}
+}
+
+func TestSpeedConditionsMultiplier(t *testing.T) {
+	tests := []struct {
+		conditions SpeedConditions
+		expected   float64
+	}{
+		{SpeedConditions{}, 1},
+		{SpeedConditions{Stage: 2}, 2},
+		{SpeedConditions{Stage: -1}, 2.0 / 3},
+		{SpeedConditions{Tailwind: true, Paralyzed: true}, 1},
+		{SpeedConditions{ChoiceScarf: true, Stage: 1}, 2.25},
+		{SpeedConditions{Ability: "Swift Swim"}, 2},
+		{SpeedConditions{Ability: "Unburden", Stage: -1}, 4.0 / 3},
+		{SpeedConditions{Ability: "Quick Feet"}, 1.5},
+		{SpeedConditions{Ability: "Intimidate"}, 1},
+		{SpeedConditions{IronBall: true, ChoiceScarf: true}, 0.75},
+	}
+
+	for _, tt := range tests {
+		if got := tt.conditions.Multiplier(); got != tt.expected {
+			t.Errorf("%+v: expected %v, got %v", tt.conditions, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,629 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dtsong/vgccorner/backend/internal/calc"
+	"github.com/dtsong/vgccorner/backend/internal/dex"
+)
+
+// variablePowerHits are multi-hit moves whose hits don't share a base power.
+var variablePowerHits = map[string]bool{"Triple Axel": true, "Triple Kick": true}
+
+// Natures standing in for a raised, lowered or untouched stat. Each raises
+// or lowers Speed alongside, which no hit depends on.
+var (
+	raisingNatures  = map[string]string{"atk": "Brave", "def": "Relaxed", "spa": "Quiet", "spd": "Sassy"}
+	loweringNatures = map[string]string{"atk": "Timid", "def": "Hasty", "spa": "Jolly", "spd": "Naive"}
+)
+
+const neutralNature = "Serious"
+
+// maxLikelySpreads is how many spreads a StatInference lists.
+const maxLikelySpreads = 5
+
+var spreadStatLabels = map[string]string{"hp": "HP", "atk": "Atk", "def": "Def", "spa": "SpA", "spd": "SpD"}
+
+// attachSpreads infers a spread for every team member the battle's samples
+// say something about.
+func attachSpreads(summary *BattleSummary) {
+	for _, player := range []struct {
+		id   string
+		team []Pokémon
+	}{
+		{"player1", summary.Player1.Team},
+		{"player2", summary.Player2.Team},
+	} {
+		for i := range player.team {
+			player.team[i].Spread = InferSpread(player.team[i].Name, player.id, summary.DamageSamples)
+		}
+	}
+}
+
+// InferPlayerSpreads infers the spread of each of a player's Pokémon from
+// the damage samples of several battles, assuming it ran the same spread in
+// all of them. Players are matched by name, and their Pokémon come out in
+// the order they were first sampled.
+func InferPlayerSpreads(player string, battles []*BattleSummary) []SpreadInference {
+	var order []string
+	bySpecies := make(map[string][]DamageSample)
+	for _, battle := range battles {
+		var side, opponent string
+		switch {
+		case strings.EqualFold(battle.Player1.Name, player):
+			side, opponent = "player1", battle.Player2.Name
+		case strings.EqualFold(battle.Player2.Name, player):
+			side, opponent = "player2", battle.Player1.Name
+		default:
+			continue
+		}
+
+		for _, sample := range battle.DamageSamples {
+			// Label each side by name, so samples from different battles line up
+			var sampled []string
+			for _, poke := range []*SamplePokemon{&sample.Attacker, &sample.Defender} {
+				if poke.Player == side {
+					poke.Player = player
+					sampled = append(sampled, poke.Pokemon)
+				} else {
+					poke.Player = opponent
+				}
+			}
+			for _, species := range sampled {
+				if _, ok := bySpecies[species]; !ok {
+					order = append(order, species)
+				}
+				bySpecies[species] = append(bySpecies[species], sample)
+			}
+		}
+	}
+
+	inferences := []SpreadInference{}
+	for _, species := range order {
+		if inference := InferSpread(species, player, bySpecies[species]); inference != nil {
+			inferences = append(inferences, *inference)
+		}
+	}
+	return inferences
+}
+
+// InferSpread works out the spreads of a player's Pokémon consistent with
+// every sample where it dealt or took damage. Hits depending on its Attack
+// or Special Attack bound that stat; hits it took bound HP with Defense or
+// Special Defense. The other Pokémon's spread is unknown, so each hit is
+// allowed anything from its least invested (0 EVs, hindering nature) to its
+// most invested (252 EVs, boosting nature, and 252 HP when it defends). It
+// returns nil if no sample can be used.
+//
+// Fixed-damage moves, Foul Play, Body Press and multi-hit moves whose hits
+// differ in power are skipped, as are hits the calculator can't work out.
+func InferSpread(pokemon, player string, samples []DamageSample) *SpreadInference {
+	groups := make(map[string][]*preparedSample)
+	counts := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, sample := range samples {
+		prepared, ok := prepareSample(sample, pokemon, player)
+		if !ok {
+			continue
+		}
+		counts[prepared.stat]++
+		// Repeats of a hit, down to the HP readings, can't narrow things further
+		if key := fmt.Sprint(prepared.input, sample.Before, sample.After, sample.LowerBound); !seen[key] {
+			seen[key] = true
+			groups[prepared.stat] = append(groups[prepared.stat], prepared)
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	fits := make(map[string]*spreadFit)
+	for _, stat := range []string{"atk", "def", "spa", "spd"} {
+		switch group := groups[stat]; {
+		case len(group) == 0:
+		case stat == "def" || stat == "spd":
+			fits[stat] = inferBulk(group, stat)
+		default:
+			fits[stat] = inferPower(group, stat)
+		}
+	}
+	// Both defensive stats share one HP stat
+	if def, spd := fits["def"], fits["spd"]; def != nil && spd != nil {
+		defHP, spdHP := def.hpEVs(), spd.hpEVs()
+		if len(defHP) > 0 && len(spdHP) > 0 {
+			def.keepHP(spdHP)
+			spd.keepHP(defHP)
+		}
+	}
+
+	inference := &SpreadInference{Pokemon: pokemon, Player: player, Stats: []StatInference{}}
+	for _, stat := range []string{"atk", "def", "spa", "spd"} {
+		if fit, ok := fits[stat]; ok {
+			inference.Stats = append(inference.Stats, StatInference{
+				Stat:       stat,
+				Samples:    counts[stat],
+				Candidates: fit.count(),
+				Likely:     fit.likelySpreads(),
+			})
+		}
+	}
+	return inference
+}
+
+// preparedSample is a sample the inference can use, seen from the side of
+// the Pokémon whose spread is being inferred.
+type preparedSample struct {
+	DamageSample
+	defending  bool   // The Pokémon took the hit rather than dealt it
+	stat       string // Its stat the damage depends on
+	counterKey string // The other Pokémon's stat the damage depends on
+	input      string // Everything the damage depends on, for sharing damage curves
+}
+
+// prepareSample checks a sample involves the Pokémon and can be used, and
+// works out which stats its damage depends on.
+func prepareSample(sample DamageSample, pokemon, player string) (*preparedSample, bool) {
+	defending := sample.Defender.Pokemon == pokemon && sample.Defender.Player == player
+	attacking := sample.Attacker.Pokemon == pokemon && sample.Attacker.Player == player
+	if defending == attacking || variablePowerHits[sample.Move.Name] {
+		return nil, false
+	}
+	if move, ok := dex.LookupMove(sample.Move.Name); ok && move.OverrideOffensivePokemon != "" {
+		// Foul Play uses the defender's Attack
+		return nil, false
+	}
+
+	probe, err := calc.Calculate(sample.Attacker.Set, sample.Defender.Set, sample.Move, sample.Field)
+	if err != nil || probe.FixedDamage {
+		return nil, false
+	}
+	prepared := &preparedSample{DamageSample: sample, defending: defending}
+	if defending {
+		prepared.stat, prepared.counterKey = probe.DefenseStat, probe.AttackStat
+	} else {
+		prepared.stat, prepared.counterKey = probe.AttackStat, probe.DefenseStat
+	}
+	if prepared.stat == "def" && !defending {
+		// Body Press
+		return nil, false
+	}
+	prepared.input = fmt.Sprint(defending, sample.Attacker.Set, sample.Defender.Set, sample.Move, sample.Field)
+	return prepared, true
+}
+
+// target returns the inferred Pokémon's set.
+func (p *preparedSample) target() calc.Pokemon {
+	if p.defending {
+		return p.Defender.Set
+	}
+	return p.Attacker.Set
+}
+
+// counterpart returns the other Pokémon at its least or most invested.
+func (p *preparedSample) counterpart(strongest bool) calc.Pokemon {
+	set := p.Defender.Set
+	if p.defending {
+		set = p.Attacker.Set
+	}
+	if !strongest {
+		return withSpread(set, map[string]int{}, natureFor(p.counterKey, "-"))
+	}
+	evs := map[string]int{p.counterKey: 252}
+	if !p.defending {
+		evs["hp"] = 252
+	}
+	return withSpread(set, evs, natureFor(p.counterKey, "+"))
+}
+
+// hpRange returns the HP a reading allows for a Pokémon with maxHP HP. An
+// exact reading only fits its own max HP.
+func (r HPReading) hpRange(maxHP int) (lo, hi int, ok bool) {
+	if r.Max != 100 {
+		return r.Current, r.Current, r.Max == maxHP
+	}
+	switch r.Current {
+	case 0:
+		return 0, 0, true
+	case 100:
+		return maxHP, maxHP, true
+	case 99:
+		lo, hi = 98*maxHP/100+1, maxHP-1
+	default:
+		lo, hi = (r.Current-1)*maxHP/100+1, r.Current*maxHP/100
+	}
+	return lo, hi, lo <= hi
+}
+
+// fraction returns the share of max HP a reading allows.
+func (r HPReading) fraction() (lo, hi float64) {
+	if r.Max != 100 {
+		f := float64(r.Current) / float64(r.Max)
+		return f, f
+	}
+	switch r.Current {
+	case 0:
+		return 0, 0
+	case 100:
+		return 1, 1
+	case 99:
+		return 0.98, 1
+	}
+	return float64(r.Current-1) / 100, float64(r.Current) / 100
+}
+
+// statSpread is one way to invest in a stat.
+type statSpread struct {
+	evs    int
+	nature string // "+", "-" or ""
+	value  int    // The stat it gives
+}
+
+// statSpreadCache holds statSpreads by species, level and stat.
+var statSpreadCache sync.Map
+
+// statSpreads lists every value a stat can take, each with the fewest EVs
+// that reach it under a neutral, boosting and hindering nature. HP has no
+// nature.
+func statSpreads(set calc.Pokemon, stat string) []statSpread {
+	key := fmt.Sprintf("%s|%d|%s", set.Species, set.Level, stat)
+	if spreads, ok := statSpreadCache.Load(key); ok {
+		return spreads.([]statSpread)
+	}
+
+	signs := []string{"", "+", "-"}
+	if stat == "hp" {
+		signs = signs[:1]
+	}
+	var spreads []statSpread
+	for _, sign := range signs {
+		seen := make(map[int]bool)
+		for evs := 0; evs <= 252; evs += 4 {
+			stats, err := calc.Stats(withSpread(set, map[string]int{stat: evs}, natureFor(stat, sign)))
+			if err != nil {
+				return nil
+			}
+			value := dexStat(stats, stat)
+			if !seen[value] {
+				seen[value] = true
+				spreads = append(spreads, statSpread{evs: evs, nature: sign, value: value})
+			}
+		}
+	}
+	statSpreadCache.Store(key, spreads)
+	return spreads
+}
+
+// statValues is every value the spreads reach, lowest first, with a spread
+// reaching each.
+type statValues struct {
+	stat    string
+	values  []int
+	spreads map[int]statSpread
+}
+
+func newStatValues(spreads []statSpread, stat string) *statValues {
+	v := &statValues{stat: stat, spreads: make(map[int]statSpread)}
+	for _, spread := range spreads {
+		if _, ok := v.spreads[spread.value]; !ok {
+			v.spreads[spread.value] = spread
+			v.values = append(v.values, spread.value)
+		}
+	}
+	sort.Ints(v.values)
+	return v
+}
+
+// index returns the position of a value.
+func (v *statValues) index(value int) int {
+	return sort.SearchInts(v.values, value)
+}
+
+// damageCurve is the least and most one hit could do for each value of the
+// inferred stat, against every spread of the other Pokémon: in HP when the
+// inferred Pokémon defends, as a fraction of the defender's max HP when it
+// attacks. Both only ever move one way as the stat rises, so consistent
+// values are found by binary search, and each point is only worked out when
+// a search needs it.
+type damageCurve struct {
+	sample *preparedSample
+	values *statValues
+	least  map[int]float64 // Value index -> bound
+	most   map[int]float64
+}
+
+func newDamageCurve(sample *preparedSample, values *statValues) *damageCurve {
+	return &damageCurve{
+		sample: sample,
+		values: values,
+		least:  make(map[int]float64),
+		most:   make(map[int]float64),
+	}
+}
+
+// bound returns the least or most damage at the i-th value. A hit the
+// calculator can't work out rules nothing out.
+func (c *damageCurve) bound(i int, most bool) float64 {
+	cache := c.least
+	if most {
+		cache = c.most
+	}
+	if bound, ok := cache[i]; ok {
+		return bound
+	}
+
+	p := c.sample
+	spread := c.values.spreads[c.values.values[i]]
+	target := withSpread(p.target(), map[string]int{c.values.stat: spread.evs}, natureFor(c.values.stat, spread.nature))
+	// The least damage comes from the least invested attacker, or the most
+	// invested defender
+	other := p.counterpart(most == p.defending)
+	var result *calc.Result
+	var err error
+	if p.defending {
+		result, err = calc.Calculate(other, target, p.Move, p.Field)
+	} else {
+		result, err = calc.Calculate(target, other, p.Move, p.Field)
+	}
+
+	var bound float64
+	switch {
+	case err != nil && most:
+		bound = math.Inf(1)
+	case err != nil:
+		bound = math.Inf(-1)
+	case most:
+		bound = float64(result.Max / result.Hits)
+	default:
+		bound = float64(result.Min / result.Hits)
+	}
+	if err == nil && !p.defending {
+		bound /= float64(result.DefenderStats.HP)
+	}
+	cache[i] = bound
+	return bound
+}
+
+// defending returns the value indexes [lo, hi) for which a hit explains
+// the HP readings of a defender with maxHP HP.
+func (c *damageCurve) defending(maxHP int) (lo, hi int) {
+	p := c.sample
+	n := len(c.values.values)
+	beforeLo, beforeHi, ok := p.Before.hpRange(maxHP)
+	if !ok {
+		return 0, 0
+	}
+	afterLo, afterHi, ok := p.After.hpRange(maxHP)
+	if !ok {
+		return 0, 0
+	}
+	// Damage falls as the defensive stat rises
+	if p.LowerBound {
+		return 0, sort.Search(n, func(i int) bool { return c.bound(i, true) < float64(beforeLo) })
+	}
+	lo = sort.Search(n, func(i int) bool { return c.bound(i, false) <= float64(beforeHi-afterLo) })
+	hi = sort.Search(n, func(i int) bool { return c.bound(i, true) < float64(beforeLo-afterHi) })
+	return lo, hi
+}
+
+// attacking returns the value indexes [lo, hi) for which a hit explains
+// the defender's HP readings.
+func (c *damageCurve) attacking() (lo, hi int) {
+	const epsilon = 1e-9
+	p := c.sample
+	n := len(c.values.values)
+	beforeLo, beforeHi := p.Before.fraction()
+	afterLo, afterHi := p.After.fraction()
+	// Damage rises with the attacking stat
+	if p.LowerBound {
+		return sort.Search(n, func(i int) bool { return c.bound(i, true) >= beforeLo-epsilon }), n
+	}
+	lo = sort.Search(n, func(i int) bool { return c.bound(i, true) >= beforeLo-afterHi-epsilon })
+	hi = sort.Search(n, func(i int) bool { return c.bound(i, false) > beforeHi-afterLo+epsilon })
+	return lo, hi
+}
+
+// curves returns a damage curve for each sample, sharing them between
+// samples with the same inputs.
+func curves(samples []*preparedSample, values *statValues, shared map[string]*damageCurve) []*damageCurve {
+	result := make([]*damageCurve, len(samples))
+	for i, sample := range samples {
+		curve, ok := shared[sample.input]
+		if !ok {
+			curve = newDamageCurve(sample, values)
+			shared[sample.input] = curve
+		}
+		// Same inputs, but its own HP readings
+		result[i] = &damageCurve{sample: sample, values: values, least: curve.least, most: curve.most}
+	}
+	return result
+}
+
+// spreadFit is the spreads consistent with every hit: for each HP spread, a
+// range of values of the stat. Attacking stats have a single, unused HP
+// spread.
+type spreadFit struct {
+	stat    string
+	bulk    bool
+	hps     []statSpread
+	spreads []statSpread
+	indexes []int    // Value index of each spread
+	ranges  [][2]int // Value indexes [lo, hi) consistent with each HP spread
+}
+
+func newSpreadFit(base calc.Pokemon, stat string, bulk bool) (*spreadFit, *statValues) {
+	fit := &spreadFit{stat: stat, bulk: bulk, hps: []statSpread{{}}, spreads: statSpreads(base, stat)}
+	if bulk {
+		fit.hps = statSpreads(base, "hp")
+	}
+	values := newStatValues(fit.spreads, stat)
+	for _, spread := range fit.spreads {
+		fit.indexes = append(fit.indexes, values.index(spread.value))
+	}
+	fit.ranges = make([][2]int, len(fit.hps))
+	return fit, values
+}
+
+// each calls yield with every consistent spread.
+func (f *spreadFit) each(yield func(EVSpread)) {
+	for h, hp := range f.hps {
+		for i, spread := range f.spreads {
+			if index := f.indexes[i]; index >= f.ranges[h][0] && index < f.ranges[h][1] {
+				yield(EVSpread{HP: hp.evs, EVs: spread.evs, Nature: spread.nature})
+			}
+		}
+	}
+}
+
+// count returns how many spreads are consistent.
+func (f *spreadFit) count() int {
+	n := 0
+	f.each(func(EVSpread) { n++ })
+	return n
+}
+
+// hpEVs returns the HP EVs with at least one consistent spread.
+func (f *spreadFit) hpEVs() map[int]bool {
+	hps := make(map[int]bool)
+	f.each(func(spread EVSpread) { hps[spread.HP] = true })
+	return hps
+}
+
+// keepHP rules out the HP spreads whose EVs aren't in hps.
+func (f *spreadFit) keepHP(hps map[int]bool) {
+	for h, hp := range f.hps {
+		if !hps[hp.evs] {
+			f.ranges[h] = [2]int{}
+		}
+	}
+}
+
+// inferBulk finds the HP and defensive spreads consistent with every hit
+// the Pokémon took. Damage doesn't depend on HP, so each hit's curve serves
+// every HP value.
+func inferBulk(samples []*preparedSample, stat string) *spreadFit {
+	fit, values := newSpreadFit(samples[0].target(), stat, true)
+	sampleCurves := curves(samples, values, make(map[string]*damageCurve))
+	for h, hp := range fit.hps {
+		lo, hi := 0, len(values.values)
+		for _, curve := range sampleCurves {
+			curveLo, curveHi := curve.defending(hp.value)
+			lo, hi = max(lo, curveLo), min(hi, curveHi)
+			if lo >= hi {
+				break
+			}
+		}
+		fit.ranges[h] = [2]int{lo, hi}
+	}
+	return fit
+}
+
+// inferPower finds the attacking spreads consistent with every hit the
+// Pokémon dealt.
+func inferPower(samples []*preparedSample, stat string) *spreadFit {
+	fit, values := newSpreadFit(samples[0].target(), stat, false)
+	lo, hi := 0, len(values.values)
+	for _, curve := range curves(samples, values, make(map[string]*damageCurve)) {
+		curveLo, curveHi := curve.attacking()
+		lo, hi = max(lo, curveLo), min(hi, curveHi)
+		if lo >= hi {
+			break
+		}
+	}
+	fit.ranges[0] = [2]int{lo, hi}
+	return fit
+}
+
+// likelySpreads picks out and labels the most plausible consistent spreads,
+// best first: those using the usual EV amounts (0, 4 and 252), then neutral
+// natures over boosting over hindering, then fewer EVs.
+func (f *spreadFit) likelySpreads() []EVSpread {
+	best := make([]EVSpread, 0, maxLikelySpreads+1)
+	f.each(func(spread EVSpread) {
+		i := sort.Search(len(best), func(i int) bool { return moreLikely(spread, best[i]) })
+		if i == maxLikelySpreads {
+			return
+		}
+		best = append(best, EVSpread{})
+		copy(best[i+1:], best[i:])
+		best[i] = spread
+		best = best[:min(len(best), maxLikelySpreads)]
+	})
+
+	for i := range best {
+		best[i].Label = spreadLabel(best[i].EVs, best[i].Nature, f.stat)
+		if f.bulk {
+			best[i].Label = fmt.Sprintf("%d HP / %s", best[i].HP, best[i].Label)
+		}
+	}
+	return best
+}
+
+// natureRank orders neutral natures before boosting before hindering.
+var natureRank = map[string]int{"": 0, "+": 1, "-": 2}
+
+func moreLikely(a, b EVSpread) bool {
+	if usualA, usualB := usualEVs(a), usualEVs(b); usualA != usualB {
+		return usualA > usualB
+	}
+	if natureRank[a.Nature] != natureRank[b.Nature] {
+		return natureRank[a.Nature] < natureRank[b.Nature]
+	}
+	return a.HP+a.EVs < b.HP+b.EVs
+}
+
+// usualEVs counts the spread's EV amounts that are 0, 4 or 252.
+func usualEVs(s EVSpread) int {
+	usual := func(evs int) int {
+		if evs == 0 || evs == 4 || evs == 252 {
+			return 1
+		}
+		return 0
+	}
+	return usual(s.HP) + usual(s.EVs)
+}
+
+// withSpread returns set with its EVs and nature replaced.
+func withSpread(set calc.Pokemon, evs map[string]int, nature string) calc.Pokemon {
+	set.EVs = evs
+	set.Nature = nature
+	set.IVs = nil
+	return set
+}
+
+// natureFor returns a nature that raises ("+"), lowers ("-") or leaves the
+// stat alone ("").
+func natureFor(stat, sign string) string {
+	switch sign {
+	case "+":
+		return raisingNatures[stat]
+	case "-":
+		return loweringNatures[stat]
+	}
+	return neutralNature
+}
+
+// spreadLabel describes an investment the way damage calculators do, e.g.
+// "252+ SpA".
+func spreadLabel(evs int, nature, stat string) string {
+	return fmt.Sprintf("%d%s %s", evs, nature, spreadStatLabels[stat])
+}
+
+func dexStat(stats dex.Stats, stat string) int {
+	switch stat {
+	case "hp":
+		return stats.HP
+	case "atk":
+		return stats.Atk
+	case "def":
+		return stats.Def
+	case "spa":
+		return stats.SpA
+	case "spd":
+		return stats.SpD
+	}
+	return stats.Spe
+}
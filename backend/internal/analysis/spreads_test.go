@@ -0,0 +1,310 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/calc"
+)
+
+func TestHPReadingRange(t *testing.T) {
+	tests := []struct {
+		reading HPReading
+		maxHP   int
+		lo, hi  int
+		ok      bool
+	}{
+		{HPReading{100, 100}, 201, 201, 201, true},
+		{HPReading{0, 100}, 201, 0, 0, true},
+		{HPReading{99, 100}, 201, 197, 200, true},
+		{HPReading{50, 100}, 201, 99, 100, true},
+		{HPReading{1, 100}, 201, 1, 2, true},
+		// Exact readings only fit their own max HP
+		{HPReading{120, 201}, 201, 120, 120, true},
+		{HPReading{120, 201}, 202, 120, 120, false},
+		// Too few HP for every percentage to be reachable
+		{HPReading{51, 100}, 50, 26, 25, false},
+	}
+
+	for _, tt := range tests {
+		lo, hi, ok := tt.reading.hpRange(tt.maxHP)
+		if lo != tt.lo || hi != tt.hi || ok != tt.ok {
+			t.Errorf("%+v at %d max HP: expected [%d, %d] %v, got [%d, %d] %v", tt.reading, tt.maxHP, tt.lo, tt.hi, tt.ok, lo, hi, ok)
+		}
+	}
+
+	if lo, hi := (HPReading{99, 100}).fraction(); lo != 0.98 || hi != 1 {
+		t.Errorf("expected 99%% to be (0.98, 1], got (%v, %v]", lo, hi)
+	}
+}
+
+// spreadHit builds the sample Showdown would show for a hit at full HP with
+// the given roll: percentages, rounded up, and 99% short of full.
+func spreadHit(t *testing.T, attacker, defender calc.Pokemon, move string, field calc.Field, roll int) DamageSample {
+	t.Helper()
+	result, err := calc.Calculate(attacker, defender, calc.Move{Name: move}, field)
+	if err != nil {
+		t.Fatalf("%s: %v", move, err)
+	}
+	maxHP := result.DefenderStats.HP
+	remaining := max(0, maxHP-result.Rolls[roll])
+	percent := int(math.Ceil(float64(remaining) * 100 / float64(maxHP)))
+	if percent == 100 && remaining < maxHP {
+		percent = 99
+	}
+
+	// The sample only knows what the battle revealed
+	attacker.EVs, attacker.Nature = nil, ""
+	defender.EVs, defender.Nature = nil, ""
+	return DamageSample{
+		Attacker:   SamplePokemon{Pokemon: attacker.Species, Player: "player1", Set: attacker},
+		Defender:   SamplePokemon{Pokemon: defender.Species, Player: "player2", Set: defender},
+		Move:       calc.Move{Name: move},
+		Field:      field,
+		Before:     HPReading{100, 100},
+		After:      HPReading{percent, 100},
+		LowerBound: remaining == 0,
+	}
+}
+
+// fitAllows reports whether a fit keeps a spread giving the same stats as
+// truth's.
+func fitAllows(t *testing.T, fit *spreadFit, truth calc.Pokemon) bool {
+	t.Helper()
+	want, err := calc.Stats(truth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	fit.each(func(spread EVSpread) {
+		evs := map[string]int{fit.stat: spread.EVs}
+		if fit.bulk {
+			evs["hp"] = spread.HP
+		}
+		stats, err := calc.Stats(withSpread(truth, evs, natureFor(fit.stat, spread.Nature)))
+		if err == nil && dexStat(stats, fit.stat) == dexStat(want, fit.stat) && (!fit.bulk || stats.HP == want.HP) {
+			found = true
+		}
+	})
+	return found
+}
+
+func prepareAll(t *testing.T, samples []DamageSample, pokemon, player string) []*preparedSample {
+	t.Helper()
+	var prepared []*preparedSample
+	for _, sample := range samples {
+		if p, ok := prepareSample(sample, pokemon, player); ok {
+			prepared = append(prepared, p)
+		}
+	}
+	return prepared
+}
+
+func TestInferSpreadBulk(t *testing.T) {
+	amoonguss := calc.Pokemon{Species: "Amoonguss", Level: 50, Nature: "Bold", EVs: map[string]int{"hp": 236, "def": 156, "spd": 116}}
+	incineroar := calc.Pokemon{Species: "Incineroar", Level: 50, Nature: "Adamant", EVs: map[string]int{"atk": 180}}
+	urshifu := calc.Pokemon{Species: "Urshifu", Level: 50, Ability: "Unseen Fist", Nature: "Jolly", EVs: map[string]int{"atk": 252}}
+	flutter := calc.Pokemon{Species: "Flutter Mane", Level: 50, Nature: "Timid", EVs: map[string]int{"spa": 252}}
+
+	samples := []DamageSample{
+		spreadHit(t, incineroar, amoonguss, "Flare Blitz", calc.Field{SingleTarget: true}, 0),
+		spreadHit(t, incineroar, amoonguss, "Flare Blitz", calc.Field{SingleTarget: true}, 15),
+		spreadHit(t, urshifu, amoonguss, "Wicked Blow", calc.Field{SingleTarget: true}, 7),
+		spreadHit(t, urshifu, amoonguss, "Close Combat", calc.Field{SingleTarget: true, Reflect: true}, 3),
+		spreadHit(t, flutter, amoonguss, "Moonblast", calc.Field{SingleTarget: true}, 10),
+		spreadHit(t, flutter, amoonguss, "Dazzling Gleam", calc.Field{}, 4),
+	}
+
+	inference := InferSpread("Amoonguss", "player2", samples)
+	if inference == nil || inference.Pokemon != "Amoonguss" || inference.Player != "player2" {
+		t.Fatalf("expected an inference for player2's Amoonguss, got %+v", inference)
+	}
+	if len(inference.Stats) != 2 || inference.Stats[0].Stat != "def" || inference.Stats[1].Stat != "spd" {
+		t.Fatalf("expected Def and SpD, got %+v", inference.Stats)
+	}
+	def, spd := inference.Stats[0], inference.Stats[1]
+	if def.Samples != 4 || spd.Samples != 2 {
+		t.Errorf("expected 4 physical and 2 special hits, got %d and %d", def.Samples, spd.Samples)
+	}
+	if def.Candidates == 0 || spd.Candidates == 0 {
+		t.Fatalf("expected candidates for both, got %+v", inference.Stats)
+	}
+	if len(def.Likely) == 0 || len(def.Likely) > maxLikelySpreads || !strings.HasSuffix(def.Likely[0].Label, " Def") ||
+		!strings.Contains(def.Likely[0].Label, " HP / ") {
+		t.Errorf("expected labelled HP / Def spreads, got %+v", def.Likely)
+	}
+
+	// The true spread is among them
+	prepared := prepareAll(t, samples, "Amoonguss", "player2")
+	var physical, special []*preparedSample
+	for _, p := range prepared {
+		if p.stat == "def" {
+			physical = append(physical, p)
+		} else {
+			special = append(special, p)
+		}
+	}
+	if !fitAllows(t, inferBulk(physical, "def"), amoonguss) {
+		t.Error("expected the true HP and Def to fit the physical hits")
+	}
+	if !fitAllows(t, inferBulk(special, "spd"), amoonguss) {
+		t.Error("expected the true HP and SpD to fit the special hits")
+	}
+
+	// Def and SpD agree on HP
+	defFit, spdFit := inferBulk(physical, "def"), inferBulk(special, "spd")
+	defFit.keepHP(spdFit.hpEVs())
+	for hp := range defFit.hpEVs() {
+		if !spdFit.hpEVs()[hp] {
+			t.Errorf("expected %d HP to fit the special hits too", hp)
+		}
+	}
+
+	// No spread survives a weak hit doing nearly everything
+	weak := spreadHit(t, incineroar, amoonguss, "Flare Blitz", calc.Field{SingleTarget: true}, 0)
+	weak.Move.Name, weak.After = "Knock Off", HPReading{5, 100}
+	inference = InferSpread("Amoonguss", "player2", append(samples, weak))
+	if inference.Stats[0].Candidates != 0 || len(inference.Stats[0].Likely) != 0 {
+		t.Errorf("expected no spread to fit, got %+v", inference.Stats[0])
+	}
+}
+
+func TestInferSpreadPower(t *testing.T) {
+	flutter := calc.Pokemon{Species: "Flutter Mane", Level: 50, Nature: "Modest", EVs: map[string]int{"spa": 252}}
+	incineroar := calc.Pokemon{Species: "Incineroar", Level: 50, EVs: map[string]int{"hp": 252, "spd": 4}}
+	rillaboom := calc.Pokemon{Species: "Rillaboom", Level: 50, EVs: map[string]int{"hp": 252, "spd": 116}}
+
+	samples := []DamageSample{
+		spreadHit(t, flutter, incineroar, "Moonblast", calc.Field{SingleTarget: true}, 3),
+		spreadHit(t, flutter, rillaboom, "Moonblast", calc.Field{SingleTarget: true}, 12),
+		spreadHit(t, flutter, rillaboom, "Shadow Ball", calc.Field{SingleTarget: true}, 8),
+		// Seismic Toss's damage says nothing about a spread
+		{Attacker: SamplePokemon{Pokemon: "Flutter Mane", Player: "player1", Set: calc.Pokemon{Species: "Flutter Mane"}},
+			Defender: SamplePokemon{Pokemon: "Rillaboom", Player: "player2", Set: calc.Pokemon{Species: "Rillaboom"}},
+			Move:     calc.Move{Name: "Seismic Toss"}, Before: HPReading{100, 100}, After: HPReading{70, 100}},
+	}
+
+	inference := InferSpread("Flutter Mane", "player1", samples)
+	if inference == nil || len(inference.Stats) != 1 || inference.Stats[0].Stat != "spa" {
+		t.Fatalf("expected a SpA inference, got %+v", inference)
+	}
+	spa := inference.Stats[0]
+	if spa.Samples != 3 || spa.Candidates == 0 {
+		t.Errorf("expected candidates from 3 hits, got %+v", spa)
+	}
+	for _, spread := range spa.Likely {
+		if spread.HP != 0 || !strings.HasSuffix(spread.Label, " SpA") || strings.Contains(spread.Label, "HP") {
+			t.Errorf("expected a SpA-only spread, got %+v", spread)
+		}
+	}
+	if !fitAllows(t, inferPower(prepareAll(t, samples, "Flutter Mane", "player1"), "spa"), flutter) {
+		t.Error("expected the true SpA to fit")
+	}
+
+	if InferSpread("Rillaboom", "player1", samples) != nil {
+		t.Error("expected nothing for a Pokémon on the other side")
+	}
+}
+
+func TestInferSpreadProtectedSpreadTarget(t *testing.T) {
+	flutter := calc.Pokemon{Species: "Flutter Mane", Level: 50, Nature: "Timid", EVs: map[string]int{"spa": 252}}
+	amoonguss := calc.Pokemon{Species: "Amoonguss", Level: 50, Nature: "Calm", EVs: map[string]int{"hp": 236, "spd": 196}}
+	hit := spreadHit(t, flutter, amoonguss, "Dazzling Gleam", calc.Field{}, 7)
+
+	// Incineroar protected, so [spread] names Amoonguss alone, but the hit
+	// still took the spread reduction
+	log := fmt.Sprintf(`|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Flutter Mane, L50|
+|poke|p2|Incineroar, L50|
+|poke|p2|Amoonguss, L50|
+|start
+|switch|p1a: Flutter Mane|Flutter Mane, L50|100/100
+|switch|p2a: Incineroar|Incineroar, L50|100/100
+|switch|p2b: Amoonguss|Amoonguss, L50|100/100
+|turn|1
+|move|p2a: Incineroar|Protect|p2a: Incineroar
+|-singleturn|p2a: Incineroar|Protect
+|move|p1a: Flutter Mane|Dazzling Gleam|p2b: Amoonguss|[spread] p2b
+|-activate|p2a: Incineroar|move: Protect
+|-damage|p2b: Amoonguss|%d/100
+|upkeep
+|win|Alice`, hit.After.Current)
+
+	summary, err := ParseShowdownLog(log)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(summary.DamageSamples) != 1 || summary.DamageSamples[0].Field.SingleTarget {
+		t.Fatalf("expected one spread hit, got %+v", summary.DamageSamples)
+	}
+
+	inference := InferSpread("Amoonguss", "player2", summary.DamageSamples)
+	if inference == nil || len(inference.Stats) != 1 || inference.Stats[0].Candidates == 0 {
+		t.Fatalf("expected SpD candidates, got %+v", inference)
+	}
+	if !fitAllows(t, inferBulk(prepareAll(t, summary.DamageSamples, "Amoonguss", "player2"), "spd"), amoonguss) {
+		t.Error("expected the true HP and SpD to fit the spread hit")
+	}
+}
+
+func TestInferPlayerSpreads(t *testing.T) {
+	flutter := calc.Pokemon{Species: "Flutter Mane", Level: 50, Nature: "Modest", EVs: map[string]int{"spa": 252}}
+	incineroar := calc.Pokemon{Species: "Incineroar", Level: 50, Nature: "Careful", EVs: map[string]int{"hp": 252, "spd": 100}}
+
+	first := spreadHit(t, flutter, incineroar, "Moonblast", calc.Field{SingleTarget: true}, 2)
+	// The second battle has Bob as player1, with the sides swapped
+	second := spreadHit(t, flutter, incineroar, "Moonblast", calc.Field{SingleTarget: true}, 14)
+	second.Attacker.Player, second.Defender.Player = "player2", "player1"
+
+	battles := []*BattleSummary{
+		{Player1: Player{Name: "Alice"}, Player2: Player{Name: "Bob"}, DamageSamples: []DamageSample{first}},
+		{Player1: Player{Name: "bob"}, Player2: Player{Name: "Carol"}, DamageSamples: []DamageSample{second}},
+		{Player1: Player{Name: "Dave"}, Player2: Player{Name: "Erin"}, DamageSamples: []DamageSample{first}},
+	}
+
+	inferences := InferPlayerSpreads("Bob", battles)
+	if len(inferences) != 1 {
+		t.Fatalf("expected only Incineroar, got %+v", inferences)
+	}
+	inference := inferences[0]
+	if inference.Pokemon != "Incineroar" || inference.Player != "Bob" {
+		t.Errorf("expected Bob's Incineroar, got %s's %s", inference.Player, inference.Pokemon)
+	}
+	if len(inference.Stats) != 1 || inference.Stats[0].Stat != "spd" || inference.Stats[0].Samples != 2 {
+		t.Errorf("expected SpD from both battles, got %+v", inference.Stats)
+	}
+
+	// Flutter Mane is on opposite sides of Bob, so it's only his opponents'
+	if inferences := InferPlayerSpreads("Alice", battles); len(inferences) != 1 || inferences[0].Pokemon != "Flutter Mane" ||
+		inferences[0].Stats[0].Samples != 1 {
+		t.Errorf("expected Alice's Flutter Mane from one battle, got %+v", inferences)
+	}
+	if inferences := InferPlayerSpreads("Zed", battles); len(inferences) != 0 {
+		t.Errorf("expected nothing for a player in no battle, got %+v", inferences)
+	}
+}
+
+func TestParseShowdownLogAttachesSpreads(t *testing.T) {
+	summary, err := ParseShowdownLog(damageSampleBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spreads := make(map[string]*SpreadInference)
+	for _, team := range [][]Pokémon{summary.Player1.Team, summary.Player2.Team} {
+		for _, poke := range team {
+			spreads[poke.Name] = poke.Spread
+		}
+	}
+	for _, name := range []string{"Flutter Mane", "Rillaboom", "Incineroar", "Amoonguss"} {
+		if spreads[name] == nil {
+			t.Errorf("expected a spread for %s", name)
+		}
+	}
+	if spreads["Incineroar"] != nil && spreads["Incineroar"].Player != "player2" {
+		t.Errorf("expected player2's Incineroar, got %+v", spreads["Incineroar"])
+	}
+}
@@ -32,8 +32,7 @@ func ClassifyTeam(team []Pokémon) TeamClassification {
 		}
 
 		// Check ability-based weather setters
-		ability := strings.ToLower(poke.Ability)
-		switch ability {
+		switch toID(poke.Ability) {
 		case "drought":
 			classification.HasWeatherSetter = true
 			classification.WeatherType = "sun"
@@ -42,19 +41,26 @@ func ClassifyTeam(team []Pokémon) TeamClassification {
 			classification.HasWeatherSetter = true
 			classification.WeatherType = "rain"
 			classification.WeatherSetters = append(classification.WeatherSetters, poke.Name)
-		case "sand stream":
+		case "sandstream":
 			classification.HasWeatherSetter = true
 			classification.WeatherType = "sand"
 			classification.WeatherSetters = append(classification.WeatherSetters, poke.Name)
-		case "snow warning":
+		case "snowwarning":
 			classification.HasWeatherSetter = true
 			classification.WeatherType = "snow"
 			classification.WeatherSetters = append(classification.WeatherSetters, poke.Name)
+		case "orichalcumpulse":
+			classification.HasWeatherSetter = true
+			classification.WeatherType = "sun"
+			classification.WeatherSetters = append(classification.WeatherSetters, poke.Name)
+		case "psychicsurge":
+			classification.HasPsyTerrain = true
+			classification.PsyTerrainUsers = append(classification.PsyTerrainUsers, poke.Name)
 		}
 
 		// Check item
-		item := strings.ToLower(poke.Item)
-		if item == "choice specs" || item == "choice band" || item == "choice scarf" {
+		item := toID(poke.Item)
+		if item == "choicespecs" || item == "choiceband" || item == "choicescarf" {
 			classification.HasChoiceItems = true
 			classification.ChoiceUsers = append(classification.ChoiceUsers, poke.Name)
 		}
@@ -62,7 +68,7 @@ func ClassifyTeam(team []Pokémon) TeamClassification {
 		// Check moves
 		for _, move := range poke.Moves {
 			moveName := strings.ToLower(move.Name)
-			moveID := strings.ToLower(move.ID)
+			moveID := toID(move.ID)
 
 			switch {
 			case moveName == "trick room" || moveID == "trickroom":
@@ -0,0 +1,81 @@
+package analysis
+
+import "strings"
+
+// Team preview choices. In VGC each player brings four of their six and
+// leads with two; the log only shows the four as they're sent out, so a
+// Pokémon that stays in the back all game is never named.
+
+// RevealIllusion replaces the Pokémon an Illusion user was disguised as with
+// the real one when |replace| gives it away, so the disguise isn't counted
+// as brought or as a lead.
+func (st *StateTracker) RevealIllusion(slot, pokeName string) {
+	slot = normalizeSlot(slot)
+	playerID := slotSide(slot)
+	disguise, ok := st.activePokemon[slot]
+	if !ok || disguise.Name == pokeName {
+		return
+	}
+	st.leads[playerID] = replaceName(st.leads[playerID], disguise.Name, pokeName)
+	st.brought[playerID] = replaceName(st.brought[playerID], disguise.Name, pokeName)
+}
+
+// GetBackLine returns the brought Pokémon that didn't lead, in the order they
+// were sent out.
+func (st *StateTracker) GetBackLine(playerID string) []string {
+	return difference(st.brought[playerID], st.leads[playerID])
+}
+
+// GetBenched returns the team preview Pokémon the player left out, and
+// whether they're known. They only are once every Pokémon the player brought
+// has been sent out; until then the list is empty.
+func (st *StateTracker) GetBenched(playerID string) ([]string, bool) {
+	benched := []string{}
+	if st.GetUnrevealed(playerID) > 0 || len(st.brought[playerID]) == 0 {
+		return benched, false
+	}
+	for _, poke := range st.teams[playerID] {
+		if !broughtAs(st.brought[playerID], poke.Name) {
+			benched = append(benched, poke.Name)
+		}
+	}
+	return benched, true
+}
+
+// GetUnrevealed returns how many of the Pokémon the player brought were
+// never sent out.
+func (st *StateTracker) GetUnrevealed(playerID string) int {
+	size, ok := st.teamSizes[playerID]
+	if !ok || size < len(st.brought[playerID]) {
+		return 0
+	}
+	return size - len(st.brought[playerID])
+}
+
+// broughtAs reports whether the team preview entry previewName is one of the
+// brought Pokémon. Preview hides some formes, e.g. "Urshifu-*" for either
+// Urshifu.
+func broughtAs(brought []string, previewName string) bool {
+	base, hidden := strings.CutSuffix(previewName, "-*")
+	for _, name := range brought {
+		if name == previewName || (hidden && (name == base || strings.HasPrefix(name, base+"-"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceName swaps old for name in names, dropping old instead when name is
+// already there.
+func replaceName(names []string, old, name string) []string {
+	result := make([]string, 0, len(names))
+	for _, n := range names {
+		switch {
+		case n != old:
+			result = append(result, n)
+		case !contains(names, name):
+			result = append(result, name)
+		}
+	}
+	return result
+}
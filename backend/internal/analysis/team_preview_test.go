@@ -0,0 +1,104 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+const teamPreviewBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Incineroar, L50|
+|poke|p1|Rillaboom, L50|
+|poke|p1|Urshifu-*, L50|
+|poke|p1|Amoonguss, L50|
+|poke|p1|Flutter Mane, L50|
+|poke|p1|Zoroark-Hisui, L50|
+|poke|p2|Tornadus, L50|
+|poke|p2|Chien-Pao, L50|
+|poke|p2|Landorus, L50|
+|poke|p2|Iron Hands, L50|
+|poke|p2|Farigiraf, L50|
+|poke|p2|Gholdengo, L50|
+|teampreview|4
+|teamsize|p1|4
+|teamsize|p2|4
+|start
+|switch|p1a: Amoonguss|Amoonguss, L50|100/100
+|switch|p1b: Urshifu|Urshifu-Rapid-Strike, L50|100/100
+|switch|p2a: Tornadus|Tornadus, L50|100/100
+|switch|p2b: Chien-Pao|Chien-Pao, L50|100/100
+|turn|1
+|move|p2b: Chien-Pao|Sucker Punch|p1a: Amoonguss
+|-damage|p1a: Amoonguss|40/100
+|replace|p1a: Zoroark|Zoroark-Hisui, L50
+|-end|p1a: Zoroark|Illusion
+|upkeep
+|turn|2
+|move|p2b: Chien-Pao|Sucker Punch|p1a: Zoroark
+|-damage|p1a: Zoroark|0 fnt
+|faint|p1a: Zoroark
+|upkeep
+|switch|p1a: Incineroar|Incineroar, L50|100/100
+|turn|3
+|switch|p1b: Rillaboom|Rillaboom, L50|100/100
+|switch|p2a: Iron Hands|Iron Hands, L50|100/100
+|upkeep
+|turn|4
+|win|Alice`
+
+func TestParseShowdownLogTeamPreviewChoices(t *testing.T) {
+	summary, err := ParseShowdownLog(teamPreviewBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p1 := summary.Player1
+	if !reflect.DeepEqual(p1.Leads, []string{"Zoroark-Hisui", "Urshifu-Rapid-Strike"}) {
+		t.Errorf("expected Illusion disguise to be replaced in leads, got %v", p1.Leads)
+	}
+	if !reflect.DeepEqual(p1.Brought, []string{"Zoroark-Hisui", "Urshifu-Rapid-Strike", "Incineroar", "Rillaboom"}) {
+		t.Errorf("unexpected brought Pokémon %v", p1.Brought)
+	}
+	if !reflect.DeepEqual(p1.BackLine, []string{"Incineroar", "Rillaboom"}) {
+		t.Errorf("unexpected back line %v", p1.BackLine)
+	}
+	if !p1.BenchedKnown || !reflect.DeepEqual(p1.Benched, []string{"Amoonguss", "Flutter Mane"}) {
+		t.Errorf("expected Amoonguss and Flutter Mane benched, got %v", p1.Benched)
+	}
+	if p1.Unrevealed != 0 {
+		t.Errorf("expected all of player 1's Pokémon revealed, got %d unrevealed", p1.Unrevealed)
+	}
+
+	p2 := summary.Player2
+	if !reflect.DeepEqual(p2.Leads, []string{"Tornadus", "Chien-Pao"}) {
+		t.Errorf("unexpected leads %v", p2.Leads)
+	}
+	if !reflect.DeepEqual(p2.BackLine, []string{"Iron Hands"}) {
+		t.Errorf("unexpected back line %v", p2.BackLine)
+	}
+	if p2.Unrevealed != 1 {
+		t.Errorf("expected 1 unrevealed Pokémon, got %d", p2.Unrevealed)
+	}
+	if p2.BenchedKnown || p2.Benched == nil || len(p2.Benched) != 0 {
+		t.Errorf("expected benched Pokémon to be unknown with one unrevealed, got %v", p2.Benched)
+	}
+}
+
+func TestBroughtAs(t *testing.T) {
+	brought := []string{"Urshifu-Rapid-Strike", "Incineroar"}
+	tests := []struct {
+		preview  string
+		expected bool
+	}{
+		{"Incineroar", true},
+		{"Urshifu-*", true},
+		{"Urshifu", false},
+		{"Amoonguss", false},
+	}
+
+	for _, tt := range tests {
+		if got := broughtAs(brought, tt.preview); got != tt.expected {
+			t.Errorf("broughtAs(%q) = %v, expected %v", tt.preview, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package analysis
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ParsePackedTeam decodes a team in Showdown's packed format, as sent in
+// |showteam| lines for open team sheet formats.
+//
+// Each Pokémon is separated by "]" and has the fields
+// NICKNAME|SPECIES|ITEM|ABILITY|MOVES|NATURE|EVS|GENDER|IVS|SHINY|LEVEL|MISC
+// where MISC is "HAPPINESS,POKEBALL,HIDDENPOWERTYPE,GIGANTAMAX,DYNAMAXLEVEL,TERATYPE".
+// Item, ability and move names are packed without spaces ("ChoiceSpecs"), so
+// they are expanded into display names here.
+func ParsePackedTeam(packed string) []Pokémon {
+	var team []Pokémon
+
+	for _, packedPoke := range strings.Split(packed, "]") {
+		if strings.TrimSpace(packedPoke) == "" {
+			continue
+		}
+
+		fields := strings.Split(packedPoke, "|")
+		field := func(i int) string {
+			if i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		// Species is omitted when it matches the nickname
+		species := field(1)
+		if species == "" {
+			species = field(0)
+		}
+		if species == "" {
+			continue
+		}
+
+		poke := Pokémon{
+			ID:        normalizeID(species),
+			Name:      species,
+			Item:      unpackName(field(2)),
+			Ability:   unpackName(field(3)),
+			Gender:    field(7),
+			Shiny:     field(9) == "S",
+			Level:     100,
+			Happiness: 255,
+			MaxHP:     100,
+			CurrentHP: 100,
+		}
+
+		if level := field(10); level != "" {
+			poke.Level = parseInt(level)
+		}
+
+		for _, moveStr := range strings.Split(field(4), ",") {
+			moveName := unpackName(moveStr)
+			if moveName == "" {
+				continue
+			}
+			poke.Moves = append(poke.Moves, Move{Name: moveName})
+		}
+
+		misc := strings.Split(field(11), ",")
+		if len(misc) > 0 && strings.TrimSpace(misc[0]) != "" {
+			poke.Happiness = parseInt(misc[0])
+		}
+		if len(misc) > 5 {
+			poke.TeraType = strings.TrimSpace(misc[5])
+		}
+
+		team = append(team, withDexData(poke))
+	}
+
+	return team
+}
+
+// MergeTeamSheet fills in details from a team sheet onto the Pokémon already
+// known from team preview, matching by species. A preview name hiding the
+// forme, such as "Urshifu-*", takes the forme the sheet names. Pokémon
+// missing from preview are appended.
+func MergeTeamSheet(team []Pokémon, sheet []Pokémon) []Pokémon {
+	for _, sheetPoke := range sheet {
+		merged := false
+		for i := range team {
+			if toID(team[i].Name) != toID(sheetPoke.Name) && !broughtAs([]string{sheetPoke.Name}, team[i].Name) {
+				continue
+			}
+			if strings.HasSuffix(team[i].Name, "-*") {
+				team[i].ID = sheetPoke.ID
+				team[i].Name = sheetPoke.Name
+				team[i].Types = sheetPoke.Types
+				team[i].Stats = sheetPoke.Stats
+			}
+			team[i].Item = sheetPoke.Item
+			team[i].Ability = sheetPoke.Ability
+			team[i].Moves = sheetPoke.Moves
+			team[i].TeraType = sheetPoke.TeraType
+			team[i].Shiny = sheetPoke.Shiny
+			team[i].Happiness = sheetPoke.Happiness
+			if team[i].Gender == "" {
+				team[i].Gender = sheetPoke.Gender
+			}
+			if team[i].Level == 0 {
+				team[i].Level = sheetPoke.Level
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			team = append(team, sheetPoke)
+		}
+	}
+	return team
+}
+
+// unpackName turns a packed name such as "ChoiceSpecs" into "Choice Specs".
+// Names that are already spaced are returned unchanged. Splitting on capitals
+// can't restore punctuation, so withDexData replaces the result with the
+// dex's name when it knows one.
+func unpackName(packed string) string {
+	packed = strings.TrimSpace(packed)
+	if packed == "" || strings.Contains(packed, " ") {
+		return packed
+	}
+
+	var b strings.Builder
+	runes := []rune(packed)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// toID converts a name to Showdown's ID form: lowercase letters and digits only.
+// "Sand Stream", "SandStream" and "sand-stream" all become "sandstream".
+func toID(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
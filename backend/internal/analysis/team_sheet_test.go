@@ -0,0 +1,245 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePackedTeam(t *testing.T) {
+	packed := `Ursaluna-Bloodmoon||LifeOrb|MindsEye|BloodMoon,HyperVoice,EarthPower,Protect|||M|||50|,,,,,Normal]Farigiraf||ThroatSpray|ArmorTail|HyperVoice,Psychic,TrickRoom,Protect|||F|||50|,,,,,Water`
+
+	team := ParsePackedTeam(packed)
+	if len(team) != 2 {
+		t.Fatalf("expected 2 Pokémon, got %d", len(team))
+	}
+
+	ursaluna := team[0]
+	if ursaluna.Name != "Ursaluna-Bloodmoon" {
+		t.Errorf("expected species from nickname, got %q", ursaluna.Name)
+	}
+	if ursaluna.Item != "Life Orb" {
+		t.Errorf("expected item 'Life Orb', got %q", ursaluna.Item)
+	}
+	if ursaluna.Ability != "Mind's Eye" {
+		t.Errorf("expected ability 'Mind's Eye', got %q", ursaluna.Ability)
+	}
+	if ursaluna.Gender != "M" || ursaluna.Level != 50 {
+		t.Errorf("expected male level 50, got %q level %d", ursaluna.Gender, ursaluna.Level)
+	}
+	if ursaluna.TeraType != "Normal" {
+		t.Errorf("expected tera type 'Normal', got %q", ursaluna.TeraType)
+	}
+	if len(ursaluna.Moves) != 4 {
+		t.Fatalf("expected 4 moves, got %d", len(ursaluna.Moves))
+	}
+	if ursaluna.Moves[0].Name != "Blood Moon" || ursaluna.Moves[0].ID != "bloodmoon" {
+		t.Errorf("expected Blood Moon/bloodmoon, got %q/%q", ursaluna.Moves[0].Name, ursaluna.Moves[0].ID)
+	}
+
+	farigiraf := team[1]
+	if farigiraf.Moves[2].ID != "trickroom" {
+		t.Errorf("expected trickroom, got %q", farigiraf.Moves[2].ID)
+	}
+	if farigiraf.TeraType != "Water" {
+		t.Errorf("expected tera type 'Water', got %q", farigiraf.TeraType)
+	}
+}
+
+func TestParsePackedTeamNicknameAndShiny(t *testing.T) {
+	packed := `Bolt|Pikachu|LightBall|Static|Thunderbolt|Timid|,,,252,4,252|F||S|50|200,,,,,Electric`
+
+	team := ParsePackedTeam(packed)
+	if len(team) != 1 {
+		t.Fatalf("expected 1 Pokémon, got %d", len(team))
+	}
+
+	poke := team[0]
+	if poke.Name != "Pikachu" {
+		t.Errorf("expected species Pikachu, got %q", poke.Name)
+	}
+	if !poke.Shiny {
+		t.Error("expected shiny")
+	}
+	if poke.Happiness != 200 {
+		t.Errorf("expected happiness 200, got %d", poke.Happiness)
+	}
+}
+
+func TestParsePackedTeamPunctuatedNames(t *testing.T) {
+	packed := `Gholdengo||ChoiceSpecs|GoodasGold|MakeItRain,ShadowBall,Trick,NastyPlot|||||||]Incineroar||SafetyGoggles|Intimidate|FakeOut,Uturn,WillOWisp,KnockOff|||||||`
+
+	team := ParsePackedTeam(packed)
+	if len(team) != 2 {
+		t.Fatalf("expected 2 Pokémon, got %d", len(team))
+	}
+	if team[0].Ability != "Good as Gold" {
+		t.Errorf("expected ability 'Good as Gold', got %q", team[0].Ability)
+	}
+	if team[0].Moves[0].Name != "Make It Rain" {
+		t.Errorf("expected 'Make It Rain', got %q", team[0].Moves[0].Name)
+	}
+	incineroar := team[1]
+	if incineroar.Moves[1].Name != "U-turn" || incineroar.Moves[2].Name != "Will-O-Wisp" {
+		t.Errorf("expected U-turn and Will-O-Wisp, got %q and %q", incineroar.Moves[1].Name, incineroar.Moves[2].Name)
+	}
+	if incineroar.Moves[1].ID != "uturn" {
+		t.Errorf("expected ID uturn, got %q", incineroar.Moves[1].ID)
+	}
+}
+
+func TestParsePackedTeamEmpty(t *testing.T) {
+	if team := ParsePackedTeam(""); len(team) != 0 {
+		t.Errorf("expected empty team, got %d", len(team))
+	}
+}
+
+func TestMergeTeamSheet(t *testing.T) {
+	team := []Pokémon{
+		{ID: "whimsicott", Name: "Whimsicott", Level: 50, Gender: "M"},
+		{ID: "incineroar", Name: "Incineroar", Level: 50, Gender: "M"},
+	}
+	sheet := []Pokémon{
+		{Name: "Whimsicott", Item: "Focus Sash", Ability: "Prankster", Moves: []Move{{ID: "tailwind", Name: "Tailwind"}}},
+		{Name: "Amoonguss", Item: "Rocky Helmet"},
+	}
+
+	merged := MergeTeamSheet(team, sheet)
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 Pokémon after merge, got %d", len(merged))
+	}
+	if merged[0].Item != "Focus Sash" || merged[0].Ability != "Prankster" || len(merged[0].Moves) != 1 {
+		t.Errorf("expected Whimsicott to get sheet data, got %+v", merged[0])
+	}
+	if merged[1].Item != "" {
+		t.Errorf("expected Incineroar to be untouched, got item %q", merged[1].Item)
+	}
+	if merged[2].Name != "Amoonguss" {
+		t.Errorf("expected Amoonguss to be appended, got %q", merged[2].Name)
+	}
+}
+
+func TestMergeTeamSheetHiddenForme(t *testing.T) {
+	team := []Pokémon{
+		{ID: "urshifu*", Name: "Urshifu-*", Level: 50, Stats: Stats{Attack: 130}},
+		{ID: "incineroar", Name: "Incineroar", Level: 50},
+	}
+	sheet := ParsePackedTeam(`Urshifu-Rapid-Strike||ChoiceScarf|UnseenFist|SurgingStrikes,CloseCombat,Uturn,AquaJet|||||||]Incineroar||SafetyGoggles|Intimidate|FakeOut|||||||`)
+
+	merged := MergeTeamSheet(team, sheet)
+	if len(merged) != 2 {
+		t.Fatalf("expected the sheet's Urshifu to replace the preview's, got %d Pokémon", len(merged))
+	}
+	urshifu := merged[0]
+	if urshifu.Name != "Urshifu-Rapid-Strike" || urshifu.ID != "urshifurapidstrike" || urshifu.Item != "Choice Scarf" {
+		t.Errorf("expected Urshifu-Rapid-Strike with its sheet, got %+v", urshifu)
+	}
+	if len(urshifu.Types) != 2 || urshifu.Types[0] != "Fighting" || urshifu.Types[1] != "Water" {
+		t.Errorf("expected the forme's Fighting/Water typing, got %v", urshifu.Types)
+	}
+	if urshifu.Level != 50 {
+		t.Errorf("expected the preview level to be kept, got %d", urshifu.Level)
+	}
+}
+
+func TestParseShowdownLogHiddenFormeTeamSheet(t *testing.T) {
+	log := `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Urshifu-*, L50|
+|poke|p1|Incineroar, L50, M|
+|poke|p1|Amoonguss, L50, F|
+|poke|p2|Pikachu, L50|
+|showteam|p1|Urshifu-Rapid-Strike||ChoiceScarf|UnseenFist|SurgingStrikes|||||||]Incineroar||SafetyGoggles|Intimidate|FakeOut||||||50|]Amoonguss||RockyHelmet|Regenerator|Spore||||||50|
+|teamsize|p1|2
+|teamsize|p2|1
+|start
+|switch|p1a: Urshifu|Urshifu-Rapid-Strike, L50|100/100
+|switch|p1b: Incineroar|Incineroar, L50, M|100/100
+|switch|p2a: Pikachu|Pikachu, L50|100/100
+|turn|1
+|win|Alice
+`
+	summary, _ := ParseShowdownLog(log)
+
+	team := summary.Player1.Team
+	if len(team) != 3 || team[0].Name != "Urshifu-Rapid-Strike" {
+		t.Fatalf("expected the sheet's Urshifu-Rapid-Strike in place of Urshifu-*, got %+v", team)
+	}
+	if !reflect.DeepEqual(summary.Player1.Benched, []string{"Amoonguss"}) {
+		t.Errorf("expected only Amoonguss benched, got %v", summary.Player1.Benched)
+	}
+
+	// Without a sheet, sending it out reveals the forme
+	var noSheet []string
+	for _, line := range strings.Split(log, "\n") {
+		if !strings.HasPrefix(line, "|showteam|") {
+			noSheet = append(noSheet, line)
+		}
+	}
+	summary, _ = ParseShowdownLog(strings.Join(noSheet, "\n"))
+	team = summary.Player1.Team
+	if len(team) != 3 || team[0].Name != "Urshifu-Rapid-Strike" || len(team[0].Types) != 2 {
+		t.Fatalf("expected Urshifu-* to become Urshifu-Rapid-Strike on switching in, got %+v", team)
+	}
+	bench := summary.Turns[0].StateAfter.Player1Bench
+	if len(bench) != 1 || bench[0].Name != "Amoonguss" {
+		t.Errorf("expected only Amoonguss on the bench, got %+v", bench)
+	}
+}
+
+func TestUnpackName(t *testing.T) {
+	tests := []struct {
+		packed   string
+		expected string
+	}{
+		{"ChoiceSpecs", "Choice Specs"},
+		{"TrickRoom", "Trick Room"},
+		{"Protect", "Protect"},
+		{"Sitrus Berry", "Sitrus Berry"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := unpackName(tt.packed); got != tt.expected {
+			t.Errorf("unpackName(%q): expected %q, got %q", tt.packed, tt.expected, got)
+		}
+	}
+}
+
+func TestParseShowdownLogShowteamClassification(t *testing.T) {
+	log := `|player|p1|Player1|giovanni|1487
+|player|p2|Player2|steven|1398
+|tier|[Gen 9] VGC 2025 Reg H (Bo3)
+|poke|p1|Whimsicott, L50, M|
+|poke|p1|Farigiraf, L50, F|
+|poke|p2|Torkoal, L50, M|
+|poke|p2|Lilligant-Hisui, L50, F|
+|showteam|p1|Whimsicott||FocusSash|Prankster|Moonblast,Tailwind,Encore,SunnyDay|||M|||50|,,,,,Ghost]Farigiraf||ThroatSpray|ArmorTail|HyperVoice,Psychic,TrickRoom,Protect|||F|||50|,,,,,Water
+|showteam|p2|Torkoal||Charcoal|Drought|Eruption,HeatWave,EarthPower,Protect|||M|||50|,,,,,Fire]Lilligant-Hisui||ChoiceScarf|Chlorophyll|CloseCombat,LeafBlade,SleepPowder,Protect|||F|||50|,,,,,Fighting
+|start
+|turn|1
+|win|Player1`
+
+	summary, _ := ParseShowdownLog(log)
+	if summary == nil {
+		t.Fatal("expected summary")
+	}
+
+	whimsicott := summary.Player1.Team[0]
+	if whimsicott.Item != "Focus Sash" || whimsicott.Ability != "Prankster" || whimsicott.TeraType != "Ghost" {
+		t.Errorf("expected team sheet merged into Whimsicott, got %+v", whimsicott)
+	}
+	if len(whimsicott.Moves) != 4 {
+		t.Errorf("expected 4 moves on Whimsicott, got %d", len(whimsicott.Moves))
+	}
+
+	if summary.Player1.TeamArchetype != "TailRoom" {
+		t.Errorf("expected player1 archetype TailRoom, got %q", summary.Player1.TeamArchetype)
+	}
+	if summary.Player2.TeamArchetype != "Sun Offense" {
+		t.Errorf("expected player2 archetype Sun Offense, got %q", summary.Player2.TeamArchetype)
+	}
+	if !summary.Player2.Classification.HasChoiceItems {
+		t.Error("expected player2 choice item to be detected")
+	}
+}
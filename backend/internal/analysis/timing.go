@@ -0,0 +1,76 @@
+package analysis
+
+import "time"
+
+// turnClock derives wall-clock timing from the |t:|<unix> markers in a log.
+//
+// Showdown writes a timestamp whenever a request resolves. The last timestamp
+// before |turn|N marks when players were asked for their turn N choices, and
+// the first timestamp after it marks when both had chosen, so the gap between
+// them is the decision time for the turn.
+type turnClock struct {
+	first         int64
+	last          int64
+	turnStart     int64
+	awaitingMoves bool
+}
+
+// Tick records a |t:| timestamp. The first one after a turn begins ends that
+// turn's decision phase.
+func (c *turnClock) Tick(timestamp int64, current *Turn) {
+	if timestamp <= 0 {
+		return
+	}
+	if c.first == 0 {
+		c.first = timestamp
+	}
+	c.last = timestamp
+
+	if c.awaitingMoves && current != nil && c.turnStart > 0 {
+		current.DecisionTimeSec = int(timestamp - c.turnStart)
+		c.awaitingMoves = false
+	}
+}
+
+// BeginTurn closes out the previous turn's duration and starts timing a new one.
+func (c *turnClock) BeginTurn(previous *Turn) {
+	c.closeTurn(previous)
+	c.turnStart = c.last
+	c.awaitingMoves = true
+}
+
+// Finish closes out the final turn's duration.
+func (c *turnClock) Finish(last *Turn) {
+	c.closeTurn(last)
+	c.awaitingMoves = false
+}
+
+func (c *turnClock) closeTurn(turn *Turn) {
+	if turn != nil && c.turnStart > 0 {
+		turn.DurationSec = int(c.last - c.turnStart)
+	}
+}
+
+// HasTimestamps reports whether any |t:| markers were seen.
+func (c *turnClock) HasTimestamps() bool {
+	return c.first > 0
+}
+
+// StartTime is the first timestamp in the log, when the battle room opened.
+func (c *turnClock) StartTime() time.Time {
+	return time.Unix(c.first, 0).UTC()
+}
+
+// EndTime is the last timestamp in the log.
+func (c *turnClock) EndTime() time.Time {
+	return time.Unix(c.last, 0).UTC()
+}
+
+// DurationSec is the total wall-clock length of the battle.
+func (c *turnClock) DurationSec() int {
+	return int(c.last - c.first)
+}
+
+func parseTimestamp(s string) int64 {
+	return int64(parseInt(s))
+}
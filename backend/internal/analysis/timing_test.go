@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const timedBattleLog = `|t:|1700000000
+|player|p1|Alice|1|
+|player|p2|Bob|2|
+|poke|p1|Pikachu, L50|
+|poke|p2|Charizard, L50|
+|start
+|switch|p1a: Pikachu|Pikachu, L50|100/100
+|switch|p2a: Charizard|Charizard, L50|100/100
+|t:|1700000030
+|turn|1
+|inactive|Alice has 60 seconds left.
+|t:|1700000075
+|move|p1a: Pikachu|Thunderbolt|p2a: Charizard
+|-damage|p2a: Charizard|40/100
+|move|p2a: Charizard|Flamethrower|p1a: Pikachu
+|-damage|p1a: Pikachu|50/100
+|
+|t:|1700000080
+|turn|2
+|t:|1700000090
+|move|p1a: Pikachu|Thunderbolt|p2a: Charizard
+|-damage|p2a: Charizard|0 fnt
+|faint|p2a: Charizard
+|win|Alice
+`
+
+func TestParseShowdownLogTiming(t *testing.T) {
+	summary, err := ParseShowdownLog(timedBattleLog)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !summary.Timestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("expected start time from first |t:|, got %v", summary.Timestamp)
+	}
+	if !summary.EndTime.Equal(time.Unix(1700000090, 0)) {
+		t.Errorf("expected end time from last |t:|, got %v", summary.EndTime)
+	}
+	if summary.Duration != 90 {
+		t.Errorf("expected duration 90, got %d", summary.Duration)
+	}
+
+	if len(summary.Turns) != 2 {
+		t.Fatalf("expected 2 turns, got %d", len(summary.Turns))
+	}
+
+	expected := []struct{ decision, duration int }{
+		{45, 50},
+		{10, 10},
+	}
+	for i, want := range expected {
+		turn := summary.Turns[i]
+		if turn.DecisionTimeSec != want.decision {
+			t.Errorf("turn %d: expected decision time %d, got %d", turn.TurnNumber, want.decision, turn.DecisionTimeSec)
+		}
+		if turn.DurationSec != want.duration {
+			t.Errorf("turn %d: expected duration %d, got %d", turn.TurnNumber, want.duration, turn.DurationSec)
+		}
+	}
+}
+
+func TestParseEnhancedShowdownLogTiming(t *testing.T) {
+	summary, err := ParseEnhancedShowdownLog(timedBattleLog)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(summary.Turns) != 2 {
+		t.Fatalf("expected 2 turns, got %d", len(summary.Turns))
+	}
+	if summary.Turns[0].DecisionTimeSec != 45 || summary.Turns[0].DurationSec != 50 {
+		t.Errorf("expected turn 1 timing 45s/50s, got %ds/%ds",
+			summary.Turns[0].DecisionTimeSec, summary.Turns[0].DurationSec)
+	}
+}
+
+func TestParseShowdownLogWithoutTimestamps(t *testing.T) {
+	before := time.Now()
+	var lines []string
+	for _, line := range strings.Split(timedBattleLog, "\n") {
+		if !strings.HasPrefix(line, "|t:|") {
+			lines = append(lines, line)
+		}
+	}
+
+	summary, err := ParseShowdownLog(strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if summary.Timestamp.Before(before) {
+		t.Errorf("expected parse time as timestamp without |t:| markers, got %v", summary.Timestamp)
+	}
+	if summary.Duration != 0 {
+		t.Errorf("expected zero duration without |t:| markers, got %d", summary.Duration)
+	}
+	for _, turn := range summary.Turns {
+		if turn.DecisionTimeSec != 0 || turn.DurationSec != 0 {
+			t.Errorf("turn %d: expected no timing, got %ds/%ds", turn.TurnNumber, turn.DecisionTimeSec, turn.DurationSec)
+		}
+	}
+}
+
+func TestSampleReplayTiming(t *testing.T) {
+	summary, err := ParseShowdownReplayHTML(readSampleReplay(t))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !summary.Timestamp.Equal(time.Unix(1763188046, 0)) {
+		t.Errorf("expected start time 1763188046, got %v", summary.Timestamp.Unix())
+	}
+	if summary.Duration != 239 {
+		t.Errorf("expected duration 239, got %d", summary.Duration)
+	}
+
+	expected := map[int]struct{ decision, duration int }{
+		1: {29, 29},
+		3: {16, 30},
+		6: {23, 23},
+	}
+	for _, turn := range summary.Turns {
+		want, ok := expected[turn.TurnNumber]
+		if !ok {
+			continue
+		}
+		if turn.DecisionTimeSec != want.decision || turn.DurationSec != want.duration {
+			t.Errorf("turn %d: expected %ds/%ds, got %ds/%ds", turn.TurnNumber,
+				want.decision, want.duration, turn.DecisionTimeSec, turn.DurationSec)
+		}
+	}
+}
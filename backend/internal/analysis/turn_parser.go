@@ -1,11 +1,11 @@
 package analysis
 
-import "strings"
+import "github.com/dtsong/vgccorner/backend/internal/protocol"
 
 // TurnParser handles parsing detailed turn-by-turn information from battle logs
 type TurnParser struct {
 	currentTurn      *Turn
-	pendingEvents    []string
+	pendingEvents    []protocol.Event
 	actionOrder      int
 	lastMovedPokemon map[string]string // tracks which Pokemon just moved for impact attribution
 }
@@ -13,67 +13,88 @@ type TurnParser struct {
 // NewTurnParser creates a new turn parser
 func NewTurnParser() *TurnParser {
 	return &TurnParser{
-		pendingEvents:    []string{},
+		pendingEvents:    []protocol.Event{},
 		lastMovedPokemon: make(map[string]string),
 		actionOrder:      0,
 	}
 }
 
-// ProcessTurnEvent processes a single line from the battle log during a turn
-func (tp *TurnParser) ProcessTurnEvent(line string, tracker *StateTracker) {
-	if line == "" || !strings.HasPrefix(line, "|") {
-		return
+// ProcessTurnEvent processes a single line from the battle log during a turn.
+// It decodes the line and hands it to HandleEvent.
+func (tp *TurnParser) ProcessTurnEvent(line string) {
+	if event := protocol.Parse(line); event != nil {
+		tp.HandleEvent(event)
 	}
+}
 
-	parts := strings.Split(line, "|")
-	if len(parts) < 2 {
-		return
-	}
-
-	command := parts[1]
-
-	switch command {
-	case "move":
+// HandleEvent processes a single decoded event during a turn
+func (tp *TurnParser) HandleEvent(event protocol.Event) {
+	switch e := event.(type) {
+	case protocol.MoveEvent:
 		// Process any pending events for the previous action
 		tp.flushPendingEvents()
 
-		// Parse the move
-		if len(parts) >= 4 {
-			action := tp.parseMove(parts)
-			action.OrderInTurn = tp.actionOrder
-			tp.actionOrder++
-
-			if tp.currentTurn != nil {
-				tp.currentTurn.Actions = append(tp.currentTurn.Actions, action)
-			}
+		action := tp.parseMove(e)
+		action.OrderInTurn = tp.actionOrder
+		tp.actionOrder++
 
-			// Track which Pokemon just moved
-			tp.lastMovedPokemon["last"] = action.Pokemon
+		if tp.currentTurn != nil {
+			tp.currentTurn.Actions = append(tp.currentTurn.Actions, action)
 		}
 
-	case "switch":
+		// Track which Pokemon just moved
+		tp.lastMovedPokemon["last"] = action.Pokemon
+
+	case protocol.SwitchEvent:
 		tp.flushPendingEvents()
 
-		if len(parts) >= 4 {
-			action := tp.parseSwitch(parts)
-			action.OrderInTurn = tp.actionOrder
-			tp.actionOrder++
+		action := tp.parseSwitch(e)
+		action.OrderInTurn = tp.actionOrder
+		tp.actionOrder++
 
-			if tp.currentTurn != nil {
-				tp.currentTurn.Actions = append(tp.currentTurn.Actions, action)
-			}
+		if tp.currentTurn != nil {
+			tp.currentTurn.Actions = append(tp.currentTurn.Actions, action)
 		}
 
-	case "-damage", "-heal", "-status", "faint", "-crit", "-supereffective", "-resisted",
-		"-immune", "-miss", "-weather", "-fieldstart", "-boost", "-unboost":
+	case HPChange:
+		tp.recordHPChange(e)
+		tp.pendingEvents = append(tp.pendingEvents, event)
+
+	case ItemChange, Volatile:
+		tp.pendingEvents = append(tp.pendingEvents, event)
+
+	case protocol.StatusEvent, protocol.FaintEvent,
+		protocol.CritEvent, protocol.EffectivenessEvent, protocol.MissEvent, protocol.WeatherEvent,
+		protocol.FieldEvent, protocol.BoostEvent:
 		// Collect events that relate to the last action
-		tp.pendingEvents = append(tp.pendingEvents, line)
+		tp.pendingEvents = append(tp.pendingEvents, event)
 
 	default:
 		// Other events - might want to track these too
 	}
 }
 
+// recordHPChange adds an HP change to the current turn's totals. Damage counts
+// toward the opponent of the side that lost HP; healing toward the side that
+// gained it.
+func (tp *TurnParser) recordHPChange(change HPChange) {
+	if tp.currentTurn == nil {
+		return
+	}
+	player := sideToPlayer(change.Pokemon.Side)
+	if damage := change.Damage(); damage > 0 {
+		tp.currentTurn.DamageTaken[player] += damage
+		// Only damage the other side was responsible for counts as dealt,
+		// so recoil, Life Orb and friendly fire don't
+		if dealer := change.Source.Player; dealer != "" && dealer != player {
+			tp.currentTurn.DamageDealt[dealer] += damage
+		}
+	}
+	if healing := change.Healing(); healing > 0 {
+		tp.currentTurn.HealingDone[player] += healing
+	}
+}
+
 // StartNewTurn starts tracking a new turn
 func (tp *TurnParser) StartNewTurn(turnNumber int) *Turn {
 	// Flush any pending events from previous turn
@@ -83,6 +104,7 @@ func (tp *TurnParser) StartNewTurn(turnNumber int) *Turn {
 		TurnNumber:  turnNumber,
 		Actions:     []Action{},
 		DamageDealt: make(map[string]int),
+		DamageTaken: make(map[string]int),
 		HealingDone: make(map[string]int),
 	}
 	tp.actionOrder = 0
@@ -98,6 +120,8 @@ func (tp *TurnParser) FinalizeTurn(tracker *StateTracker) *Turn {
 
 	if tp.currentTurn != nil {
 		tp.currentTurn.PositionScore = tracker.CalculatePositionScore()
+		tp.currentTurn.Features = tracker.PositionFeatures()
+		tp.currentTurn.StateAfter = tracker.GetBattleState()
 	}
 
 	turn := tp.currentTurn
@@ -105,6 +129,15 @@ func (tp *TurnParser) FinalizeTurn(tracker *StateTracker) *Turn {
 	return turn
 }
 
+// currentAction returns the action resolving now, the latest this turn, or
+// nil before the first.
+func (tp *TurnParser) currentAction() *Action {
+	if tp.currentTurn == nil || len(tp.currentTurn.Actions) == 0 {
+		return nil
+	}
+	return &tp.currentTurn.Actions[len(tp.currentTurn.Actions)-1]
+}
+
 // flushPendingEvents applies pending events to the last action
 func (tp *TurnParser) flushPendingEvents() {
 	if len(tp.pendingEvents) == 0 {
@@ -122,166 +155,69 @@ func (tp *TurnParser) flushPendingEvents() {
 	}
 
 	// Clear pending events
-	tp.pendingEvents = []string{}
+	tp.pendingEvents = []protocol.Event{}
 }
 
 // parseMove parses a move command with enhanced details
-func (tp *TurnParser) parseMove(parts []string) Action {
+func (tp *TurnParser) parseMove(e protocol.MoveEvent) Action {
 	// |move|p1a: Gengar|Shadow Ball|p2a: Dusclops
-	playerID := extractPlayerIDFromRef(parts[2])
-	pokemonName := extractPokemonName(parts[2])
-	moveName := strings.TrimSpace(parts[3])
-
+	move := moveFromDex(e.Move)
 	action := Action{
-		Player:     playerID,
+		Player:     sideToPlayer(e.Source.Side),
 		ActionType: "move",
-		Pokemon:    pokemonName,
-		Move: &Move{
-			ID:   normalizeID(moveName),
-			Name: moveName,
-		},
-	}
-
-	// Parse target if present
-	if len(parts) >= 5 {
-		targetName := extractPokemonName(parts[4])
-		action.Target = targetName
+		Pokemon:    e.Source.Name,
+		Move:       &move,
+		Target:     e.Target.Name,
+		Targets:    moveTargets(e),
 	}
 
 	return action
 }
 
-// parseSwitch parses a switch command
-func (tp *TurnParser) parseSwitch(parts []string) Action {
-	// |switch|p1b: Typhlosion|Typhlosion-Hisui, L50, M|100/100
-	playerID := extractPlayerIDFromRef(parts[2])
-	pokemonName := extractPokemonName(parts[2])
-	switchToPoke := extractPokemonName(parts[3])
-
-	return Action{
-		Player:     playerID,
-		ActionType: "switch",
-		Pokemon:    pokemonName,
-		SwitchTo:   switchToPoke,
-	}
-}
-
-// ParseEnhancedShowdownLog is an enhanced version of ParseShowdownLog with better turn tracking
-func ParseEnhancedShowdownLog(logContent string) (*BattleSummary, error) {
-	// First do the basic parsing
-	summary, err := ParseShowdownLog(logContent)
-	if err != nil {
-		return nil, err
-	}
-
-	// Now do enhanced turn parsing for more detailed action tracking
-	lines := strings.Split(logContent, "\n")
-	tracker := NewStateTracker()
-	turnParser := NewTurnParser()
-
-	// First pass: set up tracker
-	for _, line := range lines {
-		if line == "" || !strings.HasPrefix(line, "|") {
-			continue
-		}
-
-		parts := strings.Split(line, "|")
-		if len(parts) < 2 {
-			continue
-		}
-
-		command := parts[1]
-
-		switch command {
-		case "player":
-			if len(parts) > 3 {
-				playerID := parts[2]
-				playerName := parts[3]
-				tracker.SetPlayerName(playerID, playerName)
-			}
-		case "teamsize":
-			if len(parts) > 3 {
-				playerID := parts[2]
-				teamSize := parseInt(parts[3])
-				tracker.SetTeamSize(playerID, teamSize)
-			}
-		case "poke":
-			if len(parts) > 3 {
-				playerID := parts[2]
-				pokeStr := parts[3]
-				poke := parsePokemonFromTeamPreview(pokeStr)
-				tracker.AddPokemonToTeam(playerID, poke)
-			}
-		}
-	}
-
-	// Second pass: detailed turn parsing
-	var enhancedTurns []Turn
-	var currentTurnNumber int
-
-	for _, line := range lines {
-		if line == "" || !strings.HasPrefix(line, "|") {
-			continue
+// moveTargets lists the Pokémon a move was aimed at: its target plus, for
+// spread moves, every slot in [spread]. Names for spread slots other than the
+// main target are filled in as events for them arrive.
+func moveTargets(e protocol.MoveEvent) []ActionTarget {
+	var targets []ActionTarget
+	add := func(ref protocol.PokemonRef) {
+		if ref.Slot == "" {
+			return
 		}
-
-		parts := strings.Split(line, "|")
-		if len(parts) < 2 {
-			continue
-		}
-
-		command := parts[1]
-
-		switch command {
-		case "turn":
-			// Finalize previous turn
-			if currentTurnNumber > 0 {
-				turn := turnParser.FinalizeTurn(tracker)
-				if turn != nil {
-					enhancedTurns = append(enhancedTurns, *turn)
-				}
-			}
-
-			// Start new turn
-			currentTurnNumber = parseInt(parts[2])
-			turnParser.StartNewTurn(currentTurnNumber)
-
-		case "switch":
-			turnParser.ProcessTurnEvent(line, tracker)
-			// Update tracker
-			if len(parts) >= 4 {
-				playerID := extractRawPlayerID(parts[2])
-				pokeName := extractPokemonName(parts[3])
-				pokehp := extractHPFromSwitch(parts)
-				tracker.SwitchPokemon(playerID, pokeName, pokehp)
-			}
-
-		case "move", "-damage", "-heal", "-status", "faint", "-crit",
-			"-supereffective", "-resisted", "-immune", "-miss", "-weather",
-			"-fieldstart", "-boost", "-unboost":
-			turnParser.ProcessTurnEvent(line, tracker)
-
-			// Update tracker for damage/healing
-			if command == "-damage" && len(parts) >= 4 {
-				playerID := extractRawPlayerID(parts[2])
-				hpStr := parts[3]
-				hp, maxHP := parseHP(hpStr)
-				tracker.UpdatePokemonHP(playerID, hp, maxHP)
+		for _, target := range targets {
+			if target.Slot == ref.Slot {
+				return
 			}
 		}
+		targets = append(targets, ActionTarget{
+			Pokemon: ref.Name,
+			Player:  sideToPlayer(ref.Side),
+			Slot:    ref.Slot,
+			Missed:  e.Miss && ref == e.Target,
+		})
 	}
 
-	// Finalize last turn
-	if currentTurnNumber > 0 {
-		turn := turnParser.FinalizeTurn(tracker)
-		if turn != nil {
-			enhancedTurns = append(enhancedTurns, *turn)
-		}
+	add(e.Target)
+	for _, slot := range e.Spread {
+		add(protocol.ParsePokemonRef(slot))
 	}
+	return targets
+}
 
-	// Replace turns in summary with enhanced turns if we got more detailed data
-	if len(enhancedTurns) > 0 {
-		summary.Turns = enhancedTurns
+// parseSwitch parses a switch command
+func (tp *TurnParser) parseSwitch(e protocol.SwitchEvent) Action {
+	// |switch|p1b: Typhlosion|Typhlosion-Hisui, L50, M|100/100
+	return Action{
+		Player:     sideToPlayer(e.Pokemon.Side),
+		ActionType: "switch",
+		Pokemon:    e.Pokemon.Name,
+		SwitchTo:   e.Details.Species,
 	}
+}
 
-	return summary, nil
+// ParseEnhancedShowdownLog parses a battle log with detailed turn tracking.
+//
+// Deprecated: ParseShowdownLog now builds detailed turns in the same pass; use
+// it or ParseShowdownLogReader instead.
+func ParseEnhancedShowdownLog(logContent string) (*BattleSummary, error) {
+	return ParseShowdownLog(logContent)
 }
@@ -1,14 +1,21 @@
 package analysis
 
-import "time"
+import (
+	"time"
+
+	"github.com/dtsong/vgccorner/backend/internal/calc"
+)
 
 // BattleSummary represents the complete analysis of a Pokémon battle.
 type BattleSummary struct {
 	// Metadata about the battle
 	ID        string    `json:"id"`
-	Format    string    `json:"format"` // e.g., "Regulation H"
+	ReplayID  string    `json:"replayId,omitempty"` // Showdown replay ID, when known
+	Format    string    `json:"format"`             // e.g., "Regulation H"
 	Timestamp time.Time `json:"timestamp"`
+	EndTime   time.Time `json:"endTime"`
 	Duration  int       `json:"duration"` // in seconds
+	Rated     bool      `json:"rated"`    // Ladder or tournament rated battle
 
 	// Player information
 	Player1 Player `json:"player1"`
@@ -23,11 +30,42 @@ type BattleSummary struct {
 
 	// Key moments and highlights
 	KeyMoments []KeyMoment `json:"keyMoments"`
+
+	// PositionEvaluator that produced each turn's PositionScore, e.g. "board";
+	// scores from different models aren't comparable
+	PositionModel string `json:"positionModel"`
+
+	// Version of the WinProbabilityModel behind each turn's WinProbability
+	WinProbabilityModel string `json:"winProbabilityModel"`
+
+	// Every field condition set during the battle, in the order it was set
+	FieldTimeline []FieldCondition `json:"fieldTimeline"`
+
+	// Every item revealed, consumed, removed or swapped, in order
+	ItemTimeline []ItemChange `json:"itemTimeline"`
+
+	// Every time an ability showed itself or took effect, in order
+	AbilityActivations []AbilityActivation `json:"abilityActivations"`
+
+	// Every volatile condition and single-turn effect, in the order they started
+	VolatileTimeline []Volatile `json:"volatileTimeline"`
+
+	// Who outsped whom, speed ties and the Speed ranges that follow
+	Speed SpeedAnalysis `json:"speed"`
+
+	// Every direct hit from a move, with what went into its damage
+	DamageSamples []DamageSample `json:"damageSamples"`
+
+	// Best-of-3 series linking, from the |uhtml|bestof| marker
+	SeriesID   string `json:"seriesId,omitempty"`   // e.g., "bestof3-gen9vgc2025reghbo3-2481642253"
+	GameNumber int    `json:"gameNumber,omitempty"` // 1-based game number within the series
 }
 
 // Player represents a single player in the battle.
 type Player struct {
 	Name           string             `json:"name"`
+	Rating         int                `json:"rating,omitempty"` // Ladder rating before the game
+	Avatar         string             `json:"avatar,omitempty"`
 	Team           []Pokémon          `json:"team"`
 	Active         *Pokémon           `json:"active"`         // Currently active Pokémon
 	Losses         int                `json:"losses"`         // Number of fainted Pokémon
@@ -35,24 +73,46 @@ type Player struct {
 	ActiveIndex    int                `json:"activeIndex"`    // Index in team of active Pokémon
 	TeamArchetype  string             `json:"teamArchetype"`  // e.g., "Hard Trick Room", "Tailwind Hyper Offense"
 	Classification TeamClassification `json:"classification"` // Detailed team classification
+	Leads          []string           `json:"leads"`          // Pokémon sent out before turn 1
+	Brought        []string           `json:"brought"`        // Pokémon that appeared in battle
+	BackLine       []string           `json:"backLine"`       // Brought Pokémon that didn't lead
+	Benched        []string           `json:"benched"`        // Team preview Pokémon left out; empty unless BenchedKnown
+	BenchedKnown   bool               `json:"benchedKnown"`   // Every brought Pokémon was seen, so Benched is complete
+	Unrevealed     int                `json:"unrevealed"`     // Brought Pokémon never sent out
+	Terastallized  *Terastallization  `json:"terastallized"`  // Tera used this game, if any
+}
+
+// Terastallization records a player's use of Tera in a game.
+type Terastallization struct {
+	Pokemon    string `json:"pokemon"`
+	TeraType   string `json:"teraType"`
+	TurnNumber int    `json:"turnNumber"`
 }
 
 // Pokémon represents a single Pokémon with its stats and moves.
 type Pokémon struct {
-	ID        string `json:"id"` // e.g., "pikachu"
-	Name      string `json:"name"`
-	Level     int    `json:"level"`
-	Gender    string `json:"gender"` // "M", "F", or ""
-	Ability   string `json:"ability"`
-	Item      string `json:"item"`
-	Stats     Stats  `json:"stats"` // Base stats
-	Moves     []Move `json:"moves"`
-	Happiness int    `json:"happiness"` // 0-255
-	Shiny     bool   `json:"shiny"`
-	CurrentHP int    `json:"currentHP"` // Current HP in battle
-	MaxHP     int    `json:"maxHP"`     // Maximum HP
-	Status    string `json:"status"`    // "burn", "freeze", "paralysis", "poison", "sleep", or ""
-	TeraType  string `json:"teraType"`  // Terastallization type if terastallized
+	ID        string   `json:"id"` // e.g., "pikachu"
+	Name      string   `json:"name"`
+	Level     int      `json:"level"`
+	Gender    string   `json:"gender"` // "M", "F", or ""
+	Ability   string   `json:"ability"`
+	Item      string   `json:"item"`
+	Types     []string `json:"types"` // From the species, before any Tera
+	Stats     Stats    `json:"stats"` // Base stats
+	Moves     []Move   `json:"moves"`
+	Happiness int      `json:"happiness"` // 0-255
+	Shiny     bool     `json:"shiny"`
+	CurrentHP int      `json:"currentHP"` // Current HP in battle
+	MaxHP     int      `json:"maxHP"`     // Maximum HP
+	Status    string   `json:"status"`    // "burn", "freeze", "paralysis", "poison", "sleep", or ""
+	TeraType  string   `json:"teraType"`  // Tera type from the team sheet, or once terastallized
+
+	// Set in Turn.StateAfter only
+	Terastallized bool           `json:"terastallized,omitempty"` // Has terastallized
+	Boosts        map[string]int `json:"boosts,omitempty"`        // Non-zero stat stages, e.g. "atk": 2
+
+	// Set in BattleSummary teams only
+	Spread *SpreadInference `json:"spread,omitempty"` // EVs and nature consistent with the damage it dealt and took
 }
 
 // Move represents a move a Pokémon knows.
@@ -60,8 +120,9 @@ type Move struct {
 	ID       string `json:"id"` // e.g., "thunderbolt"
 	Name     string `json:"name"`
 	Type     string `json:"type"`     // e.g., "Electric"
+	Category string `json:"category"` // "Physical", "Special" or "Status"
 	Power    int    `json:"power"`    // 0 if N/A
-	Accuracy int    `json:"accuracy"` // 0-100, 0 if N/A
+	Accuracy int    `json:"accuracy"` // 0-100, 0 if it never misses
 	PP       int    `json:"pp"`       // Power Points
 }
 
@@ -77,12 +138,17 @@ type Stats struct {
 
 // Turn represents a single turn in the battle.
 type Turn struct {
-	TurnNumber    int            `json:"turnNumber"`
-	Actions       []Action       `json:"actions"`
-	StateAfter    BattleState    `json:"stateAfter"`
-	DamageDealt   map[string]int `json:"damageDealt"`   // Player name -> damage dealt
-	HealingDone   map[string]int `json:"healingDone"`   // Player name -> healing done
-	PositionScore *PositionScore `json:"positionScore"` // Evaluation of positions after this turn
+	TurnNumber      int              `json:"turnNumber"`
+	Actions         []Action         `json:"actions"`
+	StateAfter      BattleState      `json:"stateAfter"`
+	DamageDealt     map[string]int   `json:"damageDealt"`              // Player name -> damage their Pokémon dealt to the opponent's
+	DamageTaken     map[string]int   `json:"damageTaken"`              // Player name -> damage their Pokémon took from any source
+	HealingDone     map[string]int   `json:"healingDone"`              // Player name -> healing done
+	PositionScore   *PositionScore   `json:"positionScore"`            // Evaluation of positions after this turn
+	Features        PositionFeatures `json:"features,omitempty"`       // Position after this turn, for win-probability models
+	WinProbability  *WinProbability  `json:"winProbability,omitempty"` // Each player's chance of winning after this turn
+	DecisionTimeSec int              `json:"decisionTimeSec"`          // Seconds players spent choosing this turn's actions
+	DurationSec     int              `json:"durationSec"`              // Wall-clock seconds from this turn's prompt to the next
 }
 
 // PositionScore represents the evaluated position for both players after a turn.
@@ -92,43 +158,265 @@ type PositionScore struct {
 	MomentumPlayer string  `json:"momentumPlayer"` // "player1", "player2", or "neutral"
 }
 
+// WinProbability is each player's chance of winning from a position, 0-1.
+type WinProbability struct {
+	Player1 float64 `json:"player1"`
+	Player2 float64 `json:"player2"`
+}
+
 // Action represents an action taken by a player during a turn.
 type Action struct {
-	Player      string      `json:"player"`     // "player1" or "player2"
-	ActionType  string      `json:"actionType"` // "move", "switch", "item"
-	Pokemon     string      `json:"pokemon"`    // Pokémon performing the action
-	Move        *Move       `json:"move,omitempty"`
-	SwitchTo    string      `json:"switchTo,omitempty"` // Pokémon name if switch
-	Item        string      `json:"item,omitempty"`     // Item used if item action
-	Target      string      `json:"target,omitempty"`   // Target of the action
-	Result      string      `json:"result,omitempty"`   // "critical-hit", "super-effective", etc.
-	Details     string      `json:"details,omitempty"`  // Additional details
-	Impact      *MoveImpact `json:"impact,omitempty"`   // Detailed impact of the action
-	OrderInTurn int         `json:"orderInTurn"`        // Order within the turn (0-based)
+	Player      string         `json:"player"`     // "player1" or "player2"
+	ActionType  string         `json:"actionType"` // "move", "switch", "item"
+	Pokemon     string         `json:"pokemon"`    // Pokémon performing the action
+	Move        *Move          `json:"move,omitempty"`
+	SwitchTo    string         `json:"switchTo,omitempty"` // Pokémon name if switch
+	Item        string         `json:"item,omitempty"`     // Item used if item action
+	Target      string         `json:"target,omitempty"`   // Target of the action
+	Targets     []ActionTarget `json:"targets,omitempty"`  // Every Pokémon the move was aimed at, with per-target results
+	Result      string         `json:"result,omitempty"`   // "critical-hit", "super-effective", etc.
+	Details     string         `json:"details,omitempty"`  // Additional details
+	Impact      *MoveImpact    `json:"impact,omitempty"`   // Detailed impact of the action
+	OrderInTurn int            `json:"orderInTurn"`        // Order within the turn (0-based)
+}
+
+// ActionTarget is the result of a move on one of the Pokémon it targeted.
+// Spread moves such as Heat Wave have one entry per Pokémon hit.
+type ActionTarget struct {
+	Pokemon       string `json:"pokemon"`
+	Player        string `json:"player"`                  // "player1" or "player2"
+	Slot          string `json:"slot"`                    // e.g., "p2a"
+	DamageDealt   int    `json:"damageDealt"`             // Percentage points of max HP
+	HealingDone   int    `json:"healingDone"`             // Percentage points of max HP
+	Effectiveness string `json:"effectiveness,omitempty"` // "super-effective", "not-very-effective" or "immune"
+	Critical      bool   `json:"critical"`
+	Missed        bool   `json:"missed"`
+	Immune        bool   `json:"immune"`
+	Fainted       bool   `json:"fainted"` // Knocked out by this move
 }
 
 // BattleState represents the state of the battle at a point in time.
 type BattleState struct {
-	Player1Active *Pokémon `json:"player1Active"`
-	Player2Active *Pokémon `json:"player2Active"`
-	Player1Team   []string `json:"player1Team"` // List of alive Pokémon names
-	Player2Team   []string `json:"player2Team"`
+	Player1Active []*Pokémon     `json:"player1Active"` // Active Pokémon by slot (0 = a, 1 = b); nil if empty
+	Player2Active []*Pokémon     `json:"player2Active"`
+	Player1Bench  []BenchPokemon `json:"player1Bench"` // Every team member not on the field, in team order
+	Player2Bench  []BenchPokemon `json:"player2Bench"`
+	Player1Team   []string       `json:"player1Team"` // List of alive Pokémon names
+	Player2Team   []string       `json:"player2Team"`
+	Field         FieldState     `json:"field"`     // Weather, terrain, rooms and side conditions in effect
+	Volatiles     []Volatile     `json:"volatiles"` // Volatile conditions on the active Pokémon
+}
+
+// BenchPokemon is a Pokémon in the back at a point in the battle.
+type BenchPokemon struct {
+	ID        string `json:"id"` // e.g., "incineroar"
+	Name      string `json:"name"`
+	CurrentHP int    `json:"currentHP"` // 0 once fainted
+	MaxHP     int    `json:"maxHP"`
+	Status    string `json:"status,omitempty"`
+	Revealed  bool   `json:"revealed"` // Has been sent out; otherwise it's only known from team preview
+}
+
+// FieldState is every field condition in effect at a point in time.
+type FieldState struct {
+	Weather     *FieldCondition  `json:"weather"`
+	Terrain     *FieldCondition  `json:"terrain"`
+	Rooms       []FieldCondition `json:"rooms"`       // Trick Room, Magic Room, Wonder Room and Gravity
+	Player1Side []FieldCondition `json:"player1Side"` // Tailwind, screens, Safeguard, hazards
+	Player2Side []FieldCondition `json:"player2Side"`
+}
+
+// FieldCondition is a weather, terrain, room or side condition over its lifetime.
+type FieldCondition struct {
+	Kind            string `json:"kind"`                      // "weather", "terrain", "room" or "side"
+	Name            string `json:"name"`                      // e.g., "RainDance", "Psychic Terrain", "Trick Room", "Tailwind"
+	Side            string `json:"side,omitempty"`            // "player1" or "player2" for side conditions
+	Setter          string `json:"setter,omitempty"`          // Pokémon that set it, if known
+	SetterPlayer    string `json:"setterPlayer,omitempty"`    // "player1" or "player2", if known
+	StartTurn       int    `json:"startTurn"`                 // Turn it was set; 0 for before turn 1
+	ExpectedEndTurn int    `json:"expectedEndTurn,omitempty"` // Last turn it should last; 0 if it has no fixed duration
+	EndTurn         int    `json:"endTurn,omitempty"`         // Turn it actually ended; 0 if it never did
+	TurnsLeft       int    `json:"turnsLeft,omitempty"`       // In Turn.StateAfter: turns still expected after this one
+}
+
+// Volatile is a condition on one Pokémon that goes away when it switches out,
+// such as confusion, Taunt, Encore, Substitute or a Protosynthesis boost, or a
+// single-turn effect such as Protect or Follow Me.
+type Volatile struct {
+	Pokemon    string `json:"pokemon"`
+	Player     string `json:"player"`            // "player1" or "player2"
+	Slot       string `json:"slot"`              // e.g., "p1a"; follows the Pokémon through Ally Switch
+	Name       string `json:"name"`              // e.g., "confusion", "Taunt", "Protect", "protosynthesisatk"
+	Value      string `json:"value,omitempty"`   // Extra detail, e.g. the new type for typechange or Perish Song's count
+	Source     string `json:"source,omitempty"`  // Pokémon that caused it, if known
+	Move       string `json:"move,omitempty"`    // Move resolving when it started, if any
+	SingleTurn bool   `json:"singleTurn"`        // Lasts only the turn it was used
+	StartTurn  int    `json:"startTurn"`         // Turn it started; 0 for before turn 1
+	EndTurn    int    `json:"endTurn,omitempty"` // Turn it ended, including by switching out or fainting; 0 if it never did
+}
+
+// ItemChange is a change in what's known about a Pokémon's held item.
+type ItemChange struct {
+	TurnNumber int    `json:"turnNumber"`
+	Pokemon    string `json:"pokemon"`
+	Player     string `json:"player"` // "player1" or "player2"
+	Item       string `json:"item"`
+	Change     string `json:"change"`          // "revealed", "consumed", "removed", "swapped" or "gained"
+	Cause      string `json:"cause,omitempty"` // Effect behind the change, e.g. "Knock Off", "Trick", "Frisk"
+	Move       string `json:"move,omitempty"`  // Move resolving when it happened, if any
+	By         string `json:"by,omitempty"`    // Pokémon responsible, if known
+}
+
+// AbilityActivation is an ability announcing itself or taking effect, such
+// as Intimidate on switch-in, Drought setting sun or Good as Gold blocking a move.
+type AbilityActivation struct {
+	TurnNumber  int          `json:"turnNumber"`
+	Pokemon     string       `json:"pokemon"` // Pokémon with the ability
+	Player      string       `json:"player"`  // "player1" or "player2"
+	Ability     string       `json:"ability"`
+	Event       string       `json:"event"`                 // Protocol message it showed up in, e.g. "-ability", "-weather", "-immune"
+	Target      string       `json:"target,omitempty"`      // Other Pokémon involved, e.g. the attacker Rough Skin hurt
+	Result      string       `json:"result,omitempty"`      // What it set or inflicted, e.g. "SunnyDay", "Psychic Terrain", "par", "immune"
+	StatChanges []StatChange `json:"statChanges,omitempty"` // e.g. Intimidate's attack drops on both foes
+}
+
+// SpeedAnalysis is what the order moves resolved in reveals about Speed.
+type SpeedAnalysis struct {
+	Comparisons []SpeedComparison `json:"comparisons"` // Every pair of moves in the same priority bracket, in order
+	Ties        []SpeedTie        `json:"ties"`
+	Ranges      []SpeedRange      `json:"ranges"` // Each Pokémon's Speed relative to the opposing Pokémon it was compared with, and as a stat range
+}
+
+// SpeedComparison is one Pokémon moving before another in the same priority
+// bracket: outside Trick Room, First outsped Second under their conditions.
+type SpeedComparison struct {
+	TurnNumber int       `json:"turnNumber"`
+	First      SpeedSide `json:"first"`
+	Second     SpeedSide `json:"second"`
+	Priority   int       `json:"priority"`  // Both moves' priority bracket
+	TrickRoom  bool      `json:"trickRoom"` // Trick Room was up, so First was the slower
+}
+
+// SpeedSide is one Pokémon in a SpeedComparison.
+type SpeedSide struct {
+	Pokemon     string          `json:"pokemon"`
+	Player      string          `json:"player"` // "player1" or "player2"
+	Move        string          `json:"move"`
+	OrderInTurn int             `json:"orderInTurn"`
+	Conditions  SpeedConditions `json:"conditions"` // When First moved
+}
+
+// SpeedConditions are the known modifiers on a Pokémon's Speed.
+type SpeedConditions struct {
+	Stage       int  `json:"stage,omitempty"` // Speed stat stage, -6 to +6
+	Tailwind    bool `json:"tailwind,omitempty"`
+	Paralyzed   bool `json:"paralyzed,omitempty"`
+	ChoiceScarf bool `json:"choiceScarf,omitempty"` // Known to hold a Choice Scarf
+	Boosted     bool `json:"boosted,omitempty"`     // Protosynthesis or Quark Drive raised Speed
+
+	// Ability raising Speed, e.g. "Chlorophyll" in sun or "Unburden" once
+	// its item is gone
+	Ability  string `json:"ability,omitempty"`
+	IronBall bool   `json:"ironBall,omitempty"` // Known to hold an Iron Ball
+}
+
+// SpeedTie is a pair seen moving in both orders under the same conditions,
+// meaning their Speed was equal.
+type SpeedTie struct {
+	Pokemon   []MomentPokemon `json:"pokemon"`
+	TrickRoom bool            `json:"trickRoom"`
+	Turns     []int           `json:"turns"`
+}
+
+// SpeedRange bounds a Pokémon's unmodified Speed stat against opposing
+// Pokémon, e.g. AtLeast {Incineroar, 1.5} means at least 1.5x Incineroar's,
+// and as a level 50 stat.
+type SpeedRange struct {
+	Pokemon  string       `json:"pokemon"`
+	Player   string       `json:"player"` // "player1" or "player2"
+	AtLeast  []SpeedBound `json:"atLeast"`
+	AtMost   []SpeedBound `json:"atMost"`
+	MinSpeed int          `json:"minSpeed,omitempty"` // Lowest Speed stat the comparisons allow; 0 if the species isn't in the dex
+	MaxSpeed int          `json:"maxSpeed,omitempty"` // Highest Speed stat the comparisons allow
+}
+
+// SpeedBound is a Speed bound relative to another Pokémon's Speed stat.
+type SpeedBound struct {
+	Pokemon string  `json:"pokemon"`
+	Player  string  `json:"player"`
+	Ratio   float64 `json:"ratio"`
+}
+
+// DamageSample is one hit from a move with everything the log shows about
+// what went into its damage. Spreads are unknown, so the sets carry no EVs or
+// nature.
+type DamageSample struct {
+	TurnNumber int           `json:"turnNumber"`
+	Attacker   SamplePokemon `json:"attacker"`
+	Defender   SamplePokemon `json:"defender"`
+	Move       calc.Move     `json:"move"`
+	Field      calc.Field    `json:"field"`
+	Before     HPReading     `json:"before"` // Defender's HP before the hit
+	After      HPReading     `json:"after"`
+	LowerBound bool          `json:"lowerBound,omitempty"` // It knocked the defender out or would have without Focus Sash, Sturdy or Endure, so it did at least the HP lost
+}
+
+// SamplePokemon is a Pokémon in a DamageSample, with its level, ability,
+// item, status, stat stages and Tera as known when the hit landed.
+type SamplePokemon struct {
+	Pokemon string       `json:"pokemon"`
+	Player  string       `json:"player"` // "player1" or "player2"
+	Set     calc.Pokemon `json:"set"`
+}
+
+// HPReading is HP as the log reports it. A Max of 100 is Showdown's
+// percentage scale, rounded up except that 99 covers anything short of full.
+type HPReading struct {
+	Current int `json:"current"`
+	Max     int `json:"max"`
+}
+
+// SpreadInference is what a Pokémon's damage samples say about its EVs and
+// nature.
+type SpreadInference struct {
+	Pokemon string          `json:"pokemon"`
+	Player  string          `json:"player"` // "player1" or "player2"; the player's name across battles
+	Stats   []StatInference `json:"stats"`  // One per stat the samples bear on, in atk/def/spa/spd order
+}
+
+// StatInference is the spreads in one stat consistent with every sample
+// that depends on it. Defensive stats are paired with HP.
+type StatInference struct {
+	Stat       string     `json:"stat"`       // "atk", "def", "spa" or "spd"
+	Samples    int        `json:"samples"`    // Hits the inference rests on
+	Candidates int        `json:"candidates"` // Spreads consistent with all of them; 0 if they conflict
+	Likely     []EVSpread `json:"likely"`     // The most plausible of those, best first
+}
+
+// EVSpread is an investment in a stat, and in HP for defensive stats.
+type EVSpread struct {
+	HP     int    `json:"hp,omitempty"` // HP EVs
+	EVs    int    `json:"evs"`          // EVs in the stat
+	Nature string `json:"nature"`       // "+", "-" or "" for a neutral nature
+	Label  string `json:"label"`        // e.g. "252 HP / 4+ Def"
 }
 
 // BattleStats represents aggregate statistics about the battle.
 type BattleStats struct {
-	TotalTurns       int            `json:"totalTurns"`
-	MoveFrequency    map[string]int `json:"moveFrequency"` // Move ID -> count
-	TypeCoverage     map[string]int `json:"typeCoverage"`  // Type -> count
-	Switch           int            `json:"switches"`      // Total switches by both players
-	CriticalHits     int            `json:"criticalHits"`
-	SuperEffective   int            `json:"superEffective"`
-	NotVeryEffective int            `json:"notVeryEffective"`
-	AvgDamagePerTurn float64        `json:"avgDamagePerTurn"`
-	AvgHealPerTurn   float64        `json:"avgHealPerTurn"`
-	Player1Stats     PlayerStats    `json:"player1Stats"`
-	Player2Stats     PlayerStats    `json:"player2Stats"`
-	TurningPoints    []TurningPoint `json:"turningPoints"` // Key moments where momentum shifted
+	TotalTurns       int             `json:"totalTurns"`
+	MoveFrequency    map[string]int  `json:"moveFrequency"` // Move ID -> count
+	TypeCoverage     map[string]int  `json:"typeCoverage"`  // Type -> count
+	Switch           int             `json:"switches"`      // Total switches by both players
+	CriticalHits     int             `json:"criticalHits"`
+	SuperEffective   int             `json:"superEffective"`
+	NotVeryEffective int             `json:"notVeryEffective"`
+	AvgDamagePerTurn float64         `json:"avgDamagePerTurn"`
+	AvgHealPerTurn   float64         `json:"avgHealPerTurn"`
+	TrickRoomTurns   int             `json:"trickRoomTurns"` // Turns Trick Room was up
+	Player1Stats     PlayerStats     `json:"player1Stats"`
+	Player2Stats     PlayerStats     `json:"player2Stats"`
+	PokemonDamage    []PokemonDamage `json:"pokemonDamage"` // Damage dealt and taken by each Pokémon, by source
+	TurningPoints    []TurningPoint  `json:"turningPoints"` // Key moments where momentum shifted
 }
 
 // TurningPoint represents a turn where the battle's momentum shifted significantly.
@@ -138,21 +426,45 @@ type TurningPoint struct {
 	Score1After   float64 `json:"score1After"`  // Player1's score after this turn
 	Score2Before  float64 `json:"score2Before"`
 	Score2After   float64 `json:"score2After"`
-	MomentumShift float64 `json:"momentumShift"` // Negative means P2 gained, positive means P1 gained
+	WinProbBefore float64 `json:"winProbBefore"` // Player1's chance of winning before this turn, 0-1
+	WinProbAfter  float64 `json:"winProbAfter"`
+	MomentumShift float64 `json:"momentumShift"` // Swing in player1's win probability, in percentage points; negative means P2 gained
 	Significance  int     `json:"significance"`  // 1-10 scale
 	Description   string  `json:"description"`
 }
 
 // PlayerStats represents stats for an individual player.
 type PlayerStats struct {
-	MoveCount       int                `json:"moveCount"`
-	SwitchCount     int                `json:"switchCount"`
-	DamageDealt     int                `json:"damageDealt"`
-	DamageTaken     int                `json:"damageTaken"`
-	HealingDone     int                `json:"healingDone"`
-	HealingReceived int                `json:"healingReceived"`
-	MovesByType     map[string]int     `json:"movesByType"` // Type -> count
-	Effectiveness   EffectivenessStats `json:"effectiveness"`
+	MoveCount           int                `json:"moveCount"`
+	SwitchCount         int                `json:"switchCount"`
+	DamageDealt         int                `json:"damageDealt"`
+	DamageTaken         int                `json:"damageTaken"`
+	HealingDone         int                `json:"healingDone"`
+	HealingReceived     int                `json:"healingReceived"`
+	TailwindTurns       int                `json:"tailwindTurns"`       // Turns the player's Tailwind was up
+	DamageDealtBySource map[string]int     `json:"damageDealtBySource"` // Source kind -> damage to the opponent's Pokémon
+	DamageTakenBySource map[string]int     `json:"damageTakenBySource"` // Source kind -> damage to this player's Pokémon
+	MovesByType         map[string]int     `json:"movesByType"`         // Type -> count
+	Effectiveness       EffectivenessStats `json:"effectiveness"`
+}
+
+// DamageSource identifies what caused an HP change and who was responsible.
+type DamageSource struct {
+	Kind    string `json:"kind"`              // "move", "item", "ability", "weather", "status", or "recoil"
+	Name    string `json:"name"`              // e.g., "Flare Blitz", "Life Orb", "Sandstorm", "psn"
+	Pokemon string `json:"pokemon,omitempty"` // Pokémon responsible, if known
+	Player  string `json:"player,omitempty"`  // "player1" or "player2", if known
+}
+
+// PokemonDamage breaks down the damage a single Pokémon dealt and took.
+// Source keys are "kind: name", e.g. "item: Rocky Helmet" or "weather: Sandstorm".
+type PokemonDamage struct {
+	Player        string         `json:"player"`
+	Pokemon       string         `json:"pokemon"`       // Species, not nickname
+	DamageDealt   int            `json:"damageDealt"`   // Damage to opposing Pokémon this Pokémon was responsible for
+	DamageTaken   int            `json:"damageTaken"`   // Damage this Pokémon took from any source
+	DealtBySource map[string]int `json:"dealtBySource"` // Source -> damage dealt
+	TakenBySource map[string]int `json:"takenBySource"` // Source -> damage taken
 }
 
 // EffectivenessStats tracks type effectiveness in the battle.
@@ -164,10 +476,19 @@ type EffectivenessStats struct {
 
 // KeyMoment represents a significant moment in the battle.
 type KeyMoment struct {
-	TurnNumber   int    `json:"turnNumber"`
-	Description  string `json:"description"`  // e.g., "Player 2 switched to Charizard"
-	Type         string `json:"type"`         // "switch", "kO", "status", "weather", etc.
-	Significance int    `json:"significance"` // 1-10 scale
+	TurnNumber   int             `json:"turnNumber"`
+	Description  string          `json:"description"`           // e.g., "Player 2's Incineroar knocked out Player 1's Amoonguss with Flare Blitz"
+	Type         string          `json:"type"`                  // One of the Moment* types, e.g. "KO" or "tera"
+	Significance int             `json:"significance"`          // 1-10 scale
+	Player       string          `json:"player,omitempty"`      // "player1" or "player2": the side that made the play or is affected
+	Pokemon      []MomentPokemon `json:"pokemon,omitempty"`     // Pokémon involved, the main one first
+	ActionOrder  *int            `json:"actionOrder,omitempty"` // OrderInTurn of the action that triggered it; nil for end-of-turn effects
+}
+
+// MomentPokemon is a Pokémon involved in a key moment.
+type MomentPokemon struct {
+	Pokemon string `json:"pokemon"`
+	Player  string `json:"player"` // "player1" or "player2"
 }
 
 // TeamClassification contains detailed information about a team's archetype
@@ -203,6 +524,10 @@ type MoveImpact struct {
 	Critical        bool         `json:"critical"`        // Was this a critical hit?
 	Effectiveness   string       `json:"effectiveness"`   // "super-effective", "not-very-effective", "immune"
 	Missed          bool         `json:"missed"`          // Did the move miss?
+	Spread          bool         `json:"spread"`          // Did the move hit more than one target?
+	SpreadModifier  float64      `json:"spreadModifier"`  // Damage multiplier from hitting multiple targets (0.75), or 1
+	ItemChanges     []ItemChange `json:"itemChanges"`     // Items knocked off, swapped or used up while the move resolved
+	Volatiles       []Volatile   `json:"volatiles"`       // Volatile conditions the move started, e.g. confusion, Taunt, Protect
 }
 
 // StatChange represents a stat modification
@@ -211,3 +536,51 @@ type StatChange struct {
 	Stat    string `json:"stat"`    // "attack", "defense", "speed", etc.
 	Stages  int    `json:"stages"`  // Positive for boost, negative for drop
 }
+
+// SeriesSummary represents the analysis of a best-of-N series.
+// Players are identified by name, since sides can swap between games.
+type SeriesSummary struct {
+	SeriesID    string             `json:"seriesId"`
+	Player1     string             `json:"player1"` // Player 1 of the first game
+	Player2     string             `json:"player2"`
+	Player1Wins int                `json:"player1Wins"`
+	Player2Wins int                `json:"player2Wins"`
+	Winner      string             `json:"winner"` // Name of the series winner, or "" if undecided
+	Games       []SeriesGame       `json:"games"`
+	Adjustments []SeriesAdjustment `json:"adjustments"` // Changes each player made between games
+}
+
+// SeriesGame summarizes one game of a series.
+type SeriesGame struct {
+	GameNumber int              `json:"gameNumber"`
+	BattleID   string           `json:"battleId"`
+	ReplayID   string           `json:"replayId,omitempty"`
+	Winner     string           `json:"winner"` // Name of the game winner
+	TotalTurns int              `json:"totalTurns"`
+	Player1    SeriesPlayerGame `json:"player1"` // Series player 1, whichever side they played
+	Player2    SeriesPlayerGame `json:"player2"`
+}
+
+// SeriesPlayerGame captures one player's choices in a single game.
+type SeriesPlayerGame struct {
+	Name          string            `json:"name"`
+	Archetype     string            `json:"archetype"`
+	Leads         []string          `json:"leads"`
+	Brought       []string          `json:"brought"`
+	Terastallized *Terastallization `json:"terastallized"`
+}
+
+// SeriesAdjustment describes how a player changed their approach from one game to the next.
+type SeriesAdjustment struct {
+	Player       string            `json:"player"`
+	FromGame     int               `json:"fromGame"`
+	ToGame       int               `json:"toGame"`
+	LeadsChanged bool              `json:"leadsChanged"`
+	LeadsBefore  []string          `json:"leadsBefore"`
+	LeadsAfter   []string          `json:"leadsAfter"`
+	BroughtIn    []string          `json:"broughtIn"`   // Brought this game but not the previous one
+	LeftBehind   []string          `json:"leftBehind"`  // Brought the previous game but not this one
+	TeraChanged  bool              `json:"teraChanged"` // Different Tera target or type, or Tera used/unused
+	TeraBefore   *Terastallization `json:"teraBefore"`
+	TeraAfter    *Terastallization `json:"teraAfter"`
+}
@@ -0,0 +1,152 @@
+package analysis
+
+import (
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+// Command lets volatiles travel through the TurnParser alongside the protocol
+// events they came from.
+func (v Volatile) Command() string {
+	if v.SingleTurn {
+		return "-singleturn"
+	}
+	return "-start"
+}
+
+// volatileTimeline records every volatile started during a battle and which
+// are still up, keyed by slot and name.
+type volatileTimeline struct {
+	volatiles []Volatile
+	active    map[string]int // "p1a|Taunt" -> index in volatiles
+}
+
+func newVolatileTimeline() *volatileTimeline {
+	return &volatileTimeline{
+		volatiles: []Volatile{},
+		active:    make(map[string]int),
+	}
+}
+
+// end closes the volatile under key, if it's up.
+func (vt *volatileTimeline) end(key string, turn int) {
+	if i, ok := vt.active[key]; ok {
+		vt.volatiles[i].EndTurn = turn
+		delete(vt.active, key)
+	}
+}
+
+// endSlot closes every volatile on the Pokémon in slot, for when it leaves the field.
+func (vt *volatileTimeline) endSlot(slot string, turn int) {
+	for key := range vt.active {
+		if strings.HasPrefix(key, slot+"|") {
+			vt.end(key, turn)
+		}
+	}
+}
+
+// swapSlots moves the volatiles up on the Pokémon in slots a and b over to
+// each other's slot, for Ally Switch.
+func (vt *volatileTimeline) swapSlots(a, b string) {
+	moved := make(map[string]int)
+	for key, i := range vt.active {
+		slot, name, _ := strings.Cut(key, "|")
+		switch slot {
+		case a:
+			slot = b
+		case b:
+			slot = a
+		default:
+			continue
+		}
+		delete(vt.active, key)
+		vt.volatiles[i].Slot = slot
+		moved[slot+"|"+name] = i
+	}
+	maps.Copy(vt.active, moved)
+}
+
+// snapshot returns the volatiles up right now, in the order they started.
+func (vt *volatileTimeline) snapshot() []Volatile {
+	indexes := make([]int, 0, len(vt.active))
+	for _, i := range vt.active {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	volatiles := make([]Volatile, 0, len(indexes))
+	for _, i := range indexes {
+		volatiles = append(volatiles, vt.volatiles[i])
+	}
+	return volatiles
+}
+
+// RecordVolatile tracks |-start| and |-end|. It returns the volatile and true
+// when one starts. Counters such as Perish Song ("perish3", "perish2") and
+// Stockpile update the volatile already up rather than starting a new one.
+func (st *StateTracker) RecordVolatile(e protocol.VolatileEvent, source protocol.PokemonRef, move string) (Volatile, bool) {
+	name, value := volatileName(e.Effect.Name, e.Value)
+	slot := refSlot(e.Pokemon)
+	if name == "" || slot == "" {
+		return Volatile{}, false
+	}
+
+	key := slot + "|" + name
+	if e.Ended {
+		st.volatiles.end(key, st.turnNumber)
+		return Volatile{}, false
+	}
+	if i, ok := st.volatiles.active[key]; ok {
+		st.volatiles.volatiles[i].Value = value
+		return Volatile{}, false
+	}
+
+	volatile := st.newVolatile(e.Pokemon, name, source, move)
+	volatile.Value = value
+	st.volatiles.active[key] = len(st.volatiles.volatiles)
+	st.volatiles.volatiles = append(st.volatiles.volatiles, volatile)
+	return volatile, true
+}
+
+// RecordSingleTurn tracks |-singleturn| effects, which end with the turn.
+func (st *StateTracker) RecordSingleTurn(e protocol.SingleTurnEvent, source protocol.PokemonRef, move string) Volatile {
+	volatile := st.newVolatile(e.Pokemon, e.Effect.Name, source, move)
+	volatile.SingleTurn = true
+	volatile.EndTurn = st.turnNumber
+	st.volatiles.volatiles = append(st.volatiles.volatiles, volatile)
+	return volatile
+}
+
+// GetVolatiles returns the volatiles on the active Pokémon right now.
+func (st *StateTracker) GetVolatiles() []Volatile {
+	return st.volatiles.snapshot()
+}
+
+// GetVolatileTimeline returns every volatile started so far, in order.
+func (st *StateTracker) GetVolatileTimeline() []Volatile {
+	return st.volatiles.volatiles
+}
+
+func (st *StateTracker) newVolatile(ref protocol.PokemonRef, name string, source protocol.PokemonRef, move string) Volatile {
+	return Volatile{
+		Pokemon:   ref.Name,
+		Player:    sideToPlayer(ref.Side),
+		Slot:      ref.Slot,
+		Name:      name,
+		Source:    source.Name,
+		Move:      move,
+		StartTurn: st.turnNumber,
+	}
+}
+
+// volatileName splits a trailing counter off effects like "perish3" and
+// "stockpile2", so the count becomes the value.
+func volatileName(name, value string) (string, string) {
+	if n := len(name); n > 1 && name[n-1] >= '0' && name[n-1] <= '9' {
+		return name[:n-1], name[n-1:]
+	}
+	return name, value
+}
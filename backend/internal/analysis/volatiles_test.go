@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/protocol"
+)
+
+const volatileBattleLog = `|player|p1|Alice|
+|player|p2|Bob|
+|poke|p1|Amoonguss, L50|
+|poke|p1|Tornadus, L50|
+|poke|p1|Incineroar, L50|
+|poke|p2|Farigiraf, L50|
+|poke|p2|Iron Bundle, L50|
+|teamsize|p1|3
+|teamsize|p2|2
+|start
+|switch|p1a: Amoonguss|Amoonguss, L50|100/100
+|switch|p1b: Tornadus|Tornadus, L50|100/100
+|switch|p2a: Farigiraf|Farigiraf, L50|100/100
+|switch|p2b: Iron Bundle|Iron Bundle, L50|100/100
+|-activate|p2b: Iron Bundle|ability: Quark Drive|[fromitem]
+|-start|p2b: Iron Bundle|quarkdrivespe
+|turn|1
+|move|p1a: Amoonguss|Rage Powder|p1a: Amoonguss
+|-singleturn|p1a: Amoonguss|move: Rage Powder
+|move|p1b: Tornadus|Taunt|p2a: Farigiraf
+|-start|p2a: Farigiraf|move: Taunt
+|move|p2b: Iron Bundle|Hydro Pump|p1b: Tornadus
+|-damage|p1b: Tornadus|60/100
+|move|p2a: Farigiraf|Hyper Voice|p1a: Amoonguss|[spread] p1a,p1b
+|-damage|p1a: Amoonguss|80/100
+|-damage|p1b: Tornadus|40/100
+|upkeep
+|turn|2
+|switch|p1a: Incineroar|Incineroar, L50|100/100
+|move|p1b: Tornadus|Hurricane|p2b: Iron Bundle
+|-damage|p2b: Iron Bundle|30/100
+|-start|p2b: Iron Bundle|confusion
+|move|p2b: Iron Bundle|Freeze-Dry|p1b: Tornadus
+|-activate|p2b: Iron Bundle|confusion
+|-damage|p2b: Iron Bundle|20/100|[from] confusion
+|-end|p2a: Farigiraf|move: Taunt
+|upkeep
+|turn|3
+|win|Alice`
+
+func TestParseShowdownLogVolatileTimeline(t *testing.T) {
+	summary, err := ParseShowdownLog(volatileBattleLog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Volatile{
+		{Pokemon: "Iron Bundle", Player: "player2", Slot: "p2b", Name: "quarkdrivespe"},
+		{Pokemon: "Amoonguss", Player: "player1", Slot: "p1a", Name: "Rage Powder", Source: "Amoonguss", Move: "Rage Powder", SingleTurn: true, StartTurn: 1, EndTurn: 1},
+		{Pokemon: "Farigiraf", Player: "player2", Slot: "p2a", Name: "Taunt", Source: "Tornadus", Move: "Taunt", StartTurn: 1, EndTurn: 2},
+		{Pokemon: "Iron Bundle", Player: "player2", Slot: "p2b", Name: "confusion", Source: "Tornadus", Move: "Hurricane", StartTurn: 2},
+	}
+	if !reflect.DeepEqual(summary.VolatileTimeline, expected) {
+		t.Errorf("expected %+v, got %+v", expected, summary.VolatileTimeline)
+	}
+}
+
+func TestParseShowdownLogVolatilesOnBoard(t *testing.T) {
+	summary, _ := ParseShowdownLog(volatileBattleLog)
+
+	names := func(volatiles []Volatile) []string {
+		var names []string
+		for _, v := range volatiles {
+			names = append(names, v.Pokemon+": "+v.Name)
+		}
+		return names
+	}
+
+	// Rage Powder is gone by the end of the turn
+	turn1 := names(summary.Turns[0].StateAfter.Volatiles)
+	if !reflect.DeepEqual(turn1, []string{"Iron Bundle: quarkdrivespe", "Farigiraf: Taunt"}) {
+		t.Errorf("turn 1: unexpected volatiles %v", turn1)
+	}
+
+	turn2 := names(summary.Turns[1].StateAfter.Volatiles)
+	if !reflect.DeepEqual(turn2, []string{"Iron Bundle: quarkdrivespe", "Iron Bundle: confusion"}) {
+		t.Errorf("turn 2: unexpected volatiles %v", turn2)
+	}
+}
+
+func TestParseShowdownLogMoveImpactVolatiles(t *testing.T) {
+	summary, _ := ParseShowdownLog(volatileBattleLog)
+
+	ragePowder := summary.Turns[0].Actions[0].Impact
+	if len(ragePowder.Volatiles) != 1 || !ragePowder.Volatiles[0].SingleTurn {
+		t.Errorf("expected Rage Powder to start a single-turn effect, got %+v", ragePowder.Volatiles)
+	}
+
+	hurricane := summary.Turns[1].Actions[1].Impact
+	if len(hurricane.Volatiles) != 1 || hurricane.Volatiles[0].Name != "confusion" {
+		t.Errorf("expected Hurricane to confuse, got %+v", hurricane.Volatiles)
+	}
+
+	// Hitting itself in confusion isn't Freeze-Dry starting anything
+	if freezeDry := summary.Turns[1].Actions[2].Impact; len(freezeDry.Volatiles) != 0 {
+		t.Errorf("expected no volatiles from Freeze-Dry, got %+v", freezeDry.Volatiles)
+	}
+}
+
+func TestRecordVolatileCounter(t *testing.T) {
+	tracker := NewStateTracker()
+	tracker.AddPokemonToTeam("p1", Pokémon{Name: "Gengar"})
+	tracker.SwitchPokemon("p1a", "Gengar", 100)
+
+	for turn, line := range []string{"|-start|p1a: Gengar|perish3", "|-start|p1a: Gengar|perish2"} {
+		tracker.SetTurn(turn + 1)
+		event := protocol.Parse(line).(protocol.VolatileEvent)
+		tracker.RecordVolatile(event, event.Pokemon, "")
+	}
+
+	volatiles := tracker.GetVolatiles()
+	if len(volatiles) != 1 || volatiles[0].Name != "perish" || volatiles[0].Value != "2" || volatiles[0].StartTurn != 1 {
+		t.Errorf("expected one Perish Song count at 2, got %+v", volatiles)
+	}
+}
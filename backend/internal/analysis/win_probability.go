@@ -0,0 +1,249 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// PositionFeatures describes the position after a turn as differences
+// between the sides, player 1 minus player 2, keyed by FeatureNames.
+type PositionFeatures map[string]float64
+
+// FeatureNames lists the position features a win-probability model can use.
+var FeatureNames = []string{
+	"remainingHP",   // Share of the team's total HP left
+	"pokemonLeft",   // Pokémon that haven't fainted
+	"board",         // Share of active slots with a Pokémon standing
+	"speedControl",  // Tailwind up, or a Trick Room it set
+	"boosts",        // Stat stages on the active Pokémon, in maxBoostScore units
+	"status",        // Status on Pokémon still standing, in maxStatusPenalty units
+	"teraAvailable", // 1 if Tera hasn't been used
+}
+
+// PositionFeatures measures the current position for win-probability models.
+func (st *StateTracker) PositionFeatures() PositionFeatures {
+	side := func(playerID string) PositionFeatures {
+		features := PositionFeatures{
+			"remainingHP":  remainingHP(st, playerID),
+			"pokemonLeft":  float64(st.GetTeamSize(playerID) - st.losses[playerID]),
+			"board":        boardPresence(st, playerID),
+			"speedControl": speedControl(st, playerID),
+			"boosts":       st.boostScore(playerID) / maxBoostScore,
+			"status":       statusPenalty(st, playerID) / maxStatusPenalty,
+		}
+		if st.GetTerastallization(playerID) == nil {
+			features["teraAvailable"] = 1
+		}
+		return features
+	}
+
+	player1, player2 := side("p1"), side("p2")
+	features := make(PositionFeatures, len(FeatureNames))
+	for _, name := range FeatureNames {
+		features[name] = player1[name] - player2[name]
+	}
+	return features
+}
+
+// mirror swaps the sides, giving the same position from player 2's view.
+func (f PositionFeatures) mirror() PositionFeatures {
+	mirrored := make(PositionFeatures, len(f))
+	for name, value := range f {
+		mirrored[name] = -value
+	}
+	return mirrored
+}
+
+// winProbabilityFormat is the artifact format version; bump it when
+// WinProbabilityModel's fields change meaning.
+const winProbabilityFormat = 1
+
+// WinProbabilityModel is a logistic regression over PositionFeatures giving
+// the chance player 1 wins from a position. Saved as a JSON artifact.
+type WinProbabilityModel struct {
+	Format    int       `json:"format"`             // winProbabilityFormat
+	Version   string    `json:"version"`            // e.g. "20261016-120000"; recorded in BattleSummary.WinProbabilityModel
+	TrainedAt time.Time `json:"trainedAt,omitzero"` // Zero for the built-in baseline
+	Features  []string  `json:"features"`           // Feature names, in the order of Weights
+	Weights   []float64 `json:"weights"`            // One per feature
+	Bias      float64   `json:"bias"`               // Zero when trained on mirrored positions
+	Battles   int       `json:"battles,omitempty"`  // Battles it was trained on
+	Samples   int       `json:"samples,omitempty"`  // Turns it was trained on
+	LogLoss   float64   `json:"logLoss,omitempty"`  // On the training turns
+	Accuracy  float64   `json:"accuracy,omitempty"` // Share of training turns whose eventual winner it favored
+}
+
+// DefaultWinProbabilityModel is used unless the parser is given another with
+// WithWinProbabilityModel. It starts as a hand-tuned baseline; the API
+// replaces it with a trained artifact when one is configured.
+var DefaultWinProbabilityModel = &WinProbabilityModel{
+	Format:   winProbabilityFormat,
+	Version:  "baseline",
+	Features: FeatureNames,
+	Weights:  []float64{4, 0.6, 0.8, 0.4, 0.5, -0.6, 0.3},
+}
+
+// Predict returns the chance player 1 wins from a position. Features the
+// model doesn't know are ignored and ones it expects but are missing count as 0.
+func (m *WinProbabilityModel) Predict(features PositionFeatures) float64 {
+	z := m.Bias
+	for i, name := range m.Features {
+		z += m.Weights[i] * features[name]
+	}
+	return sigmoid(z)
+}
+
+// WinProbability returns both players' chances of winning from a position.
+func (m *WinProbabilityModel) WinProbability(features PositionFeatures) *WinProbability {
+	player1 := m.Predict(features)
+	return &WinProbability{Player1: player1, Player2: 1 - player1}
+}
+
+// Save writes the model as an indented JSON artifact.
+func (m *WinProbabilityModel) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode win probability model: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write win probability model: %w", err)
+	}
+	return nil
+}
+
+// LoadWinProbabilityModel reads a model saved with Save.
+func LoadWinProbabilityModel(path string) (*WinProbabilityModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read win probability model: %w", err)
+	}
+
+	var model WinProbabilityModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to decode win probability model: %w", err)
+	}
+	if model.Format != winProbabilityFormat {
+		return nil, fmt.Errorf("unsupported win probability model format %d, want %d", model.Format, winProbabilityFormat)
+	}
+	if len(model.Features) != len(model.Weights) {
+		return nil, fmt.Errorf("win probability model has %d features but %d weights", len(model.Features), len(model.Weights))
+	}
+	return &model, nil
+}
+
+// TrainingSample is the position after one turn of a finished battle.
+type TrainingSample struct {
+	Features   PositionFeatures
+	Player1Won bool
+}
+
+// TrainOptions tunes TrainWinProbabilityModel. Zero values use the defaults.
+type TrainOptions struct {
+	Epochs       int     // Passes of full-batch gradient descent; default 2000
+	LearningRate float64 // Default 0.5
+	L2           float64 // Weight decay; default 0.001
+}
+
+// TrainWinProbabilityModel fits a logistic regression to samples. Every
+// position is also added from the other side, so the model treats both
+// players alike and its bias stays at 0.
+func TrainWinProbabilityModel(samples []TrainingSample, opts TrainOptions) (*WinProbabilityModel, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no training samples")
+	}
+	if opts.Epochs <= 0 {
+		opts.Epochs = 2000
+	}
+	if opts.LearningRate <= 0 {
+		opts.LearningRate = 0.5
+	}
+	if opts.L2 < 0 {
+		return nil, errors.New("L2 must not be negative")
+	} else if opts.L2 == 0 {
+		opts.L2 = 0.001
+	}
+
+	// Vectorize, with each position mirrored
+	n := len(FeatureNames)
+	xs := make([][]float64, 0, 2*len(samples))
+	ys := make([]float64, 0, 2*len(samples))
+	for _, sample := range samples {
+		for _, mirrored := range []bool{false, true} {
+			features, won := sample.Features, sample.Player1Won
+			if mirrored {
+				features, won = features.mirror(), !won
+			}
+			x := make([]float64, n)
+			for i, name := range FeatureNames {
+				x[i] = features[name]
+			}
+			xs = append(xs, x)
+			ys = append(ys, boolToFloat(won))
+		}
+	}
+
+	weights := make([]float64, n)
+	gradient := make([]float64, n)
+	for epoch := 0; epoch < opts.Epochs; epoch++ {
+		for i := range gradient {
+			gradient[i] = opts.L2 * weights[i]
+		}
+		for j, x := range xs {
+			residual := sigmoid(dot(weights, x)) - ys[j]
+			for i := range x {
+				gradient[i] += residual * x[i] / float64(len(xs))
+			}
+		}
+		for i := range weights {
+			weights[i] -= opts.LearningRate * gradient[i]
+		}
+	}
+
+	model := &WinProbabilityModel{
+		Format:   winProbabilityFormat,
+		Features: append([]string(nil), FeatureNames...),
+		Weights:  weights,
+		Samples:  len(samples),
+	}
+
+	// Fit on the turns as they happened
+	correct := 0
+	for _, sample := range samples {
+		p := math.Min(math.Max(model.Predict(sample.Features), 1e-9), 1-1e-9)
+		if sample.Player1Won {
+			model.LogLoss -= math.Log(p)
+		} else {
+			model.LogLoss -= math.Log(1 - p)
+		}
+		if (p > 0.5) == sample.Player1Won {
+			correct++
+		}
+	}
+	model.LogLoss /= float64(len(samples))
+	model.Accuracy = float64(correct) / float64(len(samples))
+
+	return model, nil
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPositionFeatures(t *testing.T) {
+	summary, _ := ParseShowdownLog(boardBattleLog)
+
+	// After turn 1: Amoonguss fainted and Annihilape terastallized at +1/+1,
+	// while Dondozo took recoil and Tatsugiri fell asleep
+	features := summary.Turns[0].Features
+	expected := PositionFeatures{
+		"remainingHP":   (1+0+1)/3.0 - (0.85+1+1)/3.0,
+		"pokemonLeft":   -1,
+		"board":         0,
+		"speedControl":  0,
+		"boosts":        0.3,
+		"status":        -0.4,
+		"teraAvailable": -1,
+	}
+	for _, name := range FeatureNames {
+		if math.Abs(features[name]-expected[name]) > 1e-9 {
+			t.Errorf("%s: expected %.3f, got %.3f", name, expected[name], features[name])
+		}
+	}
+}
+
+func TestParseShowdownLogWinProbability(t *testing.T) {
+	summary, _ := ParseShowdownLog(boardBattleLog)
+	if summary.WinProbabilityModel != "baseline" {
+		t.Errorf("expected the baseline model, got %q", summary.WinProbabilityModel)
+	}
+	for _, turn := range summary.Turns {
+		p := turn.WinProbability
+		if p == nil || math.Abs(p.Player1+p.Player2-1) > 1e-9 {
+			t.Errorf("turn %d: expected probabilities summing to 1, got %+v", turn.TurnNumber, p)
+		}
+	}
+
+	// Player 1 lost a Pokémon on turn 1
+	if p := summary.Turns[0].WinProbability; p.Player1 >= 0.5 {
+		t.Errorf("expected player2 to be favored after turn 1, got %+v", p)
+	}
+
+	model := &WinProbabilityModel{Format: winProbabilityFormat, Version: "test", Features: []string{"board"}, Weights: []float64{1}}
+	summary, _ = ParseShowdownLog(boardBattleLog, WithWinProbabilityModel(model))
+	if summary.WinProbabilityModel != "test" {
+		t.Errorf("expected the test model, got %q", summary.WinProbabilityModel)
+	}
+	if p := summary.Turns[0].WinProbability; p.Player1 != 0.5 {
+		t.Errorf("expected an even board to be a coin flip for the test model, got %+v", p)
+	}
+}
+
+func TestTrainWinProbabilityModel(t *testing.T) {
+	// The side with more HP left wins three times out of four
+	var samples []TrainingSample
+	for i := 0; i < 40; i++ {
+		hp := float64(i%10)/10 + 0.1
+		samples = append(samples,
+			TrainingSample{Features: PositionFeatures{"remainingHP": hp}, Player1Won: i%4 != 0},
+			TrainingSample{Features: PositionFeatures{"remainingHP": -hp}, Player1Won: i%4 == 0},
+		)
+	}
+
+	model, err := TrainWinProbabilityModel(samples, TrainOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	weights := make(map[string]float64)
+	for i, name := range model.Features {
+		weights[name] = model.Weights[i]
+	}
+	if weights["remainingHP"] <= 0 {
+		t.Errorf("expected more HP to mean better chances, got weight %.3f", weights["remainingHP"])
+	}
+	if model.Bias != 0 || weights["board"] != 0 {
+		t.Errorf("expected no bias and no weight on unused features, got %+v", model)
+	}
+	if model.Accuracy != 0.75 || model.Samples != len(samples) {
+		t.Errorf("expected 75%% accuracy over %d samples, got %.2f over %d", len(samples), model.Accuracy, model.Samples)
+	}
+	if p := model.Predict(PositionFeatures{"remainingHP": 0.5}); p <= 0.5 || p >= 1 {
+		t.Errorf("expected player1 favored but not certain, got %.3f", p)
+	}
+
+	if _, err := TrainWinProbabilityModel(nil, TrainOptions{}); err == nil {
+		t.Error("expected an error with no samples")
+	}
+}
+
+func TestWinProbabilityModelSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "winprob.json")
+	if err := DefaultWinProbabilityModel.Save(path); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	loaded, err := LoadWinProbabilityModel(path)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	features := PositionFeatures{"remainingHP": 0.2, "board": 0.5}
+	if loaded.Version != "baseline" || loaded.Predict(features) != DefaultWinProbabilityModel.Predict(features) {
+		t.Errorf("expected the loaded model to match, got %+v", loaded)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"format": 99, "version": "future"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWinProbabilityModel(path); err == nil {
+		t.Error("expected an error for an unknown artifact format")
+	}
+}
@@ -0,0 +1,941 @@
+// Package calc implements the Gen 9 damage formula for doubles battles.
+//
+// Calculate works out the 16 damage rolls one move does from an attacker to
+// a defender, given their EVs, IVs, natures, items, abilities and Tera, and
+// the state of the field: weather, terrain, screens, Helping Hand and Ruin
+// abilities. It follows the games' order of operations and 4096-based
+// rounding, so rolls match Showdown's calculator for the cases it covers.
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/dtsong/vgccorner/backend/internal/dex"
+)
+
+// Pokemon is one side of a calculation.
+type Pokemon struct {
+	Species       string         `json:"species"`
+	Level         int            `json:"level,omitempty"` // Defaults to 50
+	Ability       string         `json:"ability,omitempty"`
+	Item          string         `json:"item,omitempty"`
+	Nature        string         `json:"nature,omitempty"` // Defaults to a neutral nature
+	EVs           map[string]int `json:"evs,omitempty"`    // e.g. "atk": 252; missing stats are 0
+	IVs           map[string]int `json:"ivs,omitempty"`    // Missing stats are 31
+	Boosts        map[string]int `json:"boosts,omitempty"` // Stat stages, e.g. "atk": 1
+	Status        string         `json:"status,omitempty"` // "burn", "paralysis", "poison", "sleep", "freeze" or ""
+	TeraType      string         `json:"teraType,omitempty"`
+	Terastallized bool           `json:"terastallized,omitempty"`
+	HPPercent     float64        `json:"hpPercent,omitempty"` // Current HP; 0 means full
+}
+
+// Move is the move being used.
+type Move struct {
+	Name string `json:"name"`
+	Crit bool   `json:"crit,omitempty"` // Always set for moves that always crit
+	Hits int    `json:"hits,omitempty"` // For multi-hit moves; see hitCount for defaults
+}
+
+// Field is the state of the battle around the attacker and defender.
+type Field struct {
+	Weather      string   `json:"weather,omitempty"`      // "Sun", "Rain", "Sand" or "Snow"
+	Terrain      string   `json:"terrain,omitempty"`      // "Electric", "Grassy", "Psychic" or "Misty"
+	SingleTarget bool     `json:"singleTarget,omitempty"` // A spread move has only one target, so isn't reduced
+	Ruin         []string `json:"ruin,omitempty"`         // Ruin abilities active from other Pokémon, e.g. an ally Chien-Pao
+
+	// Attacker's side
+	HelpingHand bool `json:"helpingHand,omitempty"`
+	Battery     bool `json:"battery,omitempty"`
+	PowerSpot   bool `json:"powerSpot,omitempty"`
+
+	// Defender's side
+	Reflect     bool `json:"reflect,omitempty"`
+	LightScreen bool `json:"lightScreen,omitempty"`
+	AuroraVeil  bool `json:"auroraVeil,omitempty"`
+	FriendGuard bool `json:"friendGuard,omitempty"`
+}
+
+// Result is the outcome of a calculation.
+type Result struct {
+	AttackerStats dex.Stats `json:"attackerStats"`
+	DefenderStats dex.Stats `json:"defenderStats"`
+	MoveType      string    `json:"moveType"`              // After Tera Blast, -ate abilities and the like
+	Category      string    `json:"category"`              // "Physical" or "Special"
+	AttackStat    string    `json:"attackStat"`            // Stat the move attacks with, e.g. "def" for Body Press
+	DefenseStat   string    `json:"defenseStat"`           // Stat it hits, e.g. "def" for Psyshock
+	FixedDamage   bool      `json:"fixedDamage,omitempty"` // Damage doesn't depend on stats, as for Seismic Toss
+	Effectiveness float64   `json:"effectiveness"`
+	Crit          bool      `json:"crit"`
+	Hits          int       `json:"hits"`
+	Rolls         []int     `json:"rolls"` // 16 damage rolls, lowest first, totalled over every hit
+	Min           int       `json:"min"`
+	Max           int       `json:"max"`
+	MinPercent    float64   `json:"minPercent"` // Of the defender's max HP
+	MaxPercent    float64   `json:"maxPercent"`
+	DefenderHP    int       `json:"defenderHP"` // Current HP the KO chance is worked out against
+	KO            KOChance  `json:"ko"`
+	Description   string    `json:"description"` // Showdown-style summary of the calc
+}
+
+// Errors for moves the calculator can't work out damage for.
+var (
+	ErrStatusMove      = errors.New("status moves deal no damage")
+	ErrUnsupportedMove = errors.New("move's damage isn't supported by the calculator")
+)
+
+// computedPower are the moves without a base power whose damage Calculate
+// still works out.
+var computedPower = map[string]bool{
+	"lowkick": true, "grassknot": true, "heavyslam": true, "heatcrash": true,
+	"seismictoss": true, "nightshade": true, "superfang": true, "ruination": true,
+}
+
+var statuses = map[string]bool{"": true, "burn": true, "paralysis": true, "poison": true, "sleep": true, "freeze": true}
+
+// weathers and terrains map accepted spellings to the canonical name.
+var (
+	weathers = map[string]string{
+		"": "", "sun": "Sun", "sunnyday": "Sun", "harshsunlight": "Sun",
+		"rain": "Rain", "raindance": "Rain",
+		"sand": "Sand", "sandstorm": "Sand",
+		"snow": "Snow", "snowscape": "Snow",
+	}
+	terrains = map[string]string{
+		"": "", "electric": "Electric", "electricterrain": "Electric",
+		"grassy": "Grassy", "grassyterrain": "Grassy",
+		"psychic": "Psychic", "psychicterrain": "Psychic",
+		"misty": "Misty", "mistyterrain": "Misty",
+	}
+	ruinAbilities = map[string]bool{"swordofruin": true, "beadsofruin": true, "tabletsofruin": true, "vesselofruin": true}
+)
+
+// combatant is a Pokémon resolved against the dex, with its stats worked out.
+type combatant struct {
+	Pokemon
+	species *dex.Species
+	stats   dex.Stats
+	types   []string // Current types, after any Tera
+	hp      int      // Current HP
+	ability string   // IDs, for comparison
+	item    string
+}
+
+func newCombatant(p Pokemon) (*combatant, error) {
+	if p.Level == 0 {
+		p.Level = 50
+	}
+	species, ok := dex.LookupSpecies(p.Species)
+	if !ok {
+		return nil, fmt.Errorf("unknown species %q: the dex only covers species seen in VGC", p.Species)
+	}
+	if err := validateSpread(&p); err != nil {
+		return nil, fmt.Errorf("%s: %w", species.Name, err)
+	}
+	if !statuses[p.Status] {
+		return nil, fmt.Errorf("%s: unknown status %q", species.Name, p.Status)
+	}
+	if p.TeraType != "" && !isType(p.TeraType) && p.TeraType != "Stellar" {
+		return nil, fmt.Errorf("%s: unknown Tera type %q", species.Name, p.TeraType)
+	}
+	if p.Terastallized && p.TeraType == "" {
+		return nil, fmt.Errorf("%s: terastallized without a Tera type", species.Name)
+	}
+	if p.HPPercent < 0 || p.HPPercent > 100 {
+		return nil, fmt.Errorf("%s: HP percent must be between 0 and 100, got %g", species.Name, p.HPPercent)
+	}
+
+	c := &combatant{
+		Pokemon: p,
+		species: species,
+		stats:   calcStats(species.BaseStats, &p),
+		types:   species.Types,
+		ability: dex.ID(p.Ability),
+		item:    dex.ID(p.Item),
+	}
+	c.Species = species.Name
+	if c.teraActive() {
+		c.types = []string{p.TeraType}
+	}
+	c.hp = c.stats.HP
+	if p.HPPercent > 0 && p.HPPercent < 100 {
+		c.hp = max(1, int(float64(c.stats.HP)*p.HPPercent/100))
+	}
+	return c, nil
+}
+
+// Stats works out a Pokémon's stats from its species, level, EVs, IVs and
+// nature.
+func Stats(p Pokemon) (dex.Stats, error) {
+	c, err := newCombatant(p)
+	if err != nil {
+		return dex.Stats{}, err
+	}
+	return c.stats, nil
+}
+
+// teraActive reports whether the Pokémon has terastallized into a type that
+// replaces its own; Stellar keeps the original types.
+func (c *combatant) teraActive() bool {
+	return c.Terastallized && c.TeraType != "Stellar"
+}
+
+func (c *combatant) hasType(t string) bool {
+	return contains(c.types, t)
+}
+
+func (c *combatant) hasOriginalType(t string) bool {
+	return contains(c.species.Types, t)
+}
+
+// grounded reports whether terrain affects the Pokémon.
+func (c *combatant) grounded() bool {
+	if c.item == "ironball" {
+		return true
+	}
+	return !c.hasType("Flying") && c.ability != "levitate" && c.item != "airballoon"
+}
+
+// boost returns the Pokémon's stat stage for key.
+func (c *combatant) boost(key string) int {
+	return c.Boosts[key]
+}
+
+// boostedStat returns a stat after its stat stage.
+func (c *combatant) boostedStat(key string) int {
+	return boostedStat(statValue(c.stats, key), c.boost(key))
+}
+
+// paradoxBoost returns the stat Protosynthesis or Quark Drive raises, or ""
+// if the ability isn't active. Booster Energy activates either; otherwise
+// Protosynthesis needs sun and Quark Drive Electric Terrain.
+func (c *combatant) paradoxBoost(field *Field) string {
+	switch {
+	case c.ability == "protosynthesis" && (c.item == "boosterenergy" || field.Weather == "Sun"):
+	case c.ability == "quarkdrive" && (c.item == "boosterenergy" || field.Terrain == "Electric"):
+	default:
+		return ""
+	}
+	best, bestValue := "", 0
+	for _, key := range []string{"atk", "def", "spa", "spd", "spe"} {
+		if value := c.boostedStat(key); value > bestValue {
+			best, bestValue = key, value
+		}
+	}
+	return best
+}
+
+// calculation carries the state of one Calculate call between its steps.
+type calculation struct {
+	attacker, defender *combatant
+	move               *dex.Move
+	field              *Field
+	moveType           string
+	category           string
+	attackKey          string // Stats the move attacks with and against
+	defenseKey         string
+	crit               bool
+	effectiveness      float64
+	defAbility         string // Defender's ability, unless Mold Breaker ignores it
+	notes              descNotes
+}
+
+// Calculate works out the damage move does from attacker to defender.
+func Calculate(attacker, defender Pokemon, move Move, field Field) (*Result, error) {
+	a, err := newCombatant(attacker)
+	if err != nil {
+		return nil, err
+	}
+	d, err := newCombatant(defender)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := dex.LookupMove(move.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown move %q: the dex only covers moves seen in VGC", move.Name)
+	}
+	if data.Category == "Status" {
+		return nil, ErrStatusMove
+	}
+	if data.BasePower == 0 && !computedPower[dex.ID(data.Name)] {
+		return nil, ErrUnsupportedMove
+	}
+	if move.Hits < 0 {
+		return nil, fmt.Errorf("hits must not be negative, got %d", move.Hits)
+	}
+
+	weather, ok := weathers[dex.ID(field.Weather)]
+	if !ok {
+		return nil, fmt.Errorf("unknown weather %q", field.Weather)
+	}
+	terrain, ok := terrains[dex.ID(field.Terrain)]
+	if !ok {
+		return nil, fmt.Errorf("unknown terrain %q", field.Terrain)
+	}
+	field.Weather, field.Terrain = weather, terrain
+	for _, ruin := range field.Ruin {
+		if !ruinAbilities[dex.ID(ruin)] {
+			return nil, fmt.Errorf("unknown Ruin ability %q", ruin)
+		}
+	}
+
+	c := &calculation{
+		attacker:   a,
+		defender:   d,
+		move:       data,
+		field:      &field,
+		crit:       move.Crit || data.WillCrit,
+		defAbility: d.ability,
+	}
+	switch a.ability {
+	case "moldbreaker", "teravolt", "turboblaze":
+		c.defAbility = ""
+	}
+	c.resolveTypeAndCategory()
+	c.effectiveness = c.typeEffectiveness()
+
+	hits := c.hitCount(move.Hits)
+	perHit := c.rolls()
+	rolls := make([]int, len(perHit))
+	for i, damage := range perHit {
+		rolls[i] = damage * hits
+	}
+	_, fixed := c.fixedDamage()
+
+	result := &Result{
+		AttackerStats: a.stats,
+		DefenderStats: d.stats,
+		MoveType:      c.moveType,
+		Category:      c.category,
+		AttackStat:    c.attackKey,
+		DefenseStat:   c.defenseKey,
+		FixedDamage:   fixed,
+		Effectiveness: c.effectiveness,
+		Crit:          c.crit,
+		Hits:          hits,
+		Rolls:         rolls,
+		Min:           rolls[0],
+		Max:           rolls[len(rolls)-1],
+		MinPercent:    percentOf(rolls[0], d.stats.HP),
+		MaxPercent:    percentOf(rolls[len(rolls)-1], d.stats.HP),
+		DefenderHP:    d.hp,
+		KO:            koChance(rolls, d.hp),
+	}
+	result.Description = c.describe(result)
+	return result, nil
+}
+
+// resolveTypeAndCategory works out the move's type and category after
+// anything that changes them.
+func (c *calculation) resolveTypeAndCategory() {
+	a := c.attacker
+	c.moveType = c.move.Type
+	c.category = c.move.Category
+
+	switch dex.ID(c.move.Name) {
+	case "terablast":
+		if a.Terastallized && a.TeraType != "Stellar" {
+			c.moveType = a.TeraType
+			if a.boostedStat("atk") > a.boostedStat("spa") {
+				c.category = "Physical"
+			}
+		}
+	case "ivycudgel":
+		switch a.species.Forme {
+		case "Wellspring":
+			c.moveType = "Water"
+		case "Hearthflame":
+			c.moveType = "Fire"
+		case "Cornerstone":
+			c.moveType = "Rock"
+		}
+	}
+
+	c.attackKey, c.defenseKey = "atk", "def"
+	if c.category == "Special" {
+		c.attackKey, c.defenseKey = "spa", "spd"
+	}
+	if c.move.OverrideOffensiveStat != "" {
+		c.attackKey = c.move.OverrideOffensiveStat
+	}
+	if c.move.OverrideDefensiveStat != "" {
+		c.defenseKey = c.move.OverrideDefensiveStat
+	}
+
+	if c.moveType == "Normal" {
+		ateTypes := map[string]string{"aerilate": "Flying", "pixilate": "Fairy", "refrigerate": "Ice", "galvanize": "Electric"}
+		if t, ok := ateTypes[a.ability]; ok {
+			c.moveType = t
+			c.notes.attackerAbility = true
+		}
+	}
+}
+
+// typeEffectiveness returns the move's effectiveness against the defender,
+// including immunities from abilities and items.
+func (c *calculation) typeEffectiveness() float64 {
+	a, d := c.attacker, c.defender
+	id := dex.ID(c.move.Name)
+
+	eff := 1.0
+	for _, t := range d.types {
+		e := typeEffectiveness(c.moveType, t)
+		switch {
+		case id == "freezedry" && t == "Water":
+			e = 2
+		case t == "Ghost" && (c.moveType == "Normal" || c.moveType == "Fighting") &&
+			(a.ability == "scrappy" || a.ability == "mindseye"):
+			e = 1
+		}
+		eff *= e
+	}
+
+	if c.moveType == "Ground" && d.item != "ironball" {
+		switch {
+		case d.item == "airballoon":
+			c.notes.defenderItem = true
+			return 0
+		case c.defAbility == "levitate":
+			c.notes.defenderAbility = true
+			return 0
+		}
+	}
+
+	immune := false
+	switch c.defAbility {
+	case "flashfire", "wellbakedbody":
+		immune = c.moveType == "Fire"
+	case "waterabsorb", "stormdrain", "dryskin":
+		immune = c.moveType == "Water"
+	case "voltabsorb", "lightningrod", "motordrive":
+		immune = c.moveType == "Electric"
+	case "sapsipper":
+		immune = c.moveType == "Grass"
+	case "eartheater":
+		immune = c.moveType == "Ground"
+	case "windrider":
+		immune = c.move.HasFlag("wind")
+	case "bulletproof":
+		immune = c.move.HasFlag("bullet")
+	case "soundproof":
+		immune = c.move.HasFlag("sound")
+	}
+	if immune {
+		c.notes.defenderAbility = true
+		return 0
+	}
+	return eff
+}
+
+// hitCount returns how many times the move hits. Moves with a variable
+// number of hits default to 3, or 4 with Loaded Dice and 5 with Skill Link;
+// Population Bomb defaults to 10.
+func (c *calculation) hitCount(requested int) int {
+	m := c.move
+	if m.MaxHits == 0 {
+		return 1
+	}
+	if m.MinHits == m.MaxHits {
+		return m.MaxHits
+	}
+	if requested > 0 {
+		return min(max(requested, m.MinHits), m.MaxHits)
+	}
+	switch {
+	case m.MaxHits > 5:
+		return m.MaxHits
+	case c.attacker.ability == "skilllink":
+		return m.MaxHits
+	case c.attacker.item == "loadeddice":
+		c.notes.attackerItem = true
+		return 4
+	}
+	return 3
+}
+
+// rolls returns the damage of a single hit for each of the 16 random rolls.
+func (c *calculation) rolls() []int {
+	rolls := make([]int, 16)
+	if c.effectiveness == 0 {
+		return rolls
+	}
+	if damage, ok := c.fixedDamage(); ok {
+		for i := range rolls {
+			rolls[i] = damage
+		}
+		return rolls
+	}
+
+	a := c.attacker
+	basePower := c.basePower()
+	attack := c.attackStat()
+	defense := c.defenseStat()
+
+	baseDamage := (2*a.Level/5+2)*basePower*attack/defense/50 + 2
+
+	if c.move.IsSpread() && !c.field.SingleTarget {
+		baseDamage = applyMod(baseDamage, 3072)
+	}
+	if mod := c.weatherMod(); mod != 4096 {
+		baseDamage = applyMod(baseDamage, mod)
+	}
+	if c.crit {
+		baseDamage = baseDamage * 3 / 2
+	}
+
+	stabMod := c.stabMod()
+	finalMod := chainMods(c.finalMods())
+	burned := a.Status == "burn" && c.category == "Physical" && a.ability != "guts" && dex.ID(c.move.Name) != "facade"
+	if burned {
+		c.notes.burned = true
+	}
+
+	for i := range rolls {
+		damage := float64(baseDamage * (85 + i) / 100)
+		if stabMod != 4096 {
+			damage = damage * float64(stabMod) / 4096
+		}
+		damage = math.Floor(float64(pokeRound(damage)) * c.effectiveness)
+		if burned {
+			damage = math.Floor(damage / 2)
+		}
+		rolls[i] = pokeRound(math.Max(1, damage*float64(finalMod)/4096))
+	}
+	return rolls
+}
+
+// fixedDamage returns the damage of moves that ignore the formula.
+func (c *calculation) fixedDamage() (int, bool) {
+	switch dex.ID(c.move.Name) {
+	case "seismictoss", "nightshade":
+		return c.attacker.Level, true
+	case "superfang", "ruination":
+		return max(1, c.defender.hp/2), true
+	}
+	return 0, false
+}
+
+// basePower returns the move's power after every base power modifier.
+func (c *calculation) basePower() int {
+	a, d, f := c.attacker, c.defender, c.field
+	bp := c.move.BasePower
+
+	switch dex.ID(c.move.Name) {
+	case "lowkick", "grassknot":
+		bp = weightPower(d.species.WeightKg)
+	case "heavyslam", "heatcrash":
+		bp = weightRatioPower(a.species.WeightKg, d.species.WeightKg)
+	case "eruption", "waterspout", "dragonenergy":
+		bp = max(1, bp*a.hp/a.stats.HP)
+	case "facade":
+		if a.Status != "" {
+			bp *= 2
+		}
+	case "hex":
+		if d.Status != "" {
+			bp *= 2
+		}
+	case "acrobatics":
+		if a.item == "" {
+			bp *= 2
+		}
+	case "weatherball":
+		if f.Weather != "" {
+			bp *= 2
+		}
+	case "risingvoltage":
+		if f.Terrain == "Electric" && d.grounded() {
+			bp *= 2
+		}
+	case "expandingforce":
+		if f.Terrain == "Psychic" && a.grounded() {
+			bp = bp * 3 / 2
+		}
+	}
+
+	// Terastallizing raises weak moves of the Tera type to 60 power
+	if a.teraActive() && c.moveType == a.TeraType && bp < 60 && c.move.Priority <= 0 && c.move.MaxHits == 0 {
+		bp = 60
+	}
+
+	var mods []int
+	attackerAbility := func(applies bool, mod int) {
+		if applies {
+			mods = append(mods, mod)
+			c.notes.attackerAbility = true
+		}
+	}
+	attackerAbility(a.ability == "technician" && bp <= 60, 6144)
+	attackerAbility(a.ability == "sandforce" && f.Weather == "Sand" &&
+		(c.moveType == "Rock" || c.moveType == "Ground" || c.moveType == "Steel"), 5325)
+	if c.moveType != c.move.Type && c.move.Type == "Normal" {
+		switch a.ability {
+		case "aerilate", "pixilate", "refrigerate", "galvanize":
+			mods = append(mods, 4915)
+		}
+	}
+	attackerAbility(a.ability == "ironfist" && c.move.HasFlag("punch"), 4915)
+	attackerAbility(a.ability == "toughclaws" && c.move.HasFlag("contact") && a.item != "punchingglove", 5325)
+	attackerAbility(a.ability == "strongjaw" && c.move.HasFlag("bite"), 6144)
+	attackerAbility(a.ability == "megalauncher" && c.move.HasFlag("pulse"), 6144)
+	attackerAbility(a.ability == "sharpness" && c.move.HasFlag("slicing"), 6144)
+	attackerAbility(a.ability == "punkrock" && c.move.HasFlag("sound"), 5325)
+
+	if c.defAbility == "dryskin" && c.moveType == "Fire" {
+		mods = append(mods, 5120)
+		c.notes.defenderAbility = true
+	}
+
+	if f.HelpingHand {
+		mods = append(mods, 6144)
+	}
+	if f.Battery && c.category == "Special" {
+		mods = append(mods, 5325)
+	}
+	if f.PowerSpot {
+		mods = append(mods, 5325)
+	}
+
+	terrainTypes := map[string]string{"Electric": "Electric", "Grassy": "Grass", "Psychic": "Psychic"}
+	if t, ok := terrainTypes[f.Terrain]; ok && c.moveType == t && a.grounded() {
+		mods = append(mods, 5325)
+		c.notes.terrain = true
+	}
+	if f.Terrain == "Misty" && c.moveType == "Dragon" && d.grounded() {
+		mods = append(mods, 2048)
+		c.notes.terrain = true
+	}
+	if id := dex.ID(c.move.Name); f.Terrain == "Grassy" && (id == "earthquake" || id == "bulldoze") && d.grounded() {
+		mods = append(mods, 2048)
+		c.notes.terrain = true
+	}
+
+	if mod := c.itemPowerMod(); mod != 4096 {
+		mods = append(mods, mod)
+		c.notes.attackerItem = true
+	}
+
+	switch dex.ID(c.move.Name) {
+	case "knockoff":
+		if d.item != "" {
+			mods = append(mods, 6144)
+		}
+	case "collisioncourse", "electrodrift":
+		if c.effectiveness > 1 {
+			mods = append(mods, 5461)
+		}
+	}
+
+	return max(1, applyMod(bp, chainMods(mods)))
+}
+
+// itemPowerMod returns the attacker's item's base power modifier.
+func (c *calculation) itemPowerMod() int {
+	a := c.attacker
+	switch a.item {
+	case "muscleband":
+		if c.category == "Physical" {
+			return 4505
+		}
+	case "wiseglasses":
+		if c.category == "Special" {
+			return 4505
+		}
+	case "punchingglove":
+		if c.move.HasFlag("punch") {
+			return 4506
+		}
+	}
+	if item, ok := dex.LookupItem(a.Item); ok {
+		switch {
+		case item.Category == "type-boost" && item.Type == c.moveType:
+			return 4915
+		case item.Category == "mask" && strings.HasPrefix(a.species.Name, "Ogerpon"):
+			return 4915
+		}
+	}
+	return 4096
+}
+
+// weightPower returns Low Kick and Grass Knot's power against a target of
+// the given weight.
+func weightPower(kg float64) int {
+	switch {
+	case kg >= 200:
+		return 120
+	case kg >= 100:
+		return 100
+	case kg >= 50:
+		return 80
+	case kg >= 25:
+		return 60
+	case kg >= 10:
+		return 40
+	}
+	return 20
+}
+
+// weightRatioPower returns Heavy Slam and Heat Crash's power from the user's
+// and target's weights.
+func weightRatioPower(userKg, targetKg float64) int {
+	ratio := userKg / targetKg
+	switch {
+	case ratio >= 5:
+		return 120
+	case ratio >= 4:
+		return 100
+	case ratio >= 3:
+		return 80
+	case ratio >= 2:
+		return 60
+	}
+	return 40
+}
+
+// attackStat returns the attacking stat after stat stages and modifiers.
+func (c *calculation) attackStat() int {
+	a, d, f := c.attacker, c.defender, c.field
+
+	source, key := a, c.attackKey
+	if c.move.OverrideOffensivePokemon == "target" {
+		source = d
+	}
+
+	boost := source.boost(key)
+	if c.defAbility == "unaware" || (c.crit && boost < 0) {
+		boost = 0
+	}
+	attack := boostedStat(statValue(source.stats, key), boost)
+
+	if a.ability == "hustle" && c.category == "Physical" {
+		attack = pokeRound(float64(attack*3) / 2)
+		c.notes.attackerAbility = true
+	}
+
+	var mods []int
+	attackerAbility := func(applies bool, mod int) {
+		if applies {
+			mods = append(mods, mod)
+			c.notes.attackerAbility = true
+		}
+	}
+	physical, special := c.category == "Physical", c.category == "Special"
+	attackerAbility((a.ability == "hugepower" || a.ability == "purepower") && physical, 8192)
+	attackerAbility(a.ability == "guts" && a.Status != "" && physical, 6144)
+	attackerAbility(a.ability == "solarpower" && f.Weather == "Sun" && special, 6144)
+	attackerAbility(a.ability == "gorillatactics" && physical, 6144)
+	attackerAbility(a.ability == "orichalcumpulse" && f.Weather == "Sun" && physical, 5461)
+	attackerAbility(a.ability == "hadronengine" && f.Terrain == "Electric" && special, 5461)
+	attackerAbility(a.paradoxBoost(f) == key && (key == "atk" || key == "spa"), 5325)
+	attackerAbility(a.ability == "steelworker" && c.moveType == "Steel", 6144)
+	attackerAbility(a.ability == "rockypayload" && c.moveType == "Rock", 6144)
+	attackerAbility(a.ability == "dragonsmaw" && c.moveType == "Dragon", 6144)
+	attackerAbility(a.ability == "transistor" && c.moveType == "Electric", 5325)
+	attackerAbility(a.ability == "waterbubble" && c.moveType == "Water", 8192)
+
+	switch {
+	case c.defAbility == "thickfat" && (c.moveType == "Fire" || c.moveType == "Ice"),
+		c.defAbility == "heatproof" && c.moveType == "Fire",
+		c.defAbility == "waterbubble" && c.moveType == "Fire",
+		c.defAbility == "purifyingsalt" && c.moveType == "Ghost":
+		mods = append(mods, 2048)
+		c.notes.defenderAbility = true
+	}
+
+	if (a.item == "choiceband" && physical) || (a.item == "choicespecs" && special) {
+		mods = append(mods, 6144)
+		c.notes.attackerItem = true
+	}
+
+	if (key == "atk" && c.ruinActive("tabletsofruin", a)) || (key == "spa" && c.ruinActive("vesselofruin", a)) {
+		mods = append(mods, 3072)
+	}
+
+	return max(1, applyMod(attack, chainMods(mods)))
+}
+
+// defenseStat returns the defending stat after stat stages and modifiers.
+func (c *calculation) defenseStat() int {
+	a, d, f := c.attacker, c.defender, c.field
+
+	key := c.defenseKey
+
+	boost := d.boost(key)
+	if a.ability == "unaware" || (c.crit && boost > 0) {
+		boost = 0
+	}
+	defense := boostedStat(statValue(d.stats, key), boost)
+
+	if f.Weather == "Sand" && key == "spd" && d.hasType("Rock") {
+		defense = pokeRound(float64(defense*3) / 2)
+	}
+	if f.Weather == "Snow" && key == "def" && d.hasType("Ice") {
+		defense = pokeRound(float64(defense*3) / 2)
+	}
+
+	var mods []int
+	if c.defAbility == "furcoat" && key == "def" {
+		mods = append(mods, 8192)
+		c.notes.defenderAbility = true
+	}
+	if c.defAbility != "" && d.paradoxBoost(f) == key {
+		mods = append(mods, 5325)
+		c.notes.defenderAbility = true
+	}
+	if d.item == "eviolite" || (d.item == "assaultvest" && key == "spd") {
+		mods = append(mods, 6144)
+		c.notes.defenderItem = true
+	}
+	if (key == "def" && c.ruinActive("swordofruin", d)) || (key == "spd" && c.ruinActive("beadsofruin", d)) {
+		mods = append(mods, 3072)
+	}
+
+	return max(1, applyMod(defense, chainMods(mods)))
+}
+
+// ruinActive reports whether a Ruin ability is on the field and affects
+// target, which it doesn't if target has the ability itself.
+func (c *calculation) ruinActive(ability string, target *combatant) bool {
+	if target.ability == ability {
+		return false
+	}
+	if c.attacker.ability == ability || c.defender.ability == ability {
+		c.notes.ruin = append(c.notes.ruin, ability)
+		return true
+	}
+	for _, ruin := range c.field.Ruin {
+		if dex.ID(ruin) == ability {
+			c.notes.ruin = append(c.notes.ruin, ability)
+			return true
+		}
+	}
+	return false
+}
+
+// weatherMod returns the weather's modifier for the move.
+func (c *calculation) weatherMod() int {
+	switch c.field.Weather {
+	case "Sun":
+		switch {
+		case c.moveType == "Fire", dex.ID(c.move.Name) == "hydrosteam":
+			c.notes.weather = true
+			return 6144
+		case c.moveType == "Water":
+			c.notes.weather = true
+			return 2048
+		}
+	case "Rain":
+		switch c.moveType {
+		case "Water":
+			c.notes.weather = true
+			return 6144
+		case "Fire":
+			c.notes.weather = true
+			return 2048
+		}
+	}
+	return 4096
+}
+
+// stabMod returns the same-type attack bonus, including Tera STAB and
+// Adaptability.
+func (c *calculation) stabMod() int {
+	a := c.attacker
+	mod := 4096
+	if a.hasOriginalType(c.moveType) {
+		mod += 2048
+	}
+	if a.Terastallized && a.TeraType == c.moveType {
+		mod += 2048
+	}
+	if a.ability == "adaptability" && a.hasType(c.moveType) {
+		c.notes.attackerAbility = true
+		if a.teraActive() && a.hasOriginalType(a.TeraType) {
+			mod += 1024
+		} else {
+			mod += 2048
+		}
+	}
+	return mod
+}
+
+// finalMods returns the modifiers applied after type effectiveness.
+func (c *calculation) finalMods() []int {
+	a, d, f := c.attacker, c.defender, c.field
+	var mods []int
+
+	if !c.crit && a.ability != "infiltrator" {
+		switch {
+		case f.AuroraVeil:
+			mods = append(mods, 2732)
+			c.notes.screen = "Aurora Veil"
+		case f.Reflect && c.category == "Physical":
+			mods = append(mods, 2732)
+			c.notes.screen = "Reflect"
+		case f.LightScreen && c.category == "Special":
+			mods = append(mods, 2732)
+			c.notes.screen = "Light Screen"
+		}
+	}
+
+	if a.ability == "sniper" && c.crit {
+		mods = append(mods, 6144)
+		c.notes.attackerAbility = true
+	}
+	if a.ability == "tintedlens" && c.effectiveness < 1 {
+		mods = append(mods, 8192)
+		c.notes.attackerAbility = true
+	}
+
+	defenderAbility := func(applies bool, mod int) {
+		if applies {
+			mods = append(mods, mod)
+			c.notes.defenderAbility = true
+		}
+	}
+	contact := c.move.HasFlag("contact") && a.item != "punchingglove" && a.item != "protectivepads"
+	defenderAbility((d.ability == "shadowshield" || c.defAbility == "multiscale") && d.hp == d.stats.HP, 2048)
+	defenderAbility(c.defAbility == "fluffy" && contact && c.moveType != "Fire", 2048)
+	defenderAbility(c.defAbility == "fluffy" && !contact && c.moveType == "Fire", 8192)
+	defenderAbility(c.defAbility == "punkrock" && c.move.HasFlag("sound"), 2048)
+	defenderAbility(c.defAbility == "icescales" && c.category == "Special", 2048)
+	defenderAbility((c.defAbility == "solidrock" || c.defAbility == "filter" || d.ability == "prismarmor") && c.effectiveness > 1, 3072)
+
+	if f.FriendGuard {
+		mods = append(mods, 3072)
+	}
+
+	switch {
+	case a.item == "expertbelt" && c.effectiveness > 1:
+		mods = append(mods, 4915)
+		c.notes.attackerItem = true
+	case a.item == "lifeorb":
+		mods = append(mods, 5324)
+		c.notes.attackerItem = true
+	}
+
+	if item, ok := dex.LookupItem(d.Item); ok && item.Category == "berry" && item.Type == c.moveType &&
+		(c.effectiveness > 1 || item.Type == "Normal") {
+		mods = append(mods, 2048)
+		c.notes.defenderItem = true
+	}
+
+	return mods
+}
+
+// percentOf returns damage as a percentage of hp, rounded down to one
+// decimal place.
+func percentOf(damage, hp int) float64 {
+	return math.Floor(float64(damage)*1000/float64(hp)) / 10
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var flutterMane = Pokemon{Species: "Flutter Mane", Nature: "Modest", EVs: map[string]int{"spa": 252}}
+
+var rillaboom = Pokemon{Species: "Rillaboom", EVs: map[string]int{"hp": 4}}
+
+func TestCalcStats(t *testing.T) {
+	tests := []struct {
+		name string
+		poke Pokemon
+		key  string
+		want int
+	}{
+		{"max HP", Pokemon{Species: "Incineroar", EVs: map[string]int{"hp": 252}}, "hp", 202},
+		{"boosting nature", Pokemon{Species: "Flutter Mane", Nature: "Timid", EVs: map[string]int{"spe": 252}}, "spe", 205},
+		{"minimum Speed", Pokemon{Species: "Amoonguss", Nature: "Relaxed", IVs: map[string]int{"spe": 0}}, "spe", 31},
+		{"level 100", Pokemon{Species: "Incineroar", Level: 100}, "hp", 331},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newCombatant(tt.poke)
+			if err != nil {
+				t.Fatalf("newCombatant failed: %v", err)
+			}
+			if got := statValue(c.stats, tt.key); got != tt.want {
+				t.Errorf("expected %s %d, got %d", tt.key, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCalculateSpreadMoveThroughLightScreen(t *testing.T) {
+	result, err := Calculate(flutterMane, rillaboom, Move{Name: "Dazzling Gleam"}, Field{LightScreen: true})
+	if err != nil {
+		t.Fatalf("Calculate failed: %v", err)
+	}
+
+	// 205 SpA into 90 SpD: base damage 82, 61 after the spread reduction,
+	// then STAB and Light Screen on each roll
+	want := []int{51, 52, 53, 53, 54, 54, 55, 56, 56, 57, 57, 58, 59, 59, 60, 61}
+	if !reflect.DeepEqual(result.Rolls, want) {
+		t.Errorf("expected rolls %v, got %v", want, result.Rolls)
+	}
+	if result.MinPercent != 28.9 || result.MaxPercent != 34.6 {
+		t.Errorf("expected 28.9 - 34.6%%, got %v - %v%%", result.MinPercent, result.MaxPercent)
+	}
+	if result.KO.Hits != 3 || result.KO.Text != "6.5% chance to 3HKO" {
+		t.Errorf("expected 6.5%% chance to 3HKO, got %+v", result.KO)
+	}
+
+	wantDesc := "252+ SpA Flutter Mane Dazzling Gleam vs. 4 HP / 0 SpD Rillaboom through Light Screen: 51-61 (28.9 - 34.6%) -- 6.5% chance to 3HKO"
+	if result.Description != wantDesc {
+		t.Errorf("expected description %q, got %q", wantDesc, result.Description)
+	}
+}
+
+func TestCalculateModifiers(t *testing.T) {
+	base, err := Calculate(flutterMane, rillaboom, Move{Name: "Dazzling Gleam"}, Field{})
+	if err != nil {
+		t.Fatalf("Calculate failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		attacker func(*Pokemon)
+		defender func(*Pokemon)
+		field    Field
+		change   int // Compared with the base calc: -1 weaker, 0 the same, 1 stronger
+	}{
+		{name: "single target", field: Field{SingleTarget: true}, change: 1},
+		{name: "Helping Hand", field: Field{HelpingHand: true}, change: 1},
+		{name: "Choice Specs", attacker: func(p *Pokemon) { p.Item = "Choice Specs" }, change: 1},
+		{name: "Tera Fairy", attacker: func(p *Pokemon) { p.TeraType, p.Terastallized = "Fairy", true }, change: 1},
+		{name: "Tera into another type keeps STAB", attacker: func(p *Pokemon) { p.TeraType, p.Terastallized = "Water", true }, change: 0},
+		{name: "Beads of Ruin", field: Field{Ruin: []string{"Beads of Ruin"}}, change: 1},
+		{name: "SpD drop", defender: func(p *Pokemon) { p.Boosts = map[string]int{"spd": -1} }, change: 1},
+		{name: "Assault Vest", defender: func(p *Pokemon) { p.Item = "Assault Vest" }, change: -1},
+		{name: "Friend Guard", field: Field{FriendGuard: true}, change: -1},
+		{name: "Misty Terrain doesn't affect Fairy moves", field: Field{Terrain: "Misty"}, change: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attacker, defender := flutterMane, rillaboom
+			if tt.attacker != nil {
+				tt.attacker(&attacker)
+			}
+			if tt.defender != nil {
+				tt.defender(&defender)
+			}
+			result, err := Calculate(attacker, defender, Move{Name: "Dazzling Gleam"}, tt.field)
+			if err != nil {
+				t.Fatalf("Calculate failed: %v", err)
+			}
+			change := 0
+			switch {
+			case result.Max > base.Max:
+				change = 1
+			case result.Max < base.Max:
+				change = -1
+			}
+			if change != tt.change {
+				t.Errorf("expected change %d from max %d, got max %d", tt.change, base.Max, result.Max)
+			}
+		})
+	}
+}
+
+func TestCalculateCrit(t *testing.T) {
+	attacker := Pokemon{Species: "Urshifu", Item: "Choice Band", Nature: "Adamant", EVs: map[string]int{"atk": 252}}
+	defender := Pokemon{Species: "Incineroar", EVs: map[string]int{"hp": 252}, Boosts: map[string]int{"def": 2}}
+
+	// Wicked Blow always crits, so it ignores the Defense boost and Reflect
+	withReflect, err := Calculate(attacker, defender, Move{Name: "Wicked Blow"}, Field{Reflect: true})
+	if err != nil {
+		t.Fatalf("Calculate failed: %v", err)
+	}
+	defender.Boosts = nil
+	plain, err := Calculate(attacker, defender, Move{Name: "Wicked Blow"}, Field{})
+	if err != nil {
+		t.Fatalf("Calculate failed: %v", err)
+	}
+	if !withReflect.Crit || !reflect.DeepEqual(withReflect.Rolls, plain.Rolls) {
+		t.Errorf("expected a crit through Reflect to match %v, got %v", plain.Rolls, withReflect.Rolls)
+	}
+	if !strings.Contains(plain.Description, "Choice Band Urshifu Wicked Blow") || !strings.HasSuffix(strings.Split(plain.Description, ":")[0], "on a critical hit") {
+		t.Errorf("unexpected description %q", plain.Description)
+	}
+}
+
+func TestCalculateMultiHit(t *testing.T) {
+	attacker := Pokemon{Species: "Urshifu-Rapid-Strike", Nature: "Adamant", EVs: map[string]int{"atk": 252}}
+	defender := Pokemon{Species: "Incineroar", EVs: map[string]int{"hp": 252}}
+
+	result, err := Calculate(attacker, defender, Move{Name: "Surging Strikes"}, Field{})
+	if err != nil {
+		t.Fatalf("Calculate failed: %v", err)
+	}
+	if result.Hits != 3 {
+		t.Fatalf("expected 3 hits, got %d", result.Hits)
+	}
+	for _, roll := range result.Rolls {
+		if roll%3 != 0 {
+			t.Errorf("expected every roll to total 3 equal hits, got %v", result.Rolls)
+			break
+		}
+	}
+
+	bullet, err := Calculate(Pokemon{Species: "Rillaboom", Item: "Loaded Dice"}, defender, Move{Name: "Bullet Seed"}, Field{})
+	if err != nil {
+		t.Fatalf("Calculate failed: %v", err)
+	}
+	if bullet.Hits != 4 {
+		t.Errorf("expected Loaded Dice to default to 4 hits, got %d", bullet.Hits)
+	}
+}
+
+func TestCalculateImmunities(t *testing.T) {
+	tests := []struct {
+		name     string
+		attacker Pokemon
+		defender Pokemon
+		move     string
+	}{
+		{"type immunity", Pokemon{Species: "Incineroar"}, Pokemon{Species: "Flutter Mane"}, "Fake Out"},
+		{"Levitate", Pokemon{Species: "Incineroar"}, Pokemon{Species: "Rotom-Wash", Ability: "Levitate"}, "Earthquake"},
+		{"Flash Fire", Pokemon{Species: "Incineroar"}, Pokemon{Species: "Heatran", Ability: "Flash Fire"}, "Flare Blitz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Calculate(tt.attacker, tt.defender, Move{Name: tt.move}, Field{})
+			if err != nil {
+				t.Fatalf("Calculate failed: %v", err)
+			}
+			if result.Max != 0 || result.KO.Text != "no damage" {
+				t.Errorf("expected no damage, got %v (%s)", result.Rolls, result.KO.Text)
+			}
+		})
+	}
+
+	// Mold Breaker ignores Levitate
+	result, err := Calculate(Pokemon{Species: "Ogerpon-Hearthflame", Ability: "Mold Breaker"},
+		Pokemon{Species: "Rotom-Wash", Ability: "Levitate"}, Move{Name: "Earthquake"}, Field{})
+	if err != nil {
+		t.Fatalf("Calculate failed: %v", err)
+	}
+	if result.Max == 0 {
+		t.Error("expected Mold Breaker to hit through Levitate")
+	}
+}
+
+func TestCalculateRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		attacker Pokemon
+		move     string
+		field    Field
+	}{
+		{"unknown species", Pokemon{Species: "Missingno"}, "Moonblast", Field{}},
+		{"unknown move", flutterMane, "Splash Attack", Field{}},
+		{"too many EVs", Pokemon{Species: "Flutter Mane", EVs: map[string]int{"spa": 252, "spe": 252, "hp": 12}}, "Moonblast", Field{}},
+		{"unknown nature", Pokemon{Species: "Flutter Mane", Nature: "Sleepy"}, "Moonblast", Field{}},
+		{"boost out of range", Pokemon{Species: "Flutter Mane", Boosts: map[string]int{"spa": 7}}, "Moonblast", Field{}},
+		{"unknown weather", flutterMane, "Moonblast", Field{Weather: "Hail"}},
+		{"Tera without a type", Pokemon{Species: "Flutter Mane", Terastallized: true}, "Moonblast", Field{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Calculate(tt.attacker, rillaboom, Move{Name: tt.move}, tt.field); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := Calculate(flutterMane, rillaboom, Move{Name: "Protect"}, Field{}); !errors.Is(err, ErrStatusMove) {
+		t.Errorf("expected ErrStatusMove, got %v", err)
+	}
+	if _, err := Calculate(flutterMane, rillaboom, Move{Name: "Counter"}, Field{}); !errors.Is(err, ErrUnsupportedMove) {
+		t.Errorf("expected ErrUnsupportedMove, got %v", err)
+	}
+}
+
+func TestStabMod(t *testing.T) {
+	tests := []struct {
+		name     string
+		attacker Pokemon
+		moveType string
+		want     int
+	}{
+		{"no STAB", Pokemon{Species: "Flutter Mane"}, "Water", 4096},
+		{"STAB", Pokemon{Species: "Flutter Mane"}, "Fairy", 6144},
+		{"Tera into own type", Pokemon{Species: "Flutter Mane", TeraType: "Fairy", Terastallized: true}, "Fairy", 8192},
+		{"Tera into new type", Pokemon{Species: "Flutter Mane", TeraType: "Water", Terastallized: true}, "Water", 6144},
+		{"Tera type set but not used", Pokemon{Species: "Flutter Mane", TeraType: "Fairy"}, "Fairy", 6144},
+		{"Adaptability", Pokemon{Species: "Porygon2", Ability: "Adaptability"}, "Normal", 8192},
+		{"Adaptability Tera into own type", Pokemon{Species: "Porygon2", Ability: "Adaptability", TeraType: "Normal", Terastallized: true}, "Normal", 9216},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := newCombatant(tt.attacker)
+			if err != nil {
+				t.Fatalf("newCombatant failed: %v", err)
+			}
+			c := &calculation{attacker: a, moveType: tt.moveType}
+			if got := c.stabMod(); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestKOChance(t *testing.T) {
+	rolls := func(low, high int) []int {
+		r := make([]int, 16)
+		for i := range r {
+			r[i] = low + (high-low)*i/15
+		}
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		rolls []int
+		hp    int
+		want  KOChance
+	}{
+		{"guaranteed OHKO", rolls(200, 240), 180, KOChance{Hits: 1, Chance: 1, Text: "guaranteed OHKO"}},
+		{"roll to OHKO", rolls(85, 100), 94, KOChance{Hits: 1, Chance: 0.4375, Text: "43.8% chance to OHKO"}},
+		{"guaranteed 2HKO", rolls(100, 120), 190, KOChance{Hits: 2, Chance: 1, Text: "guaranteed 2HKO"}},
+		{"out of range", rolls(1, 2), 200, KOChance{Text: "not a KO in 4 hits"}},
+		{"no damage", make([]int, 16), 200, KOChance{Text: "no damage"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := koChance(tt.rolls, tt.hp); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package calc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// descNotes records which inputs affected the damage, so the description
+// only mentions those.
+type descNotes struct {
+	attackerAbility bool
+	attackerItem    bool
+	defenderAbility bool
+	defenderItem    bool
+	burned          bool
+	weather         bool
+	terrain         bool
+	screen          string   // "Reflect", "Light Screen" or "Aurora Veil"
+	ruin            []string // Ruin ability IDs that applied
+}
+
+var statLabels = map[string]string{"hp": "HP", "atk": "Atk", "def": "Def", "spa": "SpA", "spd": "SpD", "spe": "Spe"}
+
+var ruinNames = map[string]string{
+	"swordofruin":   "Sword of Ruin",
+	"beadsofruin":   "Beads of Ruin",
+	"tabletsofruin": "Tablets of Ruin",
+	"vesselofruin":  "Vessel of Ruin",
+}
+
+// describe summarises the calculation in the style of Showdown's
+// calculator, e.g. "252+ Atk Choice Band Urshifu Wicked Blow vs. 252 HP /
+// 4 Def Incineroar: 106-126 (52.7 - 62.6%) -- guaranteed 2HKO".
+func (c *calculation) describe(r *Result) string {
+	a, d, f := c.attacker, c.defender, c.field
+	var b strings.Builder
+
+	b.WriteString(spreadLabel(a, c.attackKey))
+	if c.notes.attackerItem {
+		b.WriteString(" " + a.Item)
+	}
+	if c.notes.attackerAbility {
+		b.WriteString(" " + a.Ability)
+	}
+	if c.notes.burned {
+		b.WriteString(" burned")
+	}
+	if a.Terastallized {
+		b.WriteString(" Tera " + a.TeraType)
+	}
+	b.WriteString(" " + a.Species)
+	if f.HelpingHand {
+		b.WriteString(" Helping Hand")
+	}
+	b.WriteString(" " + c.move.Name)
+	if r.Hits > 1 {
+		fmt.Fprintf(&b, " (%d hits)", r.Hits)
+	}
+
+	b.WriteString(" vs. ")
+	fmt.Fprintf(&b, "%d HP / %s", d.EVs["hp"], spreadLabel(d, c.defenseKey))
+	if c.notes.defenderItem {
+		b.WriteString(" " + d.Item)
+	}
+	if c.notes.defenderAbility {
+		b.WriteString(" " + d.Ability)
+	}
+	if d.Terastallized {
+		b.WriteString(" Tera " + d.TeraType)
+	}
+	b.WriteString(" " + d.Species)
+
+	if c.notes.screen != "" {
+		b.WriteString(" through " + c.notes.screen)
+	}
+	if f.FriendGuard {
+		b.WriteString(" with an ally's Friend Guard")
+	}
+	seen := make(map[string]bool)
+	for _, ruin := range c.notes.ruin {
+		if !seen[ruin] {
+			seen[ruin] = true
+			b.WriteString(" with " + ruinNames[ruin])
+		}
+	}
+	if c.notes.weather {
+		b.WriteString(" in " + f.Weather)
+	}
+	if c.notes.terrain {
+		b.WriteString(" in " + f.Terrain + " Terrain")
+	}
+	if r.Crit {
+		b.WriteString(" on a critical hit")
+	}
+
+	fmt.Fprintf(&b, ": %d-%d (%s - %s%%) -- %s", r.Min, r.Max, formatPercent(r.MinPercent), formatPercent(r.MaxPercent), r.KO.Text)
+	return b.String()
+}
+
+// spreadLabel describes a Pokémon's investment in a stat, e.g. "+1 252+ Atk".
+func spreadLabel(c *combatant, key string) string {
+	label := strconv.Itoa(c.EVs[key])
+	switch nature := natures[c.Nature]; key {
+	case nature[0]:
+		label += "+"
+	case nature[1]:
+		label += "-"
+	}
+	label += " " + statLabels[key]
+	if boost := c.boost(key); boost != 0 {
+		label = fmt.Sprintf("%+d %s", boost, label)
+	}
+	return label
+}
+
+func formatPercent(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
@@ -0,0 +1,67 @@
+package calc
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// maxKOHits is how many hits koChance looks ahead.
+const maxKOHits = 4
+
+// KOChance is the chance for repeated uses of a move to knock out the
+// defender, assuming each use rolls independently and nothing restores HP in
+// between.
+type KOChance struct {
+	Hits   int     `json:"hits"`   // Fewest uses with a chance to KO; 0 if more than 4
+	Chance float64 `json:"chance"` // Chance to KO in that many uses, from 0 to 1
+	Text   string  `json:"text"`   // e.g. "guaranteed OHKO" or "43.8% chance to 2HKO"
+}
+
+// koChance works out the fewest uses of a move with the given rolls that can
+// KO a defender with hp HP, and the chance they do.
+func koChance(rolls []int, hp int) KOChance {
+	if rolls[len(rolls)-1] == 0 {
+		return KOChance{Text: "no damage"}
+	}
+
+	// dist[s] is the chance of having dealt s damage so far; damage at or
+	// past hp all lands in dist[hp]
+	dist, next := make([]float64, hp+1), make([]float64, hp+1)
+	dist[0] = 1
+	for hits := 1; hits <= maxKOHits; hits++ {
+		clear(next)
+		for dealt, p := range dist {
+			if p == 0 {
+				continue
+			}
+			if dealt == hp {
+				next[hp] += p
+				continue
+			}
+			for _, roll := range rolls {
+				next[min(dealt+roll, hp)] += p / float64(len(rolls))
+			}
+		}
+		dist, next = next, dist
+
+		if chance := dist[hp]; chance > 0 {
+			return KOChance{Hits: hits, Chance: chance, Text: koText(hits, chance)}
+		}
+	}
+	return KOChance{Text: fmt.Sprintf("not a KO in %d hits", maxKOHits)}
+}
+
+// koText describes a KO chance the way Showdown's calculator does.
+func koText(hits int, chance float64) string {
+	name := "OHKO"
+	if hits > 1 {
+		name = fmt.Sprintf("%dHKO", hits)
+	}
+	// Guard against float error on a certain KO
+	if chance > 0.99999 {
+		return "guaranteed " + name
+	}
+	percent := math.Round(chance*1000) / 10
+	return strconv.FormatFloat(percent, 'f', -1, 64) + "% chance to " + name
+}
@@ -0,0 +1,168 @@
+package calc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dtsong/vgccorner/backend/internal/dex"
+)
+
+// statKeys are the keys used for EVs, IVs and boosts, matching the dex's
+// base stat keys.
+var statKeys = []string{"hp", "atk", "def", "spa", "spd", "spe"}
+
+// natures maps each nature to the stats it raises and lowers. Neutral
+// natures raise and lower nothing.
+var natures = map[string][2]string{
+	"Hardy": {}, "Docile": {}, "Serious": {}, "Bashful": {}, "Quirky": {},
+	"Lonely": {"atk", "def"}, "Brave": {"atk", "spe"}, "Adamant": {"atk", "spa"}, "Naughty": {"atk", "spd"},
+	"Bold": {"def", "atk"}, "Relaxed": {"def", "spe"}, "Impish": {"def", "spa"}, "Lax": {"def", "spd"},
+	"Timid": {"spe", "atk"}, "Hasty": {"spe", "def"}, "Jolly": {"spe", "spa"}, "Naive": {"spe", "spd"},
+	"Modest": {"spa", "atk"}, "Mild": {"spa", "def"}, "Quiet": {"spa", "spe"}, "Rash": {"spa", "spd"},
+	"Calm": {"spd", "atk"}, "Gentle": {"spd", "def"}, "Sassy": {"spd", "spe"}, "Careful": {"spd", "spa"},
+}
+
+// validateSpread checks a Pokémon's level, nature, EVs, IVs and boosts.
+func validateSpread(p *Pokemon) error {
+	if p.Level < 1 || p.Level > 100 {
+		return fmt.Errorf("level must be between 1 and 100, got %d", p.Level)
+	}
+	if p.Nature != "" {
+		if _, ok := natures[p.Nature]; !ok {
+			return fmt.Errorf("unknown nature %q", p.Nature)
+		}
+	}
+
+	total := 0
+	for key, ev := range p.EVs {
+		if !isStatKey(key) {
+			return fmt.Errorf("unknown EV stat %q", key)
+		}
+		if ev < 0 || ev > 252 {
+			return fmt.Errorf("%s EVs must be between 0 and 252, got %d", key, ev)
+		}
+		total += ev
+	}
+	if total > 510 {
+		return fmt.Errorf("EVs total %d, more than 510", total)
+	}
+	for key, iv := range p.IVs {
+		if !isStatKey(key) {
+			return fmt.Errorf("unknown IV stat %q", key)
+		}
+		if iv < 0 || iv > 31 {
+			return fmt.Errorf("%s IVs must be between 0 and 31, got %d", key, iv)
+		}
+	}
+	for key, boost := range p.Boosts {
+		if !isStatKey(key) || key == "hp" {
+			return fmt.Errorf("unknown boost stat %q", key)
+		}
+		if boost < -6 || boost > 6 {
+			return fmt.Errorf("%s boost must be between -6 and +6, got %d", key, boost)
+		}
+	}
+	return nil
+}
+
+func isStatKey(key string) bool {
+	for _, k := range statKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// calcStats works out a Pokémon's stats from its base stats, EVs, IVs,
+// nature and level. IVs default to 31.
+func calcStats(base dex.Stats, p *Pokemon) dex.Stats {
+	stat := func(key string, baseStat int) int {
+		iv, ok := p.IVs[key]
+		if !ok {
+			iv = 31
+		}
+		core := (2*baseStat + iv + p.EVs[key]/4) * p.Level / 100
+		if key == "hp" {
+			return core + p.Level + 10
+		}
+		value := core + 5
+		switch nature := natures[p.Nature]; key {
+		case nature[0]:
+			value = value * 110 / 100
+		case nature[1]:
+			value = value * 90 / 100
+		}
+		return value
+	}
+
+	stats := dex.Stats{
+		HP:  stat("hp", base.HP),
+		Atk: stat("atk", base.Atk),
+		Def: stat("def", base.Def),
+		SpA: stat("spa", base.SpA),
+		SpD: stat("spd", base.SpD),
+		Spe: stat("spe", base.Spe),
+	}
+	// Shedinja always has 1 HP
+	if base.HP == 1 {
+		stats.HP = 1
+	}
+	return stats
+}
+
+// statValue returns the stat with the given key.
+func statValue(stats dex.Stats, key string) int {
+	switch key {
+	case "hp":
+		return stats.HP
+	case "atk":
+		return stats.Atk
+	case "def":
+		return stats.Def
+	case "spa":
+		return stats.SpA
+	case "spd":
+		return stats.SpD
+	case "spe":
+		return stats.Spe
+	}
+	return 0
+}
+
+// boostedStat applies a stat stage to a stat.
+func boostedStat(stat, boost int) int {
+	switch {
+	case boost > 0:
+		return stat * (2 + boost) / 2
+	case boost < 0:
+		return stat * 2 / (2 - boost)
+	}
+	return stat
+}
+
+// pokeRound rounds to the nearest integer, with halves rounding down as the
+// games do.
+func pokeRound(x float64) int {
+	if x-math.Floor(x) > 0.5 {
+		return int(math.Ceil(x))
+	}
+	return int(math.Floor(x))
+}
+
+// chainMods combines 4096-based modifiers the way the games do, rounding
+// after each one.
+func chainMods(mods []int) int {
+	m := 4096
+	for _, mod := range mods {
+		if mod != 4096 {
+			m = (m*mod + 2048) >> 12
+		}
+	}
+	return m
+}
+
+// applyMod applies a 4096-based modifier to a value.
+func applyMod(value, mod int) int {
+	return pokeRound(float64(value*mod) / 4096)
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 )
 
@@ -25,6 +26,11 @@ func NewDatabase(connString string) (*Database, error) {
 	return &Database{conn: conn}, nil
 }
 
+// NewDatabaseFromConn wraps an open connection, such as a mock in tests.
+func NewDatabaseFromConn(conn *sql.DB) *Database {
+	return &Database{conn: conn}
+}
+
 // Close closes the database connection.
 func (db *Database) Close() error {
 	return db.conn.Close()
@@ -73,11 +79,14 @@ func (db *Database) StoreBattle(ctx context.Context, battle *Battle) (string, er
 	err := db.WithTx(ctx, func(tx *sql.Tx) error {
 		// Insert battle
 		err := tx.QueryRowContext(ctx,
-			`INSERT INTO battles (format, timestamp, duration_sec, winner, player1_id, player2_id, battle_log, is_private, created_at, updated_at)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+			`INSERT INTO battles (replay_id, series_id, game_number, format, timestamp, duration_sec, winner, player1_id, player2_id, battle_log, is_private,
+			                      rated, player1_rating, player2_rating, player1_avatar, player2_avatar, created_at, updated_at)
+			 VALUES (NULLIF($1, ''), NULLIF($2, ''), NULLIF($3, 0), $4, $5, $6, $7, $8, $9, $10, $11,
+			         $12, NULLIF($13, 0), NULLIF($14, 0), NULLIF($15, ''), NULLIF($16, ''), NOW(), NOW())
 			 RETURNING id`,
-			battle.Format, battle.Timestamp, battle.DurationSec, battle.Winner,
+			battle.ReplayID, battle.SeriesID, battle.GameNumber, battle.Format, battle.Timestamp, battle.DurationSec, battle.Winner,
 			battle.Player1ID, battle.Player2ID, battle.BattleLog, battle.IsPrivate,
+			battle.Rated, battle.Player1Rating, battle.Player2Rating, battle.Player1Avatar, battle.Player2Avatar,
 		).Scan(&battleID)
 
 		if err != nil {
@@ -109,11 +118,16 @@ func (db *Database) StoreBattle(ctx context.Context, battle *Battle) (string, er
 // GetBattle retrieves a battle by ID.
 func (db *Database) GetBattle(ctx context.Context, battleID string) (*Battle, error) {
 	var b Battle
+	var replayID, seriesID sql.NullString
+	var gameNumber sql.NullInt64
+	var ratings ratingColumns
 	err := db.QueryRow(ctx,
-		`SELECT id, format, timestamp, duration_sec, winner, player1_id, player2_id, battle_log, is_private, created_at, updated_at
+		`SELECT id, replay_id, series_id, game_number, format, timestamp, duration_sec, winner, player1_id, player2_id, battle_log, is_private,
+		        rated, player1_rating, player2_rating, player1_avatar, player2_avatar, created_at, updated_at
 		 FROM battles WHERE id = $1`,
 		battleID,
-	).Scan(&b.ID, &b.Format, &b.Timestamp, &b.DurationSec, &b.Winner, &b.Player1ID, &b.Player2ID, &b.BattleLog, &b.IsPrivate, &b.CreatedAt, &b.UpdatedAt)
+	).Scan(&b.ID, &replayID, &seriesID, &gameNumber, &b.Format, &b.Timestamp, &b.DurationSec, &b.Winner, &b.Player1ID, &b.Player2ID, &b.BattleLog, &b.IsPrivate,
+		&b.Rated, &ratings.player1Rating, &ratings.player2Rating, &ratings.player1Avatar, &ratings.player2Avatar, &b.CreatedAt, &b.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -121,6 +135,10 @@ func (db *Database) GetBattle(ctx context.Context, battleID string) (*Battle, er
 		}
 		return nil, err
 	}
+	b.ReplayID = replayID.String
+	b.SeriesID = seriesID.String
+	b.GameNumber = int(gameNumber.Int64)
+	ratings.apply(&b)
 
 	// Get analysis data
 	analysis, err := getBattleAnalysis(ctx, db, battleID)
@@ -141,7 +159,9 @@ func (db *Database) GetBattle(ctx context.Context, battleID string) (*Battle, er
 
 // ListBattles retrieves battles with optional filtering.
 func (db *Database) ListBattles(ctx context.Context, filter *BattleFilter, limit int, offset int) ([]*Battle, int, error) {
-	query := `SELECT id, format, timestamp, duration_sec, winner, player1_id, player2_id, is_private FROM battles WHERE 1=1`
+	query := `SELECT id, format, timestamp, duration_sec, winner, player1_id, player2_id, is_private,
+	                 rated, player1_rating, player2_rating, player1_avatar, player2_avatar
+	          FROM battles WHERE 1=1`
 	var args []interface{}
 	argIndex := 1
 
@@ -156,6 +176,23 @@ func (db *Database) ListBattles(ctx context.Context, filter *BattleFilter, limit
 			args = append(args, *filter.IsPrivate)
 			argIndex++
 		}
+		if filter.Rated != nil {
+			query += fmt.Sprintf(" AND rated = $%d", argIndex)
+			args = append(args, *filter.Rated)
+			argIndex++
+		}
+		if filter.MinRating > 0 {
+			query += fmt.Sprintf(" AND %s >= $%d", battleRatingExpr, argIndex)
+			args = append(args, filter.MinRating)
+			argIndex++
+		}
+		if filter.Lead != "" {
+			query += fmt.Sprintf(` AND EXISTS (SELECT 1 FROM battle_team_choices c
+			                                  WHERE c.battle_id = battles.id AND c.role = '%s' AND c.pokemon = $%d)`,
+				TeamRoleLead, argIndex)
+			args = append(args, filter.Lead)
+			argIndex++
+		}
 	}
 
 	// Get total count
@@ -171,7 +208,12 @@ func (db *Database) ListBattles(ctx context.Context, filter *BattleFilter, limit
 		return nil, 0, err
 	}
 
-	query += fmt.Sprintf(" ORDER BY timestamp DESC LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+	orderBy := "timestamp DESC"
+	if filter != nil && filter.SortBy == SortByRating {
+		orderBy = battleRatingExpr + " DESC, timestamp DESC"
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", orderBy, argIndex, argIndex+1)
 	args = append(args, limit, offset)
 
 	rows, err := db.Query(ctx, query, args...)
@@ -185,18 +227,110 @@ func (db *Database) ListBattles(ctx context.Context, filter *BattleFilter, limit
 	var battles []*Battle
 	for rows.Next() {
 		var b Battle
-		err := rows.Scan(&b.ID, &b.Format, &b.Timestamp, &b.DurationSec, &b.Winner, &b.Player1ID, &b.Player2ID, &b.IsPrivate)
+		var ratings ratingColumns
+		err := rows.Scan(&b.ID, &b.Format, &b.Timestamp, &b.DurationSec, &b.Winner, &b.Player1ID, &b.Player2ID, &b.IsPrivate,
+			&b.Rated, &ratings.player1Rating, &ratings.player2Rating, &ratings.player1Avatar, &ratings.player2Avatar)
 		if err != nil {
 			return nil, 0, err
 		}
+		ratings.apply(&b)
 		battles = append(battles, &b)
 	}
 
 	return battles, total, rows.Err()
 }
 
+// GetSeriesBattles retrieves all games of a best-of-N series, ordered by game number.
+func (db *Database) GetSeriesBattles(ctx context.Context, seriesID string) ([]*Battle, error) {
+	rows, err := db.Query(ctx,
+		`SELECT id, replay_id, series_id, game_number, format, timestamp, duration_sec, winner, player1_id, player2_id, battle_log, is_private
+		 FROM battles WHERE series_id = $1 ORDER BY game_number, timestamp`,
+		seriesID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var battles []*Battle
+	for rows.Next() {
+		var b Battle
+		var replayID, battleSeriesID sql.NullString
+		var gameNumber sql.NullInt64
+		err := rows.Scan(&b.ID, &replayID, &battleSeriesID, &gameNumber, &b.Format, &b.Timestamp, &b.DurationSec, &b.Winner, &b.Player1ID, &b.Player2ID, &b.BattleLog, &b.IsPrivate)
+		if err != nil {
+			return nil, err
+		}
+		b.ReplayID = replayID.String
+		b.SeriesID = battleSeriesID.String
+		b.GameNumber = int(gameNumber.Int64)
+		battles = append(battles, &b)
+	}
+
+	return battles, rows.Err()
+}
+
+// GetPlayerBattles retrieves a player's most recent battles with their logs,
+// newest first, optionally only those of one format. Players are matched by
+// name, ignoring case.
+func (db *Database) GetPlayerBattles(ctx context.Context, player, format string, limit int) ([]*Battle, error) {
+	query := `SELECT id, replay_id, series_id, game_number, format, timestamp, duration_sec, winner, player1_id, player2_id, battle_log, is_private
+	          FROM battles WHERE (LOWER(player1_id) = LOWER($1) OR LOWER(player2_id) = LOWER($1))`
+	args := []interface{}{player}
+	if format != "" {
+		query += " AND format = $2"
+		args = append(args, format)
+	}
+	query += fmt.Sprintf(" ORDER BY timestamp DESC LIMIT $%d", len(args)+1)
+	args = append(args, limit)
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var battles []*Battle
+	for rows.Next() {
+		var b Battle
+		var replayID, seriesID sql.NullString
+		var gameNumber sql.NullInt64
+		err := rows.Scan(&b.ID, &replayID, &seriesID, &gameNumber, &b.Format, &b.Timestamp, &b.DurationSec, &b.Winner, &b.Player1ID, &b.Player2ID, &b.BattleLog, &b.IsPrivate)
+		if err != nil {
+			return nil, err
+		}
+		b.ReplayID = replayID.String
+		b.SeriesID = seriesID.String
+		b.GameNumber = int(gameNumber.Int64)
+		battles = append(battles, &b)
+	}
+
+	return battles, rows.Err()
+}
+
 // Helper functions
 
+// battleRatingExpr rates a battle by its lower-rated player, so filtering on
+// it keeps only games where both players are at least that rating.
+const battleRatingExpr = "LEAST(COALESCE(player1_rating, 0), COALESCE(player2_rating, 0))"
+
+// ratingColumns holds the nullable player rating and avatar columns.
+type ratingColumns struct {
+	player1Rating, player2Rating sql.NullInt64
+	player1Avatar, player2Avatar sql.NullString
+}
+
+func (r ratingColumns) apply(b *Battle) {
+	b.Player1Rating = int(r.player1Rating.Int64)
+	b.Player2Rating = int(r.player2Rating.Int64)
+	b.Player1Avatar = r.player1Avatar.String
+	b.Player2Avatar = r.player2Avatar.String
+}
+
 func insertBattleAnalysis(ctx context.Context, tx *sql.Tx, battleID string, analysis *BattleAnalysis) error {
 	_, err := tx.ExecContext(ctx,
 		`INSERT INTO battle_analysis (battle_id, total_turns, avg_damage_per_turn, avg_heal_per_turn, moves_used_count, switches_count, super_effective_moves, not_very_effective_moves, critical_hits, player1_damage_dealt, player1_damage_taken, player1_healing_done, player2_damage_dealt, player2_damage_taken, player2_healing_done, created_at)
@@ -211,10 +345,28 @@ func insertBattleAnalysis(ctx context.Context, tx *sql.Tx, battleID string, anal
 }
 
 func insertKeyMoment(ctx context.Context, tx *sql.Tx, battleID string, moment *KeyMoment) error {
+	var pokemon []byte
+	if len(moment.Pokemon) > 0 {
+		var err error
+		if pokemon, err = json.Marshal(moment.Pokemon); err != nil {
+			return fmt.Errorf("failed to encode key moment Pokémon: %w", err)
+		}
+	}
+
+	var player sql.NullString
+	if moment.Player != "" {
+		player = sql.NullString{String: moment.Player, Valid: true}
+	}
+	var actionOrder sql.NullInt64
+	if moment.ActionOrder != nil {
+		actionOrder = sql.NullInt64{Int64: int64(*moment.ActionOrder), Valid: true}
+	}
+
 	_, err := tx.ExecContext(ctx,
-		`INSERT INTO key_moments (battle_id, turn_number, moment_type, description, significance, created_at)
-		 VALUES ($1, $2, $3, $4, $5, NOW())`,
+		`INSERT INTO key_moments (battle_id, turn_number, moment_type, description, significance, player, pokemon, action_order, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())`,
 		battleID, moment.TurnNumber, moment.MomentType, moment.Description, moment.Significance,
+		player, pokemon, actionOrder,
 	)
 	return err
 }
@@ -243,7 +395,8 @@ func getBattleAnalysis(ctx context.Context, db *Database, battleID string) (*Bat
 
 func getKeyMoments(ctx context.Context, db *Database, battleID string) ([]*KeyMoment, error) {
 	rows, err := db.Query(ctx,
-		`SELECT turn_number, moment_type, description, significance FROM key_moments WHERE battle_id = $1 ORDER BY turn_number`,
+		`SELECT turn_number, moment_type, description, significance, player, pokemon, action_order
+		 FROM key_moments WHERE battle_id = $1 ORDER BY turn_number, action_order NULLS LAST`,
 		battleID,
 	)
 	if err != nil {
@@ -256,10 +409,23 @@ func getKeyMoments(ctx context.Context, db *Database, battleID string) ([]*KeyMo
 	var moments []*KeyMoment
 	for rows.Next() {
 		var m KeyMoment
-		err := rows.Scan(&m.TurnNumber, &m.MomentType, &m.Description, &m.Significance)
+		var player sql.NullString
+		var pokemon []byte
+		var actionOrder sql.NullInt64
+		err := rows.Scan(&m.TurnNumber, &m.MomentType, &m.Description, &m.Significance, &player, &pokemon, &actionOrder)
 		if err != nil {
 			return nil, err
 		}
+		m.Player = player.String
+		if len(pokemon) > 0 {
+			if err := json.Unmarshal(pokemon, &m.Pokemon); err != nil {
+				return nil, fmt.Errorf("failed to decode key moment Pokémon: %w", err)
+			}
+		}
+		if actionOrder.Valid {
+			order := int(actionOrder.Int64)
+			m.ActionOrder = &order
+		}
 		moments = append(moments, &m)
 	}
 
@@ -4,10 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dtsong/vgccorner/backend/internal/dex"
 )
 
 func TestNewDatabase(t *testing.T) {
@@ -210,9 +212,14 @@ func TestStoreBattleWithAnalysis(t *testing.T) {
 		KeyMoments: []*KeyMoment{
 			{
 				TurnNumber:   5,
-				MomentType:   "ko",
-				Description:  "Critical KO",
-				Significance: 8,
+				MomentType:   "critical",
+				Description:  "Bob's Incineroar knocked out Alice's Amoonguss with a critical hit from Flare Blitz",
+				Significance: 9,
+				Player:       "player2",
+				Pokemon: []KeyMomentPokemon{
+					{Pokemon: "Incineroar", Player: "player2"},
+					{Pokemon: "Amoonguss", Player: "player1"},
+				},
 			},
 		},
 	}
@@ -255,12 +262,14 @@ func TestGetBattle(t *testing.T) {
 	timestamp := time.Now()
 
 	battleRows := sqlmock.NewRows([]string{
-		"id", "format", "timestamp", "duration_sec", "winner",
+		"id", "replay_id", "series_id", "game_number", "format", "timestamp", "duration_sec", "winner",
 		"player1_id", "player2_id", "battle_log", "is_private",
+		"rated", "player1_rating", "player2_rating", "player1_avatar", "player2_avatar",
 		"created_at", "updated_at",
 	}).AddRow(
-		battleID, "VGC 2025", timestamp, 300, "player1",
+		battleID, "gen9vgc2025reghbo3-2481642254", "bestof3-gen9vgc2025reghbo3-2481642253", 1, "VGC 2025", timestamp, 300, "player1",
 		"Alice", "Bob", "log content", false,
+		true, 1487, 1398, "giovanni", "steven",
 		timestamp, timestamp,
 	)
 
@@ -278,10 +287,13 @@ func TestGetBattle(t *testing.T) {
 			"player2_damage_dealt", "player2_damage_taken", "player2_healing_done",
 		}).AddRow(battleID, 10, 50.5, 10.2, 20, 5, 3, 2, 1, 100, 80, 20, 90, 100, 15))
 
-	// Mock key moments query (matches 4 fields from getKeyMoments)
+	// Mock key moments query (matches 7 fields from getKeyMoments)
 	mock.ExpectQuery("SELECT (.+) FROM key_moments WHERE battle_id").
 		WithArgs(battleID).
-		WillReturnRows(sqlmock.NewRows([]string{"turn_number", "moment_type", "description", "significance"}))
+		WillReturnRows(sqlmock.NewRows([]string{
+			"turn_number", "moment_type", "description", "significance", "player", "pokemon", "action_order",
+		}).AddRow(3, "tera", "Bob terastallized Incineroar into the Ghost type", 7, "player2",
+			[]byte(`[{"pokemon":"Incineroar","player":"player2"}]`), 0))
 
 	battle, err := database.GetBattle(ctx, battleID)
 	if err != nil {
@@ -300,6 +312,27 @@ func TestGetBattle(t *testing.T) {
 		t.Errorf("expected format 'VGC 2025', got %s", battle.Format)
 	}
 
+	if battle.ReplayID != "gen9vgc2025reghbo3-2481642254" {
+		t.Errorf("expected replay ID to be loaded, got %q", battle.ReplayID)
+	}
+
+	if battle.SeriesID != "bestof3-gen9vgc2025reghbo3-2481642253" || battle.GameNumber != 1 {
+		t.Errorf("expected series game 1 to be loaded, got %q game %d", battle.SeriesID, battle.GameNumber)
+	}
+
+	if !battle.Rated || battle.Player1Rating != 1487 || battle.Player2Rating != 1398 || battle.Player1Avatar != "giovanni" {
+		t.Errorf("expected ratings and avatars to be loaded, got %+v", battle)
+	}
+
+	if len(battle.KeyMoments) != 1 {
+		t.Fatalf("expected 1 key moment, got %d", len(battle.KeyMoments))
+	}
+	moment := battle.KeyMoments[0]
+	if moment.Player != "player2" || len(moment.Pokemon) != 1 || moment.Pokemon[0].Pokemon != "Incineroar" ||
+		moment.ActionOrder == nil || *moment.ActionOrder != 0 {
+		t.Errorf("expected key moment details to be loaded, got %+v", moment)
+	}
+
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
@@ -360,9 +393,10 @@ func TestListBattles(t *testing.T) {
 	battleRows := sqlmock.NewRows([]string{
 		"id", "format", "timestamp", "duration_sec", "winner",
 		"player1_id", "player2_id", "is_private",
+		"rated", "player1_rating", "player2_rating", "player1_avatar", "player2_avatar",
 	}).
-		AddRow("id1", "VGC 2025", timestamp, 300, "player1", "Alice", "Bob", false).
-		AddRow("id2", "VGC 2025", timestamp, 250, "player2", "Charlie", "Dave", false)
+		AddRow("id1", "VGC 2025", timestamp, 300, "player1", "Alice", "Bob", false, true, 1487, 1398, "giovanni", "steven").
+		AddRow("id2", "VGC 2025", timestamp, 250, "player2", "Charlie", "Dave", false, false, nil, nil, nil, nil)
 
 	mock.ExpectQuery("SELECT (.+) FROM battles").
 		WillReturnRows(battleRows)
@@ -377,7 +411,146 @@ func TestListBattles(t *testing.T) {
 	}
 
 	if len(battles) != 2 {
-		t.Errorf("expected 2 battles, got %d", len(battles))
+		t.Fatalf("expected 2 battles, got %d", len(battles))
+	}
+
+	if !battles[0].Rated || battles[0].Player1Rating != 1487 || battles[0].Player2Avatar != "steven" {
+		t.Errorf("expected ratings and avatars for first battle, got %+v", battles[0])
+	}
+	if battles[1].Rated || battles[1].Player1Rating != 0 || battles[1].Player1Avatar != "" {
+		t.Errorf("expected zero ratings for unrated battle, got %+v", battles[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestListBattlesByRating(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	database := &Database{conn: db}
+	ctx := context.Background()
+
+	filter := &BattleFilter{
+		Rated:     boolPtr(true),
+		MinRating: 1500,
+		SortBy:    SortByRating,
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(SELECT (.+) AND rated = \$1 AND LEAST\((.+)\) >= \$2\) AS filtered`).
+		WithArgs(true, 1500).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	battleRows := sqlmock.NewRows([]string{
+		"id", "format", "timestamp", "duration_sec", "winner",
+		"player1_id", "player2_id", "is_private",
+		"rated", "player1_rating", "player2_rating", "player1_avatar", "player2_avatar",
+	}).
+		AddRow("id1", "VGC 2025", time.Now(), 300, "player1", "Alice", "Bob", false, true, 1620, 1580, nil, nil)
+
+	mock.ExpectQuery(`ORDER BY LEAST\((.+)\) DESC, timestamp DESC LIMIT \$3 OFFSET \$4`).
+		WithArgs(true, 1500, 10, 0).
+		WillReturnRows(battleRows)
+
+	battles, total, err := database.ListBattles(ctx, filter, 10, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if total != 1 || len(battles) != 1 {
+		t.Fatalf("expected 1 battle, got total %d, len %d", total, len(battles))
+	}
+
+	if battles[0].Player1Rating != 1620 || battles[0].Player2Rating != 1580 {
+		t.Errorf("expected ratings 1620/1580, got %d/%d", battles[0].Player1Rating, battles[0].Player2Rating)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestListBattlesByLead(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	database := &Database{conn: db}
+	ctx := context.Background()
+
+	filter := &BattleFilter{Format: "VGC 2025", Lead: "Incineroar"}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(SELECT (.+) AND format = \$1 AND EXISTS \(SELECT 1 FROM battle_team_choices c(.+)c.role = 'lead' AND c.pokemon = \$2\)\) AS filtered`).
+		WithArgs("VGC 2025", "Incineroar").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectQuery(`FROM battles WHERE (.+) ORDER BY timestamp DESC LIMIT \$3 OFFSET \$4`).
+		WithArgs("VGC 2025", "Incineroar", 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "format", "timestamp", "duration_sec", "winner",
+			"player1_id", "player2_id", "is_private",
+			"rated", "player1_rating", "player2_rating", "player1_avatar", "player2_avatar",
+		}))
+
+	if _, _, err := database.ListBattles(ctx, filter, 10, 0); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetSeriesBattles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	database := &Database{conn: db}
+	ctx := context.Background()
+
+	seriesID := "bestof3-gen9vgc2025reghbo3-2481642253"
+	timestamp := time.Now()
+
+	rows := sqlmock.NewRows([]string{
+		"id", "replay_id", "series_id", "game_number", "format", "timestamp", "duration_sec", "winner",
+		"player1_id", "player2_id", "battle_log", "is_private",
+	}).
+		AddRow("id1", "gen9vgc2025reghbo3-2481642254", seriesID, 1, "VGC 2025", timestamp, 300, "player2", "Alice", "Bob", "log 1", false).
+		AddRow("id2", nil, seriesID, 2, "VGC 2025", timestamp, 250, "player1", "Bob", "Alice", "log 2", false)
+
+	mock.ExpectQuery("SELECT (.+) FROM battles WHERE series_id").
+		WithArgs(seriesID).
+		WillReturnRows(rows)
+
+	battles, err := database.GetSeriesBattles(ctx, seriesID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(battles) != 2 {
+		t.Fatalf("expected 2 battles, got %d", len(battles))
+	}
+
+	if battles[0].GameNumber != 1 || battles[1].GameNumber != 2 {
+		t.Errorf("expected games 1 and 2, got %d and %d", battles[0].GameNumber, battles[1].GameNumber)
+	}
+
+	if battles[1].ReplayID != "" {
+		t.Errorf("expected empty replay ID for NULL, got %q", battles[1].ReplayID)
+	}
+
+	if battles[0].BattleLog != "log 1" {
+		t.Errorf("expected battle log to be loaded, got %q", battles[0].BattleLog)
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -437,3 +610,219 @@ func TestWithTx(t *testing.T) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestListBattleFeatures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	database := &Database{conn: db}
+	ctx := context.Background()
+
+	rows := sqlmock.NewRows([]string{"id", "winner", "features"}).
+		AddRow("id1", "player1", []byte(`{"remainingHP": 0}`)).
+		AddRow("id1", "player1", []byte(`{"remainingHP": 0.25}`)).
+		AddRow("id2", "player2", []byte(`{"remainingHP": -0.5}`))
+
+	mock.ExpectQuery("SELECT (.+) FROM battles b JOIN battle_turns t").
+		WithArgs("gen9vgc2025regh").
+		WillReturnRows(rows)
+
+	battles, err := database.ListBattleFeatures(ctx, "gen9vgc2025regh")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(battles) != 2 {
+		t.Fatalf("expected 2 battles, got %d", len(battles))
+	}
+	if battles[0].Winner != "player1" || len(battles[0].Turns) != 2 || battles[0].Turns[1]["remainingHP"] != 0.25 {
+		t.Errorf("expected both of id1's turns in order, got %+v", battles[0])
+	}
+	if battles[1].Winner != "player2" || len(battles[1].Turns) != 1 {
+		t.Errorf("expected one turn for id2, got %+v", battles[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestListLeadStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	database := &Database{conn: db}
+	ctx := context.Background()
+
+	rows := sqlmock.NewRows([]string{"leads", "games", "wins"}).
+		AddRow("Incineroar,Rillaboom", 12, 7).
+		AddRow("Flutter Mane,Incineroar", 5, 2)
+
+	mock.ExpectQuery("SELECT (.+) FROM battle_team_choices WHERE role = \\$1(.+)JOIN battles b").
+		WithArgs(TeamRoleLead, "Incineroar", "gen9vgc2025regh", 20).
+		WillReturnRows(rows)
+
+	stats, err := database.ListLeadStats(ctx, LeadStatsFilter{Format: "gen9vgc2025regh", Pokemon: "Incineroar", Limit: 20})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 lead pairs, got %d", len(stats))
+	}
+	if !reflect.DeepEqual(stats[0].Leads, []string{"Incineroar", "Rillaboom"}) || stats[0].Games != 12 || stats[0].Wins != 7 {
+		t.Errorf("unexpected first lead pair %+v", stats[0])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestListSpeedMatchups(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	database := &Database{conn: db}
+	ctx := context.Background()
+
+	rows := sqlmock.NewRows([]string{"opponent", "outsped", "outsped_by"}).
+		AddRow("Incineroar", 9, 0).
+		AddRow("Chien-Pao", 3, 4)
+
+	mock.ExpectQuery("SELECT (.+) FROM battle_speed_comparisons s JOIN battles b(.+)WHERE s.even").
+		WithArgs("Flutter Mane", "gen9vgc2025regh", 20).
+		WillReturnRows(rows)
+
+	matchups, err := database.ListSpeedMatchups(ctx, SpeedMatchupFilter{Format: "gen9vgc2025regh", Pokemon: "Flutter Mane", Limit: 20})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []*SpeedMatchup{
+		{Opponent: "Incineroar", Outsped: 9, OutspedBy: 0},
+		{Opponent: "Chien-Pao", Outsped: 3, OutspedBy: 4},
+	}
+	if !reflect.DeepEqual(matchups, expected) {
+		t.Errorf("expected %+v, got %+v", expected, matchups)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSeedReferenceData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	database := &Database{conn: db}
+	ctx := context.Background()
+
+	t.Run("seeds every species, move and item", func(t *testing.T) {
+		mock.ExpectBegin()
+		for _, species := range dex.AllSpecies() {
+			expectation := mock.ExpectExec("INSERT INTO pokemon_species (.+) ON CONFLICT \\(name\\) DO UPDATE")
+			if species.Name == "Urshifu-Rapid-Strike" {
+				expectation = expectation.WithArgs("Urshifu-Rapid-Strike", 892, "Fighting", "Water", "Urshifu",
+					100, 130, 100, 63, 60, 97, []byte(`["Unseen Fist"]`), 105.0)
+			}
+			expectation.WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+		for _, move := range dex.AllMoves() {
+			expectation := mock.ExpectExec("INSERT INTO moves (.+) ON CONFLICT \\(name\\) DO UPDATE")
+			if move.Name == "Protect" {
+				// Power and accuracy are NULL for moves without them
+				expectation = expectation.WithArgs("Protect", "Normal", "Status", nil, nil, 10, 4, "self", []byte(`[]`))
+			}
+			expectation.WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+		for range dex.AllItems() {
+			mock.ExpectExec("INSERT INTO items (.+) ON CONFLICT \\(name\\) DO UPDATE").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+		mock.ExpectCommit()
+
+		if err := database.SeedReferenceData(ctx); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO pokemon_species").WillReturnError(errors.New("relation does not exist"))
+		mock.ExpectRollback()
+
+		if err := database.SeedReferenceData(ctx); err == nil {
+			t.Error("expected an error")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetPlayerBattles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	database := &Database{conn: db}
+	ctx := context.Background()
+	timestamp := time.Now()
+
+	columns := []string{
+		"id", "replay_id", "series_id", "game_number", "format", "timestamp", "duration_sec", "winner",
+		"player1_id", "player2_id", "battle_log", "is_private",
+	}
+
+	mock.ExpectQuery(`SELECT (.+) FROM battles WHERE \(LOWER\(player1_id\) = LOWER\(\$1\) OR LOWER\(player2_id\) = LOWER\(\$1\)\) AND format = \$2 ORDER BY timestamp DESC LIMIT \$3`).
+		WithArgs("bob", "VGC 2025", 20).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("id2", nil, nil, nil, "VGC 2025", timestamp, 250, "player1", "Bob", "Alice", "log 2", false).
+			AddRow("id1", "gen9vgc2025reghbo3-2481642254", "bestof3-gen9vgc2025reghbo3-2481642253", 1, "VGC 2025", timestamp, 300, "player2", "Alice", "Bob", "log 1", false))
+
+	battles, err := database.GetPlayerBattles(ctx, "bob", "VGC 2025", 20)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(battles) != 2 {
+		t.Fatalf("expected 2 battles, got %d", len(battles))
+	}
+	if battles[0].ID != "id2" || battles[0].SeriesID != "" || battles[0].BattleLog != "log 2" {
+		t.Errorf("unexpected first battle: %+v", battles[0])
+	}
+	if battles[1].GameNumber != 1 || battles[1].ReplayID != "gen9vgc2025reghbo3-2481642254" {
+		t.Errorf("unexpected second battle: %+v", battles[1])
+	}
+
+	// Without a format, the limit is the second argument
+	mock.ExpectQuery(`FROM battles WHERE (.+) ORDER BY timestamp DESC LIMIT \$2`).
+		WithArgs("bob", 5).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	if _, err := database.GetPlayerBattles(ctx, "bob", "", 5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dtsong/vgccorner/backend/internal/dex"
+)
+
+// SeedReferenceData fills the pokemon_species, moves and items tables from
+// the embedded dex, updating rows that are already there.
+func (db *Database) SeedReferenceData(ctx context.Context) error {
+	return db.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, species := range dex.AllSpecies() {
+			if err := upsertSpecies(ctx, tx, species); err != nil {
+				return fmt.Errorf("failed to seed species %s: %w", species.Name, err)
+			}
+		}
+		for _, move := range dex.AllMoves() {
+			if err := upsertMove(ctx, tx, move); err != nil {
+				return fmt.Errorf("failed to seed move %s: %w", move.Name, err)
+			}
+		}
+		for _, item := range dex.AllItems() {
+			if err := upsertItem(ctx, tx, item); err != nil {
+				return fmt.Errorf("failed to seed item %s: %w", item.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+func upsertSpecies(ctx context.Context, tx *sql.Tx, species *dex.Species) error {
+	abilities, _ := json.Marshal(species.Abilities)
+
+	var type2, baseSpecies sql.NullString
+	if len(species.Types) > 1 {
+		type2 = sql.NullString{String: species.Types[1], Valid: true}
+	}
+	if species.BaseSpecies != "" {
+		baseSpecies = sql.NullString{String: species.BaseSpecies, Valid: true}
+	}
+
+	stats := species.BaseStats
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO pokemon_species (name, pokedex_number, type_1, type_2, base_species,
+		     hp_base, attack_base, defense_base, sp_atk_base, sp_def_base, speed_base, abilities, weight_kg)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 ON CONFLICT (name) DO UPDATE SET
+		     pokedex_number = EXCLUDED.pokedex_number, type_1 = EXCLUDED.type_1, type_2 = EXCLUDED.type_2,
+		     base_species = EXCLUDED.base_species, hp_base = EXCLUDED.hp_base, attack_base = EXCLUDED.attack_base,
+		     defense_base = EXCLUDED.defense_base, sp_atk_base = EXCLUDED.sp_atk_base, sp_def_base = EXCLUDED.sp_def_base,
+		     speed_base = EXCLUDED.speed_base, abilities = EXCLUDED.abilities, weight_kg = EXCLUDED.weight_kg`,
+		species.Name, species.Num, species.Types[0], type2, baseSpecies,
+		stats.HP, stats.Atk, stats.Def, stats.SpA, stats.SpD, stats.Spe, abilities, species.WeightKg,
+	)
+	return err
+}
+
+func upsertMove(ctx context.Context, tx *sql.Tx, move *dex.Move) error {
+	flags, _ := json.Marshal(move.Flags)
+
+	var power, accuracy sql.NullInt64
+	if move.BasePower > 0 {
+		power = sql.NullInt64{Int64: int64(move.BasePower), Valid: true}
+	}
+	if move.Accuracy > 0 {
+		accuracy = sql.NullInt64{Int64: int64(move.Accuracy), Valid: true}
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO moves (name, type, category, power, accuracy, pp_max, priority, target, flags)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (name) DO UPDATE SET
+		     type = EXCLUDED.type, category = EXCLUDED.category, power = EXCLUDED.power,
+		     accuracy = EXCLUDED.accuracy, pp_max = EXCLUDED.pp_max, priority = EXCLUDED.priority,
+		     target = EXCLUDED.target, flags = EXCLUDED.flags`,
+		move.Name, move.Type, move.Category, power, accuracy, move.PP, move.Priority, move.Target, flags,
+	)
+	return err
+}
+
+func upsertItem(ctx context.Context, tx *sql.Tx, item *dex.Item) error {
+	var itemType sql.NullString
+	if item.Type != "" {
+		itemType = sql.NullString{String: item.Type, Valid: true}
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO items (name, category, type, description)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (name) DO UPDATE SET
+		     category = EXCLUDED.category, type = EXCLUDED.type, description = EXCLUDED.description`,
+		item.Name, item.Category, itemType, item.Desc,
+	)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/dtsong/vgccorner/backend/internal/analysis"
 )
@@ -17,9 +18,24 @@ func (db *Database) StoreTurnData(ctx context.Context, battleID string, summary
 			return fmt.Errorf("failed to store team archetypes: %w", err)
 		}
 
+		if err := storeTeamChoices(ctx, tx, battleID, summary); err != nil {
+			return fmt.Errorf("failed to store team choices: %w", err)
+		}
+
+		if err := storeSpeedComparisons(ctx, tx, battleID, summary.Speed.Comparisons); err != nil {
+			return fmt.Errorf("failed to store speed comparisons: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE battles SET win_probability_model = NULLIF($1, '') WHERE id = $2`,
+			summary.WinProbabilityModel, battleID,
+		); err != nil {
+			return fmt.Errorf("failed to store win probability model: %w", err)
+		}
+
 		// Store turn-by-turn data
 		for _, turn := range summary.Turns {
-			turnID, err := insertBattleTurn(ctx, tx, battleID, turn.TurnNumber)
+			turnID, err := insertBattleTurn(ctx, tx, battleID, turn)
 			if err != nil {
 				return fmt.Errorf("failed to insert turn %d: %w", turn.TurnNumber, err)
 			}
@@ -97,9 +113,22 @@ type TeamArchetypeData struct {
 
 // TurnData represents a single turn's data
 type TurnData struct {
-	TurnNumber int
-	Actions    []*ActionData
-	BoardState *BoardStateData
+	TurnNumber      int
+	DecisionTimeSec int
+	DurationSec     int
+	Actions         []*ActionData
+	BoardState      *BoardStateData
+	FieldState      *analysis.FieldState     // Nil for turns stored before field tracking
+	Volatiles       []analysis.Volatile      // Volatile conditions on the active Pokémon after the turn
+	WinProbability  *analysis.WinProbability // Nil for turns stored before win probability
+}
+
+// BattleFeatures is a finished battle's winner and the position after each
+// of its turns, for training win-probability models.
+type BattleFeatures struct {
+	BattleID string
+	Winner   string // "player1" or "player2"
+	Turns    []analysis.PositionFeatures
 }
 
 // ActionData represents an action in a turn
@@ -109,6 +138,7 @@ type ActionData struct {
 	Pokemon     string
 	Move        string
 	Target      string
+	Targets     []analysis.ActionTarget
 	Result      string
 	Details     string
 	OrderInTurn int
@@ -128,6 +158,8 @@ type ImpactData struct {
 	Critical        bool
 	Effectiveness   string
 	Missed          bool
+	Spread          bool
+	Volatiles       []analysis.Volatile
 	StatChanges     []*StatChangeData
 	Fainted         []string
 }
@@ -143,17 +175,33 @@ type StatChangeData struct {
 type BoardStateData struct {
 	Player1Active []*ActivePokemonData
 	Player2Active []*ActivePokemonData
+	Player1Bench  []*BenchPokemonData
+	Player2Bench  []*BenchPokemonData
 }
 
 // ActivePokemonData represents an active Pokemon on the field
 type ActivePokemonData struct {
+	Name          string
+	Species       string
+	Position      int
+	HP            int
+	MaxHP         int
+	Status        string
+	IsLead        bool
+	TeraType      string
+	Terastallized bool
+	Boosts        map[string]int // Non-zero stat stages
+}
+
+// BenchPokemonData represents a Pokemon in the back
+type BenchPokemonData struct {
 	Name     string
 	Species  string
-	Position int
+	Position int // Order in the back
 	HP       int
 	MaxHP    int
 	Status   string
-	IsLead   bool
+	Revealed bool // Has been sent out
 }
 
 // Helper functions for storing data
@@ -175,62 +223,177 @@ func storeTeamArchetypes(ctx context.Context, tx *sql.Tx, battleID string, summa
 	return err
 }
 
-func insertBattleTurn(ctx context.Context, tx *sql.Tx, battleID string, turnNumber int) (string, error) {
+// storeTeamChoices records each player's leads, back line and, when known,
+// benched Pokémon.
+func storeTeamChoices(ctx context.Context, tx *sql.Tx, battleID string, summary *analysis.BattleSummary) error {
+	for playerNum, player := range []analysis.Player{summary.Player1, summary.Player2} {
+		for _, choice := range []struct {
+			role    string
+			pokemon []string
+		}{
+			{TeamRoleLead, player.Leads},
+			{TeamRoleBack, player.BackLine},
+			{TeamRoleBenched, player.Benched},
+		} {
+			for position, pokemon := range choice.pokemon {
+				if _, err := tx.ExecContext(ctx,
+					`INSERT INTO battle_team_choices (battle_id, player_number, pokemon, role, position, created_at)
+					 VALUES ($1, $2, $3, $4, $5, NOW())
+					 ON CONFLICT (battle_id, player_number, pokemon) DO NOTHING`,
+					battleID, playerNum+1, pokemon, choice.role, position,
+				); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// storeSpeedComparisons records the move order facts behind a battle's
+// speed analysis.
+func storeSpeedComparisons(ctx context.Context, tx *sql.Tx, battleID string, comparisons []analysis.SpeedComparison) error {
+	for _, c := range comparisons {
+		firstConditions, _ := json.Marshal(c.First.Conditions)
+		secondConditions, _ := json.Marshal(c.Second.Conditions)
+
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO battle_speed_comparisons
+			 (battle_id, turn_number, first_pokemon, first_player_number, first_conditions,
+			  second_pokemon, second_player_number, second_conditions, priority, trick_room, even, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())`,
+			battleID, c.TurnNumber,
+			c.First.Pokemon, playerNumber(c.First.Player), firstConditions,
+			c.Second.Pokemon, playerNumber(c.Second.Player), secondConditions,
+			c.Priority, c.TrickRoom, c.First.Conditions.Multiplier() == c.Second.Conditions.Multiplier(),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertBattleTurn(ctx context.Context, tx *sql.Tx, battleID string, turn analysis.Turn) (string, error) {
+	fieldState, err := json.Marshal(turn.StateAfter.Field)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode field state: %w", err)
+	}
+	volatiles, err := encodeVolatiles(turn.StateAfter.Volatiles)
+	if err != nil {
+		return "", err
+	}
+	var features []byte
+	if turn.Features != nil {
+		if features, err = json.Marshal(turn.Features); err != nil {
+			return "", fmt.Errorf("failed to encode features: %w", err)
+		}
+	}
+	var winProbability sql.NullFloat64
+	if turn.WinProbability != nil {
+		winProbability = sql.NullFloat64{Float64: turn.WinProbability.Player1, Valid: true}
+	}
+
 	var turnID string
-	err := tx.QueryRowContext(ctx,
-		`INSERT INTO battle_turns (battle_id, turn_number, created_at)
-		 VALUES ($1, $2, NOW())
-		 ON CONFLICT (battle_id, turn_number) DO UPDATE SET battle_id = EXCLUDED.battle_id
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO battle_turns (battle_id, turn_number, decision_time_sec, duration_sec, field_state, volatiles,
+		                           features, player1_win_probability, created_at)
+		 VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), $5, $6, $7, $8, NOW())
+		 ON CONFLICT (battle_id, turn_number) DO UPDATE
+		 SET decision_time_sec = EXCLUDED.decision_time_sec, duration_sec = EXCLUDED.duration_sec,
+		     field_state = EXCLUDED.field_state, volatiles = EXCLUDED.volatiles,
+		     features = EXCLUDED.features, player1_win_probability = EXCLUDED.player1_win_probability
 		 RETURNING id`,
-		battleID, turnNumber,
+		battleID, turn.TurnNumber, turn.DecisionTimeSec, turn.DurationSec, fieldState, volatiles,
+		features, winProbability,
 	).Scan(&turnID)
 	return turnID, err
 }
 
 func storeBoardState(ctx context.Context, tx *sql.Tx, turnID string, state analysis.BattleState) error {
-	// Store player 1 active Pokemon
-	if state.Player1Active != nil {
-		_, err := tx.ExecContext(ctx,
-			`INSERT INTO turn_board_states (battle_turn_id, player_number, pokemon_name, pokemon_species, position, hp, max_hp, status, is_lead)
-			 VALUES ($1, 1, $2, $3, 0, $4, $5, $6, true)`,
-			turnID, state.Player1Active.Name, state.Player1Active.ID,
-			state.Player1Active.CurrentHP, state.Player1Active.MaxHP, state.Player1Active.Status,
-		)
-		if err != nil {
-			return err
-		}
+	// Store each side's active Pokemon with their slot position
+	sides := []struct {
+		playerNum int
+		active    []*analysis.Pokémon
+		bench     []analysis.BenchPokemon
+	}{
+		{1, state.Player1Active, state.Player1Bench},
+		{2, state.Player2Active, state.Player2Bench},
 	}
 
-	// Store player 2 active Pokemon
-	if state.Player2Active != nil {
-		_, err := tx.ExecContext(ctx,
-			`INSERT INTO turn_board_states (battle_turn_id, player_number, pokemon_name, pokemon_species, position, hp, max_hp, status, is_lead)
-			 VALUES ($1, 2, $2, $3, 0, $4, $5, $6, true)`,
-			turnID, state.Player2Active.Name, state.Player2Active.ID,
-			state.Player2Active.CurrentHP, state.Player2Active.MaxHP, state.Player2Active.Status,
-		)
-		if err != nil {
-			return err
+	for _, side := range sides {
+		for position, poke := range side.active {
+			if poke == nil {
+				continue
+			}
+			var boosts []byte
+			if len(poke.Boosts) > 0 {
+				var err error
+				if boosts, err = json.Marshal(poke.Boosts); err != nil {
+					return fmt.Errorf("failed to encode boosts: %w", err)
+				}
+			}
+			var teraType string
+			if poke.Terastallized {
+				teraType = poke.TeraType
+			}
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO turn_board_states (battle_turn_id, player_number, pokemon_name, pokemon_species, position, hp, max_hp, status, is_lead,
+				                                terastallized, tera_type, boosts, is_active, revealed)
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, true, $9, NULLIF($10, ''), $11, true, true)`,
+				turnID, side.playerNum, poke.Name, poke.ID, position,
+				poke.CurrentHP, poke.MaxHP, poke.Status,
+				poke.Terastallized, teraType, boosts,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Then the bench, in team order
+		for position, poke := range side.bench {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO turn_board_states (battle_turn_id, player_number, pokemon_name, pokemon_species, position, hp, max_hp, status, is_lead,
+				                                is_active, revealed)
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false, false, $9)`,
+				turnID, side.playerNum, poke.Name, poke.ID, position,
+				poke.CurrentHP, poke.MaxHP, poke.Status, poke.Revealed,
+			)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// playerNumber converts a "player1"/"player2" ID to its number.
+func playerNumber(player string) int {
+	if player == "player2" {
+		return 2
+	}
+	return 1
+}
+
 func storeAction(ctx context.Context, tx *sql.Tx, turnID string, action analysis.Action) error {
-	// Convert player ID to number
-	playerNum := 1
-	if action.Player == "player2" {
-		playerNum = 2
+	playerNum := playerNumber(action.Player)
+
+	// Per-target results; switches have none
+	var targets []byte
+	if len(action.Targets) > 0 {
+		var err error
+		if targets, err = json.Marshal(action.Targets); err != nil {
+			return fmt.Errorf("failed to encode action targets: %w", err)
+		}
 	}
 
 	// Insert action
 	var actionID string
 	err := tx.QueryRowContext(ctx,
-		`INSERT INTO battle_actions (battle_turn_id, player_number, action_type, pokemon_name, target_pokemon, result, details, order_in_turn, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		`INSERT INTO battle_actions (battle_turn_id, player_number, action_type, pokemon_name, target_pokemon, targets, result, details, order_in_turn, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
 		 RETURNING id`,
-		turnID, playerNum, action.ActionType, action.Pokemon, action.Target, action.Result, action.Details, action.OrderInTurn,
+		turnID, playerNum, action.ActionType, action.Pokemon, action.Target, targets, action.Result, action.Details, action.OrderInTurn,
 	).Scan(&actionID)
 
 	if err != nil {
@@ -246,14 +409,20 @@ func storeAction(ctx context.Context, tx *sql.Tx, turnID string, action analysis
 }
 
 func storeMoveImpact(ctx context.Context, tx *sql.Tx, actionID string, impact *analysis.MoveImpact) error {
+	volatiles, err := encodeVolatiles(impact.Volatiles)
+	if err != nil {
+		return err
+	}
+
 	var impactID string
-	err := tx.QueryRowContext(ctx,
-		`INSERT INTO move_impacts (action_id, damage_dealt, healing_done, status_inflicted, speed_control, weather_set, terrain_set, fake_out, protect_used, critical, effectiveness, missed)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO move_impacts (action_id, damage_dealt, healing_done, status_inflicted, speed_control, weather_set, terrain_set, fake_out, protect_used, critical, effectiveness, missed, spread, volatiles)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		 RETURNING id`,
 		actionID, impact.DamageDealt, impact.HealingDone, impact.StatusInflicted,
 		impact.SpeedControl, impact.WeatherSet, impact.TerrainSet,
 		impact.FakeOut, impact.Protect, impact.Critical, impact.Effectiveness, impact.Missed,
+		impact.Spread, volatiles,
 	).Scan(&impactID)
 
 	if err != nil {
@@ -287,6 +456,30 @@ func storeMoveImpact(ctx context.Context, tx *sql.Tx, actionID string, impact *a
 	return nil
 }
 
+// encodeVolatiles marshals volatiles for a JSONB column, or NULL when there are none.
+func encodeVolatiles(volatiles []analysis.Volatile) ([]byte, error) {
+	if len(volatiles) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(volatiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode volatiles: %w", err)
+	}
+	return data, nil
+}
+
+// decodeVolatiles reads a JSONB volatiles column; NULL decodes to nil.
+func decodeVolatiles(data []byte) ([]analysis.Volatile, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var volatiles []analysis.Volatile
+	if err := json.Unmarshal(data, &volatiles); err != nil {
+		return nil, fmt.Errorf("failed to decode volatiles: %w", err)
+	}
+	return volatiles, nil
+}
+
 // Helper functions for retrieving data
 
 func getTeamArchetypes(ctx context.Context, db *Database, battleID string) (*TeamArchetypeData, *TeamArchetypeData, error) {
@@ -333,7 +526,8 @@ func getTeamArchetypes(ctx context.Context, db *Database, battleID string) (*Tea
 
 func getTurns(ctx context.Context, db *Database, battleID string) ([]*TurnData, error) {
 	rows, err := db.Query(ctx,
-		`SELECT id, turn_number FROM battle_turns WHERE battle_id = $1 ORDER BY turn_number`,
+		`SELECT id, turn_number, decision_time_sec, duration_sec, field_state, volatiles, player1_win_probability
+		 FROM battle_turns WHERE battle_id = $1 ORDER BY turn_number`,
 		battleID,
 	)
 	if err != nil {
@@ -345,10 +539,27 @@ func getTurns(ctx context.Context, db *Database, battleID string) ([]*TurnData,
 	for rows.Next() {
 		var turnID string
 		var turnNumber int
-		if err := rows.Scan(&turnID, &turnNumber); err != nil {
+		var decisionTime, duration sql.NullInt64
+		var fieldData, volatileData []byte
+		var winProbability sql.NullFloat64
+		if err := rows.Scan(&turnID, &turnNumber, &decisionTime, &duration, &fieldData, &volatileData, &winProbability); err != nil {
 			return nil, err
 		}
 
+		// Turns stored before field tracking have no field state
+		var fieldState *analysis.FieldState
+		if len(fieldData) > 0 {
+			fieldState = &analysis.FieldState{}
+			if err := json.Unmarshal(fieldData, fieldState); err != nil {
+				return nil, fmt.Errorf("failed to decode field state for turn %d: %w", turnNumber, err)
+			}
+		}
+
+		volatiles, err := decodeVolatiles(volatileData)
+		if err != nil {
+			return nil, fmt.Errorf("turn %d: %w", turnNumber, err)
+		}
+
 		// Get actions for this turn
 		actions, err := getActions(ctx, db, turnID)
 		if err != nil {
@@ -361,19 +572,139 @@ func getTurns(ctx context.Context, db *Database, battleID string) ([]*TurnData,
 			return nil, err
 		}
 
-		turns = append(turns, &TurnData{
-			TurnNumber: turnNumber,
-			Actions:    actions,
-			BoardState: boardState,
-		})
+		turn := &TurnData{
+			TurnNumber:      turnNumber,
+			DecisionTimeSec: int(decisionTime.Int64),
+			DurationSec:     int(duration.Int64),
+			Actions:         actions,
+			BoardState:      boardState,
+			FieldState:      fieldState,
+			Volatiles:       volatiles,
+		}
+		if winProbability.Valid {
+			turn.WinProbability = &analysis.WinProbability{
+				Player1: winProbability.Float64,
+				Player2: 1 - winProbability.Float64,
+			}
+		}
+		turns = append(turns, turn)
 	}
 
 	return turns, rows.Err()
 }
 
+// ListBattleFeatures retrieves the per-turn position features of every
+// battle with a winner, optionally only in one format. Turns stored before
+// features were recorded are skipped, as are battles left with none.
+func (db *Database) ListBattleFeatures(ctx context.Context, format string) ([]*BattleFeatures, error) {
+	rows, err := db.Query(ctx,
+		`SELECT b.id, b.winner, t.features
+		 FROM battles b JOIN battle_turns t ON t.battle_id = b.id
+		 WHERE b.winner IN ('player1', 'player2') AND t.features IS NOT NULL
+		   AND ($1 = '' OR b.format = $1)
+		 ORDER BY b.id, t.turn_number`,
+		format,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var battles []*BattleFeatures
+	for rows.Next() {
+		var battleID, winner string
+		var data []byte
+		if err := rows.Scan(&battleID, &winner, &data); err != nil {
+			return nil, err
+		}
+
+		var features analysis.PositionFeatures
+		if err := json.Unmarshal(data, &features); err != nil {
+			return nil, fmt.Errorf("failed to decode features for battle %s: %w", battleID, err)
+		}
+
+		if len(battles) == 0 || battles[len(battles)-1].BattleID != battleID {
+			battles = append(battles, &BattleFeatures{BattleID: battleID, Winner: winner})
+		}
+		last := battles[len(battles)-1]
+		last.Turns = append(last.Turns, features)
+	}
+
+	return battles, rows.Err()
+}
+
+// ListLeadStats counts how often each pair of leads was chosen and won,
+// most chosen first.
+func (db *Database) ListLeadStats(ctx context.Context, filter LeadStatsFilter) ([]*LeadStats, error) {
+	rows, err := db.Query(ctx,
+		`SELECT l.leads, COUNT(*), COUNT(*) FILTER (WHERE b.winner = 'player' || l.player_number)
+		 FROM (SELECT battle_id, player_number,
+		              string_agg(pokemon, ',' ORDER BY pokemon) AS leads,
+		              bool_or(pokemon = $2) AS has_pokemon
+		       FROM battle_team_choices WHERE role = $1
+		       GROUP BY battle_id, player_number) l
+		 JOIN battles b ON b.id = l.battle_id
+		 WHERE ($3 = '' OR b.format = $3) AND ($2 = '' OR l.has_pokemon)
+		 GROUP BY l.leads
+		 ORDER BY COUNT(*) DESC, l.leads
+		 LIMIT $4`,
+		TeamRoleLead, filter.Pokemon, filter.Format, filter.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stats []*LeadStats
+	for rows.Next() {
+		var leads string
+		var s LeadStats
+		if err := rows.Scan(&leads, &s.Games, &s.Wins); err != nil {
+			return nil, err
+		}
+		s.Leads = strings.Split(leads, ",")
+		stats = append(stats, &s)
+	}
+
+	return stats, rows.Err()
+}
+
+// ListSpeedMatchups counts how often a Pokémon outsped each other species
+// when neither side had a Speed modifier over the other, most seen first.
+func (db *Database) ListSpeedMatchups(ctx context.Context, filter SpeedMatchupFilter) ([]*SpeedMatchup, error) {
+	rows, err := db.Query(ctx,
+		`SELECT CASE WHEN s.first_pokemon = $1 THEN s.second_pokemon ELSE s.first_pokemon END AS opponent,
+		        COUNT(*) FILTER (WHERE (s.first_pokemon = $1) <> s.trick_room),
+		        COUNT(*) FILTER (WHERE (s.first_pokemon = $1) = s.trick_room)
+		 FROM battle_speed_comparisons s JOIN battles b ON b.id = s.battle_id
+		 WHERE s.even AND s.first_pokemon <> s.second_pokemon
+		   AND (s.first_pokemon = $1 OR s.second_pokemon = $1)
+		   AND ($2 = '' OR b.format = $2)
+		 GROUP BY opponent
+		 ORDER BY COUNT(*) DESC, opponent
+		 LIMIT $3`,
+		filter.Pokemon, filter.Format, filter.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var matchups []*SpeedMatchup
+	for rows.Next() {
+		var m SpeedMatchup
+		if err := rows.Scan(&m.Opponent, &m.Outsped, &m.OutspedBy); err != nil {
+			return nil, err
+		}
+		matchups = append(matchups, &m)
+	}
+
+	return matchups, rows.Err()
+}
+
 func getActions(ctx context.Context, db *Database, turnID string) ([]*ActionData, error) {
 	rows, err := db.Query(ctx,
-		`SELECT id, player_number, action_type, pokemon_name, target_pokemon, result, details, order_in_turn
+		`SELECT id, player_number, action_type, pokemon_name, target_pokemon, targets, result, details, order_in_turn
 		 FROM battle_actions WHERE battle_turn_id = $1 ORDER BY order_in_turn`,
 		turnID,
 	)
@@ -387,12 +718,20 @@ func getActions(ctx context.Context, db *Database, turnID string) ([]*ActionData
 		var actionID string
 		var playerNum int
 		var actionType, pokemonName, target, result, details sql.NullString
+		var targetsData []byte
 		var orderInTurn int
 
-		if err := rows.Scan(&actionID, &playerNum, &actionType, &pokemonName, &target, &result, &details, &orderInTurn); err != nil {
+		if err := rows.Scan(&actionID, &playerNum, &actionType, &pokemonName, &target, &targetsData, &result, &details, &orderInTurn); err != nil {
 			return nil, err
 		}
 
+		var targets []analysis.ActionTarget
+		if len(targetsData) > 0 {
+			if err := json.Unmarshal(targetsData, &targets); err != nil {
+				return nil, fmt.Errorf("failed to decode action targets: %w", err)
+			}
+		}
+
 		player := "player1"
 		if playerNum == 2 {
 			player = "player2"
@@ -403,6 +742,7 @@ func getActions(ctx context.Context, db *Database, turnID string) ([]*ActionData
 			ActionType:  actionType.String,
 			Pokemon:     pokemonName.String,
 			Target:      target.String,
+			Targets:     targets,
 			Result:      result.String,
 			Details:     details.String,
 			OrderInTurn: orderInTurn,
@@ -424,14 +764,16 @@ func getActions(ctx context.Context, db *Database, turnID string) ([]*ActionData
 func getMoveImpact(ctx context.Context, db *Database, actionID string) (*ImpactData, error) {
 	var impact ImpactData
 	var statusInflicted, speedControl, weatherSet, terrainSet, effectiveness sql.NullString
+	var volatileData []byte
 
 	err := db.QueryRow(ctx,
-		`SELECT damage_dealt, healing_done, status_inflicted, speed_control, weather_set, terrain_set, fake_out, protect_used, critical, effectiveness, missed
+		`SELECT damage_dealt, healing_done, status_inflicted, speed_control, weather_set, terrain_set, fake_out, protect_used, critical, effectiveness, missed,
+		        COALESCE(spread, FALSE), volatiles
 		 FROM move_impacts WHERE action_id = $1`,
 		actionID,
 	).Scan(&impact.DamageDealt, &impact.HealingDone, &statusInflicted, &speedControl,
 		&weatherSet, &terrainSet, &impact.FakeOut, &impact.Protect,
-		&impact.Critical, &effectiveness, &impact.Missed)
+		&impact.Critical, &effectiveness, &impact.Missed, &impact.Spread, &volatileData)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -445,6 +787,9 @@ func getMoveImpact(ctx context.Context, db *Database, actionID string) (*ImpactD
 	impact.WeatherSet = weatherSet.String
 	impact.TerrainSet = terrainSet.String
 	impact.Effectiveness = effectiveness.String
+	if impact.Volatiles, err = decodeVolatiles(volatileData); err != nil {
+		return nil, err
+	}
 
 	// Get stat changes and fainted Pokemon would go here
 	// (omitted for brevity but would follow similar pattern)
@@ -454,8 +799,9 @@ func getMoveImpact(ctx context.Context, db *Database, actionID string) (*ImpactD
 
 func getBoardState(ctx context.Context, db *Database, turnID string) (*BoardStateData, error) {
 	rows, err := db.Query(ctx,
-		`SELECT player_number, pokemon_name, pokemon_species, position, hp, max_hp, status, is_lead
-		 FROM turn_board_states WHERE battle_turn_id = $1`,
+		`SELECT player_number, pokemon_name, pokemon_species, position, hp, max_hp, status, is_lead,
+		        COALESCE(terastallized, false), tera_type, boosts, COALESCE(is_active, true), COALESCE(revealed, true)
+		 FROM turn_board_states WHERE battle_turn_id = $1 ORDER BY player_number, position`,
 		turnID,
 	)
 	if err != nil {
@@ -466,26 +812,55 @@ func getBoardState(ctx context.Context, db *Database, turnID string) (*BoardStat
 	state := &BoardStateData{
 		Player1Active: []*ActivePokemonData{},
 		Player2Active: []*ActivePokemonData{},
+		Player1Bench:  []*BenchPokemonData{},
+		Player2Bench:  []*BenchPokemonData{},
 	}
 
 	for rows.Next() {
 		var playerNum, position, hp, maxHP int
 		var name, species string
-		var status sql.NullString
-		var isLead bool
+		var status, teraType sql.NullString
+		var isLead, terastallized, isActive, revealed bool
+		var boostData []byte
 
-		if err := rows.Scan(&playerNum, &name, &species, &position, &hp, &maxHP, &status, &isLead); err != nil {
+		if err := rows.Scan(&playerNum, &name, &species, &position, &hp, &maxHP, &status, &isLead,
+			&terastallized, &teraType, &boostData, &isActive, &revealed); err != nil {
 			return nil, err
 		}
 
+		if !isActive {
+			poke := &BenchPokemonData{
+				Name:     name,
+				Species:  species,
+				Position: position,
+				HP:       hp,
+				MaxHP:    maxHP,
+				Status:   status.String,
+				Revealed: revealed,
+			}
+			if playerNum == 1 {
+				state.Player1Bench = append(state.Player1Bench, poke)
+			} else {
+				state.Player2Bench = append(state.Player2Bench, poke)
+			}
+			continue
+		}
+
 		poke := &ActivePokemonData{
-			Name:     name,
-			Species:  species,
-			Position: position,
-			HP:       hp,
-			MaxHP:    maxHP,
-			Status:   status.String,
-			IsLead:   isLead,
+			Name:          name,
+			Species:       species,
+			Position:      position,
+			HP:            hp,
+			MaxHP:         maxHP,
+			Status:        status.String,
+			IsLead:        isLead,
+			TeraType:      teraType.String,
+			Terastallized: terastallized,
+		}
+		if len(boostData) > 0 {
+			if err := json.Unmarshal(boostData, &poke.Boosts); err != nil {
+				return nil, fmt.Errorf("failed to decode boosts: %w", err)
+			}
 		}
 
 		if playerNum == 1 {
@@ -4,19 +4,27 @@ import "time"
 
 // Battle represents a stored battle record.
 type Battle struct {
-	ID          string
-	Format      string
-	Timestamp   time.Time
-	DurationSec int
-	Winner      string // "player1", "player2", or "draw"
-	Player1ID   string
-	Player2ID   string
-	BattleLog   string
-	IsPrivate   bool
-	Analysis    *BattleAnalysis
-	KeyMoments  []*KeyMoment
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID            string
+	ReplayID      string // Showdown replay ID, if imported from a replay
+	SeriesID      string // Best-of-N series this game belongs to, if any
+	GameNumber    int    // Game number within the series
+	Format        string
+	Timestamp     time.Time
+	DurationSec   int
+	Rated         bool
+	Winner        string // "player1", "player2", or "draw"
+	Player1ID     string
+	Player2ID     string
+	Player1Rating int // Ladder rating before the game, 0 if unrated
+	Player2Rating int
+	Player1Avatar string
+	Player2Avatar string
+	BattleLog     string
+	IsPrivate     bool
+	Analysis      *BattleAnalysis
+	KeyMoments    []*KeyMoment
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 // BattleAnalysis stores computed statistics for a battle.
@@ -43,14 +51,69 @@ type BattleAnalysis struct {
 type KeyMoment struct {
 	BattleID     string
 	TurnNumber   int
-	MomentType   string // "switch", "ko", "status", "weather", "critical", "other"
+	MomentType   string // "KO", "critical", "tera", "speed_control", "turning_point", etc.
 	Description  string
 	Significance int
+	Player       string             // "player1" or "player2", if the moment belongs to one side
+	Pokemon      []KeyMomentPokemon // Pokémon involved, the main one first
+	ActionOrder  *int               // Order in the turn of the triggering action, if any
 	CreatedAt    time.Time
 }
 
+// KeyMomentPokemon is a Pokémon involved in a key moment.
+type KeyMomentPokemon struct {
+	Pokemon string `json:"pokemon"`
+	Player  string `json:"player"`
+}
+
 // BattleFilter is used for filtering battles in queries.
 type BattleFilter struct {
 	Format    string
 	IsPrivate *bool
+	Rated     *bool
+	MinRating int    // Both players rated at least this
+	Lead      string // Either player led with this Pokémon
+	SortBy    string // "timestamp" (default) or "rating"
+}
+
+// Battle list sort orders.
+const (
+	SortByTimestamp = "timestamp"
+	SortByRating    = "rating"
+)
+
+// Team preview roles stored in battle_team_choices.
+const (
+	TeamRoleLead    = "lead"
+	TeamRoleBack    = "back"
+	TeamRoleBenched = "benched"
+)
+
+// LeadStatsFilter narrows the battles lead statistics are drawn from.
+type LeadStatsFilter struct {
+	Format  string
+	Pokemon string // Only lead pairs including this Pokémon
+	Limit   int
+}
+
+// LeadStats is how often a pair of leads was chosen and how it fared.
+type LeadStats struct {
+	Leads []string `json:"leads"` // Sorted by name
+	Games int      `json:"games"`
+	Wins  int      `json:"wins"`
+}
+
+// SpeedMatchupFilter narrows the battles Speed matchups are drawn from.
+type SpeedMatchupFilter struct {
+	Format  string
+	Pokemon string // The Pokémon whose matchups are listed
+	Limit   int
+}
+
+// SpeedMatchup is how often a Pokémon outsped another when both had the
+// same Speed multiplier, Trick Room already accounted for.
+type SpeedMatchup struct {
+	Opponent  string `json:"opponent"`
+	Outsped   int    `json:"outsped"`
+	OutspedBy int    `json:"outspedBy"`
 }
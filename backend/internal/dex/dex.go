@@ -0,0 +1,186 @@
+// Package dex provides Gen 9 reference data for Pokémon species, moves and
+// items, embedded from the vendored dataset in data/gen9.json.
+//
+// The dataset is exported from Pokémon Showdown by gen/gen9.js, which go
+// generate runs; it needs Node and network access to install the
+// pokemon-showdown package. It covers what's legal in Gen 9 rather than the
+// full National Dex; lookups for anything else report not found.
+package dex
+
+//go:generate sh -c "cd gen && npm install --no-save --no-package-lock && npm run --silent generate"
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//go:embed data/gen9.json
+var gen9JSON []byte
+
+// Stats are a species' base stats.
+type Stats struct {
+	HP  int `json:"hp"`
+	Atk int `json:"atk"`
+	Def int `json:"def"`
+	SpA int `json:"spa"`
+	SpD int `json:"spd"`
+	Spe int `json:"spe"`
+}
+
+// Species is a Pokémon species or one of its formes.
+type Species struct {
+	Num            int      `json:"num"` // National Pokédex number, shared by formes
+	Name           string   `json:"name"`
+	BaseSpecies    string   `json:"baseSpecies,omitempty"` // Set on formes, e.g. "Urshifu"
+	Forme          string   `json:"forme,omitempty"`       // e.g. "Rapid-Strike"
+	Types          []string `json:"types"`
+	BaseStats      Stats    `json:"baseStats"`
+	Abilities      []string `json:"abilities"`
+	WeightKg       float64  `json:"weightkg"`
+	OtherFormes    []string `json:"otherFormes,omitempty"`    // Formes with their own entry
+	CosmeticFormes []string `json:"cosmeticFormes,omitempty"` // Formes that only look different
+}
+
+// Move is a move's battle data.
+type Move struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Category  string   `json:"category"` // "Physical", "Special" or "Status"
+	BasePower int      `json:"basePower"`
+	Accuracy  int      `json:"accuracy"` // 0 if it never misses
+	PP        int      `json:"pp"`
+	Priority  int      `json:"priority"`
+	Target    string   `json:"target"` // Showdown target, e.g. "normal" or "allAdjacentFoes"
+	Flags     []string `json:"flags"`  // Showdown flags, e.g. "contact", "sound"
+	MinHits   int      `json:"minHits,omitempty"`
+	MaxHits   int      `json:"maxHits,omitempty"`
+	WillCrit  bool     `json:"willCrit,omitempty"`
+
+	// Stat overrides, e.g. Body Press attacks with Defense and Psyshock
+	// targets Defense
+	OverrideOffensiveStat    string `json:"overrideOffensiveStat,omitempty"`
+	OverrideOffensivePokemon string `json:"overrideOffensivePokemon,omitempty"`
+	OverrideDefensiveStat    string `json:"overrideDefensiveStat,omitempty"`
+}
+
+// HasFlag reports whether the move has the given Showdown flag.
+func (m *Move) HasFlag(flag string) bool {
+	for _, f := range m.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSpread reports whether the move hits every adjacent foe, or every
+// adjacent Pokémon.
+func (m *Move) IsSpread() bool {
+	return m.Target == "allAdjacentFoes" || m.Target == "allAdjacent"
+}
+
+// Item is a held item.
+type Item struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`       // "berry", "choice", "mask", "type-boost" or "held"
+	Type     string `json:"type,omitempty"` // Type a type-boost item or mask powers up, or a berry resists
+	Desc     string `json:"desc"`
+}
+
+type dataset struct {
+	Species []*Species `json:"species"`
+	Moves   []*Move    `json:"moves"`
+	Items   []*Item    `json:"items"`
+}
+
+var (
+	data      dataset
+	speciesBy = make(map[string]*Species)
+	movesBy   = make(map[string]*Move)
+	itemsBy   = make(map[string]*Item)
+	abilityBy = make(map[string]string)
+)
+
+func init() {
+	if err := json.Unmarshal(gen9JSON, &data); err != nil {
+		panic(fmt.Sprintf("dex: invalid embedded dataset: %v", err))
+	}
+	for _, species := range data.Species {
+		speciesBy[ID(species.Name)] = species
+		for _, cosmetic := range species.CosmeticFormes {
+			speciesBy[ID(cosmetic)] = species
+		}
+		for _, ability := range species.Abilities {
+			abilityBy[ID(ability)] = ability
+		}
+	}
+	for _, move := range data.Moves {
+		movesBy[ID(move.Name)] = move
+	}
+	for _, item := range data.Items {
+		itemsBy[ID(item.Name)] = item
+	}
+}
+
+// ID converts a name to Showdown's ID form: lowercase letters and digits
+// only, so "Chien-Pao" and "chienpao" match.
+func ID(name string) string {
+	id := make([]byte, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c >= 'A' && c <= 'Z':
+			id = append(id, c+'a'-'A')
+		case (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9'):
+			id = append(id, c)
+		}
+	}
+	return string(id)
+}
+
+// LookupSpecies finds a species or forme by name. Cosmetic formes resolve to
+// their base species, as does a team preview name hiding the forme such as
+// "Urshifu-*".
+func LookupSpecies(name string) (*Species, bool) {
+	name = strings.TrimSuffix(name, "-*")
+	species, ok := speciesBy[ID(name)]
+	return species, ok
+}
+
+// LookupMove finds a move by name.
+func LookupMove(name string) (*Move, bool) {
+	move, ok := movesBy[ID(name)]
+	return move, ok
+}
+
+// LookupItem finds an item by name.
+func LookupItem(name string) (*Item, bool) {
+	item, ok := itemsBy[ID(name)]
+	return item, ok
+}
+
+// LookupAbility finds an ability by name and returns its proper name, so
+// "MindsEye" gives "Mind's Eye". Only abilities some species in the dex can
+// have are known.
+func LookupAbility(name string) (string, bool) {
+	ability, ok := abilityBy[ID(name)]
+	return ability, ok
+}
+
+// AllSpecies returns every species and forme, in Pokédex order. The
+// returned slice is shared and must not be modified; the same goes for
+// AllMoves and AllItems.
+func AllSpecies() []*Species {
+	return data.Species
+}
+
+// AllMoves returns every move, by name.
+func AllMoves() []*Move {
+	return data.Moves
+}
+
+// AllItems returns every item, by name.
+func AllItems() []*Item {
+	return data.Items
+}
@@ -0,0 +1,146 @@
+package dex
+
+import (
+	"testing"
+)
+
+var validTypes = map[string]bool{
+	"Normal": true, "Fire": true, "Water": true, "Electric": true, "Grass": true, "Ice": true,
+	"Fighting": true, "Poison": true, "Ground": true, "Flying": true, "Psychic": true, "Bug": true,
+	"Rock": true, "Ghost": true, "Dragon": true, "Dark": true, "Steel": true, "Fairy": true,
+}
+
+func TestDatasetIsConsistent(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, species := range AllSpecies() {
+		if seen[ID(species.Name)] {
+			t.Errorf("duplicate species %s", species.Name)
+		}
+		seen[ID(species.Name)] = true
+
+		if len(species.Types) == 0 || len(species.Types) > 2 {
+			t.Errorf("%s: expected 1 or 2 types, got %v", species.Name, species.Types)
+		}
+		for _, typ := range species.Types {
+			if !validTypes[typ] {
+				t.Errorf("%s: unknown type %q", species.Name, typ)
+			}
+		}
+		stats := species.BaseStats
+		if stats.HP <= 0 || stats.Atk <= 0 || stats.Def <= 0 || stats.SpA <= 0 || stats.SpD <= 0 || stats.Spe <= 0 {
+			t.Errorf("%s: missing base stats %+v", species.Name, stats)
+		}
+		if species.BaseSpecies != "" {
+			base, ok := LookupSpecies(species.BaseSpecies)
+			if !ok || base.Num != species.Num {
+				t.Errorf("%s: base species %s missing or numbered differently", species.Name, species.BaseSpecies)
+			}
+		}
+	}
+
+	categories := map[string]bool{"Physical": true, "Special": true, "Status": true}
+	for _, move := range AllMoves() {
+		if !validTypes[move.Type] || !categories[move.Category] {
+			t.Errorf("%s: bad type %q or category %q", move.Name, move.Type, move.Category)
+		}
+		if move.Category == "Status" && move.BasePower != 0 {
+			t.Errorf("%s: status move with base power %d", move.Name, move.BasePower)
+		}
+		if move.PP <= 0 || move.Target == "" {
+			t.Errorf("%s: missing PP or target", move.Name)
+		}
+	}
+
+	for _, item := range AllItems() {
+		if item.Type != "" && !validTypes[item.Type] {
+			t.Errorf("%s: unknown type %q", item.Name, item.Type)
+		}
+	}
+}
+
+func TestLookupSpecies(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		speed    int
+	}{
+		{"Chien-Pao", "Chien-Pao", 135},
+		{"chienpao", "Chien-Pao", 135},
+		{"Urshifu-Rapid-Strike", "Urshifu-Rapid-Strike", 97},
+		{"Urshifu-*", "Urshifu", 97},
+		{"Tatsugiri-Droopy", "Tatsugiri", 82},
+		{"Landorus-Therian", "Landorus-Therian", 91},
+		{"Ursaluna-Bloodmoon", "Ursaluna-Bloodmoon", 52},
+		{"Iron Jugulis", "Iron Jugulis", 108},
+		{"Primarina", "Primarina", 60},
+		{"kommoo", "Kommo-o", 85},
+		{"Gardevoir", "Gardevoir", 80},
+		{"Excadrill", "Excadrill", 88},
+	}
+
+	for _, tt := range tests {
+		species, ok := LookupSpecies(tt.name)
+		if !ok {
+			t.Errorf("%s: not found", tt.name)
+			continue
+		}
+		if species.Name != tt.expected || species.BaseStats.Spe != tt.speed {
+			t.Errorf("%s: expected %s with %d Speed, got %s with %d", tt.name, tt.expected, tt.speed, species.Name, species.BaseStats.Spe)
+		}
+	}
+
+	if _, ok := LookupSpecies("Missingno"); ok {
+		t.Error("expected unknown species not to be found")
+	}
+}
+
+func TestLookupMove(t *testing.T) {
+	move, ok := LookupMove("Surging Strikes")
+	if !ok {
+		t.Fatal("expected Surging Strikes to be found")
+	}
+	if move.Type != "Water" || move.BasePower != 25 || move.MaxHits != 3 || !move.WillCrit || !move.HasFlag("punch") {
+		t.Errorf("unexpected Surging Strikes %+v", move)
+	}
+
+	// Names from logs can carry stray spacing and punctuation
+	tackle, ok := LookupMove("Tackle  ")
+	if !ok || tackle.Priority != 0 || !tackle.HasFlag("contact") {
+		t.Errorf("unexpected Tackle %+v", tackle)
+	}
+
+	for name, expected := range map[string]bool{"Heat Wave": true, "Earthquake": true, "Moonblast": false} {
+		move, _ := LookupMove(name)
+		if move.IsSpread() != expected {
+			t.Errorf("%s: expected spread %v", name, expected)
+		}
+	}
+
+	if trickRoom, _ := LookupMove("Trick Room"); trickRoom.Priority != -7 {
+		t.Errorf("expected Trick Room at -7 priority, got %d", trickRoom.Priority)
+	}
+}
+
+func TestLookupItem(t *testing.T) {
+	item, ok := LookupItem("Choice Scarf")
+	if !ok || item.Category != "choice" {
+		t.Errorf("unexpected Choice Scarf %+v", item)
+	}
+
+	berry, ok := LookupItem("occaberry")
+	if !ok || berry.Type != "Fire" {
+		t.Errorf("unexpected Occa Berry %+v", berry)
+	}
+}
+
+func TestLookupAbility(t *testing.T) {
+	if name, ok := LookupAbility("MindsEye"); !ok || name != "Mind's Eye" {
+		t.Errorf("expected Mind's Eye, got %q", name)
+	}
+	if name, ok := LookupAbility("goodasgold"); !ok || name != "Good as Gold" {
+		t.Errorf("expected Good as Gold, got %q", name)
+	}
+	if _, ok := LookupAbility("Not An Ability"); ok {
+		t.Error("expected an unknown ability to be missing")
+	}
+}
@@ -0,0 +1,133 @@
+// Exports Pokémon Showdown's Gen 9 species, moves and items in the format
+// the dex package embeds. Run it through go generate in the dex package:
+//
+//	go generate ./internal/dex
+//
+// which installs the pokemon-showdown package and rewrites data/gen9.json.
+// Anything Showdown marks nonstandard in Gen 9 (Past, Unobtainable, CAP and
+// so on) is left out, as are Z-Moves, Max Moves and cosmetic formes, which
+// resolve to their base species.
+'use strict';
+
+const {Dex} = require('pokemon-showdown');
+
+const dex = Dex.forGen(9);
+
+// Move flags the analysis and the calculator look at
+const FLAGS = new Set([
+	'bite', 'bullet', 'bypasssub', 'charge', 'contact', 'dance', 'defrost', 'distance', 'heal',
+	'nonsky', 'powder', 'protect', 'pulse', 'punch', 'reflectable', 'slicing', 'sound', 'wind',
+]);
+
+// Items boosting one type's moves by 1.2x; plates are found through onPlate
+const TYPE_BOOST_ITEMS = {
+	'Black Belt': 'Fighting', 'Black Glasses': 'Dark', 'Charcoal': 'Fire', 'Dragon Fang': 'Dragon',
+	'Fairy Feather': 'Fairy', 'Hard Stone': 'Rock', 'Magnet': 'Electric', 'Metal Coat': 'Steel',
+	'Miracle Seed': 'Grass', 'Mystic Water': 'Water', 'Never-Melt Ice': 'Ice', 'Poison Barb': 'Poison',
+	'Sharp Beak': 'Flying', 'Silk Scarf': 'Normal', 'Silver Powder': 'Bug', 'Soft Sand': 'Ground',
+	'Spell Tag': 'Ghost', 'Twisted Spoon': 'Psychic',
+};
+
+const RESIST_BERRY = /^Halves damage taken from a (?:super effective )?(\w+)-type attack/;
+
+const legal = entry => entry.exists && !entry.isNonstandard;
+const byName = (a, b) => (a.name < b.name ? -1 : a.name > b.name ? 1 : 0);
+
+function exportSpecies() {
+	const all = dex.species.all().filter(legal);
+	const cosmetic = new Set(all.flatMap(s => s.cosmeticFormes || []));
+	const included = all.filter(s => !cosmetic.has(s.name));
+	const names = new Set(included.map(s => s.name));
+
+	return included
+		.sort((a, b) => a.num - b.num || included.indexOf(a) - included.indexOf(b))
+		.map(s => {
+			const entry = {num: s.num, name: s.name};
+			if (s.baseSpecies !== s.name) {
+				entry.baseSpecies = s.baseSpecies;
+				entry.forme = s.forme;
+			}
+			entry.types = s.types;
+			entry.baseStats = s.baseStats;
+			entry.abilities = Object.values(s.abilities).filter(Boolean);
+			entry.weightkg = s.weightkg;
+			const otherFormes = (s.otherFormes || []).filter(name => names.has(name));
+			if (otherFormes.length) entry.otherFormes = otherFormes;
+			if (s.cosmeticFormes && s.cosmeticFormes.length) entry.cosmeticFormes = s.cosmeticFormes;
+			return entry;
+		});
+}
+
+function exportMoves() {
+	return dex.moves.all()
+		.filter(m => legal(m) && !m.isZ && !m.isMax)
+		.sort(byName)
+		.map(m => {
+			const entry = {
+				name: m.name,
+				type: m.type,
+				category: m.category,
+				basePower: m.basePower,
+				accuracy: m.accuracy === true ? 0 : m.accuracy,
+				pp: m.pp,
+				priority: m.priority,
+				target: m.target,
+				flags: Object.keys(m.flags).filter(flag => FLAGS.has(flag)),
+			};
+			if (Array.isArray(m.multihit)) {
+				[entry.minHits, entry.maxHits] = m.multihit;
+			} else if (m.multihit) {
+				entry.minHits = m.multihit;
+				entry.maxHits = m.multihit;
+			}
+			if (m.willCrit) entry.willCrit = true;
+			if (m.overrideOffensiveStat) entry.overrideOffensiveStat = m.overrideOffensiveStat;
+			if (m.overrideOffensivePokemon) entry.overrideOffensivePokemon = m.overrideOffensivePokemon;
+			if (m.overrideDefensiveStat) entry.overrideDefensiveStat = m.overrideDefensiveStat;
+			return entry;
+		});
+}
+
+function exportItems() {
+	return dex.items.all()
+		.filter(legal)
+		.sort(byName)
+		.map(item => {
+			const desc = item.shortDesc || item.desc;
+			let category = 'held';
+			let type;
+			if (item.isBerry) {
+				category = 'berry';
+				const resisted = RESIST_BERRY.exec(desc);
+				if (resisted) type = resisted[1];
+			} else if (item.isChoice) {
+				category = 'choice';
+			} else if (item.forcedForme && item.forcedForme.startsWith('Ogerpon-')) {
+				category = 'mask';
+				type = dex.species.get(item.forcedForme).types[1];
+			} else if (TYPE_BOOST_ITEMS[item.name] || item.onPlate) {
+				category = 'type-boost';
+				type = TYPE_BOOST_ITEMS[item.name] || item.onPlate;
+			}
+			const entry = {name: item.name, category};
+			if (type) entry.type = type;
+			entry.desc = desc;
+			return entry;
+		});
+}
+
+// format writes one entry per line, as data/gen9.json has always been laid
+// out, so regenerating it gives a readable diff.
+function format(value) {
+	if (Array.isArray(value)) return '[' + value.map(format).join(', ') + ']';
+	if (value && typeof value === 'object') {
+		return '{' + Object.entries(value).map(([key, v]) => JSON.stringify(key) + ': ' + format(v)).join(', ') + '}';
+	}
+	return JSON.stringify(value);
+}
+
+const sections = {species: exportSpecies(), moves: exportMoves(), items: exportItems()};
+const body = Object.entries(sections)
+	.map(([key, entries]) => `  "${key}": [\n` + entries.map(e => '    ' + format(e)).join(',\n') + '\n  ]')
+	.join(',\n');
+process.stdout.write('{\n' + body + '\n}\n');
@@ -0,0 +1,208 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dtsong/vgccorner/backend/internal/analysis"
+	"github.com/dtsong/vgccorner/backend/internal/calc"
+	"github.com/dtsong/vgccorner/backend/internal/dex"
+)
+
+// CalcDamageRequest is the request body for damage calculations.
+//
+// With battleId set, the attacker's and defender's level, ability, item and
+// Tera type are pre-filled from that stored battle's team sheets wherever
+// the request leaves them empty. Each Pokémon is looked up by species on
+// the given player's team, or on either team if no player is given.
+type CalcDamageRequest struct {
+	Attacker calc.Pokemon `json:"attacker"`
+	Defender calc.Pokemon `json:"defender"`
+	Move     calc.Move    `json:"move"`
+	Field    calc.Field   `json:"field"`
+
+	BattleID       string `json:"battleId,omitempty"`
+	AttackerPlayer string `json:"attackerPlayer,omitempty"` // "player1" or "player2"
+	DefenderPlayer string `json:"defenderPlayer,omitempty"`
+}
+
+// CalcDamageData is the calculation with the inputs it ran on, after any
+// pre-filling.
+type CalcDamageData struct {
+	Attacker calc.Pokemon `json:"attacker"`
+	Defender calc.Pokemon `json:"defender"`
+	Result   *calc.Result `json:"result"`
+}
+
+// CalcDamageResponse is the response for damage calculation requests.
+type CalcDamageResponse struct {
+	Status string          `json:"status"`
+	Data   *CalcDamageData `json:"data"`
+}
+
+// handleCalcDamage handles POST /api/calc/damage requests.
+func (s *Server) handleCalcDamage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CalcDamageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Infof("Failed to decode request body: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Invalid request body",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	if req.Attacker.Species == "" || req.Defender.Species == "" || req.Move.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "attacker.species, defender.species and move.name are required",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	if req.BattleID != "" {
+		if !s.prefillFromBattle(w, r, &req) {
+			return
+		}
+	}
+
+	s.logger.Infof("Calculating damage: %s %s vs. %s", req.Attacker.Species, req.Move.Name, req.Defender.Species)
+
+	result, err := calc.Calculate(req.Attacker, req.Defender, req.Move, req.Field)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(CalcDamageResponse{
+		Status: "success",
+		Data: &CalcDamageData{
+			Attacker: req.Attacker,
+			Defender: req.Defender,
+			Result:   result,
+		},
+	})
+}
+
+// prefillFromBattle fills in the request's Pokémon from a stored battle's
+// team sheets. It writes an error response and returns false if that fails.
+func (s *Server) prefillFromBattle(w http.ResponseWriter, r *http.Request, req *CalcDamageRequest) bool {
+	// Database required for pre-filling
+	if s.db == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Database not configured",
+			Code:  "SERVICE_UNAVAILABLE",
+		})
+		return false
+	}
+
+	battle, err := s.db.GetBattle(r.Context(), req.BattleID)
+	if err != nil {
+		s.logger.Infof("Failed to retrieve battle: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Internal server error",
+			Code:  "INTERNAL_ERROR",
+		})
+		return false
+	}
+	if battle == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Battle not found",
+			Code:  "NOT_FOUND",
+		})
+		return false
+	}
+
+	summary, err := analysis.ParseShowdownLog(battle.BattleLog)
+	if err != nil {
+		s.logger.Infof("Failed to parse battle log for %s: %v", battle.ID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Failed to parse battle log",
+			Code:  "PARSE_ERROR",
+		})
+		return false
+	}
+	summary.ID = battle.ID
+
+	for _, side := range []struct {
+		poke   *calc.Pokemon
+		player string
+	}{
+		{&req.Attacker, req.AttackerPlayer},
+		{&req.Defender, req.DefenderPlayer},
+	} {
+		if err := prefillPokemon(side.poke, summary, side.player); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{
+				Error: err.Error(),
+				Code:  "INVALID_REQUEST",
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// prefillPokemon fills in poke's empty fields from the matching Pokémon on
+// player's team, or either team if player is empty.
+func prefillPokemon(poke *calc.Pokemon, summary *analysis.BattleSummary, player string) error {
+	var teams [][]analysis.Pokémon
+	switch player {
+	case "player1":
+		teams = [][]analysis.Pokémon{summary.Player1.Team}
+	case "player2":
+		teams = [][]analysis.Pokémon{summary.Player2.Team}
+	case "":
+		teams = [][]analysis.Pokémon{summary.Player1.Team, summary.Player2.Team}
+	default:
+		return fmt.Errorf("player must be player1 or player2, got %q", player)
+	}
+
+	for _, team := range teams {
+		for _, member := range team {
+			if !sameSpecies(member.Name, poke.Species) {
+				continue
+			}
+			if poke.Level == 0 {
+				poke.Level = member.Level
+			}
+			if poke.Ability == "" {
+				poke.Ability = member.Ability
+			}
+			if poke.Item == "" {
+				poke.Item = member.Item
+			}
+			if poke.TeraType == "" {
+				poke.TeraType = member.TeraType
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not on the team in battle %s", poke.Species, summary.ID)
+}
+
+// sameSpecies reports whether a team member is the requested species. A team
+// preview name hiding the forme, such as "Urshifu-*", matches any forme.
+func sameSpecies(teamName, species string) bool {
+	if base, ok := strings.CutSuffix(teamName, "-*"); ok {
+		teamSpecies, ok1 := dex.LookupSpecies(base)
+		wanted, ok2 := dex.LookupSpecies(species)
+		return ok1 && ok2 && teamSpecies.Num == wanted.Num
+	}
+	return dex.ID(teamName) == dex.ID(species)
+}
@@ -0,0 +1,143 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dtsong/vgccorner/backend/internal/analysis"
+	"github.com/dtsong/vgccorner/backend/internal/calc"
+	"github.com/dtsong/vgccorner/backend/internal/observability"
+)
+
+func TestCalcDamage(t *testing.T) {
+	logger := observability.NewLogger()
+	server := &Server{logger: logger, db: nil}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "valid calculation",
+			body: `{"attacker": {"species": "Flutter Mane", "nature": "Modest", "evs": {"spa": 252}},
+				"defender": {"species": "Rillaboom", "evs": {"hp": 4}},
+				"move": {"name": "Dazzling Gleam"},
+				"field": {"lightScreen": true}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid JSON",
+			body:           "invalid json",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid request body",
+		},
+		{
+			name:           "missing move",
+			body:           `{"attacker": {"species": "Flutter Mane"}, "defender": {"species": "Rillaboom"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "move.name are required",
+		},
+		{
+			name:           "unknown species",
+			body:           `{"attacker": {"species": "Missingno"}, "defender": {"species": "Rillaboom"}, "move": {"name": "Moonblast"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "unknown species",
+		},
+		{
+			name:           "status move",
+			body:           `{"attacker": {"species": "Flutter Mane"}, "defender": {"species": "Rillaboom"}, "move": {"name": "Protect"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "status moves deal no damage",
+		},
+		{
+			name:           "battle pre-fill needs database",
+			body:           `{"attacker": {"species": "Flutter Mane"}, "defender": {"species": "Rillaboom"}, "move": {"name": "Moonblast"}, "battleId": "abc"}`,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  "Database not configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/calc/damage", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			server.handleCalcDamage(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" {
+				var resp ErrorResponse
+				_ = json.NewDecoder(w.Body).Decode(&resp)
+				if !strings.Contains(resp.Error, tt.expectedError) {
+					t.Errorf("expected error containing %q, got %q", tt.expectedError, resp.Error)
+				}
+				return
+			}
+
+			var resp CalcDamageResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Status != "success" || resp.Data == nil || resp.Data.Result == nil {
+				t.Fatalf("expected a successful result, got %+v", resp)
+			}
+			if resp.Data.Result.Min != 51 || resp.Data.Result.Max != 61 || len(resp.Data.Result.Rolls) != 16 {
+				t.Errorf("expected 16 rolls from 51 to 61, got %v", resp.Data.Result.Rolls)
+			}
+		})
+	}
+}
+
+func TestPrefillPokemon(t *testing.T) {
+	summary := &analysis.BattleSummary{
+		ID: "battle-1",
+		Player1: analysis.Player{Team: []analysis.Pokémon{
+			{Name: "Urshifu-*", Level: 50},
+			{Name: "Flutter Mane", Level: 50, Ability: "Protosynthesis", Item: "Booster Energy", TeraType: "Fairy"},
+		}},
+		Player2: analysis.Player{Team: []analysis.Pokémon{
+			{Name: "Rillaboom", Level: 50, Ability: "Grassy Surge", Item: "Assault Vest", TeraType: "Fire"},
+		}},
+	}
+
+	// Fields in the request win over the team sheet
+	poke := calc.Pokemon{Species: "Flutter Mane", Item: "Choice Specs"}
+	if err := prefillPokemon(&poke, summary, "player1"); err != nil {
+		t.Fatalf("prefillPokemon failed: %v", err)
+	}
+	want := calc.Pokemon{Species: "Flutter Mane", Level: 50, Ability: "Protosynthesis", Item: "Choice Specs", TeraType: "Fairy"}
+	if poke.Level != want.Level || poke.Ability != want.Ability || poke.Item != want.Item || poke.TeraType != want.TeraType {
+		t.Errorf("expected %+v, got %+v", want, poke)
+	}
+
+	// Without a player, either team is searched
+	poke = calc.Pokemon{Species: "Rillaboom"}
+	if err := prefillPokemon(&poke, summary, ""); err != nil {
+		t.Fatalf("prefillPokemon failed: %v", err)
+	}
+	if poke.Item != "Assault Vest" {
+		t.Errorf("expected Assault Vest, got %q", poke.Item)
+	}
+
+	// Team preview hides Urshifu's forme
+	poke = calc.Pokemon{Species: "Urshifu-Rapid-Strike"}
+	if err := prefillPokemon(&poke, summary, "player1"); err != nil {
+		t.Errorf("expected Urshifu-* to match Urshifu-Rapid-Strike, got %v", err)
+	}
+
+	if err := prefillPokemon(&calc.Pokemon{Species: "Rillaboom"}, summary, "player1"); err == nil {
+		t.Error("expected an error for a Pokémon not on the team")
+	}
+	if err := prefillPokemon(&calc.Pokemon{Species: "Rillaboom"}, summary, "player3"); err == nil {
+		t.Error("expected an error for an unknown player")
+	}
+}
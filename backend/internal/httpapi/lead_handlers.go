@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/dtsong/vgccorner/backend/internal/db"
+)
+
+// LeadStatsResponse is the response for lead statistics requests.
+type LeadStatsResponse struct {
+	Status string          `json:"status"`
+	Data   []*db.LeadStats `json:"data"`
+}
+
+// handleListLeadStats handles GET /api/showdown/leads requests.
+func (s *Server) handleListLeadStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filter := db.LeadStatsFilter{
+		Format:  r.URL.Query().Get("format"),
+		Pokemon: r.URL.Query().Get("pokemon"),
+		Limit:   20,
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if v, err := strconv.Atoi(limitStr); err == nil && v > 0 && v <= 100 {
+			filter.Limit = v
+		}
+	}
+
+	s.logger.Infof("Listing lead stats: format=%s pokemon=%s limit=%d", filter.Format, filter.Pokemon, filter.Limit)
+
+	// Database required for this endpoint
+	if s.db == nil {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(LeadStatsResponse{Status: "success", Data: []*db.LeadStats{}})
+		return
+	}
+
+	stats, err := s.db.ListLeadStats(r.Context(), filter)
+	if err != nil {
+		s.logger.Infof("Failed to list lead stats: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Internal server error",
+			Code:  "INTERNAL_ERROR",
+		})
+		return
+	}
+	if stats == nil {
+		stats = []*db.LeadStats{}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(LeadStatsResponse{Status: "success", Data: stats})
+}
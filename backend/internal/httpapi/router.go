@@ -26,6 +26,13 @@ func NewRouter(logger *observability.Logger, database *db.Database) http.Handler
 	r.Get("/api/showdown/replays", s.handleListShowdownReplays)
 	r.Get("/api/showdown/replays/{replayId}", s.handleGetShowdownReplay)
 	r.Get("/api/showdown/replays/{replayId}/turns", s.handleGetTurnAnalysis)
+	r.Get("/api/showdown/series/{seriesId}", s.handleGetSeries)
+	r.Get("/api/showdown/leads", s.handleListLeadStats)
+	r.Get("/api/showdown/speed", s.handleListSpeedMatchups)
+	r.Get("/api/showdown/spreads", s.handleListSpreads)
+
+	// Damage calculator
+	r.Post("/api/calc/damage", s.handleCalcDamage)
 
 	// TCG Live endpoint (planned)
 	r.Post("/api/tcglive/analyze", s.handleAnalyzeTCGLive)
@@ -68,6 +68,11 @@ func TestRouterEndpointsExist(t *testing.T) {
 		{"showdown analyze POST", "POST", "/api/showdown/analyze", false, false},
 		{"showdown list GET", "GET", "/api/showdown/replays", false, true},       // Requires DB
 		{"showdown get GET", "GET", "/api/showdown/replays/test-id", true, true}, // Requires DB
+		{"showdown series GET", "GET", "/api/showdown/series/test-id", false, false},
+		{"showdown leads GET", "GET", "/api/showdown/leads", false, false},
+		{"showdown speed GET", "GET", "/api/showdown/speed?pokemon=Incineroar", false, false},
+		{"showdown spreads GET", "GET", "/api/showdown/spreads?player=Alice", false, false},
+		{"calc damage POST", "POST", "/api/calc/damage", false, false},
 		{"tcglive analyze POST", "POST", "/api/tcglive/analyze", false, false},
 	}
 
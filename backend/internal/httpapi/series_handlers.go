@@ -0,0 +1,90 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dtsong/vgccorner/backend/internal/analysis"
+	"github.com/go-chi/chi/v5"
+)
+
+// SeriesResponse is the response for series analysis requests.
+type SeriesResponse struct {
+	Status string                  `json:"status"`
+	Data   *analysis.SeriesSummary `json:"data"`
+}
+
+// handleGetSeries handles GET /api/showdown/series/{seriesId} requests.
+func (s *Server) handleGetSeries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	seriesID := chi.URLParam(r, "seriesId")
+
+	if seriesID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "seriesId is required",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	s.logger.Infof("Retrieving series: %s", seriesID)
+
+	// Database required for this endpoint
+	if s.db == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Database not configured",
+			Code:  "SERVICE_UNAVAILABLE",
+		})
+		return
+	}
+
+	ctx := r.Context()
+	battles, err := s.db.GetSeriesBattles(ctx, seriesID)
+	if err != nil {
+		s.logger.Infof("Failed to retrieve series battles: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Internal server error",
+			Code:  "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	if len(battles) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Series not found",
+			Code:  "NOT_FOUND",
+		})
+		return
+	}
+
+	// Re-parse each game so leads, brought Pokémon and Tera are available
+	games := make([]*analysis.BattleSummary, 0, len(battles))
+	for _, battle := range battles {
+		summary, err := analysis.ParseShowdownLog(battle.BattleLog)
+		if err != nil {
+			s.logger.Infof("Failed to parse battle log for %s: %v", battle.ID, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{
+				Error: "Failed to parse battle log",
+				Code:  "PARSE_ERROR",
+			})
+			return
+		}
+		summary.ID = battle.ID
+		summary.ReplayID = battle.ReplayID
+		if battle.GameNumber > 0 {
+			summary.GameNumber = battle.GameNumber
+		}
+		games = append(games, summary)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(SeriesResponse{
+		Status: "success",
+		Data:   analysis.AnalyzeSeries(seriesID, games),
+	})
+}
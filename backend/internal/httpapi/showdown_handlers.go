@@ -11,7 +11,7 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-// It supports three analysis types via discriminator: replayId, username, or rawLog.
+// It supports four analysis types via discriminator: replayId, username, rawLog, or replayHtml.
 type AnalyzeShowdownRequest struct {
 	// Discriminator field
 	AnalysisType string `json:"analysisType"`
@@ -27,6 +27,9 @@ type AnalyzeShowdownRequest struct {
 	// For rawLog analysis
 	RawLog string `json:"rawLog,omitempty"`
 
+	// For replayHtml analysis (a downloaded Showdown replay file)
+	ReplayHTML string `json:"replayHtml,omitempty"`
+
 	// Common field
 	IsPrivate bool `json:"isPrivate"`
 }
@@ -81,6 +84,7 @@ func (s *Server) handleAnalyzeShowdown(w http.ResponseWriter, r *http.Request) {
 	// Validate request based on analysis type
 	var battleSummary *analysis.BattleSummary
 	var battlelLog string
+	var replayID string
 	var err error
 
 	switch req.AnalysisType {
@@ -129,10 +133,32 @@ func (s *Server) handleAnalyzeShowdown(w http.ResponseWriter, r *http.Request) {
 		}
 		battlelLog = req.RawLog
 
+	case "replayHtml":
+		if req.ReplayHTML == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{
+				Error: "replayHtml is required for replayHtml analysis",
+				Code:  "INVALID_REQUEST",
+			})
+			return
+		}
+		replay, err := analysis.ExtractReplayHTML(req.ReplayHTML)
+		if err != nil {
+			s.logger.Infof("Failed to extract replay HTML: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{
+				Error: "Failed to read replay HTML: " + err.Error(),
+				Code:  "PARSE_ERROR",
+			})
+			return
+		}
+		battlelLog = replay.Log
+		replayID = replay.ReplayID
+
 	default:
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(ErrorResponse{
-			Error: "analysisType must be one of: replayId, username, rawLog",
+			Error: "analysisType must be one of: replayId, username, rawLog, replayHtml",
 			Code:  "INVALID_REQUEST",
 		})
 		return
@@ -140,7 +166,7 @@ func (s *Server) handleAnalyzeShowdown(w http.ResponseWriter, r *http.Request) {
 
 	// Parse battle log with enhanced turn tracking
 	parseStart := time.Now()
-	battleSummary, err = analysis.ParseEnhancedShowdownLog(battlelLog)
+	battleSummary, err = analysis.ParseShowdownLog(battlelLog)
 	parseTime := time.Since(parseStart).Milliseconds()
 
 	if err != nil {
@@ -152,23 +178,34 @@ func (s *Server) handleAnalyzeShowdown(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if replayID != "" {
+		battleSummary.ReplayID = replayID
+	}
 
 	// Store battle in database (if database is configured)
 	battleID := battleSummary.ID
 	if s.db != nil {
 		ctx := r.Context()
 		battleRecord := &db.Battle{
-			ID:          battleSummary.ID,
-			Format:      battleSummary.Format,
-			Timestamp:   battleSummary.Timestamp,
-			DurationSec: battleSummary.Duration,
-			Winner:      battleSummary.Winner,
-			Player1ID:   battleSummary.Player1.Name,
-			Player2ID:   battleSummary.Player2.Name,
-			BattleLog:   battlelLog,
-			IsPrivate:   req.IsPrivate,
-			Analysis:    convertBattleStats(battleSummary),
-			KeyMoments:  convertKeyMoments(battleSummary),
+			ID:            battleSummary.ID,
+			ReplayID:      battleSummary.ReplayID,
+			SeriesID:      battleSummary.SeriesID,
+			GameNumber:    battleSummary.GameNumber,
+			Format:        battleSummary.Format,
+			Timestamp:     battleSummary.Timestamp,
+			DurationSec:   battleSummary.Duration,
+			Rated:         battleSummary.Rated,
+			Winner:        battleSummary.Winner,
+			Player1ID:     battleSummary.Player1.Name,
+			Player2ID:     battleSummary.Player2.Name,
+			Player1Rating: battleSummary.Player1.Rating,
+			Player2Rating: battleSummary.Player2.Rating,
+			Player1Avatar: battleSummary.Player1.Avatar,
+			Player2Avatar: battleSummary.Player2.Avatar,
+			BattleLog:     battlelLog,
+			IsPrivate:     req.IsPrivate,
+			Analysis:      convertBattleStats(battleSummary),
+			KeyMoments:    convertKeyMoments(battleSummary),
 		}
 
 		// Store battle and basic analysis
@@ -233,11 +270,18 @@ func convertBattleStats(summary *analysis.BattleSummary) *db.BattleAnalysis {
 func convertKeyMoments(summary *analysis.BattleSummary) []*db.KeyMoment {
 	moments := make([]*db.KeyMoment, 0, len(summary.KeyMoments))
 	for _, km := range summary.KeyMoments {
+		pokemon := make([]db.KeyMomentPokemon, 0, len(km.Pokemon))
+		for _, poke := range km.Pokemon {
+			pokemon = append(pokemon, db.KeyMomentPokemon{Pokemon: poke.Pokemon, Player: poke.Player})
+		}
 		moments = append(moments, &db.KeyMoment{
 			TurnNumber:   km.TurnNumber,
 			MomentType:   km.Type,
 			Description:  km.Description,
 			Significance: km.Significance,
+			Player:       km.Player,
+			Pokemon:      pokemon,
+			ActionOrder:  km.ActionOrder,
 		})
 	}
 	return moments
@@ -291,7 +335,7 @@ func (s *Server) handleGetShowdownReplay(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Parse the battle log to get full summary
-	summary, err := analysis.ParseEnhancedShowdownLog(battle.BattleLog)
+	summary, err := analysis.ParseShowdownLog(battle.BattleLog)
 	if err != nil {
 		s.logger.Infof("Failed to parse battle log: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -301,6 +345,14 @@ func (s *Server) handleGetShowdownReplay(w http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
+	// The log doesn't name its replay, and only says which series it's in
+	// when Showdown's best-of-N marker is there
+	summary.ID = battle.ID
+	summary.ReplayID = battle.ReplayID
+	if battle.SeriesID != "" {
+		summary.SeriesID = battle.SeriesID
+		summary.GameNumber = battle.GameNumber
+	}
 
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(AnalyzeResponse{
@@ -318,6 +370,10 @@ func (s *Server) handleListShowdownReplays(w http.ResponseWriter, r *http.Reques
 	username := r.URL.Query().Get("username")
 	format := r.URL.Query().Get("format")
 	isPrivateStr := r.URL.Query().Get("isPrivate")
+	ratedStr := r.URL.Query().Get("rated")
+	minRatingStr := r.URL.Query().Get("minRating")
+	lead := r.URL.Query().Get("lead")
+	sortBy := r.URL.Query().Get("sort")
 	limitStr := r.URL.Query().Get("limit")
 	offsetStr := r.URL.Query().Get("offset")
 
@@ -327,6 +383,45 @@ func (s *Server) handleListShowdownReplays(w http.ResponseWriter, r *http.Reques
 		isPrivate = &val
 	}
 
+	var rated *bool
+	if ratedStr != "" {
+		val, err := strconv.ParseBool(ratedStr)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{
+				Error: "rated must be true or false",
+				Code:  "INVALID_REQUEST",
+			})
+			return
+		}
+		rated = &val
+	}
+
+	minRating := 0
+	if minRatingStr != "" {
+		v, err := strconv.Atoi(minRatingStr)
+		if err != nil || v < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{
+				Error: "minRating must be a non-negative integer",
+				Code:  "INVALID_REQUEST",
+			})
+			return
+		}
+		minRating = v
+	}
+
+	switch sortBy {
+	case "", db.SortByTimestamp, db.SortByRating:
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "sort must be one of: timestamp, rating",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
 	limit := 10
 	if limitStr != "" {
 		if v, err := strconv.Atoi(limitStr); err == nil && v > 0 && v <= 100 {
@@ -341,7 +436,8 @@ func (s *Server) handleListShowdownReplays(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	s.logger.Infof("Listing replays: username=%s format=%s isPrivate=%v limit=%d offset=%d", username, format, isPrivate, limit, offset)
+	s.logger.Infof("Listing replays: username=%s format=%s isPrivate=%v rated=%v minRating=%d lead=%s sort=%s limit=%d offset=%d",
+		username, format, isPrivate, rated, minRating, lead, sortBy, limit, offset)
 
 	// Database required for this endpoint
 	if s.db == nil {
@@ -362,6 +458,10 @@ func (s *Server) handleListShowdownReplays(w http.ResponseWriter, r *http.Reques
 	filter := &db.BattleFilter{
 		Format:    format,
 		IsPrivate: isPrivate,
+		Rated:     rated,
+		MinRating: minRating,
+		Lead:      lead,
+		SortBy:    sortBy,
 	}
 	battles, total, err := s.db.ListBattles(ctx, filter, limit, offset)
 	if err != nil {
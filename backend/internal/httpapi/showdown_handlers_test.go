@@ -2,12 +2,17 @@ package httpapi
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dtsong/vgccorner/backend/internal/analysis"
+	"github.com/dtsong/vgccorner/backend/internal/db"
 	"github.com/dtsong/vgccorner/backend/internal/observability"
 )
 
@@ -263,6 +268,63 @@ func TestGetShowdownReplay(t *testing.T) {
 	}
 }
 
+func TestGetShowdownReplayKeepsReplayAndSeries(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	router := NewRouter(observability.NewLogger(), db.NewDatabaseFromConn(conn))
+
+	battleID := "test-battle-id"
+	timestamp := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM battles WHERE id").
+		WithArgs(battleID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "replay_id", "series_id", "game_number", "format", "timestamp", "duration_sec", "winner",
+			"player1_id", "player2_id", "battle_log", "is_private",
+			"rated", "player1_rating", "player2_rating", "player1_avatar", "player2_avatar",
+			"created_at", "updated_at",
+		}).AddRow(
+			battleID, "gen9vgc2025reghbo3-2481642255", "bestof3-gen9vgc2025reghbo3-2481642253", 2, "VGC 2025", timestamp, 300, "player1",
+			"Alice", "Bob", sampleShowdownLog(), false,
+			false, nil, nil, nil, nil,
+			timestamp, timestamp,
+		))
+	mock.ExpectQuery("SELECT (.+) FROM battle_analysis WHERE battle_id").
+		WithArgs(battleID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT (.+) FROM key_moments WHERE battle_id").
+		WithArgs(battleID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"turn_number", "moment_type", "description", "significance", "player", "pokemon", "action_order",
+		}))
+
+	req := httptest.NewRequest("GET", "/api/showdown/replays/"+battleID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data analysis.BattleSummary `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	summary := resp.Data
+	if summary.ID != battleID || summary.ReplayID != "gen9vgc2025reghbo3-2481642255" {
+		t.Errorf("expected the stored IDs, got %q and replay %q", summary.ID, summary.ReplayID)
+	}
+	if summary.SeriesID != "bestof3-gen9vgc2025reghbo3-2481642253" || summary.GameNumber != 2 {
+		t.Errorf("expected game 2 of the stored series, got %q game %d", summary.SeriesID, summary.GameNumber)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
 func TestListShowdownReplays(t *testing.T) {
 	t.Skip("test requires database")
 	logger := observability.NewLogger()
@@ -303,6 +365,16 @@ func TestListShowdownReplays(t *testing.T) {
 			query:          "?limit=200",
 			expectedStatus: http.StatusOK, // Should cap at 100
 		},
+		{
+			name:           "with rating filter and sort",
+			query:          "?rated=true&minRating=1500&sort=rating",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "with lead filter",
+			query:          "?lead=Incineroar",
+			expectedStatus: http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
@@ -326,6 +398,39 @@ func TestListShowdownReplays(t *testing.T) {
 	}
 }
 
+func TestListShowdownReplaysInvalidParams(t *testing.T) {
+	server := &Server{logger: observability.NewLogger(), db: nil}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"non-numeric minRating", "?minRating=high"},
+		{"negative minRating", "?minRating=-1"},
+		{"non-boolean rated", "?rated=yes"},
+		{"unknown sort", "?sort=turns"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/showdown/replays"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			server.handleListShowdownReplays(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+
+			var resp ErrorResponse
+			_ = json.NewDecoder(w.Body).Decode(&resp)
+			if resp.Code != "INVALID_REQUEST" {
+				t.Errorf("expected code INVALID_REQUEST, got %s", resp.Code)
+			}
+		})
+	}
+}
+
 func TestAnalyzeTCGLive(t *testing.T) {
 	logger := observability.NewLogger()
 	server := &Server{logger: logger, db: nil}
@@ -595,6 +700,7 @@ func TestAnalyzeShowdownAllAnalysisTypesValidation(t *testing.T) {
 		username       string
 		format         string
 		rawLog         string
+		replayHTML     string
 		expectedStatus int
 	}{
 		{
@@ -642,6 +748,24 @@ func TestAnalyzeShowdownAllAnalysisTypesValidation(t *testing.T) {
 			format:         "",
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:           "replayHtml with replay file",
+			analysisType:   "replayHtml",
+			replayHTML:     sampleReplayHTML(),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "replayHtml without replay file",
+			analysisType:   "replayHtml",
+			replayHTML:     "",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "replayHtml without embedded log",
+			analysisType:   "replayHtml",
+			replayHTML:     "<html><body>no replay here</body></html>",
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:           "invalid analysis type",
 			analysisType:   "invalid",
@@ -657,6 +781,7 @@ func TestAnalyzeShowdownAllAnalysisTypesValidation(t *testing.T) {
 				Username:     tt.username,
 				Format:       tt.format,
 				RawLog:       tt.rawLog,
+				ReplayHTML:   tt.replayHTML,
 			}
 			body, _ := json.Marshal(req)
 			httpReq := httptest.NewRequest("POST", "/api/showdown/analyze", bytes.NewReader(body))
@@ -672,6 +797,42 @@ func TestAnalyzeShowdownAllAnalysisTypesValidation(t *testing.T) {
 	}
 }
 
+func TestAnalyzeShowdownReplayHTML(t *testing.T) {
+	logger := observability.NewLogger()
+	server := &Server{logger: logger}
+
+	body, _ := json.Marshal(AnalyzeShowdownRequest{
+		AnalysisType: "replayHtml",
+		ReplayHTML:   sampleReplayHTML(),
+	})
+	req := httptest.NewRequest("POST", "/api/showdown/analyze", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	server.handleAnalyzeShowdown(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp AnalyzeResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if resp.Data == nil {
+		t.Fatal("expected battle summary in response")
+	}
+
+	if resp.Data.ReplayID != "gen9vgc2025reghbo3-2481642254" {
+		t.Errorf("expected replay ID from HTML, got %q", resp.Data.ReplayID)
+	}
+
+	if resp.Data.Winner != "player2" {
+		t.Errorf("expected player2 to win, got %q", resp.Data.Winner)
+	}
+}
+
 // Helper functions
 
 func generateLongLog() string {
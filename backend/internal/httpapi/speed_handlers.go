@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/dtsong/vgccorner/backend/internal/db"
+)
+
+// SpeedMatchupsResponse is the response for Speed matchup requests.
+type SpeedMatchupsResponse struct {
+	Status string             `json:"status"`
+	Data   []*db.SpeedMatchup `json:"data"`
+}
+
+// handleListSpeedMatchups handles GET /api/showdown/speed requests.
+func (s *Server) handleListSpeedMatchups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filter := db.SpeedMatchupFilter{
+		Format:  r.URL.Query().Get("format"),
+		Pokemon: r.URL.Query().Get("pokemon"),
+		Limit:   20,
+	}
+	if filter.Pokemon == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "pokemon is required",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if v, err := strconv.Atoi(limitStr); err == nil && v > 0 && v <= 100 {
+			filter.Limit = v
+		}
+	}
+
+	s.logger.Infof("Listing speed matchups: format=%s pokemon=%s limit=%d", filter.Format, filter.Pokemon, filter.Limit)
+
+	// Database required for this endpoint
+	if s.db == nil {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(SpeedMatchupsResponse{Status: "success", Data: []*db.SpeedMatchup{}})
+		return
+	}
+
+	matchups, err := s.db.ListSpeedMatchups(r.Context(), filter)
+	if err != nil {
+		s.logger.Infof("Failed to list speed matchups: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Internal server error",
+			Code:  "INTERNAL_ERROR",
+		})
+		return
+	}
+	if matchups == nil {
+		matchups = []*db.SpeedMatchup{}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(SpeedMatchupsResponse{Status: "success", Data: matchups})
+}
@@ -0,0 +1,83 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/dtsong/vgccorner/backend/internal/analysis"
+)
+
+// SpreadsResponse is the response for spread inference requests.
+type SpreadsResponse struct {
+	Status string                     `json:"status"`
+	Data   []analysis.SpreadInference `json:"data"`
+}
+
+// handleListSpreads handles GET /api/showdown/spreads requests.
+func (s *Server) handleListSpreads(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	player := r.URL.Query().Get("player")
+	format := r.URL.Query().Get("format")
+	if player == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "player is required",
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if v, err := strconv.Atoi(limitStr); err == nil && v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+
+	s.logger.Infof("Inferring spreads: player=%s format=%s limit=%d", player, format, limit)
+
+	// Database required for this endpoint
+	if s.db == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Database not configured",
+			Code:  "SERVICE_UNAVAILABLE",
+		})
+		return
+	}
+
+	battles, err := s.db.GetPlayerBattles(r.Context(), player, format, limit)
+	if err != nil {
+		s.logger.Infof("Failed to retrieve player battles: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Internal server error",
+			Code:  "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	// Re-parse each battle for its damage samples
+	summaries := make([]*analysis.BattleSummary, 0, len(battles))
+	for _, battle := range battles {
+		summary, err := analysis.ParseShowdownLog(battle.BattleLog)
+		if err != nil {
+			s.logger.Infof("Failed to parse battle log for %s: %v", battle.ID, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{
+				Error: "Failed to parse battle log",
+				Code:  "PARSE_ERROR",
+			})
+			return
+		}
+		summary.ID = battle.ID
+		summaries = append(summaries, summary)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(SpreadsResponse{
+		Status: "success",
+		Data:   analysis.InferPlayerSpreads(player, summaries),
+	})
+}
@@ -1,5 +1,7 @@
 package httpapi
 
+import "strings"
+
 // sampleShowdownLog returns a minimal valid Showdown battle log for testing.
 func sampleShowdownLog() string {
 	return `|j|☆Player1
@@ -58,3 +60,16 @@ func sampleShowdownLog() string {
 |
 |win|Player2`
 }
+
+// sampleReplayHTML wraps sampleShowdownLog in a downloaded Showdown replay page.
+func sampleReplayHTML() string {
+	escapedLog := strings.ReplaceAll(sampleShowdownLog(), "/", `\/`)
+	return `<!DOCTYPE html>
+<meta charset="utf-8" />
+<title>[Gen 9] VGC 2025 Reg H (Bo3) replay sample</title>
+<div class="wrapper replay-wrapper">
+<input type="hidden" name="replayid" value="gen9vgc2025reghbo3-2481642254" />
+<script type="text/plain" class="battle-log-data">` + escapedLog + `
+</script>
+</div>`
+}
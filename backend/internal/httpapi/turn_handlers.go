@@ -23,26 +23,35 @@ type TurnAnalysisResponse struct {
 
 // TurnData represents detailed information about a single turn
 type TurnData struct {
-	TurnNumber int           `json:"turnNumber"`
-	Events     []BattleEvent `json:"events"`
-	BoardState BoardState    `json:"boardState"`
+	TurnNumber      int                      `json:"turnNumber"`
+	DecisionTimeSec int                      `json:"decisionTimeSec"` // Seconds players took to choose
+	DurationSec     int                      `json:"durationSec"`     // Wall-clock length of the turn
+	Events          []BattleEvent            `json:"events"`
+	BoardState      BoardState               `json:"boardState"`
+	WinProbability  *analysis.WinProbability `json:"winProbability,omitempty"` // Each player's chance of winning after the turn
 }
 
 // BattleEvent represents a single event during a turn
 type BattleEvent struct {
-	Type       string `json:"type"`    // "move", "switch", "faint", etc.
-	Pokemon    string `json:"pokemon"` // Pokemon performing action
-	Action     string `json:"action"`  // Description of action
-	Target     string `json:"target,omitempty"`
-	Result     string `json:"result,omitempty"`
-	Details    string `json:"details,omitempty"`
-	PlayerSide string `json:"playerSide"` // "player1" or "player2"
+	Type       string                  `json:"type"`    // "move", "switch", "faint", etc.
+	Pokemon    string                  `json:"pokemon"` // Pokemon performing action
+	Action     string                  `json:"action"`  // Description of action
+	Target     string                  `json:"target,omitempty"`
+	Targets    []analysis.ActionTarget `json:"targets,omitempty"` // Per-target results, one per Pokémon a spread move hit
+	Result     string                  `json:"result,omitempty"`
+	Details    string                  `json:"details,omitempty"`
+	Volatiles  []analysis.Volatile     `json:"volatiles,omitempty"` // Volatile conditions the move started
+	PlayerSide string                  `json:"playerSide"`          // "player1" or "player2"
 }
 
 // BoardState represents the state of the battle at a specific turn
 type BoardState struct {
-	Player1Active []ActivePokemon `json:"player1Active"`
-	Player2Active []ActivePokemon `json:"player2Active"`
+	Player1Active []ActivePokemon      `json:"player1Active"`
+	Player2Active []ActivePokemon      `json:"player2Active"`
+	Player1Bench  []BenchPokemon       `json:"player1Bench"`
+	Player2Bench  []BenchPokemon       `json:"player2Bench"`
+	Field         *analysis.FieldState `json:"field,omitempty"`     // Weather, terrain, rooms and side conditions
+	Volatiles     []analysis.Volatile  `json:"volatiles,omitempty"` // Volatile conditions on each active Pokémon
 }
 
 // ActivePokemon represents a Pokemon currently on the field
@@ -54,6 +63,20 @@ type ActivePokemon struct {
 	MaxHP    int    `json:"maxHp"`
 	Status   string `json:"status,omitempty"`
 	IsLead   bool   `json:"isLead,omitempty"`
+
+	TeraType      string         `json:"teraType,omitempty"` // Set once terastallized
+	Terastallized bool           `json:"terastallized,omitempty"`
+	Boosts        map[string]int `json:"boosts,omitempty"` // Non-zero stat stages, e.g. "atk": 2
+}
+
+// BenchPokemon represents a Pokemon in the back
+type BenchPokemon struct {
+	Species  string `json:"species"`
+	Nickname string `json:"nickname,omitempty"`
+	HP       int    `json:"hp"`
+	MaxHP    int    `json:"maxHp"`
+	Status   string `json:"status,omitempty"`
+	Revealed bool   `json:"revealed"` // Has been sent out; otherwise only seen at team preview
 }
 
 // ArchetypeInfo contains team archetype information
@@ -131,10 +154,15 @@ func convertTurnDataToResponse(data *db.TurnAnalysisData) TurnAnalysisResponse {
 
 	for _, turn := range data.Turns {
 		turnData := TurnData{
-			TurnNumber: turn.TurnNumber,
-			Events:     convertDBActionsToEvents(turn.Actions),
-			BoardState: convertDBBoardState(turn.BoardState),
+			TurnNumber:      turn.TurnNumber,
+			DecisionTimeSec: turn.DecisionTimeSec,
+			DurationSec:     turn.DurationSec,
+			Events:          convertDBActionsToEvents(turn.Actions),
+			BoardState:      convertDBBoardState(turn.BoardState),
+			WinProbability:  turn.WinProbability,
 		}
+		turnData.BoardState.Field = turn.FieldState
+		turnData.BoardState.Volatiles = turn.Volatiles
 		turns = append(turns, turnData)
 	}
 
@@ -163,10 +191,14 @@ func convertDBActionsToEvents(actions []*db.ActionData) []BattleEvent {
 			Pokemon:    action.Pokemon,
 			Action:     formatActionDescription(action),
 			Target:     action.Target,
+			Targets:    action.Targets,
 			Result:     action.Result,
 			Details:    action.Details,
 			PlayerSide: action.Player,
 		}
+		if action.Impact != nil {
+			event.Volatiles = action.Impact.Volatiles
+		}
 
 		events = append(events, event)
 	}
@@ -188,36 +220,46 @@ func formatActionDescription(action *db.ActionData) string {
 
 // convertDBBoardState converts database board state to API format
 func convertDBBoardState(state *db.BoardStateData) BoardState {
-	boardState := BoardState{
-		Player1Active: []ActivePokemon{},
-		Player2Active: []ActivePokemon{},
+	return BoardState{
+		Player1Active: convertActivePokemon(state.Player1Active),
+		Player2Active: convertActivePokemon(state.Player2Active),
+		Player1Bench:  convertBenchPokemon(state.Player1Bench),
+		Player2Bench:  convertBenchPokemon(state.Player2Bench),
 	}
+}
 
-	for _, poke := range state.Player1Active {
-		boardState.Player1Active = append(boardState.Player1Active, ActivePokemon{
-			Species:  poke.Species,
-			Nickname: poke.Name,
-			Position: poke.Position,
-			HP:       poke.HP,
-			MaxHP:    poke.MaxHP,
-			Status:   poke.Status,
-			IsLead:   poke.IsLead,
+func convertActivePokemon(active []*db.ActivePokemonData) []ActivePokemon {
+	converted := []ActivePokemon{}
+	for _, poke := range active {
+		converted = append(converted, ActivePokemon{
+			Species:       poke.Species,
+			Nickname:      poke.Name,
+			Position:      poke.Position,
+			HP:            poke.HP,
+			MaxHP:         poke.MaxHP,
+			Status:        poke.Status,
+			IsLead:        poke.IsLead,
+			TeraType:      poke.TeraType,
+			Terastallized: poke.Terastallized,
+			Boosts:        poke.Boosts,
 		})
 	}
+	return converted
+}
 
-	for _, poke := range state.Player2Active {
-		boardState.Player2Active = append(boardState.Player2Active, ActivePokemon{
+func convertBenchPokemon(bench []*db.BenchPokemonData) []BenchPokemon {
+	converted := []BenchPokemon{}
+	for _, poke := range bench {
+		converted = append(converted, BenchPokemon{
 			Species:  poke.Species,
 			Nickname: poke.Name,
-			Position: poke.Position,
 			HP:       poke.HP,
 			MaxHP:    poke.MaxHP,
 			Status:   poke.Status,
-			IsLead:   poke.IsLead,
+			Revealed: poke.Revealed,
 		})
 	}
-
-	return boardState
+	return converted
 }
 
 // convertArchetype converts database archetype to API format
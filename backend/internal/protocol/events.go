@@ -0,0 +1,591 @@
+package protocol
+
+import "strings"
+
+// Event is a decoded protocol line. Lines without a dedicated event type
+// decode to UnknownEvent so callers can still inspect them.
+type Event interface {
+	Command() string
+}
+
+// Effectiveness values reported by EffectivenessEvent.
+const (
+	SuperEffective   = "super-effective"
+	NotVeryEffective = "not-very-effective"
+	Immune           = "immune"
+)
+
+// TimestampEvent is |t:|<unix seconds>.
+type TimestampEvent struct {
+	Unix int64
+}
+
+// TurnEvent is |turn|N.
+type TurnEvent struct {
+	Number int
+}
+
+// UpkeepEvent is |upkeep|, sent once end-of-turn effects have resolved.
+// Anything after it in the turn, such as replacement switch-ins, happens
+// after the turn's counters have ticked down.
+type UpkeepEvent struct{}
+
+// PlayerEvent is |player|p1|Name|avatar|rating. A player leaving sends it with an empty name.
+type PlayerEvent struct {
+	Side   string
+	Name   string
+	Avatar string
+	Rating int // 0 outside rated ladder games
+}
+
+// TeamSizeEvent is |teamsize|p1|4.
+type TeamSizeEvent struct {
+	Side string
+	Size int
+}
+
+// TierEvent is |tier|[Gen 9] VGC 2025 Reg H (Bo3).
+type TierEvent struct {
+	Format string
+}
+
+// RatedEvent is |rated| or |rated|message.
+type RatedEvent struct {
+	Message string
+}
+
+// PokeEvent is a team preview entry, |poke|p1|Details|item.
+type PokeEvent struct {
+	Side    string
+	Details Details
+}
+
+// ShowTeamEvent is an open team sheet, |showteam|p1|<packed team>.
+type ShowTeamEvent struct {
+	Side   string
+	Packed string
+}
+
+// UHTMLEvent is |uhtml|name|html, used for best-of-N series markers.
+type UHTMLEvent struct {
+	Name string
+	HTML string
+}
+
+// SwitchEvent is |switch|, |drag| or |replace|POKEMON|DETAILS|HP.
+type SwitchEvent struct {
+	Kind    string // "switch", "drag" or "replace"
+	Pokemon PokemonRef
+	Details Details
+	HP      HP
+	HasHP   bool
+}
+
+// SwapEvent is |swap|POKEMON|POSITION, sent when Ally Switch moves a
+// Pokémon to another active position. POKEMON names it by the slot it's
+// leaving, and the Pokémon in POSITION (0 for "a") takes that slot.
+type SwapEvent struct {
+	Pokemon  PokemonRef
+	Position int
+	From     Effect
+}
+
+// MoveEvent is |move|SOURCE|MOVE|TARGET with its flags.
+type MoveEvent struct {
+	Source PokemonRef
+	Move   string
+	Target PokemonRef // Zero for moves with no target, such as charging turns
+	Spread []string   // Slots hit by a spread move, from [spread]
+	Miss   bool       // [miss]
+	Still  bool       // [still]: no animation, e.g. the charging turn of a two-turn move
+	From   Effect     // [from], e.g. lockedmove or a called move
+}
+
+// CantEvent is |cant|POKEMON|REASON|MOVE with an optional [of], sent when a
+// Pokémon can't act, e.g. because it flinched, is fully paralyzed or is
+// asleep.
+type CantEvent struct {
+	Pokemon PokemonRef
+	Reason  Effect     // e.g. "flinch", "par" or "move: Taunt"
+	Move    string     // The move it couldn't use, if known
+	Of      PokemonRef // [of], e.g. the attacker Armor Tail stopped
+}
+
+// DamageEvent is |-damage|POKEMON|HP with an optional [from] and [of].
+type DamageEvent struct {
+	Pokemon PokemonRef
+	HP      HP
+	From    Effect     // Indirect damage source, e.g. "item: Life Orb"
+	Of      PokemonRef // Pokémon responsible for indirect damage, e.g. a Leech Seed user
+}
+
+// HealEvent is |-heal|POKEMON|HP with an optional [from] and [of].
+type HealEvent struct {
+	Pokemon PokemonRef
+	HP      HP
+	From    Effect
+	Of      PokemonRef
+}
+
+// FaintEvent is |faint|POKEMON.
+type FaintEvent struct {
+	Pokemon PokemonRef
+}
+
+// StatusEvent is |-status|POKEMON|STATUS.
+type StatusEvent struct {
+	Pokemon PokemonRef
+	Status  string
+	From    Effect
+	Of      PokemonRef
+}
+
+// CureStatusEvent is |-curestatus|POKEMON|STATUS.
+type CureStatusEvent struct {
+	Pokemon PokemonRef
+	Status  string
+	From    Effect
+}
+
+// BoostEvent is |-boost| or |-unboost|POKEMON|STAT|AMOUNT. Amount is
+// negative for -unboost.
+type BoostEvent struct {
+	Pokemon PokemonRef
+	Stat    string
+	Amount  int
+	From    Effect
+}
+
+// SetBoostEvent is |-setboost|POKEMON|STAT|AMOUNT, which sets a stat stage
+// outright, e.g. Belly Drum or Anger Point maxing attack.
+type SetBoostEvent struct {
+	Pokemon PokemonRef
+	Stat    string
+	Amount  int
+	From    Effect
+}
+
+// Stat stage resets reported by ClearBoostEvent.Kind.
+const (
+	ClearBoost         = "-clearboost"         // One Pokémon's stages, e.g. Clear Smog
+	ClearAllBoost      = "-clearallboost"      // Every Pokémon's stages, e.g. Haze
+	ClearPositiveBoost = "-clearpositiveboost" // One Pokémon's boosts, e.g. Spectral Thief
+	ClearNegativeBoost = "-clearnegativeboost" // One Pokémon's drops, e.g. White Herb
+)
+
+// ClearBoostEvent resets stat stages. Pokemon is zero for -clearallboost.
+type ClearBoostEvent struct {
+	Kind    string // ClearBoost, ClearAllBoost, ClearPositiveBoost or ClearNegativeBoost
+	Pokemon PokemonRef
+	From    Effect
+}
+
+// InvertBoostEvent is |-invertboost|POKEMON, from Topsy-Turvy.
+type InvertBoostEvent struct {
+	Pokemon PokemonRef
+}
+
+// CopyBoostEvent is |-copyboost|SOURCE|TARGET: Source takes on Target's stat
+// stages, e.g. with Psych Up.
+type CopyBoostEvent struct {
+	Source PokemonRef
+	Target PokemonRef
+	From   Effect
+}
+
+// TerastallizeEvent is |-terastallize|POKEMON|TYPE.
+type TerastallizeEvent struct {
+	Pokemon  PokemonRef
+	TeraType string
+}
+
+// CritEvent is |-crit|POKEMON.
+type CritEvent struct {
+	Pokemon PokemonRef
+}
+
+// EffectivenessEvent is |-supereffective|, |-resisted| or |-immune|POKEMON.
+type EffectivenessEvent struct {
+	Pokemon       PokemonRef
+	Effectiveness string // SuperEffective, NotVeryEffective or Immune
+	From          Effect // Set for ability immunities, e.g. "ability: Levitate"
+}
+
+// MissEvent is |-miss|SOURCE|TARGET.
+type MissEvent struct {
+	Source PokemonRef
+	Target PokemonRef
+}
+
+// WeatherEvent is |-weather|WEATHER. Weather is "none" when it ends.
+type WeatherEvent struct {
+	Weather string
+	Upkeep  bool // [upkeep]: the weather continued rather than starting
+	From    Effect
+	Of      PokemonRef
+}
+
+// FieldEvent is |-fieldstart| or |-fieldend|CONDITION, e.g. terrains and Trick Room.
+type FieldEvent struct {
+	Effect Effect
+	Ended  bool
+	From   Effect
+	Of     PokemonRef
+}
+
+// SideEvent is |-sidestart| or |-sideend|SIDE|CONDITION, e.g. Tailwind and screens.
+type SideEvent struct {
+	Side   string
+	Effect Effect
+	Ended  bool
+}
+
+// VolatileEvent is |-start| or |-end|POKEMON|EFFECT, for volatile conditions
+// such as confusion, Leech Seed, Salt Cure and Taunt.
+type VolatileEvent struct {
+	Pokemon PokemonRef
+	Effect  Effect
+	Value   string // Extra argument, e.g. the new type for typechange
+	Ended   bool
+	From    Effect
+	Of      PokemonRef
+}
+
+// SingleTurnEvent is |-singleturn|POKEMON|EFFECT, for effects that last only
+// the turn they're used, such as Protect, Follow Me and Rage Powder.
+type SingleTurnEvent struct {
+	Pokemon PokemonRef
+	Effect  Effect
+	Of      PokemonRef
+}
+
+// AbilityEvent is |-ability|POKEMON|ABILITY, sent when an ability announces
+// itself, e.g. Intimidate ("boost") or Pressure on switch-in. Abilities copied
+// by Trace and similar carry [from] the copying ability and [of] the source.
+type AbilityEvent struct {
+	Pokemon PokemonRef
+	Ability string
+	Value   string // Extra argument, e.g. "boost" for Intimidate
+	From    Effect
+	Of      PokemonRef
+}
+
+// ActivateEvent is |-activate|POKEMON|EFFECT, sent when an effect such as
+// Protect, Sturdy or Quark Drive takes hold.
+type ActivateEvent struct {
+	Pokemon PokemonRef
+	Effect  Effect
+	Value   string // Extra argument, if any
+	Of      PokemonRef
+}
+
+// ItemEvent is |-item| or |-enditem|POKEMON|ITEM. -item reveals or hands a
+// Pokémon an item, e.g. through Frisk or Trick; -enditem means it lost the
+// item, whether eaten, used up or taken by a move such as Knock Off.
+type ItemEvent struct {
+	Pokemon PokemonRef
+	Item    string
+	Ended   bool
+	Eaten   bool // [eat]: a berry was eaten
+	From    Effect
+	Of      PokemonRef
+}
+
+// WinEvent is |win|USER.
+type WinEvent struct {
+	Winner string
+}
+
+// UnknownEvent is any line without a dedicated event type.
+type UnknownEvent struct {
+	Line Line
+}
+
+func (TimestampEvent) Command() string    { return "t:" }
+func (TurnEvent) Command() string         { return "turn" }
+func (UpkeepEvent) Command() string       { return "upkeep" }
+func (PlayerEvent) Command() string       { return "player" }
+func (TeamSizeEvent) Command() string     { return "teamsize" }
+func (TierEvent) Command() string         { return "tier" }
+func (RatedEvent) Command() string        { return "rated" }
+func (PokeEvent) Command() string         { return "poke" }
+func (ShowTeamEvent) Command() string     { return "showteam" }
+func (UHTMLEvent) Command() string        { return "uhtml" }
+func (e SwitchEvent) Command() string     { return e.Kind }
+func (SwapEvent) Command() string         { return "swap" }
+func (MoveEvent) Command() string         { return "move" }
+func (CantEvent) Command() string         { return "cant" }
+func (DamageEvent) Command() string       { return "-damage" }
+func (HealEvent) Command() string         { return "-heal" }
+func (FaintEvent) Command() string        { return "faint" }
+func (StatusEvent) Command() string       { return "-status" }
+func (CureStatusEvent) Command() string   { return "-curestatus" }
+func (SetBoostEvent) Command() string     { return "-setboost" }
+func (e ClearBoostEvent) Command() string { return e.Kind }
+func (InvertBoostEvent) Command() string  { return "-invertboost" }
+func (CopyBoostEvent) Command() string    { return "-copyboost" }
+func (TerastallizeEvent) Command() string { return "-terastallize" }
+func (CritEvent) Command() string         { return "-crit" }
+func (MissEvent) Command() string         { return "-miss" }
+func (WeatherEvent) Command() string      { return "-weather" }
+func (SingleTurnEvent) Command() string   { return "-singleturn" }
+func (AbilityEvent) Command() string      { return "-ability" }
+func (ActivateEvent) Command() string     { return "-activate" }
+func (WinEvent) Command() string          { return "win" }
+func (e UnknownEvent) Command() string    { return e.Line.Command }
+
+func (e BoostEvent) Command() string {
+	if e.Amount < 0 {
+		return "-unboost"
+	}
+	return "-boost"
+}
+
+func (e EffectivenessEvent) Command() string {
+	switch e.Effectiveness {
+	case SuperEffective:
+		return "-supereffective"
+	case NotVeryEffective:
+		return "-resisted"
+	}
+	return "-immune"
+}
+
+func (e FieldEvent) Command() string {
+	if e.Ended {
+		return "-fieldend"
+	}
+	return "-fieldstart"
+}
+
+func (e SideEvent) Command() string {
+	if e.Ended {
+		return "-sideend"
+	}
+	return "-sidestart"
+}
+
+func (e ItemEvent) Command() string {
+	if e.Ended {
+		return "-enditem"
+	}
+	return "-item"
+}
+
+func (e VolatileEvent) Command() string {
+	if e.Ended {
+		return "-end"
+	}
+	return "-start"
+}
+
+// Parse decodes a raw protocol line. It returns nil for lines that aren't
+// protocol messages.
+func Parse(raw string) Event {
+	line, ok := ParseLine(raw)
+	if !ok {
+		return nil
+	}
+	return Decode(line)
+}
+
+// Decode converts a split line into its typed event.
+func Decode(line Line) Event {
+	from := ParseEffect(line.Kwargs["from"])
+	of := ParsePokemonRef(line.Kwargs["of"])
+
+	switch line.Command {
+	case "t:":
+		return TimestampEvent{Unix: int64(Atoi(line.Arg(0)))}
+
+	case "turn":
+		return TurnEvent{Number: Atoi(line.Arg(0))}
+
+	case "upkeep":
+		return UpkeepEvent{}
+
+	case "player":
+		return PlayerEvent{
+			Side:   line.Arg(0),
+			Name:   line.Arg(1),
+			Avatar: line.Arg(2),
+			Rating: Atoi(line.Arg(3)),
+		}
+
+	case "teamsize":
+		return TeamSizeEvent{Side: line.Arg(0), Size: Atoi(line.Arg(1))}
+
+	case "tier":
+		return TierEvent{Format: line.Arg(0)}
+
+	case "rated":
+		return RatedEvent{Message: line.Arg(0)}
+
+	case "poke":
+		return PokeEvent{Side: line.Arg(0), Details: ParseDetails(line.Arg(1))}
+
+	case "showteam":
+		return ShowTeamEvent{Side: line.Arg(0), Packed: line.Arg(1)}
+
+	case "uhtml":
+		return UHTMLEvent{Name: line.Arg(0), HTML: line.Arg(1)}
+
+	case "switch", "drag", "replace":
+		event := SwitchEvent{
+			Kind:    line.Command,
+			Pokemon: ParsePokemonRef(line.Arg(0)),
+			Details: ParseDetails(line.Arg(1)),
+		}
+		if hp := line.Arg(2); hp != "" {
+			event.HP = ParseHP(hp)
+			event.HasHP = true
+		}
+		return event
+
+	case "swap":
+		return SwapEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Position: Atoi(line.Arg(1)), From: from}
+
+	case "move":
+		event := MoveEvent{
+			Source: ParsePokemonRef(line.Arg(0)),
+			Move:   line.Arg(1),
+			Target: ParsePokemonRef(line.Arg(2)),
+			Miss:   line.HasKwarg("miss"),
+			Still:  line.HasKwarg("still"),
+			From:   from,
+		}
+		if spread, ok := line.Kwarg("spread"); ok && spread != "" {
+			event.Spread = splitList(spread)
+		}
+		return event
+
+	case "cant":
+		return CantEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Reason: ParseEffect(line.Arg(1)), Move: line.Arg(2), Of: of}
+
+	case "-damage":
+		return DamageEvent{Pokemon: ParsePokemonRef(line.Arg(0)), HP: ParseHP(line.Arg(1)), From: from, Of: of}
+
+	case "-heal":
+		return HealEvent{Pokemon: ParsePokemonRef(line.Arg(0)), HP: ParseHP(line.Arg(1)), From: from, Of: of}
+
+	case "faint":
+		return FaintEvent{Pokemon: ParsePokemonRef(line.Arg(0))}
+
+	case "-status":
+		return StatusEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Status: line.Arg(1), From: from, Of: of}
+
+	case "-curestatus":
+		return CureStatusEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Status: line.Arg(1), From: from}
+
+	case "-boost", "-unboost":
+		amount := Atoi(line.Arg(2))
+		if line.Command == "-unboost" {
+			amount = -amount
+		}
+		return BoostEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Stat: line.Arg(1), Amount: amount, From: from}
+
+	case "-setboost":
+		return SetBoostEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Stat: line.Arg(1), Amount: Atoi(line.Arg(2)), From: from}
+
+	case "-clearboost", "-clearallboost", "-clearpositiveboost", "-clearnegativeboost":
+		return ClearBoostEvent{Kind: line.Command, Pokemon: ParsePokemonRef(line.Arg(0)), From: from}
+
+	case "-invertboost":
+		return InvertBoostEvent{Pokemon: ParsePokemonRef(line.Arg(0))}
+
+	case "-copyboost":
+		return CopyBoostEvent{Source: ParsePokemonRef(line.Arg(0)), Target: ParsePokemonRef(line.Arg(1)), From: from}
+
+	case "-terastallize":
+		return TerastallizeEvent{Pokemon: ParsePokemonRef(line.Arg(0)), TeraType: line.Arg(1)}
+
+	case "-crit":
+		return CritEvent{Pokemon: ParsePokemonRef(line.Arg(0))}
+
+	case "-supereffective":
+		return EffectivenessEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Effectiveness: SuperEffective}
+
+	case "-resisted":
+		return EffectivenessEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Effectiveness: NotVeryEffective}
+
+	case "-immune":
+		return EffectivenessEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Effectiveness: Immune, From: from}
+
+	case "-miss":
+		return MissEvent{Source: ParsePokemonRef(line.Arg(0)), Target: ParsePokemonRef(line.Arg(1))}
+
+	case "-weather":
+		return WeatherEvent{Weather: line.Arg(0), Upkeep: line.HasKwarg("upkeep"), From: from, Of: of}
+
+	case "-fieldstart", "-fieldend":
+		return FieldEvent{
+			Effect: ParseEffect(line.Arg(0)),
+			Ended:  line.Command == "-fieldend",
+			From:   from,
+			Of:     of,
+		}
+
+	case "-sidestart", "-sideend":
+		return SideEvent{
+			Side:   ParsePokemonRef(line.Arg(0)).Side,
+			Effect: ParseEffect(line.Arg(1)),
+			Ended:  line.Command == "-sideend",
+		}
+
+	case "-start", "-end":
+		return VolatileEvent{
+			Pokemon: ParsePokemonRef(line.Arg(0)),
+			Effect:  ParseEffect(line.Arg(1)),
+			Value:   line.Arg(2),
+			Ended:   line.Command == "-end",
+			From:    from,
+			Of:      of,
+		}
+
+	case "-singleturn":
+		return SingleTurnEvent{Pokemon: ParsePokemonRef(line.Arg(0)), Effect: ParseEffect(line.Arg(1)), Of: of}
+
+	case "-ability":
+		return AbilityEvent{
+			Pokemon: ParsePokemonRef(line.Arg(0)),
+			Ability: line.Arg(1),
+			Value:   line.Arg(2),
+			From:    from,
+			Of:      of,
+		}
+
+	case "-activate":
+		return ActivateEvent{
+			Pokemon: ParsePokemonRef(line.Arg(0)),
+			Effect:  ParseEffect(line.Arg(1)),
+			Value:   line.Arg(2),
+			Of:      of,
+		}
+
+	case "-item", "-enditem":
+		return ItemEvent{
+			Pokemon: ParsePokemonRef(line.Arg(0)),
+			Item:    line.Arg(1),
+			Ended:   line.Command == "-enditem",
+			Eaten:   line.HasKwarg("eat"),
+			From:    from,
+			Of:      of,
+		}
+
+	case "win":
+		return WinEvent{Winner: line.Arg(0)}
+	}
+
+	return UnknownEvent{Line: line}
+}
+
+// splitList splits a comma-separated kwarg value such as "p2a,p2b".
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,197 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEvents(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected Event
+	}{
+		{"|t:|1763188046", TimestampEvent{Unix: 1763188046}},
+		{"|turn|3", TurnEvent{Number: 3}},
+		{"|upkeep", UpkeepEvent{}},
+		{"|player|p1|Player1|giovanni|1487", PlayerEvent{Side: "p1", Name: "Player1", Avatar: "giovanni", Rating: 1487}},
+		{"|player|p1|", PlayerEvent{Side: "p1"}},
+		{"|teamsize|p2|4", TeamSizeEvent{Side: "p2", Size: 4}},
+		{"|rated|", RatedEvent{}},
+		{"|poke|p1|Incineroar, L50, M|", PokeEvent{Side: "p1", Details: Details{Species: "Incineroar", Level: 50, Gender: "M"}}},
+		{
+			"|switch|p1b: Ursaluna|Ursaluna-Bloodmoon, L50, M|100/100",
+			SwitchEvent{
+				Kind:    "switch",
+				Pokemon: PokemonRef{Side: "p1", Slot: "p1b", Name: "Ursaluna"},
+				Details: Details{Species: "Ursaluna-Bloodmoon", Level: 50, Gender: "M"},
+				HP:      HP{Current: 100, Max: 100},
+				HasHP:   true,
+			},
+		},
+		{
+			"|swap|p2a: Indeedee|1|[from] move: Ally Switch",
+			SwapEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2a", Name: "Indeedee"}, Position: 1, From: Effect{Kind: "move", Name: "Ally Switch"}},
+		},
+		{
+			"|move|p1b: Typhlosion|Eruption|p2a: Maushold|[spread] p2a,p2b",
+			MoveEvent{
+				Source: PokemonRef{Side: "p1", Slot: "p1b", Name: "Typhlosion"},
+				Move:   "Eruption",
+				Target: PokemonRef{Side: "p2", Slot: "p2a", Name: "Maushold"},
+				Spread: []string{"p2a", "p2b"},
+			},
+		},
+		{
+			"|move|p1b: Dragapult|Phantom Force||[still]",
+			MoveEvent{
+				Source: PokemonRef{Side: "p1", Slot: "p1b", Name: "Dragapult"},
+				Move:   "Phantom Force",
+				Still:  true,
+			},
+		},
+		{
+			"|move|p1b: Dragapult|Phantom Force|p2a: Whimsicott|[from] lockedmove",
+			MoveEvent{
+				Source: PokemonRef{Side: "p1", Slot: "p1b", Name: "Dragapult"},
+				Move:   "Phantom Force",
+				Target: PokemonRef{Side: "p2", Slot: "p2a", Name: "Whimsicott"},
+				From:   Effect{Name: "lockedmove"},
+			},
+		},
+		{
+			"|-damage|p2b: Gholdengo|53/100|[from] item: Life Orb",
+			DamageEvent{
+				Pokemon: PokemonRef{Side: "p2", Slot: "p2b", Name: "Gholdengo"},
+				HP:      HP{Current: 53, Max: 100},
+				From:    Effect{Kind: "item", Name: "Life Orb"},
+			},
+		},
+		{
+			"|-heal|p2a: Amoonguss|100/100|[from] Leech Seed|[of] p1a: Venusaur",
+			HealEvent{
+				Pokemon: PokemonRef{Side: "p2", Slot: "p2a", Name: "Amoonguss"},
+				HP:      HP{Current: 100, Max: 100},
+				From:    Effect{Name: "Leech Seed"},
+				Of:      PokemonRef{Side: "p1", Slot: "p1a", Name: "Venusaur"},
+			},
+		},
+		{"|faint|p1b: Typhlosion", FaintEvent{Pokemon: PokemonRef{Side: "p1", Slot: "p1b", Name: "Typhlosion"}}},
+		{"|-status|p2a: Maushold|par", StatusEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2a", Name: "Maushold"}, Status: "par"}},
+		{"|-boost|p2b: Gholdengo|spa|2", BoostEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2b", Name: "Gholdengo"}, Stat: "spa", Amount: 2}},
+		{"|-unboost|p2b: Gholdengo|spa|1", BoostEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2b", Name: "Gholdengo"}, Stat: "spa", Amount: -1}},
+		{"|-setboost|p1a: Annihilape|atk|6|[from] ability: Anger Point", SetBoostEvent{
+			Pokemon: PokemonRef{Side: "p1", Slot: "p1a", Name: "Annihilape"},
+			Stat:    "atk",
+			Amount:  6,
+			From:    Effect{Kind: "ability", Name: "Anger Point"},
+		}},
+		{"|-clearallboost", ClearBoostEvent{Kind: ClearAllBoost}},
+		{"|-clearboost|p2a: Dondozo", ClearBoostEvent{Kind: ClearBoost, Pokemon: PokemonRef{Side: "p2", Slot: "p2a", Name: "Dondozo"}}},
+		{"|-invertboost|p2a: Dondozo", InvertBoostEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2a", Name: "Dondozo"}}},
+		{"|-copyboost|p1a: Indeedee|p2a: Dondozo|[from] move: Psych Up", CopyBoostEvent{
+			Source: PokemonRef{Side: "p1", Slot: "p1a", Name: "Indeedee"},
+			Target: PokemonRef{Side: "p2", Slot: "p2a", Name: "Dondozo"},
+			From:   Effect{Kind: "move", Name: "Psych Up"},
+		}},
+		{"|-terastallize|p1b: Dragapult|Dragon", TerastallizeEvent{Pokemon: PokemonRef{Side: "p1", Slot: "p1b", Name: "Dragapult"}, TeraType: "Dragon"}},
+		{"|-crit|p1b: Dragapult", CritEvent{Pokemon: PokemonRef{Side: "p1", Slot: "p1b", Name: "Dragapult"}}},
+		{"|-supereffective|p1a: Whimsicott", EffectivenessEvent{Pokemon: PokemonRef{Side: "p1", Slot: "p1a", Name: "Whimsicott"}, Effectiveness: SuperEffective}},
+		{"|-resisted|p2b: Gholdengo", EffectivenessEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2b", Name: "Gholdengo"}, Effectiveness: NotVeryEffective}},
+		{"|-weather|SunnyDay|[upkeep]", WeatherEvent{Weather: "SunnyDay", Upkeep: true}},
+		{"|-fieldstart|move: Trick Room|[of] p1a: Farigiraf", FieldEvent{
+			Effect: Effect{Kind: "move", Name: "Trick Room"},
+			Of:     PokemonRef{Side: "p1", Slot: "p1a", Name: "Farigiraf"},
+		}},
+		{"|-sideend|p1: Player1|move: Tailwind", SideEvent{Side: "p1", Effect: Effect{Kind: "move", Name: "Tailwind"}, Ended: true}},
+		{"|-start|p2a: Amoonguss|Salt Cure", VolatileEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2a", Name: "Amoonguss"}, Effect: Effect{Name: "Salt Cure"}}},
+		{"|-start|p1a: Dragapult|typechange|Fire|[from] ability: Protean", VolatileEvent{
+			Pokemon: PokemonRef{Side: "p1", Slot: "p1a", Name: "Dragapult"},
+			Effect:  Effect{Name: "typechange"},
+			Value:   "Fire",
+			From:    Effect{Kind: "ability", Name: "Protean"},
+		}},
+		{"|-singleturn|p1b: Amoonguss|move: Rage Powder", SingleTurnEvent{Pokemon: PokemonRef{Side: "p1", Slot: "p1b", Name: "Amoonguss"}, Effect: Effect{Kind: "move", Name: "Rage Powder"}}},
+		{"|-end|p2b: Gholdengo|move: Taunt", VolatileEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2b", Name: "Gholdengo"}, Effect: Effect{Kind: "move", Name: "Taunt"}, Ended: true}},
+		{"|-item|p2a: Gholdengo|Choice Specs|[from] ability: Frisk|[of] p1a: Farigiraf", ItemEvent{
+			Pokemon: PokemonRef{Side: "p2", Slot: "p2a", Name: "Gholdengo"},
+			Item:    "Choice Specs",
+			From:    Effect{Kind: "ability", Name: "Frisk"},
+			Of:      PokemonRef{Side: "p1", Slot: "p1a", Name: "Farigiraf"},
+		}},
+		{"|-enditem|p2b: Amoonguss|Sitrus Berry|[eat]", ItemEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2b", Name: "Amoonguss"}, Item: "Sitrus Berry", Ended: true, Eaten: true}},
+		{"|-enditem|p2b: Amoonguss|Rocky Helmet|[from] move: Knock Off|[of] p1a: Incineroar", ItemEvent{
+			Pokemon: PokemonRef{Side: "p2", Slot: "p2b", Name: "Amoonguss"},
+			Item:    "Rocky Helmet",
+			Ended:   true,
+			From:    Effect{Kind: "move", Name: "Knock Off"},
+			Of:      PokemonRef{Side: "p1", Slot: "p1a", Name: "Incineroar"},
+		}},
+		{"|-ability|p1a: Incineroar|Intimidate|boost", AbilityEvent{Pokemon: PokemonRef{Side: "p1", Slot: "p1a", Name: "Incineroar"}, Ability: "Intimidate", Value: "boost"}},
+		{"|-ability|p2a: Porygon2|Intimidate|[from] ability: Trace|[of] p1a: Incineroar", AbilityEvent{
+			Pokemon: PokemonRef{Side: "p2", Slot: "p2a", Name: "Porygon2"},
+			Ability: "Intimidate",
+			From:    Effect{Kind: "ability", Name: "Trace"},
+			Of:      PokemonRef{Side: "p1", Slot: "p1a", Name: "Incineroar"},
+		}},
+		{"|-activate|p2a: Maushold|move: Protect", ActivateEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2a", Name: "Maushold"}, Effect: Effect{Kind: "move", Name: "Protect"}}},
+		{"|cant|p2b: Gholdengo|flinch", CantEvent{Pokemon: PokemonRef{Side: "p2", Slot: "p2b", Name: "Gholdengo"}, Reason: Effect{Name: "flinch"}}},
+		{"|cant|p1a: Amoonguss|move: Taunt|Spore", CantEvent{
+			Pokemon: PokemonRef{Side: "p1", Slot: "p1a", Name: "Amoonguss"},
+			Reason:  Effect{Kind: "move", Name: "Taunt"},
+			Move:    "Spore",
+		}},
+		{"|win|Player2", WinEvent{Winner: "Player2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			event := Parse(tt.raw)
+			if !reflect.DeepEqual(event, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, event)
+			}
+		})
+	}
+}
+
+func TestParseEventCommandsRoundTrip(t *testing.T) {
+	for _, raw := range []string{
+		"|switch|p1a: Pikachu|Pikachu|100/100",
+		"|drag|p1a: Pikachu|Pikachu|100/100",
+		"|-boost|p1a: Pikachu|atk|1",
+		"|-unboost|p1a: Pikachu|atk|1",
+		"|-clearnegativeboost|p1a: Pikachu",
+		"|-supereffective|p1a: Pikachu",
+		"|-resisted|p1a: Pikachu",
+		"|-immune|p1a: Pikachu",
+		"|-fieldstart|move: Trick Room",
+		"|-fieldend|move: Trick Room",
+		"|-sidestart|p1: Alice|Reflect",
+		"|-sideend|p1: Alice|Reflect",
+		"|-start|p1a: Pikachu|confusion",
+		"|-end|p1a: Pikachu|confusion",
+		"|-item|p1a: Pikachu|Light Ball",
+		"|-enditem|p1a: Pikachu|Light Ball",
+		"|-activate|p2a: Maushold|move: Protect",
+		"|cant|p2b: Gholdengo|flinch",
+		"|swap|p2a: Indeedee|1",
+	} {
+		line, _ := ParseLine(raw)
+		if got := Parse(raw).Command(); got != line.Command {
+			t.Errorf("expected %q to report command %q, got %q", raw, line.Command, got)
+		}
+	}
+}
+
+func TestParseUnknownEvent(t *testing.T) {
+	event, ok := Parse("|-fail|p2a: Maushold|move: Substitute").(UnknownEvent)
+	if !ok {
+		t.Fatal("expected an UnknownEvent")
+	}
+	if event.Line.Arg(1) != "move: Substitute" {
+		t.Errorf("expected line to be preserved, got %+v", event.Line)
+	}
+
+	if Parse("") != nil || Parse("not a protocol line") != nil {
+		t.Error("expected nil for non-protocol lines")
+	}
+}
@@ -0,0 +1,106 @@
+// Package protocol decodes Pokémon Showdown battle protocol lines into typed events.
+package protocol
+
+import "strings"
+
+// Line is a single protocol line split into its command, positional
+// arguments and trailing keyword arguments such as "[from] item: Life Orb".
+type Line struct {
+	Raw     string
+	Command string
+	Args    []string
+	Kwargs  map[string]string
+}
+
+// Commands whose final argument is free text that may itself contain "|".
+// The value is the number of positional arguments before the free text.
+var freeTextCommands = map[string]int{
+	"tier":        0,
+	"html":        0,
+	"raw":         0,
+	"error":       0,
+	"":            0, // "||message" lines
+	"uhtml":       1,
+	"uhtmlchange": 1,
+	"showteam":    1,
+	"c":           1,
+	"chat":        1,
+}
+
+// ParseLine splits a raw protocol line. It reports false for lines that
+// aren't protocol messages, such as blank lines or plain text.
+func ParseLine(raw string) (Line, bool) {
+	raw = strings.TrimRight(raw, "\r")
+	if !strings.HasPrefix(raw, "|") || raw == "|" {
+		return Line{}, false
+	}
+
+	fields := strings.Split(raw[1:], "|")
+	line := Line{
+		Raw:     raw,
+		Command: fields[0],
+		Args:    fields[1:],
+	}
+
+	if n, ok := freeTextCommands[line.Command]; ok {
+		if len(line.Args) > n+1 {
+			text := strings.Join(line.Args[n:], "|")
+			line.Args = append(line.Args[:n:n], text)
+		}
+		return line, true
+	}
+
+	// Keyword arguments always trail the positional ones
+	for len(line.Args) > 0 {
+		key, value, ok := parseKwarg(line.Args[len(line.Args)-1])
+		if !ok {
+			break
+		}
+		if line.Kwargs == nil {
+			line.Kwargs = make(map[string]string)
+		}
+		line.Kwargs[key] = value
+		line.Args = line.Args[:len(line.Args)-1]
+	}
+
+	return line, true
+}
+
+// parseKwarg reads "[from] item: Life Orb" as ("from", "item: Life Orb").
+// Keys are lowercase identifiers, which keeps text like "[Gen 9] VGC" positional.
+func parseKwarg(arg string) (string, string, bool) {
+	if !strings.HasPrefix(arg, "[") {
+		return "", "", false
+	}
+	end := strings.Index(arg, "]")
+	if end < 2 {
+		return "", "", false
+	}
+	key := arg[1:end]
+	for _, r := range key {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return "", "", false
+		}
+	}
+	return key, strings.TrimSpace(arg[end+1:]), true
+}
+
+// Arg returns the positional argument at index i, or "" if there isn't one.
+func (l Line) Arg(i int) string {
+	if i < 0 || i >= len(l.Args) {
+		return ""
+	}
+	return l.Args[i]
+}
+
+// Kwarg returns a keyword argument and whether it was present.
+func (l Line) Kwarg(key string) (string, bool) {
+	value, ok := l.Kwargs[key]
+	return value, ok
+}
+
+// HasKwarg reports whether a flag such as [still] or [miss] was present.
+func (l Line) HasKwarg(key string) bool {
+	_, ok := l.Kwargs[key]
+	return ok
+}
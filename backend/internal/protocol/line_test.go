@@ -0,0 +1,112 @@
+package protocol
+
+import "testing"
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		command string
+		args    []string
+		kwargs  map[string]string
+	}{
+		{
+			name:    "positional arguments",
+			raw:     "|switch|p1a: Whimsicott|Whimsicott, L50, M|100/100",
+			command: "switch",
+			args:    []string{"p1a: Whimsicott", "Whimsicott, L50, M", "100/100"},
+		},
+		{
+			name:    "from kwarg",
+			raw:     "|-damage|p2b: Gholdengo|53/100|[from] item: Life Orb",
+			command: "-damage",
+			args:    []string{"p2b: Gholdengo", "53/100"},
+			kwargs:  map[string]string{"from": "item: Life Orb"},
+		},
+		{
+			name:    "from and of kwargs",
+			raw:     "|-damage|p2a: Amoonguss|88/100|[from] Leech Seed|[of] p1a: Venusaur",
+			command: "-damage",
+			args:    []string{"p2a: Amoonguss", "88/100"},
+			kwargs:  map[string]string{"from": "Leech Seed", "of": "p1a: Venusaur"},
+		},
+		{
+			name:    "flag kwarg after empty target",
+			raw:     "|move|p1b: Dragapult|Phantom Force||[still]",
+			command: "move",
+			args:    []string{"p1b: Dragapult", "Phantom Force", ""},
+			kwargs:  map[string]string{"still": ""},
+		},
+		{
+			name:    "free text keeps brackets and pipes",
+			raw:     "|tier|[Gen 9] VGC 2025 Reg H (Bo3)",
+			command: "tier",
+			args:    []string{"[Gen 9] VGC 2025 Reg H (Bo3)"},
+		},
+		{
+			name:    "packed team keeps pipes",
+			raw:     "|showteam|p1|Whimsicott||FocusSash|Prankster|Tailwind|||M|||50|",
+			command: "showteam",
+			args:    []string{"p1", "Whimsicott||FocusSash|Prankster|Tailwind|||M|||50|"},
+		},
+		{
+			name:    "timestamp",
+			raw:     "|t:|1763188046",
+			command: "t:",
+			args:    []string{"1763188046"},
+		},
+		{
+			name:    "carriage return",
+			raw:     "|turn|3\r",
+			command: "turn",
+			args:    []string{"3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, ok := ParseLine(tt.raw)
+			if !ok {
+				t.Fatal("expected line to parse")
+			}
+			if line.Command != tt.command {
+				t.Errorf("expected command %q, got %q", tt.command, line.Command)
+			}
+			if len(line.Args) != len(tt.args) {
+				t.Fatalf("expected args %q, got %q", tt.args, line.Args)
+			}
+			for i := range tt.args {
+				if line.Args[i] != tt.args[i] {
+					t.Errorf("arg %d: expected %q, got %q", i, tt.args[i], line.Args[i])
+				}
+			}
+			if len(line.Kwargs) != len(tt.kwargs) {
+				t.Fatalf("expected kwargs %v, got %v", tt.kwargs, line.Kwargs)
+			}
+			for key, value := range tt.kwargs {
+				if got, ok := line.Kwarg(key); !ok || got != value {
+					t.Errorf("kwarg %s: expected %q, got %q", key, value, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLineRejectsNonProtocol(t *testing.T) {
+	for _, raw := range []string{"", "|", "plain text", " |turn|1"} {
+		if _, ok := ParseLine(raw); ok {
+			t.Errorf("expected %q not to parse", raw)
+		}
+	}
+}
+
+func TestLineArgOutOfRange(t *testing.T) {
+	line, _ := ParseLine("|-boost|p1a: Pikachu")
+
+	if line.Arg(1) != "" || line.Arg(5) != "" || line.Arg(-1) != "" {
+		t.Error("expected missing arguments to be empty")
+	}
+	if line.HasKwarg("from") {
+		t.Error("expected no kwargs")
+	}
+}
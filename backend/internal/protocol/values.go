@@ -0,0 +1,180 @@
+package protocol
+
+import (
+	"strings"
+)
+
+// PokemonRef identifies a Pokémon as the protocol refers to it, e.g.
+// "p1a: Whimsicott". Side references such as "p1: Player1" have no slot.
+type PokemonRef struct {
+	Side string // "p1" or "p2"
+	Slot string // "p1a", "p1b", ...; empty for side references
+	Name string // Nickname, or the species' base name if it has none
+}
+
+// ParsePokemonRef parses "p1a: Whimsicott", "p1: Player1" or a bare "p1a".
+func ParsePokemonRef(s string) PokemonRef {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return PokemonRef{}
+	}
+
+	position, name := s, ""
+	if idx := strings.Index(s, ":"); idx >= 0 {
+		position = strings.TrimSpace(s[:idx])
+		name = strings.TrimSpace(s[idx+1:])
+	}
+
+	ref := PokemonRef{Name: name}
+	if len(position) >= 2 && position[0] == 'p' {
+		ref.Side = position[:2]
+		if len(position) > 2 {
+			ref.Slot = position
+		}
+	}
+	return ref
+}
+
+// IsZero reports whether the reference is empty, as for a move with no target.
+func (r PokemonRef) IsZero() bool {
+	return r.Side == "" && r.Name == ""
+}
+
+// Position is the active slot index (0 for "a", 1 for "b"), or -1 for side references.
+func (r PokemonRef) Position() int {
+	if len(r.Slot) < 3 {
+		return -1
+	}
+	return int(r.Slot[2] - 'a')
+}
+
+// String formats the reference as it appears in the protocol.
+func (r PokemonRef) String() string {
+	position := r.Slot
+	if position == "" {
+		position = r.Side
+	}
+	if r.Name == "" {
+		return position
+	}
+	return position + ": " + r.Name
+}
+
+// Details is a Pokémon's details string, e.g. "Ursaluna-Bloodmoon, L50, M, shiny".
+type Details struct {
+	Species  string
+	Level    int // 100 when the details omit a level
+	Gender   string
+	Shiny    bool
+	TeraType string // Set on team preview in formats that reveal Tera types
+}
+
+// ParseDetails parses a details string from |poke| or |switch|.
+func ParseDetails(s string) Details {
+	fields := strings.Split(s, ",")
+	details := Details{
+		Species: strings.TrimSpace(fields[0]),
+		Level:   100,
+	}
+
+	for _, field := range fields[1:] {
+		field = strings.TrimSpace(field)
+		switch {
+		case field == "M" || field == "F":
+			details.Gender = field
+		case field == "shiny":
+			details.Shiny = true
+		case strings.HasPrefix(field, "tera:"):
+			details.TeraType = strings.TrimPrefix(field, "tera:")
+		case strings.HasPrefix(field, "L"):
+			if level := Atoi(field[1:]); level > 0 {
+				details.Level = level
+			}
+		}
+	}
+
+	return details
+}
+
+// HP is a parsed HP status such as "63/100", "63/100 par" or "0 fnt".
+type HP struct {
+	Current int
+	Max     int // 0 when the string doesn't include it, as for "0 fnt"
+	Status  string
+	Fainted bool
+}
+
+// ParseHP parses an HP status string. Escaped slashes from replay HTML are accepted.
+func ParseHP(s string) HP {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "\\/", "/")
+
+	var hp HP
+	amount, status, _ := strings.Cut(s, " ")
+	if status == "fnt" {
+		hp.Fainted = true
+	} else {
+		hp.Status = status
+	}
+
+	current, max, found := strings.Cut(amount, "/")
+	hp.Current = Atoi(current)
+	if found {
+		hp.Max = Atoi(max)
+	}
+	if hp.Fainted {
+		hp.Current = 0
+	}
+	return hp
+}
+
+// Effect is the source of an effect, e.g. "item: Life Orb" or "move: Tailwind".
+// Effects without a kind prefix, such as "Leech Seed" or "psn", have an empty Kind.
+type Effect struct {
+	Kind string // "item", "ability", "move" or ""
+	Name string
+}
+
+// ParseEffect parses an effect string.
+func ParseEffect(s string) Effect {
+	s = strings.TrimSpace(s)
+	if kind, name, ok := strings.Cut(s, ":"); ok {
+		switch strings.TrimSpace(kind) {
+		case "item", "ability", "move":
+			return Effect{Kind: strings.TrimSpace(kind), Name: strings.TrimSpace(name)}
+		}
+	}
+	return Effect{Name: s}
+}
+
+// IsZero reports whether no effect was given.
+func (e Effect) IsZero() bool {
+	return e.Name == ""
+}
+
+// String formats the effect as it appears in the protocol.
+func (e Effect) String() string {
+	if e.Kind == "" {
+		return e.Name
+	}
+	return e.Kind + ": " + e.Name
+}
+
+// Atoi reads the leading integer of s, ignoring surrounding space and any
+// trailing text ("50 fnt" -> 50). It returns 0 when s doesn't start with a number.
+func Atoi(s string) int {
+	s = strings.TrimSpace(s)
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	result := 0
+	for i := 0; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		result = result*10 + int(s[i]-'0')
+	}
+	if negative {
+		return -result
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package protocol
+
+import "testing"
+
+func TestParsePokemonRef(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected PokemonRef
+		position int
+	}{
+		{"p1a: Whimsicott", PokemonRef{Side: "p1", Slot: "p1a", Name: "Whimsicott"}, 0},
+		{"p2b: Gholdengo", PokemonRef{Side: "p2", Slot: "p2b", Name: "Gholdengo"}, 1},
+		{"p1: Player1", PokemonRef{Side: "p1", Name: "Player1"}, -1},
+		{"p2a", PokemonRef{Side: "p2", Slot: "p2a"}, 0},
+		{"", PokemonRef{}, -1},
+	}
+
+	for _, tt := range tests {
+		ref := ParsePokemonRef(tt.input)
+		if ref != tt.expected {
+			t.Errorf("ParsePokemonRef(%q) = %+v, expected %+v", tt.input, ref, tt.expected)
+		}
+		if ref.Position() != tt.position {
+			t.Errorf("ParsePokemonRef(%q).Position() = %d, expected %d", tt.input, ref.Position(), tt.position)
+		}
+		if ref.String() != tt.input {
+			t.Errorf("expected %q to round-trip, got %q", tt.input, ref.String())
+		}
+	}
+
+	if !ParsePokemonRef("").IsZero() {
+		t.Error("expected empty reference to be zero")
+	}
+}
+
+func TestParseDetails(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Details
+	}{
+		{"Ursaluna-Bloodmoon, L50, M", Details{Species: "Ursaluna-Bloodmoon", Level: 50, Gender: "M"}},
+		{"Gholdengo, L50", Details{Species: "Gholdengo", Level: 50}},
+		{"Pikachu", Details{Species: "Pikachu", Level: 100}},
+		{"Whimsicott, L50, F, shiny, tera:Fire", Details{Species: "Whimsicott", Level: 50, Gender: "F", Shiny: true, TeraType: "Fire"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseDetails(tt.input); got != tt.expected {
+			t.Errorf("ParseDetails(%q) = %+v, expected %+v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseHP(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected HP
+	}{
+		{"63/100", HP{Current: 63, Max: 100}},
+		{"63\\/100", HP{Current: 63, Max: 100}},
+		{"45/100 par", HP{Current: 45, Max: 100, Status: "par"}},
+		{"0 fnt", HP{Fainted: true}},
+		{"187/201", HP{Current: 187, Max: 201}},
+		{"0/100", HP{Current: 0, Max: 100}},
+		{"75", HP{Current: 75}},
+		{"", HP{}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseHP(tt.input); got != tt.expected {
+			t.Errorf("ParseHP(%q) = %+v, expected %+v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseEffect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Effect
+	}{
+		{"item: Life Orb", Effect{Kind: "item", Name: "Life Orb"}},
+		{"ability: Intimidate", Effect{Kind: "ability", Name: "Intimidate"}},
+		{"move: Tailwind", Effect{Kind: "move", Name: "Tailwind"}},
+		{"Leech Seed", Effect{Name: "Leech Seed"}},
+		{"lockedmove", Effect{Name: "lockedmove"}},
+		{"", Effect{}},
+	}
+
+	for _, tt := range tests {
+		got := ParseEffect(tt.input)
+		if got != tt.expected {
+			t.Errorf("ParseEffect(%q) = %+v, expected %+v", tt.input, got, tt.expected)
+		}
+		if got.String() != tt.input {
+			t.Errorf("expected %q to round-trip, got %q", tt.input, got.String())
+		}
+	}
+}
+
+func TestAtoi(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"42", 42},
+		{" 7 ", 7},
+		{"-3", -3},
+		{"50 fnt", 50},
+		{"L50", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Atoi(tt.input); got != tt.expected {
+			t.Errorf("Atoi(%q) = %d, expected %d", tt.input, got, tt.expected)
+		}
+	}
+}
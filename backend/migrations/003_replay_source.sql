@@ -0,0 +1,9 @@
+-- Migration: Record the Showdown replay a battle came from
+-- Version: 003_replay_source.sql
+
+ALTER TABLE battles
+ADD COLUMN IF NOT EXISTS replay_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_battles_replay_id ON battles(replay_id);
+
+COMMENT ON COLUMN battles.replay_id IS 'Showdown replay ID (e.g., gen9vgc2025reghbo3-2481642254) when imported from a replay';
@@ -0,0 +1,11 @@
+-- Migration: Group best-of-3 games into series
+-- Version: 004_battle_series.sql
+
+ALTER TABLE battles
+ADD COLUMN IF NOT EXISTS series_id VARCHAR(255),
+ADD COLUMN IF NOT EXISTS game_number INT;
+
+CREATE INDEX IF NOT EXISTS idx_battles_series ON battles(series_id, game_number);
+
+COMMENT ON COLUMN battles.series_id IS 'Best-of-N series ID from the |uhtml|bestof| marker (e.g., bestof3-gen9vgc2025reghbo3-2481642253)';
+COMMENT ON COLUMN battles.game_number IS 'Game number within the series, starting at 1';
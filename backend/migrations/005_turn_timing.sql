@@ -0,0 +1,9 @@
+-- Migration: Per-turn wall-clock timing from |t:| markers
+-- Version: 005_turn_timing.sql
+
+ALTER TABLE battle_turns
+ADD COLUMN IF NOT EXISTS decision_time_sec INT,
+ADD COLUMN IF NOT EXISTS duration_sec INT;
+
+COMMENT ON COLUMN battle_turns.decision_time_sec IS 'Seconds between the turn prompt and both players locking in their choices';
+COMMENT ON COLUMN battle_turns.duration_sec IS 'Wall-clock seconds from this turn''s prompt to the next turn''s prompt (or battle end)';
@@ -0,0 +1,18 @@
+-- Migration: Player ratings, avatars and rated status from the log header
+-- Version: 006_player_ratings.sql
+
+ALTER TABLE battles
+ADD COLUMN IF NOT EXISTS rated BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS player1_rating INT,
+ADD COLUMN IF NOT EXISTS player2_rating INT,
+ADD COLUMN IF NOT EXISTS player1_avatar VARCHAR(100),
+ADD COLUMN IF NOT EXISTS player2_avatar VARCHAR(100);
+
+-- A battle's rating is its lower-rated player's, so "high ladder" means both players
+CREATE INDEX IF NOT EXISTS idx_battles_rating ON battles(LEAST(COALESCE(player1_rating, 0), COALESCE(player2_rating, 0)) DESC);
+
+COMMENT ON COLUMN battles.rated IS 'Whether the log declared the battle rated (|rated|)';
+COMMENT ON COLUMN battles.player1_rating IS 'Player 1 ladder rating before the game, from |player|p1|name|avatar|rating';
+COMMENT ON COLUMN battles.player2_rating IS 'Player 2 ladder rating before the game, from |player|p2|name|avatar|rating';
+COMMENT ON COLUMN battles.player1_avatar IS 'Player 1 Showdown avatar';
+COMMENT ON COLUMN battles.player2_avatar IS 'Player 2 Showdown avatar';
@@ -0,0 +1,7 @@
+-- Migration: Field conditions (weather, terrain, rooms, side conditions) after each turn
+-- Version: 007_turn_field_state.sql
+
+ALTER TABLE battle_turns
+ADD COLUMN IF NOT EXISTS field_state JSONB;
+
+COMMENT ON COLUMN battle_turns.field_state IS 'Weather, terrain, rooms and side conditions in effect after the turn, with setter and turns left';
@@ -0,0 +1,11 @@
+-- Migration: Per-target results for moves, including spread moves
+-- Version: 008_action_targets.sql
+
+ALTER TABLE battle_actions
+ADD COLUMN IF NOT EXISTS targets JSONB;
+
+ALTER TABLE move_impacts
+ADD COLUMN IF NOT EXISTS spread BOOLEAN DEFAULT FALSE;
+
+COMMENT ON COLUMN battle_actions.targets IS 'Each Pokémon the move was aimed at, with damage, effectiveness, crit, miss, immunity and KO';
+COMMENT ON COLUMN move_impacts.spread IS 'Whether the move hit more than one target and took the 0.75x spread reduction';
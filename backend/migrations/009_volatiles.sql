@@ -0,0 +1,11 @@
+-- Migration: Volatile conditions on the board after each turn and those each move started
+-- Version: 009_volatiles.sql
+
+ALTER TABLE battle_turns
+ADD COLUMN IF NOT EXISTS volatiles JSONB;
+
+ALTER TABLE move_impacts
+ADD COLUMN IF NOT EXISTS volatiles JSONB;
+
+COMMENT ON COLUMN battle_turns.volatiles IS 'Volatile conditions (confusion, Taunt, Substitute, Protosynthesis boosts...) on the active Pokémon after the turn, with start turns';
+COMMENT ON COLUMN move_impacts.volatiles IS 'Volatile conditions and single-turn effects such as Protect or Follow Me that the move started';
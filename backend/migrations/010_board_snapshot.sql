@@ -0,0 +1,14 @@
+-- Migration: Full board snapshot after each turn, with Tera, stat stages and the bench
+-- Version: 010_board_snapshot.sql
+
+ALTER TABLE turn_board_states
+ADD COLUMN IF NOT EXISTS terastallized BOOLEAN DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS tera_type VARCHAR(20),
+ADD COLUMN IF NOT EXISTS boosts JSONB,
+ADD COLUMN IF NOT EXISTS is_active BOOLEAN DEFAULT TRUE,
+ADD COLUMN IF NOT EXISTS revealed BOOLEAN DEFAULT TRUE;
+
+COMMENT ON TABLE turn_board_states IS 'Board state (active and benched Pokemon) at each turn';
+COMMENT ON COLUMN turn_board_states.boosts IS 'Non-zero stat stages of an active Pokémon, e.g. {"atk": 2}';
+COMMENT ON COLUMN turn_board_states.is_active IS 'On the field; benched Pokémon use position for their order in the back';
+COMMENT ON COLUMN turn_board_states.revealed IS 'Has been sent out; benched Pokémon only seen at team preview are false';
@@ -0,0 +1,13 @@
+-- Migration: Position features and win probability after each turn
+-- Version: 011_win_probability.sql
+
+ALTER TABLE battle_turns
+ADD COLUMN IF NOT EXISTS features JSONB,
+ADD COLUMN IF NOT EXISTS player1_win_probability REAL;
+
+ALTER TABLE battles
+ADD COLUMN IF NOT EXISTS win_probability_model VARCHAR(50);
+
+COMMENT ON COLUMN battle_turns.features IS 'Position after the turn as player 1 minus player 2 differences, e.g. {"remainingHP": 0.25}; the win probability trainer reads these';
+COMMENT ON COLUMN battle_turns.player1_win_probability IS 'Player 1''s chance of winning after the turn, 0-1';
+COMMENT ON COLUMN battles.win_probability_model IS 'Version of the win probability model behind battle_turns.player1_win_probability';
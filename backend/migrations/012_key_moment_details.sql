@@ -0,0 +1,11 @@
+-- Migration: Players, Pokémon and triggering actions for key moments
+-- Version: 012_key_moment_details.sql
+
+ALTER TABLE key_moments
+ADD COLUMN IF NOT EXISTS player VARCHAR(10),
+ADD COLUMN IF NOT EXISTS pokemon JSONB,
+ADD COLUMN IF NOT EXISTS action_order INT;
+
+COMMENT ON COLUMN key_moments.player IS 'Side that made the play or was affected, player1 or player2';
+COMMENT ON COLUMN key_moments.pokemon IS 'Pokémon involved, the main one first, e.g. [{"pokemon": "Incineroar", "player": "player2"}]';
+COMMENT ON COLUMN key_moments.action_order IS 'order_in_turn of the battle action that triggered the moment; NULL for end-of-turn effects';
@@ -0,0 +1,20 @@
+-- Migration: Team preview choices (leads, back line and benched Pokémon) per player
+-- Version: 013_team_choices.sql
+
+CREATE TABLE IF NOT EXISTS battle_team_choices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    battle_id UUID NOT NULL REFERENCES battles(id) ON DELETE CASCADE,
+    player_number INT NOT NULL,
+    pokemon VARCHAR(100) NOT NULL,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('lead', 'back', 'benched')),
+    position INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(battle_id, player_number, pokemon)
+);
+
+CREATE INDEX IF NOT EXISTS idx_battle_team_choices_battle ON battle_team_choices(battle_id);
+CREATE INDEX IF NOT EXISTS idx_battle_team_choices_role_pokemon ON battle_team_choices(role, pokemon);
+
+COMMENT ON TABLE battle_team_choices IS 'Which Pokémon each player led with, kept in the back or left out at team preview';
+COMMENT ON COLUMN battle_team_choices.role IS 'lead, back (brought but not a lead) or benched (left out; only stored once all brought Pokémon were revealed)';
+COMMENT ON COLUMN battle_team_choices.position IS 'Order within the role, e.g. 0 for the slot a lead';
@@ -0,0 +1,27 @@
+-- Migration: Speed order inferred from move order, for cross-battle Speed matchups
+-- Version: 014_speed_comparisons.sql
+
+CREATE TABLE IF NOT EXISTS battle_speed_comparisons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    battle_id UUID NOT NULL REFERENCES battles(id) ON DELETE CASCADE,
+    turn_number INT NOT NULL,
+    first_pokemon VARCHAR(100) NOT NULL,
+    first_player_number INT NOT NULL,
+    first_conditions JSONB,
+    second_pokemon VARCHAR(100) NOT NULL,
+    second_player_number INT NOT NULL,
+    second_conditions JSONB,
+    priority INT NOT NULL DEFAULT 0,
+    trick_room BOOLEAN NOT NULL DEFAULT FALSE,
+    even BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_battle_speed_comparisons_battle ON battle_speed_comparisons(battle_id);
+CREATE INDEX IF NOT EXISTS idx_battle_speed_comparisons_first ON battle_speed_comparisons(first_pokemon);
+CREATE INDEX IF NOT EXISTS idx_battle_speed_comparisons_second ON battle_speed_comparisons(second_pokemon);
+
+COMMENT ON TABLE battle_speed_comparisons IS 'Pairs of Pokémon seen moving in order within the same priority bracket';
+COMMENT ON COLUMN battle_speed_comparisons.first_pokemon IS 'The Pokémon that moved first: the faster one, or the slower one under Trick Room';
+COMMENT ON COLUMN battle_speed_comparisons.first_conditions IS 'Speed stage, Tailwind, paralysis, Choice Scarf and Protosynthesis/Quark Drive when it moved';
+COMMENT ON COLUMN battle_speed_comparisons.even IS 'Both sides had the same Speed multiplier, so the order compares their Speed stats directly';
@@ -0,0 +1,32 @@
+-- Migration: Reference data columns for the embedded Gen 9 Pokédex, moves and items
+-- Version: 015_reference_data.sql
+
+-- Formes share a Pokédex number, so species are unique by name instead
+ALTER TABLE pokemon_species DROP CONSTRAINT IF EXISTS pokemon_species_pokedex_number_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pokemon_species_name ON pokemon_species(name);
+
+ALTER TABLE pokemon_species
+ADD COLUMN IF NOT EXISTS base_species VARCHAR(100),
+ADD COLUMN IF NOT EXISTS hp_base INT,
+ADD COLUMN IF NOT EXISTS attack_base INT,
+ADD COLUMN IF NOT EXISTS defense_base INT,
+ADD COLUMN IF NOT EXISTS sp_atk_base INT,
+ADD COLUMN IF NOT EXISTS sp_def_base INT,
+ADD COLUMN IF NOT EXISTS speed_base INT,
+ADD COLUMN IF NOT EXISTS abilities JSONB,
+ADD COLUMN IF NOT EXISTS weight_kg REAL;
+
+ALTER TABLE moves
+ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS target VARCHAR(30),
+ADD COLUMN IF NOT EXISTS flags JSONB;
+
+ALTER TABLE items
+ADD COLUMN IF NOT EXISTS type VARCHAR(50);
+
+COMMENT ON COLUMN pokemon_species.base_species IS 'Species a forme belongs to, e.g. Urshifu for Urshifu-Rapid-Strike; NULL for base species';
+COMMENT ON COLUMN pokemon_species.abilities IS 'Possible abilities, e.g. ["Blaze", "Intimidate"]';
+COMMENT ON COLUMN moves.accuracy IS 'Accuracy 1-100; NULL if the move never misses';
+COMMENT ON COLUMN moves.target IS 'Showdown move target, e.g. normal, allAdjacentFoes, allySide';
+COMMENT ON COLUMN moves.flags IS 'Showdown move flags, e.g. ["contact", "protect"]';
+COMMENT ON COLUMN items.type IS 'Type a type-boosting item or mask powers up, or a berry resists';